@@ -0,0 +1,604 @@
+package api
+
+import (
+	"time"
+
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/listing"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// listUsersSpec allow-lists the filters and sorts of the user listing
+var listUsersSpec = &listing.Spec{
+	Fields: map[string]listing.Field{
+		"email": {Kind: listing.String, Operators: []string{listing.Contains}},
+		"role": {
+			Kind:      listing.String,
+			Operators: []string{listing.Eq, listing.In},
+			Values:    []string{util.BorrowerRole, util.LenderRole, util.AdminRole, util.PartnerRole},
+		},
+		"status": {
+			Kind:      listing.String,
+			Operators: []string{listing.Eq, listing.In},
+			Values:    []string{util.ActiveStatus, util.SuspendedStatus, util.ClosedStatus},
+		},
+		"is_email_verified": {Kind: listing.Bool, Operators: []string{listing.Eq}},
+		"include_deleted":   {Kind: listing.Bool, Operators: []string{listing.Eq}},
+		"created_at":        {Kind: listing.Time, Operators: []string{listing.Gte, listing.Lte}},
+	},
+	SortFields:   []string{"created_at"},
+	DefaultSort:  listing.Sort{Field: "created_at"},
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+type AdminHandler struct {
+	validate       *requestValidator
+	cursorCodec    *listing.CursorCodec
+	adminService   service.AdminService
+	privacyService service.PrivacyService
+	kycService     service.KYCService
+	apiKeyService  service.APIKeyService
+}
+
+func NewAdminHandler(
+	bundle *i18n.Bundle,
+	cursorCodec *listing.CursorCodec,
+	adminService service.AdminService,
+	privacyService service.PrivacyService,
+	kycService service.KYCService,
+	apiKeyService service.APIKeyService,
+) *AdminHandler {
+	return &AdminHandler{
+		validate:       newRequestValidator(bundle),
+		cursorCodec:    cursorCodec,
+		adminService:   adminService,
+		privacyService: privacyService,
+		kycService:     kycService,
+		apiKeyService:  apiKeyService,
+	}
+}
+
+func (handler *AdminHandler) Route(app *fiber.App, authMiddleware fiber.Handler, idempotencyMiddleware fiber.Handler) {
+	router := app.Group("/api/v1/admin", authMiddleware, roleMiddleware(util.AdminRole), adminMiddleware(handler.adminService), idempotencyMiddleware)
+	router.Get("/users", handler.ListUsers)
+	router.Get("/users/:id", handler.ViewUser)
+	router.Patch("/users/:id/role", handler.ChangeUserRole)
+	router.Post("/users/:id/suspend", handler.SuspendUser)
+	router.Post("/users/:id/reactivate", handler.ReactivateUser)
+	router.Post("/users/:id/anonymize", handler.AnonymizeUser)
+	router.Get("/kyc_submissions", handler.ListKYCSubmissions)
+	router.Get("/kyc_submissions/:id/documents/:document", handler.GetKYCDocument)
+	router.Post("/kyc_submissions/:id/approve", handler.ApproveKYCSubmission)
+	router.Post("/kyc_submissions/:id/reject", handler.RejectKYCSubmission)
+	router.Post("/users/:id/api_keys", handler.IssueAPIKey)
+	router.Get("/users/:id/api_keys", handler.ListAPIKeys)
+	router.Post("/api_keys/:id/rotate", handler.RotateAPIKey)
+	router.Post("/api_keys/:id/revoke", handler.RevokeAPIKey)
+}
+
+func (handler *AdminHandler) ListUsers(ctx *fiber.Ctx) error {
+	query, after, err := parseListQuery(ctx, listUsersSpec, handler.cursorCodec)
+	if err != nil {
+		return listingErrorResponse(ctx, err)
+	}
+
+	input := &service.ListUsersInput{
+		AdminID:         authPayload(ctx).UserID,
+		Email:           stringValue(query, "email", listing.Contains),
+		Roles:           stringValues(query, "role"),
+		Statuses:        stringValues(query, "status"),
+		IsEmailVerified: boolValue(query, "is_email_verified"),
+		CreatedFrom:     timeValue(query, "created_at", listing.Gte),
+		CreatedTo:       timeValue(query, "created_at", listing.Lte),
+		Descending:      query.Sort.Desc,
+		After:           after,
+		Limit:           query.Limit,
+	}
+	if includeDeleted := boolValue(query, "include_deleted"); includeDeleted != nil {
+		input.IncludeDeleted = *includeDeleted
+	}
+
+	output, err := handler.adminService.ListUsers(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	next, err := nextCursor(handler.cursorCodec, query.Sort, output.Next)
+	if err != nil {
+		return listingErrorResponse(ctx, err)
+	}
+
+	rsp := ListUsersResponse{
+		Users:        make([]AdminUserResponse, 0, len(output.Users)),
+		PageResponse: PageResponse{NextCursor: next},
+	}
+	for _, user := range output.Users {
+		rsp.Users = append(rsp.Users, newAdminUserResponse(user))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(rsp)
+}
+
+func (handler *AdminHandler) ViewUser(ctx *fiber.Ctx) error {
+	userID, err := handler.parseUserID(ctx)
+	if err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	input := &service.ViewUserInput{
+		AdminID: authPayload(ctx).UserID,
+		UserID:  userID,
+	}
+
+	output, err := handler.adminService.ViewUser(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	rsp := newAdminUserResponse(output.User)
+	return ctx.Status(fiber.StatusOK).JSON(rsp)
+}
+
+func (handler *AdminHandler) ChangeUserRole(ctx *fiber.Ctx) error {
+	userID, err := handler.parseUserID(ctx)
+	if err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	var req ChangeUserRoleRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		msg := i18n.NewMessage("request.parse_failed").With("reason", err.Error())
+		return errorResponse(ctx, fiber.StatusBadRequest, msg)
+	}
+
+	if err := handler.validate.Struct(req); err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	input := &service.ChangeUserRoleInput{
+		AdminID: authPayload(ctx).UserID,
+		UserID:  userID,
+		Role:    req.Role,
+	}
+
+	output, err := handler.adminService.ChangeUserRole(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	rsp := newAdminUserResponse(output.User)
+	return ctx.Status(fiber.StatusOK).JSON(rsp)
+}
+
+func (handler *AdminHandler) SuspendUser(ctx *fiber.Ctx) error {
+	userID, err := handler.parseUserID(ctx)
+	if err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	var req SuspendUserRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		msg := i18n.NewMessage("request.parse_failed").With("reason", err.Error())
+		return errorResponse(ctx, fiber.StatusBadRequest, msg)
+	}
+
+	if err := handler.validate.Struct(req); err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	input := &service.SuspendUserInput{
+		AdminID: authPayload(ctx).UserID,
+		UserID:  userID,
+		Reason:  req.Reason,
+	}
+
+	output, err := handler.adminService.SuspendUser(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	rsp := newAdminUserResponse(output.User)
+	return ctx.Status(fiber.StatusOK).JSON(rsp)
+}
+
+func (handler *AdminHandler) ReactivateUser(ctx *fiber.Ctx) error {
+	userID, err := handler.parseUserID(ctx)
+	if err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	var req ReactivateUserRequest
+
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&req); err != nil {
+			msg := i18n.NewMessage("request.parse_failed").With("reason", err.Error())
+			return errorResponse(ctx, fiber.StatusBadRequest, msg)
+		}
+	}
+
+	if err := handler.validate.Struct(req); err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	input := &service.ReactivateUserInput{
+		AdminID: authPayload(ctx).UserID,
+		UserID:  userID,
+		Reason:  req.Reason,
+	}
+
+	output, err := handler.adminService.ReactivateUser(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	rsp := newAdminUserResponse(output.User)
+	return ctx.Status(fiber.StatusOK).JSON(rsp)
+}
+
+func (handler *AdminHandler) AnonymizeUser(ctx *fiber.Ctx) error {
+	userID, err := handler.parseUserID(ctx)
+	if err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	input := &service.AnonymizeUserInput{
+		AdminID: authPayload(ctx).UserID,
+		UserID:  userID,
+	}
+
+	output, err := handler.privacyService.AnonymizeUser(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	rsp := newAdminUserResponse(output.User)
+	return ctx.Status(fiber.StatusOK).JSON(rsp)
+}
+
+func (handler *AdminHandler) ListKYCSubmissions(ctx *fiber.Ctx) error {
+	var req ListKYCSubmissionsRequest
+
+	if err := ctx.QueryParser(&req); err != nil {
+		msg := i18n.NewMessage("request.parse_failed").With("reason", err.Error())
+		return errorResponse(ctx, fiber.StatusBadRequest, msg)
+	}
+
+	if err := handler.validate.Struct(req); err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	input := &service.ListPendingKYCSubmissionsInput{
+		AdminID: authPayload(ctx).UserID,
+		Limit:   req.PageSize,
+		Offset:  (req.PageID - 1) * req.PageSize,
+	}
+
+	output, err := handler.kycService.ListPendingKYCSubmissions(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	rsp := ListKYCSubmissionsResponse{
+		KYCSubmissions: make([]KYCSubmissionResponse, 0, len(output.KYCSubmissions)),
+	}
+	for _, submission := range output.KYCSubmissions {
+		rsp.KYCSubmissions = append(rsp.KYCSubmissions, newKYCSubmissionResponse(submission))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(rsp)
+}
+
+func (handler *AdminHandler) GetKYCDocument(ctx *fiber.Ctx) error {
+	var params KYCDocumentParams
+
+	if err := ctx.ParamsParser(&params); err != nil {
+		msg := i18n.NewMessage("request.parse_failed").With("reason", err.Error())
+		return errorResponse(ctx, fiber.StatusBadRequest, msg)
+	}
+
+	if err := handler.validate.Struct(params); err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	input := &service.GetKYCDocumentInput{
+		AdminID:         authPayload(ctx).UserID,
+		KYCSubmissionID: uuid.MustParse(params.ID),
+		Document:        params.Document,
+	}
+
+	output, err := handler.kycService.GetKYCDocument(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	// identity documents must never be kept by shared caches
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	ctx.Set(fiber.HeaderContentType, output.ContentType)
+	return ctx.Status(fiber.StatusOK).SendStream(output.Content)
+}
+
+func (handler *AdminHandler) ApproveKYCSubmission(ctx *fiber.Ctx) error {
+	var req ApproveKYCRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		msg := i18n.NewMessage("request.parse_failed").With("reason", err.Error())
+		return errorResponse(ctx, fiber.StatusBadRequest, msg)
+	}
+
+	if err := handler.validate.Struct(req); err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	return handler.reviewKYCSubmission(ctx, true, req.Reason)
+}
+
+func (handler *AdminHandler) RejectKYCSubmission(ctx *fiber.Ctx) error {
+	var req RejectKYCRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		msg := i18n.NewMessage("request.parse_failed").With("reason", err.Error())
+		return errorResponse(ctx, fiber.StatusBadRequest, msg)
+	}
+
+	if err := handler.validate.Struct(req); err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	return handler.reviewKYCSubmission(ctx, false, req.Reason)
+}
+
+func (handler *AdminHandler) reviewKYCSubmission(ctx *fiber.Ctx, approve bool, reason string) error {
+	var params KYCSubmissionIDParams
+
+	if err := ctx.ParamsParser(&params); err != nil {
+		msg := i18n.NewMessage("request.parse_failed").With("reason", err.Error())
+		return errorResponse(ctx, fiber.StatusBadRequest, msg)
+	}
+
+	if err := handler.validate.Struct(params); err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	input := &service.ReviewKYCSubmissionInput{
+		AdminID:         authPayload(ctx).UserID,
+		KYCSubmissionID: uuid.MustParse(params.ID),
+		Approve:         approve,
+		Reason:          reason,
+	}
+
+	output, err := handler.kycService.ReviewKYCSubmission(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	rsp := newKYCSubmissionResponse(output.KYCSubmission)
+	return ctx.Status(fiber.StatusOK).JSON(rsp)
+}
+
+func (handler *AdminHandler) IssueAPIKey(ctx *fiber.Ctx) error {
+	partnerID, err := handler.parseUserID(ctx)
+	if err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	var req IssueAPIKeyRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		msg := i18n.NewMessage("request.parse_failed").With("reason", err.Error())
+		return errorResponse(ctx, fiber.StatusBadRequest, msg)
+	}
+
+	if err := handler.validate.Struct(req); err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	input := &service.IssueAPIKeyInput{
+		AdminID:   authPayload(ctx).UserID,
+		PartnerID: partnerID,
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresAt: optionalTime(req.ExpiresAt),
+	}
+
+	output, err := handler.apiKeyService.IssueAPIKey(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	rsp := newIssuedAPIKeyResponse(output.APIKey, output.Key, output.SigningKey)
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	return ctx.Status(fiber.StatusCreated).JSON(rsp)
+}
+
+func (handler *AdminHandler) ListAPIKeys(ctx *fiber.Ctx) error {
+	partnerID, err := handler.parseUserID(ctx)
+	if err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	input := &service.ListAPIKeysInput{
+		AdminID:   authPayload(ctx).UserID,
+		PartnerID: partnerID,
+	}
+
+	output, err := handler.apiKeyService.ListAPIKeys(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	rsp := ListAPIKeysResponse{
+		APIKeys: make([]APIKeyResponse, 0, len(output.APIKeys)),
+	}
+	for _, apiKey := range output.APIKeys {
+		rsp.APIKeys = append(rsp.APIKeys, newAPIKeyResponse(apiKey))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(rsp)
+}
+
+func (handler *AdminHandler) RotateAPIKey(ctx *fiber.Ctx) error {
+	apiKeyID, err := handler.parseAPIKeyID(ctx)
+	if err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	var req RotateAPIKeyRequest
+
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&req); err != nil {
+			msg := i18n.NewMessage("request.parse_failed").With("reason", err.Error())
+			return errorResponse(ctx, fiber.StatusBadRequest, msg)
+		}
+	}
+
+	if err := handler.validate.Struct(req); err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	input := &service.RotateAPIKeyInput{
+		AdminID:     authPayload(ctx).UserID,
+		APIKeyID:    apiKeyID,
+		GracePeriod: time.Duration(req.GracePeriodSeconds) * time.Second,
+	}
+
+	output, err := handler.apiKeyService.RotateAPIKey(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	rsp := RotateAPIKeyResponse{
+		OldAPIKey: newAPIKeyResponse(output.OldAPIKey),
+		APIKey:    newIssuedAPIKeyResponse(output.APIKey, output.Key, output.SigningKey),
+	}
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	return ctx.Status(fiber.StatusCreated).JSON(rsp)
+}
+
+func (handler *AdminHandler) RevokeAPIKey(ctx *fiber.Ctx) error {
+	apiKeyID, err := handler.parseAPIKeyID(ctx)
+	if err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	var req RevokeAPIKeyRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		msg := i18n.NewMessage("request.parse_failed").With("reason", err.Error())
+		return errorResponse(ctx, fiber.StatusBadRequest, msg)
+	}
+
+	if err := handler.validate.Struct(req); err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	input := &service.RevokeAPIKeyInput{
+		AdminID:  authPayload(ctx).UserID,
+		APIKeyID: apiKeyID,
+		Reason:   req.Reason,
+	}
+
+	output, err := handler.apiKeyService.RevokeAPIKey(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	rsp := newAPIKeyResponse(output.APIKey)
+	return ctx.Status(fiber.StatusOK).JSON(rsp)
+}
+
+// parseUserID parses the user ID in the path, the error is reported by requestValidator.errorResponse
+func (handler *AdminHandler) parseUserID(ctx *fiber.Ctx) (uuid.UUID, error) {
+	var params UserIDParams
+
+	if err := ctx.ParamsParser(&params); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := handler.validate.Struct(params); err != nil {
+		return uuid.Nil, err
+	}
+
+	return uuid.MustParse(params.ID), nil
+}
+
+// parseAPIKeyID parses the API key ID in the path, the error is reported by requestValidator.errorResponse
+func (handler *AdminHandler) parseAPIKeyID(ctx *fiber.Ctx) (uuid.UUID, error) {
+	var params APIKeyIDParams
+
+	if err := ctx.ParamsParser(&params); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := handler.validate.Struct(params); err != nil {
+		return uuid.Nil, err
+	}
+
+	return uuid.MustParse(params.ID), nil
+}
+
+func newAdminUserResponse(user db.User) AdminUserResponse {
+	rsp := AdminUserResponse{
+		ID:           user.ID,
+		UserResponse: newUserResponse(user),
+		Status:       user.Status,
+	}
+	if user.DeletedAt.Valid {
+		rsp.DeletedAt = &user.DeletedAt.Time
+	}
+	return rsp
+}
+
+func newAPIKeyResponse(apiKey db.APIKey) APIKeyResponse {
+	rsp := APIKeyResponse{
+		ID:        apiKey.ID,
+		PartnerID: apiKey.PartnerID,
+		Name:      apiKey.Name,
+		KeyID:     apiKey.KeyID,
+		Scopes:    apiKey.Scopes,
+		CreatedAt: apiKey.CreatedAt,
+	}
+	if apiKey.ExpiresAt.Valid {
+		rsp.ExpiresAt = &apiKey.ExpiresAt.Time
+	}
+	if apiKey.RevokedAt.Valid {
+		rsp.RevokedAt = &apiKey.RevokedAt.Time
+	}
+	if apiKey.LastUsedAt.Valid {
+		rsp.LastUsedAt = &apiKey.LastUsedAt.Time
+	}
+	return rsp
+}
+
+func newIssuedAPIKeyResponse(apiKey db.APIKey, key, signingKey string) IssuedAPIKeyResponse {
+	return IssuedAPIKeyResponse{
+		APIKeyResponse: newAPIKeyResponse(apiKey),
+		Key:            key,
+		SigningKey:     signingKey,
+	}
+}
+
+// optionalTime parses an already validated RFC 3339 time
+func optionalTime(s string) *time.Time {
+	if s == "" {
+		return nil
+	}
+	t, _ := time.Parse(time.RFC3339, s)
+	return &t
+}
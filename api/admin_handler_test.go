@@ -0,0 +1,1096 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/listing"
+	"github.com/DamianZhang/957-lending-platform/service"
+	mocksvc "github.com/DamianZhang/957-lending-platform/service/mock"
+	"github.com/DamianZhang/957-lending-platform/token"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListUsersAPI(t *testing.T) {
+	adminID := uuid.New()
+	n := 5
+	users := make([]db.User, n)
+	for i := 0; i < n; i++ {
+		users[i], _ = randomUser(t)
+	}
+
+	testCases := []struct {
+		name       string
+		query      string
+		setUpAuth  func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs func(svc *mocksvc.MockAdminService)
+		checkRsp   func(rsp *http.Response)
+	}{
+		{
+			name:  "OK",
+			query: fmt.Sprintf("limit=%d&role[in]=borrower,lender&status=active&is_email_verified=true&created_at[gte]=2024-01-01T00:00:00Z&sort=-created_at", n),
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, adminID, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				isEmailVerified := true
+				createdFrom := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+				input := &service.ListUsersInput{
+					AdminID:         adminID,
+					Roles:           []string{util.BorrowerRole, util.LenderRole},
+					Statuses:        []string{util.ActiveStatus},
+					IsEmailVerified: &isEmailVerified,
+					CreatedFrom:     &createdFrom,
+					Descending:      true,
+					Limit:           int32(n),
+				}
+				svc.EXPECT().
+					ListUsers(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(&service.ListUsersOutput{Users: users}, nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+
+				var actualRsp ListUsersResponse
+				unmarshalRsp(t, rsp, &actualRsp)
+				require.Len(t, actualRsp.Users, n)
+				for i, user := range actualRsp.Users {
+					require.Equal(t, users[i].ID, user.ID)
+					require.Equal(t, users[i].Email, user.Email)
+				}
+				require.Nil(t, actualRsp.NextCursor)
+			},
+		},
+		{
+			name:  "Defaults",
+			query: "",
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, adminID, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				input := &service.ListUsersInput{
+					AdminID: adminID,
+					Limit:   listUsersSpec.DefaultLimit,
+				}
+				svc.EXPECT().
+					ListUsers(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(&service.ListUsersOutput{Users: []db.User{}}, nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+
+				var actualRsp map[string]any
+				unmarshalRsp(t, rsp, &actualRsp)
+				require.Equal(t, []any{}, actualRsp["users"])
+				require.Contains(t, actualRsp, "next_cursor")
+				require.Nil(t, actualRsp["next_cursor"])
+			},
+		},
+		{
+			name:  "ExclusiveRoleFilters",
+			query: "role=admin&role[in]=borrower,lender",
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, adminID, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				svc.EXPECT().
+					ListUsers(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, input *service.ListUsersInput) (*service.ListUsersOutput, error) {
+						require.NotNil(t, input.Roles)
+						require.Empty(t, input.Roles)
+						return &service.ListUsersOutput{}, nil
+					})
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+			},
+		},
+		{
+			name:  "NotAdmin",
+			query: fmt.Sprintf("limit=%d", n),
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, adminID, util.BorrowerRole, time.Minute)
+			},
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				svc.EXPECT().
+					ListUsers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusForbidden, rsp.StatusCode)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			query:     fmt.Sprintf("limit=%d", n),
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				svc.EXPECT().
+					ListUsers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusUnauthorized, rsp.StatusCode)
+			},
+		},
+		{
+			name:  "InvalidLimit",
+			query: "limit=1000",
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, adminID, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				svc.EXPECT().
+					ListUsers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name:  "InvalidCreatedFrom",
+			query: "created_at[gte]=yesterday",
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, adminID, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				svc.EXPECT().
+					ListUsers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name:  "UnknownFilter",
+			query: "nickname=bob",
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, adminID, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				svc.EXPECT().
+					ListUsers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+
+				var problem ProblemResponse
+				unmarshalRsp(t, rsp, &problem)
+				require.Equal(t, "cannot filter by nickname", problem.Detail)
+			},
+		},
+		{
+			name:  "UnsupportedOperator",
+			query: "email[eq]=bob@email.com",
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, adminID, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				svc.EXPECT().
+					ListUsers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name:  "InvalidCursor",
+			query: "cursor=forged",
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, adminID, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				svc.EXPECT().
+					ListUsers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockAdminService(ctrl)
+			expectActiveAdmin(svc)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, nil, svc, nil, nil, nil, nil, nil)
+
+			url := "/api/v1/admin/users?" + tc.query
+			request := httptest.NewRequest(http.MethodGet, url, nil)
+			tc.setUpAuth(t, request, server.tokenMaker)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+// TestListUsersAPIPagination follows the next cursor of a page to the next page
+func TestListUsersAPIPagination(t *testing.T) {
+	adminID := uuid.New()
+	users := make([]db.User, 3)
+	for i := range users {
+		users[i], _ = randomUser(t)
+	}
+	next := &listing.Key{CreatedAt: users[1].CreatedAt, ID: users[1].ID}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocksvc.NewMockAdminService(ctrl)
+	expectActiveAdmin(svc)
+	gomock.InOrder(
+		svc.EXPECT().
+			ListUsers(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, input *service.ListUsersInput) (*service.ListUsersOutput, error) {
+				require.Nil(t, input.After)
+				return &service.ListUsersOutput{Users: users[:2], Next: next}, nil
+			}),
+		svc.EXPECT().
+			ListUsers(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, input *service.ListUsersInput) (*service.ListUsersOutput, error) {
+				require.NotNil(t, input.After)
+				require.Equal(t, next.ID, input.After.ID)
+				require.True(t, next.CreatedAt.Equal(input.After.CreatedAt))
+				require.True(t, input.Descending)
+				return &service.ListUsersOutput{Users: users[2:]}, nil
+			}),
+	)
+
+	server := newTestServer(t, nil, nil, svc, nil, nil, nil, nil, nil)
+
+	listUsers := func(query url.Values) *http.Response {
+		request := httptest.NewRequest(http.MethodGet, "/api/v1/admin/users?"+query.Encode(), nil)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, adminID, util.AdminRole, time.Minute)
+
+		rsp, err := server.app.Test(request)
+		require.NoError(t, err)
+		return rsp
+	}
+
+	rsp := listUsers(url.Values{"limit": {"2"}, "sort": {"-created_at"}})
+	require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+
+	var firstPage ListUsersResponse
+	unmarshalRsp(t, rsp, &firstPage)
+	require.Len(t, firstPage.Users, 2)
+	require.NotNil(t, firstPage.NextCursor)
+
+	// the cursor only pages through the users in the sort it was issued for
+	rsp = listUsers(url.Values{"limit": {"2"}, "cursor": {*firstPage.NextCursor}})
+	require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+
+	rsp = listUsers(url.Values{"limit": {"2"}, "sort": {"-created_at"}, "cursor": {*firstPage.NextCursor}})
+	require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+
+	var secondPage ListUsersResponse
+	unmarshalRsp(t, rsp, &secondPage)
+	require.Len(t, secondPage.Users, 1)
+	require.Equal(t, users[2].ID, secondPage.Users[0].ID)
+	require.Nil(t, secondPage.NextCursor)
+}
+
+func TestChangeUserRoleAPI(t *testing.T) {
+	adminID := uuid.New()
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		userID     string
+		reqBody    ChangeUserRoleRequest
+		buildStubs func(svc *mocksvc.MockAdminService)
+		checkRsp   func(rsp *http.Response)
+	}{
+		{
+			name:    "OK",
+			userID:  user.ID.String(),
+			reqBody: ChangeUserRoleRequest{Role: util.LenderRole},
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				input := &service.ChangeUserRoleInput{
+					AdminID: adminID,
+					UserID:  user.ID,
+					Role:    util.LenderRole,
+				}
+				updatedUser := user
+				updatedUser.Role = util.LenderRole
+				svc.EXPECT().
+					ChangeUserRole(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(&service.ChangeUserRoleOutput{User: updatedUser}, nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+
+				var actualRsp AdminUserResponse
+				unmarshalRsp(t, rsp, &actualRsp)
+				require.Equal(t, user.ID, actualRsp.ID)
+				require.Equal(t, util.LenderRole, actualRsp.Role)
+			},
+		},
+		{
+			name:    "InvalidUserID",
+			userID:  "invalid-id",
+			reqBody: ChangeUserRoleRequest{Role: util.LenderRole},
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				svc.EXPECT().
+					ChangeUserRole(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name:    "InvalidRole",
+			userID:  user.ID.String(),
+			reqBody: ChangeUserRoleRequest{Role: "root"},
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				svc.EXPECT().
+					ChangeUserRole(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name:    "GrantAdmin",
+			userID:  user.ID.String(),
+			reqBody: ChangeUserRoleRequest{Role: util.AdminRole},
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				svc.EXPECT().
+					ChangeUserRole(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name:    "GrantPartner",
+			userID:  user.ID.String(),
+			reqBody: ChangeUserRoleRequest{Role: util.PartnerRole},
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				svc.EXPECT().
+					ChangeUserRole(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name:    "ServiceForbidden",
+			userID:  adminID.String(),
+			reqBody: ChangeUserRoleRequest{Role: util.LenderRole},
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				svc.EXPECT().
+					ChangeUserRole(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewError(service.ErrForbidden, nil))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusForbidden, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockAdminService(ctrl)
+			expectActiveAdmin(svc)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, nil, svc, nil, nil, nil, nil, nil)
+
+			data, err := json.Marshal(tc.reqBody)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/api/v1/admin/users/%s/role", tc.userID)
+			request := httptest.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			request.Header.Set("Content-Type", "application/json")
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, adminID, util.AdminRole, time.Minute)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+func TestSuspendUserAPI(t *testing.T) {
+	adminID := uuid.New()
+	user, _ := randomUser(t)
+	reason := util.RandomString(20)
+
+	testCases := []struct {
+		name       string
+		reqBody    SuspendUserRequest
+		buildStubs func(svc *mocksvc.MockAdminService)
+		checkRsp   func(rsp *http.Response)
+	}{
+		{
+			name:    "OK",
+			reqBody: SuspendUserRequest{Reason: reason},
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				input := &service.SuspendUserInput{
+					AdminID: adminID,
+					UserID:  user.ID,
+					Reason:  reason,
+				}
+				suspendedUser := user
+				suspendedUser.Status = util.SuspendedStatus
+				svc.EXPECT().
+					SuspendUser(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(&service.SuspendUserOutput{User: suspendedUser}, nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+
+				var actualRsp AdminUserResponse
+				unmarshalRsp(t, rsp, &actualRsp)
+				require.Equal(t, util.SuspendedStatus, actualRsp.Status)
+			},
+		},
+		{
+			name:    "MissingReason",
+			reqBody: SuspendUserRequest{},
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				svc.EXPECT().
+					SuspendUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name:    "ServiceNotFound",
+			reqBody: SuspendUserRequest{Reason: reason},
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				svc.EXPECT().
+					SuspendUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewError(service.ErrNotFound, nil))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusNotFound, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockAdminService(ctrl)
+			expectActiveAdmin(svc)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, nil, svc, nil, nil, nil, nil, nil)
+
+			data, err := json.Marshal(tc.reqBody)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/api/v1/admin/users/%s/suspend", user.ID)
+			request := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			request.Header.Set("Content-Type", "application/json")
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, adminID, util.AdminRole, time.Minute)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+func TestAnonymizeUserAPI(t *testing.T) {
+	adminID := uuid.New()
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		userID     string
+		buildStubs func(svc *mocksvc.MockPrivacyService)
+		checkRsp   func(rsp *http.Response)
+	}{
+		{
+			name:   "OK",
+			userID: user.ID.String(),
+			buildStubs: func(svc *mocksvc.MockPrivacyService) {
+				input := &service.AnonymizeUserInput{
+					AdminID: adminID,
+					UserID:  user.ID,
+				}
+				anonymizedUser := user
+				anonymizedUser.Status = util.ClosedStatus
+				svc.EXPECT().
+					AnonymizeUser(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(&service.AnonymizeUserOutput{User: anonymizedUser}, nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+
+				var actualRsp AdminUserResponse
+				unmarshalRsp(t, rsp, &actualRsp)
+				require.Equal(t, util.ClosedStatus, actualRsp.Status)
+			},
+		},
+		{
+			name:   "InvalidID",
+			userID: "invalid",
+			buildStubs: func(svc *mocksvc.MockPrivacyService) {
+				svc.EXPECT().
+					AnonymizeUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name:   "ServiceForbidden",
+			userID: user.ID.String(),
+			buildStubs: func(svc *mocksvc.MockPrivacyService) {
+				svc.EXPECT().
+					AnonymizeUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewError(service.ErrForbidden, nil))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusForbidden, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockPrivacyService(ctrl)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, nil, activeAdminService(ctrl), svc, nil, nil, nil, nil)
+
+			url := fmt.Sprintf("/api/v1/admin/users/%s/anonymize", tc.userID)
+			request := httptest.NewRequest(http.MethodPost, url, nil)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, adminID, util.AdminRole, time.Minute)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+func TestRejectKYCSubmissionAPI(t *testing.T) {
+	adminID := uuid.New()
+	user, _ := randomUser(t)
+	submission := randomKYCSubmission(user.ID)
+	reason := util.RandomString(20)
+
+	testCases := []struct {
+		name         string
+		submissionID string
+		reqBody      RejectKYCRequest
+		buildStubs   func(svc *mocksvc.MockKYCService)
+		checkRsp     func(rsp *http.Response)
+	}{
+		{
+			name:         "OK",
+			submissionID: submission.ID.String(),
+			reqBody:      RejectKYCRequest{Reason: reason},
+			buildStubs: func(svc *mocksvc.MockKYCService) {
+				input := &service.ReviewKYCSubmissionInput{
+					AdminID:         adminID,
+					KYCSubmissionID: submission.ID,
+					Approve:         false,
+					Reason:          reason,
+				}
+				rejected := submission
+				rejected.Status = util.KYCSubmissionRejected
+				rejected.ReviewReason = reason
+				svc.EXPECT().
+					ReviewKYCSubmission(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(&service.ReviewKYCSubmissionOutput{KYCSubmission: rejected}, nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+
+				var actualRsp KYCSubmissionResponse
+				unmarshalRsp(t, rsp, &actualRsp)
+				require.Equal(t, util.KYCSubmissionRejected, actualRsp.Status)
+				require.Equal(t, reason, actualRsp.ReviewReason)
+			},
+		},
+		{
+			name:         "MissingReason",
+			submissionID: submission.ID.String(),
+			reqBody:      RejectKYCRequest{},
+			buildStubs: func(svc *mocksvc.MockKYCService) {
+				svc.EXPECT().
+					ReviewKYCSubmission(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name:         "InvalidID",
+			submissionID: "invalid",
+			reqBody:      RejectKYCRequest{Reason: reason},
+			buildStubs: func(svc *mocksvc.MockKYCService) {
+				svc.EXPECT().
+					ReviewKYCSubmission(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name:         "ServiceConflict",
+			submissionID: submission.ID.String(),
+			reqBody:      RejectKYCRequest{Reason: reason},
+			buildStubs: func(svc *mocksvc.MockKYCService) {
+				svc.EXPECT().
+					ReviewKYCSubmission(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewError(service.ErrConflict, nil))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusConflict, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockKYCService(ctrl)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, nil, activeAdminService(ctrl), nil, svc, nil, nil, nil)
+
+			data, err := json.Marshal(tc.reqBody)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/api/v1/admin/kyc_submissions/%s/reject", tc.submissionID)
+			request := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			request.Header.Set("Content-Type", "application/json")
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, adminID, util.AdminRole, time.Minute)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+func TestGetKYCDocumentAPI(t *testing.T) {
+	adminID := uuid.New()
+	submissionID := uuid.New()
+	selfie := []byte(util.RandomString(32))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocksvc.NewMockKYCService(ctrl)
+	input := &service.GetKYCDocumentInput{
+		AdminID:         adminID,
+		KYCSubmissionID: submissionID,
+		Document:        util.KYCDocumentSelfie,
+	}
+	svc.EXPECT().
+		GetKYCDocument(gomock.Any(), gomock.Eq(input)).
+		Times(1).
+		Return(&service.GetKYCDocumentOutput{
+			ContentType: "image/png",
+			Content:     io.NopCloser(bytes.NewReader(selfie)),
+		}, nil)
+
+	server := newTestServer(t, nil, nil, activeAdminService(ctrl), nil, svc, nil, nil, nil)
+
+	url := fmt.Sprintf("/api/v1/admin/kyc_submissions/%s/documents/%s", submissionID, util.KYCDocumentSelfie)
+	request := httptest.NewRequest(http.MethodGet, url, nil)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, adminID, util.AdminRole, time.Minute)
+
+	rsp, err := server.app.Test(request)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+	require.Equal(t, "image/png", rsp.Header.Get(fiber.HeaderContentType))
+	require.Equal(t, "no-store", rsp.Header.Get(fiber.HeaderCacheControl))
+
+	data, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	require.Equal(t, selfie, data)
+}
+
+func TestIssueAPIKeyAPI(t *testing.T) {
+	adminID := uuid.New()
+	payload := randomPartnerPayload(t, util.ScopeBorrowersRead, util.ScopeBorrowersWrite)
+	partnerID := payload.Partner.ID
+	key := util.FormatAPIKey(payload.APIKey.KeyID, util.RandomString(43))
+
+	testCases := []struct {
+		name       string
+		reqBody    fiber.Map
+		buildStubs func(svc *mocksvc.MockAPIKeyService)
+		checkRsp   func(rsp *http.Response)
+	}{
+		{
+			name: "OK",
+			reqBody: fiber.Map{
+				"name":       payload.APIKey.Name,
+				"scopes":     payload.APIKey.Scopes,
+				"expires_at": "2030-01-01T00:00:00Z",
+			},
+			buildStubs: func(svc *mocksvc.MockAPIKeyService) {
+				expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+				input := &service.IssueAPIKeyInput{
+					AdminID:   adminID,
+					PartnerID: partnerID,
+					Name:      payload.APIKey.Name,
+					Scopes:    payload.APIKey.Scopes,
+					ExpiresAt: &expiresAt,
+				}
+				svc.EXPECT().
+					IssueAPIKey(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(&service.IssueAPIKeyOutput{
+						APIKey:     payload.APIKey,
+						Key:        key,
+						SigningKey: payload.APIKey.HashedSecret,
+					}, nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusCreated, rsp.StatusCode)
+				require.Equal(t, "no-store", rsp.Header.Get(fiber.HeaderCacheControl))
+
+				var actualRsp IssuedAPIKeyResponse
+				unmarshalRsp(t, rsp, &actualRsp)
+				require.Equal(t, payload.APIKey.ID, actualRsp.ID)
+				require.Equal(t, payload.APIKey.KeyID, actualRsp.KeyID)
+				require.Equal(t, key, actualRsp.Key)
+				require.Equal(t, payload.APIKey.HashedSecret, actualRsp.SigningKey)
+			},
+		},
+		{
+			name: "UnknownScope",
+			reqBody: fiber.Map{
+				"name":   payload.APIKey.Name,
+				"scopes": []string{"admin:everything"},
+			},
+			buildStubs: func(svc *mocksvc.MockAPIKeyService) {
+				svc.EXPECT().
+					IssueAPIKey(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name: "NoScopes",
+			reqBody: fiber.Map{
+				"name": payload.APIKey.Name,
+			},
+			buildStubs: func(svc *mocksvc.MockAPIKeyService) {
+				svc.EXPECT().
+					IssueAPIKey(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name: "NotPartner",
+			reqBody: fiber.Map{
+				"name":   payload.APIKey.Name,
+				"scopes": payload.APIKey.Scopes,
+			},
+			buildStubs: func(svc *mocksvc.MockAPIKeyService) {
+				svc.EXPECT().
+					IssueAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewError(service.ErrValidation, nil))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockAPIKeyService(ctrl)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, nil, activeAdminService(ctrl), nil, nil, svc, nil, nil)
+
+			data, err := json.Marshal(tc.reqBody)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/api/v1/admin/users/%s/api_keys", partnerID)
+			request := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			request.Header.Set("Content-Type", "application/json")
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, adminID, util.AdminRole, time.Minute)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+func TestRotateAPIKeyAPI(t *testing.T) {
+	adminID := uuid.New()
+	oldPayload := randomPartnerPayload(t, util.ScopeLoansRead)
+	newPayload := randomPartnerPayload(t, util.ScopeLoansRead)
+	key := util.FormatAPIKey(newPayload.APIKey.KeyID, util.RandomString(43))
+
+	testCases := []struct {
+		name       string
+		reqBody    string
+		buildStubs func(svc *mocksvc.MockAPIKeyService)
+		checkRsp   func(rsp *http.Response)
+	}{
+		{
+			name:    "OK",
+			reqBody: `{"grace_period_seconds":3600}`,
+			buildStubs: func(svc *mocksvc.MockAPIKeyService) {
+				input := &service.RotateAPIKeyInput{
+					AdminID:     adminID,
+					APIKeyID:    oldPayload.APIKey.ID,
+					GracePeriod: time.Hour,
+				}
+				svc.EXPECT().
+					RotateAPIKey(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(&service.RotateAPIKeyOutput{
+						OldAPIKey:  oldPayload.APIKey,
+						APIKey:     newPayload.APIKey,
+						Key:        key,
+						SigningKey: newPayload.APIKey.HashedSecret,
+					}, nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusCreated, rsp.StatusCode)
+
+				var actualRsp RotateAPIKeyResponse
+				unmarshalRsp(t, rsp, &actualRsp)
+				require.Equal(t, oldPayload.APIKey.ID, actualRsp.OldAPIKey.ID)
+				require.Equal(t, newPayload.APIKey.ID, actualRsp.APIKey.ID)
+				require.Equal(t, key, actualRsp.APIKey.Key)
+			},
+		},
+		{
+			name: "NoGracePeriod",
+			buildStubs: func(svc *mocksvc.MockAPIKeyService) {
+				input := &service.RotateAPIKeyInput{
+					AdminID:  adminID,
+					APIKeyID: oldPayload.APIKey.ID,
+				}
+				svc.EXPECT().
+					RotateAPIKey(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(&service.RotateAPIKeyOutput{OldAPIKey: oldPayload.APIKey, APIKey: newPayload.APIKey}, nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusCreated, rsp.StatusCode)
+			},
+		},
+		{
+			name:    "GracePeriodTooLong",
+			reqBody: `{"grace_period_seconds":2592000}`,
+			buildStubs: func(svc *mocksvc.MockAPIKeyService) {
+				svc.EXPECT().
+					RotateAPIKey(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name: "Revoked",
+			buildStubs: func(svc *mocksvc.MockAPIKeyService) {
+				svc.EXPECT().
+					RotateAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewError(service.ErrConflict, nil))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusConflict, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockAPIKeyService(ctrl)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, nil, activeAdminService(ctrl), nil, nil, svc, nil, nil)
+
+			url := fmt.Sprintf("/api/v1/admin/api_keys/%s/rotate", oldPayload.APIKey.ID)
+			request := httptest.NewRequest(http.MethodPost, url, bytes.NewReader([]byte(tc.reqBody)))
+			request.Header.Set("Content-Type", "application/json")
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, adminID, util.AdminRole, time.Minute)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+func TestRevokeAPIKeyAPI(t *testing.T) {
+	adminID := uuid.New()
+	payload := randomPartnerPayload(t, util.ScopeLoansRead)
+	reason := util.RandomString(20)
+
+	testCases := []struct {
+		name       string
+		reqBody    RevokeAPIKeyRequest
+		buildStubs func(svc *mocksvc.MockAPIKeyService)
+		checkRsp   func(rsp *http.Response)
+	}{
+		{
+			name:    "OK",
+			reqBody: RevokeAPIKeyRequest{Reason: reason},
+			buildStubs: func(svc *mocksvc.MockAPIKeyService) {
+				input := &service.RevokeAPIKeyInput{
+					AdminID:  adminID,
+					APIKeyID: payload.APIKey.ID,
+					Reason:   reason,
+				}
+				revokedAPIKey := payload.APIKey
+				revokedAPIKey.RevokedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+				svc.EXPECT().
+					RevokeAPIKey(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(&service.RevokeAPIKeyOutput{APIKey: revokedAPIKey}, nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+
+				var actualRsp APIKeyResponse
+				unmarshalRsp(t, rsp, &actualRsp)
+				require.NotNil(t, actualRsp.RevokedAt)
+			},
+		},
+		{
+			name:    "MissingReason",
+			reqBody: RevokeAPIKeyRequest{},
+			buildStubs: func(svc *mocksvc.MockAPIKeyService) {
+				svc.EXPECT().
+					RevokeAPIKey(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name:    "NotFound",
+			reqBody: RevokeAPIKeyRequest{Reason: reason},
+			buildStubs: func(svc *mocksvc.MockAPIKeyService) {
+				svc.EXPECT().
+					RevokeAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewError(service.ErrNotFound, nil))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusNotFound, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockAPIKeyService(ctrl)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, nil, activeAdminService(ctrl), nil, nil, svc, nil, nil)
+
+			data, err := json.Marshal(tc.reqBody)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/api/v1/admin/api_keys/%s/revoke", payload.APIKey.ID)
+			request := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			request.Header.Set("Content-Type", "application/json")
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, adminID, util.AdminRole, time.Minute)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+// expectActiveAdmin lets the admin of every request pass the admin middleware
+func expectActiveAdmin(svc *mocksvc.MockAdminService) {
+	svc.EXPECT().
+		RequireAdmin(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(nil)
+}
+
+// activeAdminService creates an admin service which is only used by the admin middleware, letting every admin pass
+func activeAdminService(ctrl *gomock.Controller) *mocksvc.MockAdminService {
+	svc := mocksvc.NewMockAdminService(ctrl)
+	expectActiveAdmin(svc)
+	return svc
+}
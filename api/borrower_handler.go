@@ -1,41 +1,38 @@
 package api
 
 import (
-	"fmt"
-
+	"github.com/DamianZhang/957-lending-platform/i18n"
 	"github.com/DamianZhang/957-lending-platform/service"
-	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 )
 
 type BorrowerHandler struct {
-	validate        *validator.Validate
+	validate        *requestValidator
 	borrowerService service.BorrowerService
 }
 
-func NewBorrowerHandler(borrowerService service.BorrowerService) *BorrowerHandler {
+func NewBorrowerHandler(bundle *i18n.Bundle, borrowerService service.BorrowerService) *BorrowerHandler {
 	return &BorrowerHandler{
-		validate:        validator.New(),
+		validate:        newRequestValidator(bundle),
 		borrowerService: borrowerService,
 	}
 }
 
-func (handler *BorrowerHandler) Route(app *fiber.App) {
+func (handler *BorrowerHandler) Route(app *fiber.App, rateLimitMiddleware func(policy string) fiber.Handler, idempotencyMiddleware fiber.Handler) {
 	router := app.Group("/api/v1/borrowers")
-	router.Post("/sign_up", handler.SignUp)
+	router.Post("/sign_up", rateLimitMiddleware(rateLimitPolicySignUp), idempotencyMiddleware, handler.SignUp)
 }
 
 func (handler *BorrowerHandler) SignUp(ctx *fiber.Ctx) error {
 	var req SignUpRequest
 
 	if err := ctx.BodyParser(&req); err != nil {
-		errMsg := fmt.Sprintf("failed to parse request: %s", err.Error())
-		return errorResponse(ctx, fiber.StatusBadRequest, errMsg)
+		msg := i18n.NewMessage("request.parse_failed").With("reason", err.Error())
+		return errorResponse(ctx, fiber.StatusBadRequest, msg)
 	}
 
 	if err := handler.validate.Struct(req); err != nil {
-		errMsg := fmt.Sprintf("invalid request: %s", err.Error())
-		return errorResponse(ctx, fiber.StatusBadRequest, errMsg)
+		return handler.validate.errorResponse(ctx, err)
 	}
 
 	input := &service.SignUpInput{
@@ -45,10 +42,10 @@ func (handler *BorrowerHandler) SignUp(ctx *fiber.Ctx) error {
 		Nickname: req.Nickname,
 	}
 
-	output, err := handler.borrowerService.SignUp(ctx.Context(), input)
+	output, err := handler.borrowerService.SignUp(ctx.UserContext(), input)
 	if err != nil {
 		apiError := FromServiceError(err)
-		return errorResponse(ctx, apiError.StatusCode, apiError.Message)
+		return problemResponse(ctx, apiError)
 	}
 
 	rsp := SignUpResponse{
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/i18n"
 	"github.com/DamianZhang/957-lending-platform/service"
 	mocksvc "github.com/DamianZhang/957-lending-platform/service/mock"
 	"github.com/DamianZhang/957-lending-platform/util"
@@ -113,6 +114,34 @@ func TestSignUpAPI(t *testing.T) {
 				require.Equal(t, fiber.StatusInternalServerError, rsp.StatusCode)
 			},
 		},
+		{
+			name:    "ServiceConflict",
+			reqBody: expectedReq,
+			setReqHeader: func(req *http.Request) {
+				req.Header.Set("Content-Type", "application/json")
+			},
+			buildStubs: func(svc *mocksvc.MockBorrowerService) {
+				svc.EXPECT().
+					SignUp(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewErrorWithDetail(service.ErrConflict, i18n.NewMessage("user.email_in_use")))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusConflict, rsp.StatusCode)
+				require.Equal(t, problemContentType, rsp.Header.Get(fiber.HeaderContentType))
+
+				var actualRsp ProblemResponse
+				unmarshalRsp(t, rsp, &actualRsp)
+				require.Equal(t, ProblemResponse{
+					Type:     "about:blank",
+					Title:    "Conflict",
+					Status:   fiber.StatusConflict,
+					Detail:   "email is already in use",
+					Instance: "/api/v1/borrowers/sign_up",
+					Code:     "conflict",
+				}, actualRsp)
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -123,7 +152,7 @@ func TestSignUpAPI(t *testing.T) {
 			svc := mocksvc.NewMockBorrowerService(ctrl)
 			tc.buildStubs(svc)
 
-			server := newTestServer(t, svc)
+			server := newTestServer(t, svc, nil, nil, nil, nil, nil, nil, nil)
 
 			data, err := json.Marshal(tc.reqBody)
 			require.NoError(t, err)
@@ -142,7 +171,7 @@ func TestSignUpAPI(t *testing.T) {
 func expectedSignUpRequest() SignUpRequest {
 	return SignUpRequest{
 		Email:    util.RandomEmail(),
-		Password: util.RandomString(6),
+		Password: util.RandomPassword(),
 		LineID:   util.RandomString(6),
 		Nickname: util.RandomString(6),
 	}
@@ -2,33 +2,149 @@ package api
 
 import (
 	"errors"
+	"log/slog"
+	"net/http"
 
+	"github.com/DamianZhang/957-lending-platform/i18n"
 	"github.com/DamianZhang/957-lending-platform/service"
 	"github.com/gofiber/fiber/v2"
 )
 
+// problemContentType is the media type of an RFC 7807 problem details body
+const problemContentType = "application/problem+json"
+
+// statusCodes maps the service errors to the HTTP status codes they are reported with.
+// A failed validation is a bad request, whether it is caught by the request validator or by the service.
+var statusCodes = map[error]int{
+	service.ErrInternalFailure:    fiber.StatusInternalServerError,
+	service.ErrNotFound:           fiber.StatusNotFound,
+	service.ErrConflict:           fiber.StatusConflict,
+	service.ErrValidation:         fiber.StatusBadRequest,
+	service.ErrUnauthenticated:    fiber.StatusUnauthorized,
+	service.ErrForbidden:          fiber.StatusForbidden,
+	service.ErrRateLimited:        fiber.StatusTooManyRequests,
+	service.ErrPreconditionFailed: fiber.StatusPreconditionFailed,
+	service.ErrAccountInactive:    fiber.StatusForbidden,
+	service.ErrKYCRequired:        fiber.StatusForbidden,
+	service.ErrRequestInProgress:  fiber.StatusTooEarly,
+}
+
+// statusErrorCodes are the machine-readable codes of the errors raised by the API layer itself
+var statusErrorCodes = map[int]string{
+	fiber.StatusBadRequest:            "invalid_request",
+	fiber.StatusUnauthorized:          "unauthenticated",
+	fiber.StatusForbidden:             "forbidden",
+	fiber.StatusNotFound:              "not_found",
+	fiber.StatusMethodNotAllowed:      "method_not_allowed",
+	fiber.StatusConflict:              "conflict",
+	fiber.StatusPreconditionFailed:    "precondition_failed",
+	fiber.StatusRequestEntityTooLarge: "payload_too_large",
+	fiber.StatusUnsupportedMediaType:  "unsupported_media_type",
+	fiber.StatusTooEarly:              "request_in_progress",
+	fiber.StatusTooManyRequests:       "rate_limited",
+	fiber.StatusInternalServerError:   "internal_failure",
+	fiber.StatusServiceUnavailable:    "unavailable",
+}
+
+// fiberErrorMessages are the messages of the errors raised by fiber itself, by status code
+var fiberErrorMessages = map[int]string{
+	fiber.StatusNotFound:              "request.route_not_found",
+	fiber.StatusMethodNotAllowed:      "request.method_not_allowed",
+	fiber.StatusRequestEntityTooLarge: "request.too_large",
+}
+
 type APIError struct {
 	StatusCode int
-	Message    string
+	Code       string
+	Message    i18n.Message // localised into the language of the client once reported
 }
 
+// FromServiceError turns an error of the service layer into the error reported to the client.
+// The cause of the error is logged but never reported, only the detail meant for clients is.
 func FromServiceError(err error) APIError {
-	var (
-		apiError APIError
-		svcError service.Error
-	)
-	if errors.As(err, &svcError) {
-		switch svcError.SvcErr() {
-		case service.ErrInternalFailure:
-			apiError.StatusCode = fiber.StatusInternalServerError
-		}
+	apiError := APIError{
+		StatusCode: fiber.StatusInternalServerError,
+		Code:       statusErrorCodes[fiber.StatusInternalServerError],
+	}
+
+	var svcError service.Error
+	if !errors.As(err, &svcError) {
+		slog.Error("unexpected error", "err", err)
+		return apiError
+	}
+
+	if statusCode, ok := statusCodes[svcError.SvcErr()]; ok {
+		apiError.StatusCode = statusCode
+		apiError.Code = svcError.Code()
+		apiError.Message = svcError.Detail()
+	}
 
-		apiError.Message = svcError.Error()
+	if apiError.StatusCode >= fiber.StatusInternalServerError {
+		slog.Error("internal error", "err", err)
 	}
 
 	return apiError
 }
 
-func errorResponse(ctx *fiber.Ctx, statusCode int, errMsg string) error {
-	return ctx.Status(statusCode).JSON(ErrorResponse{Message: errMsg})
+// errorResponse reports an error raised by the API layer itself, such as an invalid request
+func errorResponse(ctx *fiber.Ctx, statusCode int, msg i18n.Message) error {
+	code, ok := statusErrorCodes[statusCode]
+	if !ok {
+		code = statusErrorCodes[fiber.StatusInternalServerError]
+	}
+
+	return problemResponse(ctx, APIError{
+		StatusCode: statusCode,
+		Code:       code,
+		Message:    msg,
+	})
+}
+
+// problemResponse writes the error as an RFC 7807 problem details body,
+// with its detail in the language negotiated with the client
+func problemResponse(ctx *fiber.Ctx, apiError APIError) error {
+	var detail string
+	if !apiError.Message.IsZero() {
+		detail = localizer(ctx).Localize(apiError.Message)
+	}
+
+	rsp := ProblemResponse{
+		Type:     "about:blank",
+		Title:    http.StatusText(apiError.StatusCode),
+		Status:   apiError.StatusCode,
+		Detail:   detail,
+		Instance: ctx.OriginalURL(),
+		Code:     apiError.Code,
+	}
+
+	return ctx.Status(apiError.StatusCode).JSON(rsp, problemContentType)
+}
+
+// problemErrorHandler creates the handler reporting the errors left unhandled by the routes,
+// such as an unknown route or a too large body, as problem details too
+func problemErrorHandler(bundle *i18n.Bundle) fiber.ErrorHandler {
+	return func(ctx *fiber.Ctx, err error) error {
+		// a too large body is rejected before any middleware has run
+		if ctx.Locals(localizerKey) == nil {
+			ctx.Locals(localizerKey, bundle.Localizer(i18n.Negotiate(ctx.Get(fiber.HeaderAcceptLanguage))))
+		}
+
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			return errorResponse(ctx, fiberErr.Code, fiberErrorMessage(ctx, fiberErr))
+		}
+
+		return problemResponse(ctx, FromServiceError(err))
+	}
+}
+
+func fiberErrorMessage(ctx *fiber.Ctx, fiberErr *fiber.Error) i18n.Message {
+	key, ok := fiberErrorMessages[fiberErr.Code]
+	if !ok {
+		return i18n.NewMessage("request.failed").With("reason", fiberErr.Message)
+	}
+
+	return i18n.NewMessage(key).
+		With("method", ctx.Method()).
+		With("path", ctx.Path())
 }
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromServiceError(t *testing.T) {
+	cause := errors.New(`duplicate key value violates unique constraint "users_email_key"`)
+
+	testCases := []struct {
+		name               string
+		err                error
+		expectedStatusCode int
+		expectedCode       string
+		expectedMessage    i18n.Message
+	}{
+		{
+			name:               "InternalFailure",
+			err:                service.NewError(service.ErrInternalFailure, cause),
+			expectedStatusCode: fiber.StatusInternalServerError,
+			expectedCode:       "internal_failure",
+		},
+		{
+			name:               "NotFound",
+			err:                service.NewError(service.ErrNotFound, cause),
+			expectedStatusCode: fiber.StatusNotFound,
+			expectedCode:       "not_found",
+		},
+		{
+			name:               "ConflictWithDetail",
+			err:                service.NewErrorWithDetail(service.ErrConflict, i18n.NewMessage("user.email_in_use")),
+			expectedStatusCode: fiber.StatusConflict,
+			expectedCode:       "conflict",
+			expectedMessage:    i18n.NewMessage("user.email_in_use"),
+		},
+		{
+			name:               "Validation",
+			err:                service.NewError(service.ErrValidation, cause),
+			expectedStatusCode: fiber.StatusBadRequest,
+			expectedCode:       "validation_failed",
+		},
+		{
+			name:               "Unauthenticated",
+			err:                service.NewError(service.ErrUnauthenticated, cause),
+			expectedStatusCode: fiber.StatusUnauthorized,
+			expectedCode:       "unauthenticated",
+		},
+		{
+			name:               "Forbidden",
+			err:                service.NewError(service.ErrForbidden, cause),
+			expectedStatusCode: fiber.StatusForbidden,
+			expectedCode:       "forbidden",
+		},
+		{
+			name:               "RateLimited",
+			err:                service.NewError(service.ErrRateLimited, cause),
+			expectedStatusCode: fiber.StatusTooManyRequests,
+			expectedCode:       "rate_limited",
+		},
+		{
+			name:               "PreconditionFailed",
+			err:                service.NewError(service.ErrPreconditionFailed, cause),
+			expectedStatusCode: fiber.StatusPreconditionFailed,
+			expectedCode:       "precondition_failed",
+		},
+		{
+			name:               "AccountInactive",
+			err:                service.NewErrorWithDetail(service.ErrAccountInactive, i18n.NewMessage("user.account_inactive").With("status", "suspended")),
+			expectedStatusCode: fiber.StatusForbidden,
+			expectedCode:       "account_inactive",
+			expectedMessage:    i18n.NewMessage("user.account_inactive").With("status", "suspended"),
+		},
+		{
+			name:               "RequestInProgress",
+			err:                service.NewErrorWithDetail(service.ErrRequestInProgress, i18n.NewMessage("idempotency.request_in_progress")),
+			expectedStatusCode: fiber.StatusTooEarly,
+			expectedCode:       "request_in_progress",
+			expectedMessage:    i18n.NewMessage("idempotency.request_in_progress"),
+		},
+		{
+			name:               "NotAServiceError",
+			err:                cause,
+			expectedStatusCode: fiber.StatusInternalServerError,
+			expectedCode:       "internal_failure",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			apiError := FromServiceError(tc.err)
+			require.Equal(t, tc.expectedStatusCode, apiError.StatusCode)
+			require.Equal(t, tc.expectedCode, apiError.Code)
+			require.Equal(t, tc.expectedMessage, apiError.Message)
+		})
+	}
+}
+
+func TestUnknownRouteProblem(t *testing.T) {
+	server := newTestServer(t, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/unknown", nil)
+
+	rsp, err := server.app.Test(request)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNotFound, rsp.StatusCode)
+	require.Equal(t, problemContentType, rsp.Header.Get(fiber.HeaderContentType))
+
+	var actualRsp ProblemResponse
+	unmarshalRsp(t, rsp, &actualRsp)
+	require.Equal(t, "not_found", actualRsp.Code)
+	require.Equal(t, fiber.StatusNotFound, actualRsp.Status)
+}
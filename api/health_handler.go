@@ -0,0 +1,86 @@
+package api
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Statuses reported by the health endpoints
+const (
+	healthStatusOK       = "ok"
+	healthStatusReady    = "ready"
+	healthStatusNotReady = "not_ready"
+	healthStatusDraining = "draining"
+	dependencyStatusUp   = "up"
+	dependencyStatusDown = "down"
+)
+
+// HealthHandler serves the probes of the orchestrator and the load balancers
+type HealthHandler struct {
+	healthService service.HealthService
+	// draining is set once the server is shutting down
+	draining *atomic.Bool
+}
+
+func NewHealthHandler(healthService service.HealthService, draining *atomic.Bool) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+		draining:      draining,
+	}
+}
+
+func (handler *HealthHandler) Route(app *fiber.App) {
+	app.Get("/healthz", handler.Healthz)
+	app.Get("/readyz", handler.Readyz)
+}
+
+// Healthz reports that the process is alive, without checking any dependency
+func (handler *HealthHandler) Healthz(ctx *fiber.Ctx) error {
+	rsp := HealthResponse{
+		Status: healthStatusOK,
+	}
+	return ctx.Status(fiber.StatusOK).JSON(rsp)
+}
+
+// Readyz reports whether the server can serve requests. It is not ready while it is shutting down,
+// so that load balancers stop sending it requests before it stops accepting connections.
+func (handler *HealthHandler) Readyz(ctx *fiber.Ctx) error {
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+
+	if handler.draining.Load() {
+		rsp := ReadinessResponse{
+			Status: healthStatusDraining,
+		}
+		return ctx.Status(fiber.StatusServiceUnavailable).JSON(rsp)
+	}
+
+	output := handler.healthService.CheckReadiness(ctx.UserContext())
+
+	rsp := ReadinessResponse{
+		Status: healthStatusReady,
+		Checks: make(map[string]DependencyCheckResponse, len(output.Checks)),
+	}
+	for _, check := range output.Checks {
+		status := dependencyStatusUp
+		if !check.Healthy {
+			status = dependencyStatusDown
+			// the cause is logged but not reported, as it may reveal the internals of the dependency
+			slog.WarnContext(ctx.UserContext(), "dependency is unhealthy", "dependency", check.Name, "err", check.Error)
+		}
+
+		rsp.Checks[check.Name] = DependencyCheckResponse{
+			Status:    status,
+			LatencyMS: float64(check.Latency) / float64(time.Millisecond),
+		}
+	}
+
+	if !output.Ready {
+		rsp.Status = healthStatusNotReady
+		return ctx.Status(fiber.StatusServiceUnavailable).JSON(rsp)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(rsp)
+}
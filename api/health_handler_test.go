@@ -0,0 +1,130 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/service"
+	mocksvc "github.com/DamianZhang/957-lending-platform/service/mock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthzAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocksvc.NewMockHealthService(ctrl)
+	svc.EXPECT().
+		CheckReadiness(gomock.Any()).
+		Times(0)
+
+	server := newTestServer(t, nil, nil, nil, nil, nil, nil, svc, nil)
+
+	request := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+
+	rsp, err := server.app.Test(request)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+
+	var actualRsp HealthResponse
+	unmarshalRsp(t, rsp, &actualRsp)
+	require.Equal(t, healthStatusOK, actualRsp.Status)
+}
+
+func TestReadyzAPI(t *testing.T) {
+	testCases := []struct {
+		name       string
+		draining   bool
+		buildStubs func(svc *mocksvc.MockHealthService)
+		checkRsp   func(rsp *http.Response)
+	}{
+		{
+			name: "Ready",
+			buildStubs: func(svc *mocksvc.MockHealthService) {
+				svc.EXPECT().
+					CheckReadiness(gomock.Any()).
+					Times(1).
+					Return(&service.CheckReadinessOutput{
+						Ready: true,
+						Checks: []service.DependencyCheck{
+							{Name: service.DependencyDatabase, Healthy: true, Latency: 1500 * time.Microsecond},
+							{Name: service.DependencySchema, Healthy: true, Latency: time.Millisecond},
+						},
+					})
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+
+				var actualRsp ReadinessResponse
+				unmarshalRsp(t, rsp, &actualRsp)
+				require.Equal(t, healthStatusReady, actualRsp.Status)
+				require.Equal(t, DependencyCheckResponse{Status: dependencyStatusUp, LatencyMS: 1.5}, actualRsp.Checks[service.DependencyDatabase])
+				require.Equal(t, DependencyCheckResponse{Status: dependencyStatusUp, LatencyMS: 1}, actualRsp.Checks[service.DependencySchema])
+			},
+		},
+		{
+			name: "SchemaOutdated",
+			buildStubs: func(svc *mocksvc.MockHealthService) {
+				svc.EXPECT().
+					CheckReadiness(gomock.Any()).
+					Times(1).
+					Return(&service.CheckReadinessOutput{
+						Ready: false,
+						Checks: []service.DependencyCheck{
+							{Name: service.DependencyDatabase, Healthy: true},
+							{Name: service.DependencySchema, Healthy: false, Error: "schema version is 7, expected 8"},
+						},
+					})
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusServiceUnavailable, rsp.StatusCode)
+
+				var actualRsp ReadinessResponse
+				unmarshalRsp(t, rsp, &actualRsp)
+				require.Equal(t, healthStatusNotReady, actualRsp.Status)
+				require.Equal(t, dependencyStatusUp, actualRsp.Checks[service.DependencyDatabase].Status)
+				require.Equal(t, dependencyStatusDown, actualRsp.Checks[service.DependencySchema].Status)
+			},
+		},
+		{
+			name:     "Draining",
+			draining: true,
+			buildStubs: func(svc *mocksvc.MockHealthService) {
+				svc.EXPECT().
+					CheckReadiness(gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusServiceUnavailable, rsp.StatusCode)
+
+				var actualRsp ReadinessResponse
+				unmarshalRsp(t, rsp, &actualRsp)
+				require.Equal(t, healthStatusDraining, actualRsp.Status)
+				require.Empty(t, actualRsp.Checks)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockHealthService(ctrl)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, nil, nil, nil, nil, nil, svc, nil)
+			server.draining.Store(tc.draining)
+
+			request := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxKYCDocumentSize is the largest image accepted for a single KYC document
+const maxKYCDocumentSize = 5 << 20
+
+// kycDocumentContentTypes are the image formats accepted for KYC documents
+var kycDocumentContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+}
+
+// kycDocumentError is an uploaded KYC document which has been turned down
+type kycDocumentError struct {
+	statusCode int
+	msg        i18n.Message
+}
+
+func (e *kycDocumentError) Error() string {
+	return e.msg.String()
+}
+
+// kycDocument is an uploaded KYC document along with its sniffed content type
+type kycDocument struct {
+	multipart.File
+	contentType string
+}
+
+// formKYCDocument opens the uploaded file of a multipart form field and checks its size and content.
+// The content type is sniffed from the data itself, the one declared by the client is not trusted.
+func formKYCDocument(ctx *fiber.Ctx, field string) (*kycDocument, error) {
+	header, err := ctx.FormFile(field)
+	if err != nil {
+		return nil, &kycDocumentError{
+			statusCode: fiber.StatusBadRequest,
+			msg:        i18n.NewMessage("kyc.document_required").With("field", field),
+		}
+	}
+
+	if header.Size > maxKYCDocumentSize {
+		return nil, &kycDocumentError{
+			statusCode: fiber.StatusRequestEntityTooLarge,
+			msg: i18n.NewMessage("kyc.document_too_large").
+				With("field", field).
+				With("limit", maxKYCDocumentSize),
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(file, sniff)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		file.Close()
+		return nil, err
+	}
+
+	contentType := http.DetectContentType(sniff[:n])
+	if !kycDocumentContentTypes[contentType] {
+		file.Close()
+		return nil, &kycDocumentError{
+			statusCode: fiber.StatusUnsupportedMediaType,
+			msg:        i18n.NewMessage("kyc.document_unsupported_type").With("field", field),
+		}
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &kycDocument{File: file, contentType: contentType}, nil
+}
+
+func kycDocumentErrorResponse(ctx *fiber.Ctx, err error) error {
+	var docErr *kycDocumentError
+	if errors.As(err, &docErr) {
+		return errorResponse(ctx, docErr.statusCode, docErr.msg)
+	}
+
+	msg := i18n.NewMessage("kyc.upload_failed").With("reason", err.Error())
+	return errorResponse(ctx, fiber.StatusBadRequest, msg)
+}
+
+func newKYCSubmissionResponse(submission db.KYCSubmission) KYCSubmissionResponse {
+	rsp := KYCSubmissionResponse{
+		ID:           submission.ID,
+		UserID:       submission.UserID,
+		Status:       submission.Status,
+		ReviewReason: submission.ReviewReason,
+		CreatedAt:    submission.CreatedAt,
+	}
+	if submission.ReviewedAt.Valid {
+		rsp.ReviewedAt = &submission.ReviewedAt.Time
+	}
+	return rsp
+}
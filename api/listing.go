@@ -0,0 +1,104 @@
+package api
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/listing"
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseListQuery parses the filters, sort and page of a list request against the spec of the endpoint.
+// It also decodes the cursor of the request, if any, into the key the page starts after.
+func parseListQuery(ctx *fiber.Ctx, spec *listing.Spec, codec *listing.CursorCodec) (*listing.Query, *listing.Key, error) {
+	query, err := spec.Parse(ctx.Queries())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	after, err := codec.Decode(query.Cursor, query.Sort)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return query, after, nil
+}
+
+// listingErrorResponse reports a list request which does not match the spec of the endpoint
+func listingErrorResponse(ctx *fiber.Ctx, err error) error {
+	var listingErr *listing.Error
+	if !errors.As(err, &listingErr) {
+		msg := i18n.NewMessage("request.failed").With("reason", err.Error())
+		return errorResponse(ctx, fiber.StatusInternalServerError, msg)
+	}
+
+	return errorResponse(ctx, fiber.StatusBadRequest, listingErr.Message)
+}
+
+// nextCursor encodes the cursor of the page following the given key, if any
+func nextCursor(codec *listing.CursorCodec, sort listing.Sort, next *listing.Key) (*string, error) {
+	if next == nil {
+		return nil, nil
+	}
+
+	cursor, err := codec.Encode(sort, *next)
+	if err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// stringValues returns the values a string field is filtered by with the eq or in operators.
+// It returns nil when the field is not filtered, and no values when the two filters exclude each other.
+func stringValues(query *listing.Query, field string) []string {
+	var values []string
+	if filter, ok := query.Filter(field, listing.Eq); ok {
+		values = []string{filter.Values[0].(string)}
+	}
+
+	filter, ok := query.Filter(field, listing.In)
+	if !ok {
+		return values
+	}
+
+	in := make([]string, 0, len(filter.Values))
+	for _, value := range filter.Values {
+		in = append(in, value.(string))
+	}
+	if values == nil {
+		return in
+	}
+	return slices.DeleteFunc(values, func(value string) bool { return !slices.Contains(in, value) })
+}
+
+// stringValue returns the value a string field is filtered by with the operator, if any
+func stringValue(query *listing.Query, field, operator string) *string {
+	filter, ok := query.Filter(field, operator)
+	if !ok {
+		return nil
+	}
+	value := filter.Values[0].(string)
+	return &value
+}
+
+// boolValue returns the value a bool field is filtered by with the eq operator, if any
+func boolValue(query *listing.Query, field string) *bool {
+	filter, ok := query.Filter(field, listing.Eq)
+	if !ok {
+		return nil
+	}
+	value := filter.Values[0].(bool)
+	return &value
+}
+
+// timeValue returns the value a time field is filtered by with the operator, if any
+func timeValue(query *listing.Query, field, operator string) *time.Time {
+	filter, ok := query.Filter(field, operator)
+	if !ok {
+		return nil
+	}
+	value := filter.Values[0].(time.Time)
+	return &value
+}
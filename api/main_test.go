@@ -2,13 +2,35 @@ package api
 
 import (
 	"testing"
+	"time"
 
+	"github.com/DamianZhang/957-lending-platform/metrics"
+	"github.com/DamianZhang/957-lending-platform/ratelimit"
 	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/util"
 	"github.com/stretchr/testify/require"
 )
 
-func newTestServer(t *testing.T, borrowerService service.BorrowerService) *Server {
-	server, err := NewServer(borrowerService)
+func newTestServer(
+	t *testing.T,
+	borrowerService service.BorrowerService,
+	userService service.UserService,
+	adminService service.AdminService,
+	privacyService service.PrivacyService,
+	kycService service.KYCService,
+	apiKeyService service.APIKeyService,
+	healthService service.HealthService,
+	idempotencyService service.IdempotencyService,
+) *Server {
+	config := util.Config{
+		AuthConfig: util.AuthConfig{
+			TokenSymmetricKey:   util.RandomString(32),
+			CursorSigningKey:    util.RandomString(32),
+			AccessTokenDuration: time.Minute,
+		},
+	}
+
+	server, err := NewServer(config, borrowerService, userService, adminService, privacyService, kycService, apiKeyService, healthService, idempotencyService, metrics.New(), ratelimit.NewLimiter(ratelimit.NewMemoryStore(), nil), nil)
 	require.NoError(t, err)
 
 	return server
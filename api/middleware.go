@@ -0,0 +1,606 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/logging"
+	"github.com/DamianZhang/957-lending-platform/metrics"
+	"github.com/DamianZhang/957-lending-platform/ratelimit"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/token"
+	"github.com/DamianZhang/957-lending-platform/tracing"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/DamianZhang/957-lending-platform/api"
+
+const (
+	authorizationHeaderKey  = "authorization"
+	authorizationTypeBearer = "bearer"
+	authorizationPayloadKey = "authorization_payload"
+	partnerPayloadKey       = "partner_payload"
+	localizerKey            = "localizer"
+)
+
+// requestIDHeaderKey is the header carrying the ID of a request, to correlate the logs of a request across services
+const requestIDHeaderKey = "X-Request-ID"
+
+// requestIDPattern restricts the request IDs accepted from clients, so that they cannot forge log lines
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// Names of the rate limit policies of the routes, which are configured in RATE_LIMIT_POLICIES
+const (
+	rateLimitPolicySignUp  = "sign_up"
+	rateLimitPolicySignIn  = "sign_in"
+	rateLimitPolicyMe      = "me"
+	rateLimitPolicyPartner = "partner"
+)
+
+// Headers reporting the rate limit policy of a route, as in the IETF RateLimit header fields draft
+const (
+	rateLimitPolicyHeaderKey    = "RateLimit-Policy"
+	rateLimitLimitHeaderKey     = "RateLimit-Limit"
+	rateLimitRemainingHeaderKey = "RateLimit-Remaining"
+	rateLimitResetHeaderKey     = "RateLimit-Reset"
+)
+
+// Headers of the state-changing requests which clients can safely retry
+const (
+	idempotencyKeyHeaderKey = "Idempotency-Key"
+	// idempotentReplayedHeaderKey marks the responses replayed from a previous attempt of the request
+	idempotentReplayedHeaderKey = "Idempotent-Replayed"
+)
+
+// maxIdempotencyKeyLength bounds the keys chosen by clients, UUIDs are recommended
+const maxIdempotencyKeyLength = 255
+
+// Headers of a request signed with an API key
+const (
+	apiKeyIDHeaderKey  = "X-API-Key-ID"
+	timestampHeaderKey = "X-Timestamp"
+	nonceHeaderKey     = "X-Nonce"
+	signatureHeaderKey = "X-Signature"
+)
+
+// metricsMiddleware creates a fiber middleware which records the count and latency of the requests
+// by route template, such as /api/v1/admin/users/:id, rather than by path to keep the number of series bounded.
+// Requests matching no route are recorded under the route of the last middleware they went through.
+func metricsMiddleware(m *metrics.Metrics) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		start := time.Now()
+
+		nextWithErrorHandled(ctx)
+
+		m.ObserveHTTPRequest(ctx.Method(), ctx.Route().Path, ctx.Response().StatusCode(), time.Since(start))
+		return nil
+	}
+}
+
+// tracingMiddleware creates a fiber middleware which records a server span for every request,
+// continuing the trace of the client when the request carries a W3C traceparent header.
+// The span is stored in the user context of the request, which handlers pass on to the services.
+func tracingMiddleware() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		parent := otel.GetTextMapPropagator().Extract(ctx.UserContext(), fiberHeaderCarrier{ctx: ctx})
+		spanCtx, span := tracing.Tracer(tracerName).Start(parent, ctx.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(ctx.Method()),
+				semconv.URLPath(ctx.Path()),
+				semconv.UserAgentOriginal(ctx.Get(fiber.HeaderUserAgent)),
+			),
+		)
+		defer span.End()
+
+		ctx.SetUserContext(spanCtx)
+		nextWithErrorHandled(ctx)
+
+		// the route template is only known once the request has been routed
+		route := ctx.Route().Path
+		status := ctx.Response().StatusCode()
+		span.SetName(ctx.Method() + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPStatusCode(status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, utils.StatusMessage(status))
+		}
+		return nil
+	}
+}
+
+// nextWithErrorHandled runs the next handlers and turns the error they return into a response,
+// so that the middlewares recording requests know the status sent to the client
+func nextWithErrorHandled(ctx *fiber.Ctx) {
+	err := ctx.Next()
+	if err != nil {
+		if handlerErr := ctx.App().ErrorHandler(ctx, err); handlerErr != nil {
+			ctx.Status(fiber.StatusInternalServerError)
+		}
+	}
+}
+
+// fiberHeaderCarrier exposes the headers of a request to the trace context propagator
+type fiberHeaderCarrier struct {
+	ctx *fiber.Ctx
+}
+
+func (c fiberHeaderCarrier) Get(key string) string {
+	return c.ctx.Get(key)
+}
+
+func (c fiberHeaderCarrier) Set(key string, value string) {
+	c.ctx.Request().Header.Set(key, value)
+}
+
+func (c fiberHeaderCarrier) Keys() []string {
+	var keys []string
+	c.ctx.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
+
+// requestLogMiddleware creates a fiber middleware which logs every request once it has been handled.
+// The request ID sent by the client, or by a proxy in front of the server, is kept when it is well formed,
+// a new one is assigned otherwise. It is sent back in the X-Request-ID header and carried by the user context,
+// so that the logs of the services mention it. At debug level the body of the request is logged too, once redacted.
+func requestLogMiddleware(logger *slog.Logger) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		start := time.Now()
+
+		requestID := ctx.Get(requestIDHeaderKey)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+		ctx.Set(requestIDHeaderKey, requestID)
+		ctx.SetUserContext(logging.WithRequestID(ctx.UserContext(), requestID))
+
+		if logger.Enabled(ctx.UserContext(), slog.LevelDebug) {
+			logRequestBody(ctx, logger)
+		}
+
+		nextWithErrorHandled(ctx)
+
+		status := ctx.Response().StatusCode()
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Method()),
+			slog.String("route", ctx.Route().Path),
+			slog.String("path", ctx.Path()),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start))/float64(time.Millisecond)),
+		}
+		if userID := requestUserID(ctx); userID != "" {
+			attrs = append(attrs, slog.String("user_id", userID))
+		}
+
+		logger.LogAttrs(ctx.UserContext(), level, "request handled", attrs...)
+		return nil
+	}
+}
+
+// logRequestBody logs the body of a JSON request once redacted.
+// Other bodies, such as the documents of a KYC submission, are never logged, only their size is.
+func logRequestBody(ctx *fiber.Ctx, logger *slog.Logger) {
+	body := ctx.Body()
+	if len(body) == 0 {
+		return
+	}
+
+	redacted, ok := logging.RedactJSON(body)
+	if !ok {
+		logger.DebugContext(ctx.UserContext(), "request body",
+			slog.String("content_type", ctx.Get(fiber.HeaderContentType)),
+			slog.Int("size", len(body)),
+		)
+		return
+	}
+
+	logger.DebugContext(ctx.UserContext(), "request body", slog.Any("body", redacted))
+}
+
+// requestUserID returns the ID of the user or partner authenticated by the request, if any
+func requestUserID(ctx *fiber.Ctx) string {
+	if payload, ok := ctx.Locals(authorizationPayloadKey).(*token.Payload); ok {
+		return payload.UserID.String()
+	}
+	if output, ok := ctx.Locals(partnerPayloadKey).(*service.AuthenticateAPIKeyOutput); ok {
+		return output.Partner.ID.String()
+	}
+	return ""
+}
+
+// rateLimitMiddleware creates a fiber middleware enforcing the rate limit policy with the given name,
+// it must come after the middleware authenticating the requests when they are counted by user or API key.
+// Requests are not limited when the policy is not configured, nor when the limiter cannot count them,
+// as rejecting every request because the store is down would be worse than not limiting them for a while.
+// The policy is looked up on every request, as the policies can be reloaded while the server runs.
+func rateLimitMiddleware(limiter *ratelimit.Limiter, policyName string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		policy, ok := limiter.Policy(policyName)
+		if !ok {
+			return ctx.Next()
+		}
+
+		result, err := limiter.Allow(ctx.UserContext(), policy, rateLimitKey(ctx, policy.Key))
+		if err != nil {
+			slog.ErrorContext(ctx.UserContext(), "can not apply rate limit", "policy", policy.Name, "err", err)
+			return ctx.Next()
+		}
+
+		ctx.Set(rateLimitPolicyHeaderKey, policy.String())
+		ctx.Set(rateLimitLimitHeaderKey, strconv.FormatInt(result.Limit, 10))
+		ctx.Set(rateLimitRemainingHeaderKey, strconv.FormatInt(result.Remaining, 10))
+		ctx.Set(rateLimitResetHeaderKey, strconv.FormatInt(ceilSeconds(result.Reset), 10))
+
+		if !result.Allowed {
+			retryAfter := ceilSeconds(result.RetryAfter)
+			ctx.Set(fiber.HeaderRetryAfter, strconv.FormatInt(retryAfter, 10))
+
+			msg := i18n.NewMessage("request.rate_limited").With("retry_after", retryAfter)
+			return errorResponse(ctx, fiber.StatusTooManyRequests, msg)
+		}
+
+		return ctx.Next()
+	}
+}
+
+// maintenanceMiddleware creates a fiber middleware which rejects the requests while the maintenance mode is on
+func maintenanceMiddleware(maintenance *atomic.Bool) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if maintenance.Load() {
+			return errorResponse(ctx, fiber.StatusServiceUnavailable, i18n.NewMessage("request.maintenance"))
+		}
+		return ctx.Next()
+	}
+}
+
+// forwardedForMiddleware creates a fiber middleware which replaces the X-Forwarded-For header of the request
+// with the client IP, as resolved from the trusted proxies, so that the gateway forwards it to the gRPC server
+// rather than an address claimed by the client
+func forwardedForMiddleware() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		ctx.Request().Header.Set(fiber.HeaderXForwardedFor, ctx.IP())
+		return ctx.Next()
+	}
+}
+
+// rateLimitKey returns what the requests are counted by.
+// Anonymous requests are counted by IP even when the policy counts them by user or API key.
+func rateLimitKey(ctx *fiber.Ctx, key string) string {
+	switch key {
+	case ratelimit.KeyUser:
+		if payload, ok := ctx.Locals(authorizationPayloadKey).(*token.Payload); ok {
+			return "user:" + payload.UserID.String()
+		}
+	case ratelimit.KeyAPIKey:
+		if output, ok := ctx.Locals(partnerPayloadKey).(*service.AuthenticateAPIKeyOutput); ok {
+			return "api_key:" + output.APIKey.ID.String()
+		}
+	}
+	return "ip:" + ctx.IP()
+}
+
+// ceilSeconds rounds a duration up to whole seconds, as the RateLimit headers expect
+func ceilSeconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
+}
+
+// idempotencyMiddleware creates a fiber middleware which lets clients retry a state-changing request
+// with an Idempotency-Key header without applying it twice. The response of the first attempt is stored
+// and replayed to the retries, while a key reused for another request or a retry sent while the first attempt
+// is still being handled is rejected. It must come after the middleware authenticating the requests,
+// as the keys belong to the client sending them.
+// Requests without the header are handled as usual. The responses of server errors are not stored,
+// so that the request can be retried once the server has recovered.
+func idempotencyMiddleware(idempotencyService service.IdempotencyService) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		key := ctx.Get(idempotencyKeyHeaderKey)
+		if key == "" || isSafeMethod(ctx.Method()) {
+			return ctx.Next()
+		}
+
+		if len(key) > maxIdempotencyKeyLength || !isVisibleASCII(key) {
+			msg := i18n.NewMessage("idempotency.key_invalid").With("max", maxIdempotencyKeyLength)
+			return errorResponse(ctx, fiber.StatusBadRequest, msg)
+		}
+
+		scope := idempotencyScope(ctx)
+		output, err := idempotencyService.BeginRequest(ctx.UserContext(), &service.BeginIdempotentRequestInput{
+			Scope:       scope,
+			Key:         key,
+			Fingerprint: requestFingerprint(ctx),
+		})
+		if err != nil {
+			if errors.Is(err, service.ErrRequestInProgress) {
+				ctx.Set(fiber.HeaderRetryAfter, "1")
+			}
+			return problemResponse(ctx, FromServiceError(err))
+		}
+
+		if replay := output.Replay; replay != nil {
+			ctx.Set(idempotentReplayedHeaderKey, "true")
+			ctx.Set(fiber.HeaderContentType, replay.ContentType)
+			return ctx.Status(replay.StatusCode).Send(replay.Body)
+		}
+
+		nextWithErrorHandled(ctx)
+
+		statusCode := ctx.Response().StatusCode()
+		if statusCode >= fiber.StatusInternalServerError {
+			err = idempotencyService.AbandonRequest(ctx.UserContext(), &service.AbandonIdempotentRequestInput{
+				Scope: scope,
+				Key:   key,
+			})
+			if err != nil {
+				slog.ErrorContext(ctx.UserContext(), "can not abandon idempotent request", "err", err)
+			}
+			return nil
+		}
+
+		err = idempotencyService.CompleteRequest(ctx.UserContext(), &service.CompleteIdempotentRequestInput{
+			Scope: scope,
+			Key:   key,
+			Response: service.IdempotentResponse{
+				StatusCode:  statusCode,
+				ContentType: string(ctx.Response().Header.ContentType()),
+				Body:        bytes.Clone(ctx.Response().Body()),
+			},
+		})
+		if err != nil {
+			slog.ErrorContext(ctx.UserContext(), "can not complete idempotent request", "err", err)
+		}
+		return nil
+	}
+}
+
+// idempotencyScope returns the client an idempotency key belongs to, so that clients never replay
+// the responses of each other. Partners keep their keys across the rotation of their API keys.
+func idempotencyScope(ctx *fiber.Ctx) string {
+	if payload, ok := ctx.Locals(authorizationPayloadKey).(*token.Payload); ok {
+		return "user:" + payload.UserID.String()
+	}
+	if output, ok := ctx.Locals(partnerPayloadKey).(*service.AuthenticateAPIKeyOutput); ok {
+		return "partner:" + output.Partner.ID.String()
+	}
+	return "ip:" + ctx.IP()
+}
+
+// requestFingerprint identifies a request by its method, path, query string and body
+func requestFingerprint(ctx *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(ctx.Method() + "\n" + ctx.OriginalURL() + "\n"))
+	hash.Write(ctx.Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func isVisibleASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '!' || s[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// localeMiddleware creates a fiber middleware which negotiates the language of the responses
+// from the Accept-Language header of the request
+func localeMiddleware(bundle *i18n.Bundle) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		loc := bundle.Localizer(i18n.Negotiate(ctx.Get(fiber.HeaderAcceptLanguage)))
+
+		ctx.Locals(localizerKey, loc)
+		ctx.Set(fiber.HeaderContentLanguage, loc.Locale())
+		ctx.Vary(fiber.HeaderAcceptLanguage)
+		return ctx.Next()
+	}
+}
+
+// localizer returns the localizer stored by localeMiddleware
+func localizer(ctx *fiber.Ctx) *i18n.Localizer {
+	return ctx.Locals(localizerKey).(*i18n.Localizer)
+}
+
+// authMiddleware creates a fiber middleware for authorization
+func authMiddleware(tokenMaker token.Maker) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		accessToken, msg := bearerToken(ctx)
+		if !msg.IsZero() {
+			return errorResponse(ctx, fiber.StatusUnauthorized, msg)
+		}
+
+		payload, err := tokenMaker.VerifyToken(accessToken)
+		if err != nil {
+			msg := i18n.NewMessage("auth.token_invalid")
+			if errors.Is(err, token.ErrExpiredToken) {
+				msg = i18n.NewMessage("auth.token_expired")
+			}
+			return errorResponse(ctx, fiber.StatusUnauthorized, msg)
+		}
+
+		ctx.Locals(authorizationPayloadKey, payload)
+		return ctx.Next()
+	}
+}
+
+// bearerToken extracts the bearer credential of the authorization header,
+// or explains why the header does not hold one
+func bearerToken(ctx *fiber.Ctx) (string, i18n.Message) {
+	authorizationHeader := ctx.Get(authorizationHeaderKey)
+	if len(authorizationHeader) == 0 {
+		return "", i18n.NewMessage("auth.header_missing")
+	}
+
+	fields := strings.Fields(authorizationHeader)
+	if len(fields) < 2 {
+		return "", i18n.NewMessage("auth.header_invalid_format")
+	}
+
+	authorizationType := strings.ToLower(fields[0])
+	if authorizationType != authorizationTypeBearer {
+		return "", i18n.NewMessage("auth.unsupported_type").With("type", authorizationType)
+	}
+
+	return fields[1], i18n.Message{}
+}
+
+// authPayload returns the token payload stored by authMiddleware
+func authPayload(ctx *fiber.Ctx) *token.Payload {
+	return ctx.Locals(authorizationPayloadKey).(*token.Payload)
+}
+
+// roleMiddleware creates a fiber middleware which only lets users with one of the given roles pass.
+// It must be chained after authMiddleware.
+func roleMiddleware(roles ...string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		payload := authPayload(ctx)
+
+		for _, role := range roles {
+			if payload.Role == role {
+				return ctx.Next()
+			}
+		}
+
+		msg := i18n.NewMessage("auth.role_forbidden").With("role", payload.Role)
+		return errorResponse(ctx, fiber.StatusForbidden, msg)
+	}
+}
+
+// adminMiddleware creates a fiber middleware which only lets active admins pass, as they are now in the DB
+// rather than as they were when their token was issued. It must be chained after roleMiddleware.
+func adminMiddleware(adminService service.AdminService) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		err := adminService.RequireAdmin(ctx.UserContext(), authPayload(ctx).UserID)
+		if err != nil {
+			apiError := FromServiceError(err)
+			return problemResponse(ctx, apiError)
+		}
+
+		return ctx.Next()
+	}
+}
+
+// kycMiddleware creates a fiber middleware which only lets the users and partners whose identity has been verified pass.
+// It must be chained after the middleware authenticating the requests on every sensitive route,
+// such as the routes onboarding borrowers or moving money.
+func kycMiddleware(kycService service.KYCService) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var userID uuid.UUID
+		if output, ok := ctx.Locals(partnerPayloadKey).(*service.AuthenticateAPIKeyOutput); ok {
+			userID = output.Partner.ID
+		} else {
+			userID = authPayload(ctx).UserID
+		}
+
+		err := kycService.RequireKYCVerified(ctx.UserContext(), userID)
+		if err != nil {
+			apiError := FromServiceError(err)
+			return problemResponse(ctx, apiError)
+		}
+
+		return ctx.Next()
+	}
+}
+
+// apiKeyMiddleware creates a fiber middleware which authenticates partners by their API key.
+// A request is either signed with HMAC-SHA256, when it has an X-Signature header,
+// or carries the API key itself as a bearer credential.
+func apiKeyMiddleware(apiKeyService service.APIKeyService) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var output *service.AuthenticateAPIKeyOutput
+		var err error
+
+		if signature := ctx.Get(signatureHeaderKey); signature != "" {
+			timestamp, parseErr := strconv.ParseInt(ctx.Get(timestampHeaderKey), 10, 64)
+			if parseErr != nil {
+				msg := i18n.NewMessage("api_key.header_invalid").With("header", timestampHeaderKey)
+				return errorResponse(ctx, fiber.StatusUnauthorized, msg)
+			}
+
+			for _, header := range []string{apiKeyIDHeaderKey, nonceHeaderKey} {
+				if ctx.Get(header) == "" {
+					msg := i18n.NewMessage("api_key.header_invalid").With("header", header)
+					return errorResponse(ctx, fiber.StatusUnauthorized, msg)
+				}
+			}
+
+			input := &service.AuthenticateSignedRequestInput{
+				KeyID:     ctx.Get(apiKeyIDHeaderKey),
+				Timestamp: timestamp,
+				Nonce:     ctx.Get(nonceHeaderKey),
+				Signature: signature,
+				Method:    ctx.Method(),
+				Path:      ctx.OriginalURL(),
+				Body:      ctx.Body(),
+			}
+			output, err = apiKeyService.AuthenticateSignedRequest(ctx.UserContext(), input)
+		} else {
+			key, msg := bearerToken(ctx)
+			if !msg.IsZero() {
+				return errorResponse(ctx, fiber.StatusUnauthorized, msg)
+			}
+
+			input := &service.AuthenticateAPIKeyInput{
+				Key: key,
+			}
+			output, err = apiKeyService.AuthenticateAPIKey(ctx.UserContext(), input)
+		}
+		if err != nil {
+			apiError := FromServiceError(err)
+			return problemResponse(ctx, apiError)
+		}
+
+		ctx.Locals(partnerPayloadKey, output)
+		return ctx.Next()
+	}
+}
+
+// partnerPayload returns the partner and the API key stored by apiKeyMiddleware
+func partnerPayload(ctx *fiber.Ctx) *service.AuthenticateAPIKeyOutput {
+	return ctx.Locals(partnerPayloadKey).(*service.AuthenticateAPIKeyOutput)
+}
+
+// scopeMiddleware creates a fiber middleware which only lets API keys granted all the given scopes pass.
+// It must be chained after apiKeyMiddleware.
+func scopeMiddleware(scopes ...string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		apiKey := partnerPayload(ctx).APIKey
+
+		for _, scope := range scopes {
+			if !slices.Contains(apiKey.Scopes, scope) {
+				msg := i18n.NewMessage("auth.scope_missing").With("scope", scope)
+				return errorResponse(ctx, fiber.StatusForbidden, msg)
+			}
+		}
+
+		return ctx.Next()
+	}
+}
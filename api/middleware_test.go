@@ -0,0 +1,1163 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/logging"
+	"github.com/DamianZhang/957-lending-platform/ratelimit"
+	"github.com/DamianZhang/957-lending-platform/service"
+	mocksvc "github.com/DamianZhang/957-lending-platform/service/mock"
+	"github.com/DamianZhang/957-lending-platform/token"
+	"github.com/DamianZhang/957-lending-platform/tracing"
+	"github.com/DamianZhang/957-lending-platform/tracing/tracingtest"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func addAuthorization(
+	t *testing.T,
+	request *http.Request,
+	tokenMaker token.Maker,
+	authorizationType string,
+	userID uuid.UUID,
+	role string,
+	duration time.Duration,
+) {
+	token, payload, err := tokenMaker.CreateToken(userID, role, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	authorizationHeader := fmt.Sprintf("%s %s", authorizationType, token)
+	request.Header.Set(authorizationHeaderKey, authorizationHeader)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	userID := uuid.New()
+	role := util.RandomRole()
+
+	testCases := []struct {
+		name      string
+		setUpAuth func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		checkRsp  func(rsp *http.Response)
+	}{
+		{
+			name: "OK",
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, userID, role, time.Minute)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusUnauthorized, rsp.StatusCode)
+			},
+		},
+		{
+			name: "UnsupportedAuthorization",
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, "unsupported", userID, role, time.Minute)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusUnauthorized, rsp.StatusCode)
+			},
+		},
+		{
+			name: "InvalidAuthorizationFormat",
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, "", userID, role, time.Minute)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusUnauthorized, rsp.StatusCode)
+			},
+		},
+		{
+			name: "ExpiredToken",
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, userID, role, -time.Minute)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusUnauthorized, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil, nil, nil, nil, nil, nil, nil, nil)
+
+			authPath := "/auth"
+			server.app.Get(
+				authPath,
+				authMiddleware(server.tokenMaker),
+				func(ctx *fiber.Ctx) error {
+					return ctx.Status(fiber.StatusOK).JSON(fiber.Map{})
+				},
+			)
+
+			request := httptest.NewRequest(http.MethodGet, authPath, nil)
+			tc.setUpAuth(t, request, server.tokenMaker)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+func TestAdminMiddleware(t *testing.T) {
+	adminID := uuid.New()
+
+	testCases := []struct {
+		name       string
+		buildStubs func(svc *mocksvc.MockAdminService)
+		checkRsp   func(rsp *http.Response)
+	}{
+		{
+			name: "ActiveAdmin",
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				svc.EXPECT().
+					RequireAdmin(gomock.Any(), gomock.Eq(adminID)).
+					Times(1).
+					Return(nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+			},
+		},
+		{
+			name: "Suspended",
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				svc.EXPECT().
+					RequireAdmin(gomock.Any(), gomock.Eq(adminID)).
+					Times(1).
+					Return(service.NewError(service.ErrAccountInactive, nil))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusForbidden, rsp.StatusCode)
+			},
+		},
+		{
+			name: "Demoted",
+			buildStubs: func(svc *mocksvc.MockAdminService) {
+				svc.EXPECT().
+					RequireAdmin(gomock.Any(), gomock.Eq(adminID)).
+					Times(1).
+					Return(service.NewError(service.ErrForbidden, nil))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusForbidden, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockAdminService(ctrl)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, nil, svc, nil, nil, nil, nil, nil)
+
+			adminPath := "/admin"
+			server.app.Get(
+				adminPath,
+				authMiddleware(server.tokenMaker),
+				roleMiddleware(util.AdminRole),
+				adminMiddleware(svc),
+				func(ctx *fiber.Ctx) error {
+					return ctx.Status(fiber.StatusOK).JSON(fiber.Map{})
+				},
+			)
+
+			request := httptest.NewRequest(http.MethodGet, adminPath, nil)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, adminID, util.AdminRole, time.Minute)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+func TestKYCMiddleware(t *testing.T) {
+	userID := uuid.New()
+
+	testCases := []struct {
+		name       string
+		buildStubs func(svc *mocksvc.MockKYCService)
+		checkRsp   func(rsp *http.Response)
+	}{
+		{
+			name: "Verified",
+			buildStubs: func(svc *mocksvc.MockKYCService) {
+				svc.EXPECT().
+					RequireKYCVerified(gomock.Any(), gomock.Eq(userID)).
+					Times(1).
+					Return(nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+			},
+		},
+		{
+			name: "NotVerified",
+			buildStubs: func(svc *mocksvc.MockKYCService) {
+				svc.EXPECT().
+					RequireKYCVerified(gomock.Any(), gomock.Eq(userID)).
+					Times(1).
+					Return(service.NewError(service.ErrKYCRequired, nil))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusForbidden, rsp.StatusCode)
+
+				var problem ProblemResponse
+				require.NoError(t, json.NewDecoder(rsp.Body).Decode(&problem))
+				require.Equal(t, "kyc_required", problem.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockKYCService(ctrl)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, nil, nil, nil, svc, nil, nil, nil)
+
+			kycPath := "/kyc"
+			server.app.Get(
+				kycPath,
+				authMiddleware(server.tokenMaker),
+				kycMiddleware(svc),
+				func(ctx *fiber.Ctx) error {
+					return ctx.Status(fiber.StatusOK).JSON(fiber.Map{})
+				},
+			)
+
+			request := httptest.NewRequest(http.MethodGet, kycPath, nil)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, userID, util.BorrowerRole, time.Minute)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+func TestLocaleMiddleware(t *testing.T) {
+	testCases := []struct {
+		name                    string
+		acceptLanguage          string
+		expectedContentLanguage string
+		expectedDetail          string
+	}{
+		{
+			name:                    "Default",
+			expectedContentLanguage: "en",
+			expectedDetail:          "authorization header is not provided",
+		},
+		{
+			name:                    "TraditionalChinese",
+			acceptLanguage:          "zh-TW,zh;q=0.9,en;q=0.8",
+			expectedContentLanguage: "zh-TW",
+			expectedDetail:          "未提供授權標頭",
+		},
+		{
+			name:                    "SimplifiedChinese",
+			acceptLanguage:          "zh-CN",
+			expectedContentLanguage: "zh-CN",
+			expectedDetail:          "未提供授权标头",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil, nil, nil, nil, nil, nil, nil, nil)
+
+			request := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+			if tc.acceptLanguage != "" {
+				request.Header.Set(fiber.HeaderAcceptLanguage, tc.acceptLanguage)
+			}
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			require.Equal(t, fiber.StatusUnauthorized, rsp.StatusCode)
+			require.Equal(t, tc.expectedContentLanguage, rsp.Header.Get(fiber.HeaderContentLanguage))
+
+			var problem ProblemResponse
+			unmarshalRsp(t, rsp, &problem)
+			require.Equal(t, tc.expectedDetail, problem.Detail)
+		})
+	}
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	output := randomPartnerPayload(t, util.ScopeBorrowersRead)
+	key := util.FormatAPIKey(output.APIKey.KeyID, util.RandomString(43))
+	body := `{"amount":1000}`
+	timestamp := time.Now().Unix()
+
+	testCases := []struct {
+		name       string
+		setUpAuth  func(request *http.Request)
+		buildStubs func(svc *mocksvc.MockAPIKeyService)
+		checkRsp   func(rsp *http.Response)
+	}{
+		{
+			name: "BearerOK",
+			setUpAuth: func(request *http.Request) {
+				request.Header.Set(authorizationHeaderKey, "Bearer "+key)
+			},
+			buildStubs: func(svc *mocksvc.MockAPIKeyService) {
+				svc.EXPECT().
+					AuthenticateAPIKey(gomock.Any(), gomock.Eq(&service.AuthenticateAPIKeyInput{Key: key})).
+					Times(1).
+					Return(output, nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+			},
+		},
+		{
+			name: "SignedOK",
+			setUpAuth: func(request *http.Request) {
+				request.Header.Set(apiKeyIDHeaderKey, output.APIKey.KeyID)
+				request.Header.Set(timestampHeaderKey, fmt.Sprint(timestamp))
+				request.Header.Set(nonceHeaderKey, "nonce")
+				request.Header.Set(signatureHeaderKey, "signature")
+			},
+			buildStubs: func(svc *mocksvc.MockAPIKeyService) {
+				input := &service.AuthenticateSignedRequestInput{
+					KeyID:     output.APIKey.KeyID,
+					Timestamp: timestamp,
+					Nonce:     "nonce",
+					Signature: "signature",
+					Method:    http.MethodPost,
+					Path:      "/partner?page=1",
+					Body:      []byte(body),
+				}
+				svc.EXPECT().
+					AuthenticateSignedRequest(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(output, nil)
+				svc.EXPECT().
+					AuthenticateAPIKey(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+			},
+		},
+		{
+			name: "SignedInvalidTimestamp",
+			setUpAuth: func(request *http.Request) {
+				request.Header.Set(apiKeyIDHeaderKey, output.APIKey.KeyID)
+				request.Header.Set(timestampHeaderKey, "yesterday")
+				request.Header.Set(nonceHeaderKey, "nonce")
+				request.Header.Set(signatureHeaderKey, "signature")
+			},
+			buildStubs: func(svc *mocksvc.MockAPIKeyService) {
+				svc.EXPECT().
+					AuthenticateSignedRequest(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusUnauthorized, rsp.StatusCode)
+			},
+		},
+		{
+			name: "SignedMissingNonce",
+			setUpAuth: func(request *http.Request) {
+				request.Header.Set(apiKeyIDHeaderKey, output.APIKey.KeyID)
+				request.Header.Set(timestampHeaderKey, fmt.Sprint(timestamp))
+				request.Header.Set(signatureHeaderKey, "signature")
+			},
+			buildStubs: func(svc *mocksvc.MockAPIKeyService) {
+				svc.EXPECT().
+					AuthenticateSignedRequest(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusUnauthorized, rsp.StatusCode)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			setUpAuth: func(request *http.Request) {},
+			buildStubs: func(svc *mocksvc.MockAPIKeyService) {
+				svc.EXPECT().
+					AuthenticateAPIKey(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusUnauthorized, rsp.StatusCode)
+			},
+		},
+		{
+			name: "Unauthenticated",
+			setUpAuth: func(request *http.Request) {
+				request.Header.Set(authorizationHeaderKey, "Bearer "+key)
+			},
+			buildStubs: func(svc *mocksvc.MockAPIKeyService) {
+				svc.EXPECT().
+					AuthenticateAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewError(service.ErrUnauthenticated, nil))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusUnauthorized, rsp.StatusCode)
+			},
+		},
+		{
+			name: "PartnerInactive",
+			setUpAuth: func(request *http.Request) {
+				request.Header.Set(authorizationHeaderKey, "Bearer "+key)
+			},
+			buildStubs: func(svc *mocksvc.MockAPIKeyService) {
+				svc.EXPECT().
+					AuthenticateAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewError(service.ErrAccountInactive, nil))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusForbidden, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockAPIKeyService(ctrl)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, nil, nil, nil, nil, svc, nil, nil)
+
+			partnerPath := "/partner"
+			server.app.Post(
+				partnerPath,
+				apiKeyMiddleware(svc),
+				func(ctx *fiber.Ctx) error {
+					require.Equal(t, output, partnerPayload(ctx))
+					return ctx.Status(fiber.StatusOK).JSON(fiber.Map{})
+				},
+			)
+
+			request := httptest.NewRequest(http.MethodPost, partnerPath+"?page=1", strings.NewReader(body))
+			tc.setUpAuth(request)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+func TestScopeMiddleware(t *testing.T) {
+	testCases := []struct {
+		name               string
+		scopes             []string
+		expectedStatusCode int
+	}{
+		{
+			name:               "Granted",
+			scopes:             []string{util.ScopeBorrowersRead, util.ScopeBorrowersWrite},
+			expectedStatusCode: fiber.StatusOK,
+		},
+		{
+			name:               "PartlyGranted",
+			scopes:             []string{util.ScopeBorrowersRead},
+			expectedStatusCode: fiber.StatusForbidden,
+		},
+		{
+			name:               "NotGranted",
+			scopes:             []string{util.ScopeLoansRead},
+			expectedStatusCode: fiber.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil, nil, nil, nil, nil, nil, nil, nil)
+
+			scopePath := "/scope"
+			server.app.Get(
+				scopePath,
+				func(ctx *fiber.Ctx) error {
+					ctx.Locals(partnerPayloadKey, randomPartnerPayload(t, tc.scopes...))
+					return ctx.Next()
+				},
+				scopeMiddleware(util.ScopeBorrowersRead, util.ScopeBorrowersWrite),
+				func(ctx *fiber.Ctx) error {
+					return ctx.Status(fiber.StatusOK).JSON(fiber.Map{})
+				},
+			)
+
+			request := httptest.NewRequest(http.MethodGet, scopePath, nil)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStatusCode, rsp.StatusCode)
+		})
+	}
+}
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Increment(ctx context.Context, key string, windowStart time.Time, window time.Duration) (int64, int64, error) {
+	return 0, 0, errors.New("store is down")
+}
+
+func (failingRateLimitStore) Purge(ctx context.Context, now time.Time) (int64, error) {
+	return 0, errors.New("store is down")
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	policy := ratelimit.Policy{Name: "test", Limit: 2, Window: time.Hour, Key: ratelimit.KeyUser}
+	userID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		store         ratelimit.Store
+		policies      map[string]ratelimit.Policy
+		checkResponse func(t *testing.T, send func(userID uuid.UUID) *http.Response)
+	}{
+		{
+			name:     "Limited",
+			store:    ratelimit.NewMemoryStore(),
+			policies: map[string]ratelimit.Policy{policy.Name: policy},
+			checkResponse: func(t *testing.T, send func(userID uuid.UUID) *http.Response) {
+				for i := int64(1); i <= policy.Limit; i++ {
+					rsp := send(userID)
+					require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+					require.Equal(t, "2;w=3600", rsp.Header.Get(rateLimitPolicyHeaderKey))
+					require.Equal(t, "2", rsp.Header.Get(rateLimitLimitHeaderKey))
+					require.Equal(t, fmt.Sprint(policy.Limit-i), rsp.Header.Get(rateLimitRemainingHeaderKey))
+					require.NotEmpty(t, rsp.Header.Get(rateLimitResetHeaderKey))
+				}
+
+				rsp := send(userID)
+				require.Equal(t, fiber.StatusTooManyRequests, rsp.StatusCode)
+				require.Equal(t, "0", rsp.Header.Get(rateLimitRemainingHeaderKey))
+
+				retryAfter, err := strconv.Atoi(rsp.Header.Get(fiber.HeaderRetryAfter))
+				require.NoError(t, err)
+				require.Positive(t, retryAfter)
+
+				var problem ProblemResponse
+				require.NoError(t, json.NewDecoder(rsp.Body).Decode(&problem))
+				require.Equal(t, "rate_limited", problem.Code)
+
+				// the requests of every user are counted separately
+				rsp = send(uuid.New())
+				require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+			},
+		},
+		{
+			name:     "PolicyNotConfigured",
+			store:    ratelimit.NewMemoryStore(),
+			policies: nil,
+			checkResponse: func(t *testing.T, send func(userID uuid.UUID) *http.Response) {
+				for i := int64(0); i <= policy.Limit; i++ {
+					rsp := send(userID)
+					require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+					require.Empty(t, rsp.Header.Get(rateLimitPolicyHeaderKey))
+				}
+			},
+		},
+		{
+			name:     "StoreFailure",
+			store:    failingRateLimitStore{},
+			policies: map[string]ratelimit.Policy{policy.Name: policy},
+			checkResponse: func(t *testing.T, send func(userID uuid.UUID) *http.Response) {
+				for i := int64(0); i <= policy.Limit; i++ {
+					rsp := send(userID)
+					require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+					require.Empty(t, rsp.Header.Get(rateLimitPolicyHeaderKey))
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil, nil, nil, nil, nil, nil, nil, nil)
+			limiter := ratelimit.NewLimiter(tc.store, tc.policies)
+
+			rateLimitPath := "/rate_limit"
+			server.app.Get(
+				rateLimitPath,
+				func(ctx *fiber.Ctx) error {
+					userID, err := uuid.Parse(ctx.Get("X-User-ID"))
+					require.NoError(t, err)
+					ctx.Locals(authorizationPayloadKey, &token.Payload{UserID: userID})
+					return ctx.Next()
+				},
+				rateLimitMiddleware(limiter, policy.Name),
+				func(ctx *fiber.Ctx) error {
+					return ctx.Status(fiber.StatusOK).JSON(fiber.Map{})
+				},
+			)
+
+			tc.checkResponse(t, func(userID uuid.UUID) *http.Response {
+				request := httptest.NewRequest(http.MethodGet, rateLimitPath, nil)
+				request.Header.Set("X-User-ID", userID.String())
+
+				rsp, err := server.app.Test(request)
+				require.NoError(t, err)
+				return rsp
+			})
+		})
+	}
+}
+
+func TestRateLimitMiddlewareReloadedPolicies(t *testing.T) {
+	policy := ratelimit.Policy{Name: "test", Limit: 1, Window: time.Hour, Key: ratelimit.KeyIP}
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), nil)
+
+	server := newTestServer(t, nil, nil, nil, nil, nil, nil, nil, nil)
+	server.app.Get("/rate_limit", rateLimitMiddleware(limiter, policy.Name), func(ctx *fiber.Ctx) error {
+		return ctx.SendStatus(fiber.StatusOK)
+	})
+
+	send := func() *http.Response {
+		rsp, err := server.app.Test(httptest.NewRequest(http.MethodGet, "/rate_limit", nil))
+		require.NoError(t, err)
+		return rsp
+	}
+
+	// the policy configured after the routes are set up is enforced from the next request on
+	require.Empty(t, send().Header.Get(rateLimitPolicyHeaderKey))
+
+	limiter.SetPolicies(map[string]ratelimit.Policy{policy.Name: policy})
+	rsp := send()
+	require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+	require.Equal(t, policy.String(), rsp.Header.Get(rateLimitPolicyHeaderKey))
+	require.Equal(t, fiber.StatusTooManyRequests, send().StatusCode)
+}
+
+func TestMaintenanceMiddleware(t *testing.T) {
+	server := newTestServer(t, nil, nil, nil, nil, nil, nil, nil, nil)
+	server.SetMaintenanceMode(true)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/users/sign_in", nil)
+	rsp, err := server.app.Test(request)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusServiceUnavailable, rsp.StatusCode)
+
+	var problem ProblemResponse
+	require.NoError(t, json.NewDecoder(rsp.Body).Decode(&problem))
+	require.Equal(t, "unavailable", problem.Code)
+
+	// the documents and metrics are still served
+	for _, path := range []string{openAPIPath, "/metrics"} {
+		rsp, err = server.app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+	}
+
+	server.SetMaintenanceMode(false)
+	rsp, err = server.app.Test(httptest.NewRequest(http.MethodPost, "/api/v1/users/sign_in", nil))
+	require.NoError(t, err)
+	require.NotEqual(t, fiber.StatusServiceUnavailable, rsp.StatusCode)
+}
+
+func TestRateLimitKey(t *testing.T) {
+	userID := uuid.New()
+	partner := randomPartnerPayload(t)
+
+	testCases := []struct {
+		name        string
+		key         string
+		locals      func(ctx *fiber.Ctx)
+		expectedKey string
+	}{
+		{
+			name:        "IP",
+			key:         ratelimit.KeyIP,
+			locals:      func(ctx *fiber.Ctx) {},
+			expectedKey: "ip:0.0.0.0",
+		},
+		{
+			name: "User",
+			key:  ratelimit.KeyUser,
+			locals: func(ctx *fiber.Ctx) {
+				ctx.Locals(authorizationPayloadKey, &token.Payload{UserID: userID})
+			},
+			expectedKey: "user:" + userID.String(),
+		},
+		{
+			name:        "AnonymousUser",
+			key:         ratelimit.KeyUser,
+			locals:      func(ctx *fiber.Ctx) {},
+			expectedKey: "ip:0.0.0.0",
+		},
+		{
+			name: "APIKey",
+			key:  ratelimit.KeyAPIKey,
+			locals: func(ctx *fiber.Ctx) {
+				ctx.Locals(partnerPayloadKey, partner)
+			},
+			expectedKey: "api_key:" + partner.APIKey.ID.String(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var key string
+			app := fiber.New()
+			app.Get("/", func(ctx *fiber.Ctx) error {
+				tc.locals(ctx)
+				key = rateLimitKey(ctx, tc.key)
+				return ctx.SendStatus(fiber.StatusNoContent)
+			})
+
+			_, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedKey, key)
+		})
+	}
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	userID := uuid.New()
+	key := uuid.NewString()
+	body := `{"nickname":"john"}`
+	replay := &service.IdempotentResponse{
+		StatusCode:  fiber.StatusCreated,
+		ContentType: fiber.MIMEApplicationJSON,
+		Body:        []byte(`{"id":"first"}`),
+	}
+
+	testCases := []struct {
+		name            string
+		method          string
+		key             string
+		handlerErr      error
+		buildStubs      func(svc *mocksvc.MockIdempotencyService)
+		expectedHandled bool
+		checkResponse   func(t *testing.T, rsp *http.Response)
+	}{
+		{
+			name:   "Handled",
+			method: http.MethodPost,
+			key:    key,
+			buildStubs: func(svc *mocksvc.MockIdempotencyService) {
+				fingerprint := sha256.Sum256([]byte(http.MethodPost + "\n/idempotent?page=1\n" + body))
+				svc.EXPECT().
+					BeginRequest(gomock.Any(), gomock.Eq(&service.BeginIdempotentRequestInput{
+						Scope:       "user:" + userID.String(),
+						Key:         key,
+						Fingerprint: hex.EncodeToString(fingerprint[:]),
+					})).
+					Times(1).
+					Return(&service.BeginIdempotentRequestOutput{}, nil)
+				svc.EXPECT().
+					CompleteRequest(gomock.Any(), gomock.Eq(&service.CompleteIdempotentRequestInput{
+						Scope:    "user:" + userID.String(),
+						Key:      key,
+						Response: *replay,
+					})).
+					Times(1).
+					Return(nil)
+			},
+			expectedHandled: true,
+			checkResponse: func(t *testing.T, rsp *http.Response) {
+				require.Equal(t, fiber.StatusCreated, rsp.StatusCode)
+				require.Empty(t, rsp.Header.Get(idempotentReplayedHeaderKey))
+			},
+		},
+		{
+			name:   "Replayed",
+			method: http.MethodPost,
+			key:    key,
+			buildStubs: func(svc *mocksvc.MockIdempotencyService) {
+				svc.EXPECT().
+					BeginRequest(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(&service.BeginIdempotentRequestOutput{Replay: replay}, nil)
+				svc.EXPECT().
+					CompleteRequest(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *http.Response) {
+				require.Equal(t, fiber.StatusCreated, rsp.StatusCode)
+				require.Equal(t, "true", rsp.Header.Get(idempotentReplayedHeaderKey))
+				require.Equal(t, fiber.MIMEApplicationJSON, rsp.Header.Get(fiber.HeaderContentType))
+
+				data, err := io.ReadAll(rsp.Body)
+				require.NoError(t, err)
+				require.Equal(t, replay.Body, data)
+			},
+		},
+		{
+			name:   "KeyReused",
+			method: http.MethodPost,
+			key:    key,
+			buildStubs: func(svc *mocksvc.MockIdempotencyService) {
+				svc.EXPECT().
+					BeginRequest(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewErrorWithDetail(service.ErrConflict, i18n.NewMessage("idempotency.key_reused")))
+			},
+			checkResponse: func(t *testing.T, rsp *http.Response) {
+				require.Equal(t, fiber.StatusConflict, rsp.StatusCode)
+			},
+		},
+		{
+			name:   "InProgress",
+			method: http.MethodPost,
+			key:    key,
+			buildStubs: func(svc *mocksvc.MockIdempotencyService) {
+				svc.EXPECT().
+					BeginRequest(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewErrorWithDetail(service.ErrRequestInProgress, i18n.NewMessage("idempotency.request_in_progress")))
+			},
+			checkResponse: func(t *testing.T, rsp *http.Response) {
+				require.Equal(t, fiber.StatusTooEarly, rsp.StatusCode)
+				require.Equal(t, "1", rsp.Header.Get(fiber.HeaderRetryAfter))
+
+				var problem ProblemResponse
+				unmarshalRsp(t, rsp, &problem)
+				require.Equal(t, "request_in_progress", problem.Code)
+			},
+		},
+		{
+			name:       "ServerError",
+			method:     http.MethodPost,
+			key:        key,
+			handlerErr: errors.New("connection refused"),
+			buildStubs: func(svc *mocksvc.MockIdempotencyService) {
+				svc.EXPECT().
+					BeginRequest(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(&service.BeginIdempotentRequestOutput{}, nil)
+				svc.EXPECT().
+					CompleteRequest(gomock.Any(), gomock.Any()).
+					Times(0)
+				svc.EXPECT().
+					AbandonRequest(gomock.Any(), gomock.Eq(&service.AbandonIdempotentRequestInput{
+						Scope: "user:" + userID.String(),
+						Key:   key,
+					})).
+					Times(1).
+					Return(nil)
+			},
+			expectedHandled: true,
+			checkResponse: func(t *testing.T, rsp *http.Response) {
+				require.Equal(t, fiber.StatusInternalServerError, rsp.StatusCode)
+			},
+		},
+		{
+			name:   "InvalidKey",
+			method: http.MethodPost,
+			key:    "key with spaces",
+			buildStubs: func(svc *mocksvc.MockIdempotencyService) {
+				svc.EXPECT().
+					BeginRequest(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name:   "NoKey",
+			method: http.MethodPost,
+			buildStubs: func(svc *mocksvc.MockIdempotencyService) {
+				svc.EXPECT().
+					BeginRequest(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			expectedHandled: true,
+			checkResponse: func(t *testing.T, rsp *http.Response) {
+				require.Equal(t, fiber.StatusCreated, rsp.StatusCode)
+			},
+		},
+		{
+			name:   "SafeMethod",
+			method: http.MethodGet,
+			key:    key,
+			buildStubs: func(svc *mocksvc.MockIdempotencyService) {
+				svc.EXPECT().
+					BeginRequest(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			expectedHandled: true,
+			checkResponse: func(t *testing.T, rsp *http.Response) {
+				require.Equal(t, fiber.StatusCreated, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockIdempotencyService(ctrl)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, nil, nil, nil, nil, nil, nil, nil)
+
+			handled := false
+			idempotentPath := "/idempotent"
+			server.app.Add(
+				tc.method,
+				idempotentPath,
+				func(ctx *fiber.Ctx) error {
+					ctx.Locals(authorizationPayloadKey, &token.Payload{UserID: userID})
+					return ctx.Next()
+				},
+				idempotencyMiddleware(svc),
+				func(ctx *fiber.Ctx) error {
+					handled = true
+					if tc.handlerErr != nil {
+						return tc.handlerErr
+					}
+					ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+					return ctx.Status(fiber.StatusCreated).Send(replay.Body)
+				},
+			)
+
+			request := httptest.NewRequest(tc.method, idempotentPath+"?page=1", strings.NewReader(body))
+			if tc.key != "" {
+				request.Header.Set(idempotencyKeyHeaderKey, tc.key)
+			}
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedHandled, handled)
+			tc.checkResponse(t, rsp)
+		})
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	server := newTestServer(t, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	for _, path := range []string{"/healthz", "/healthz", "/api/v1/me", "/unknown"} {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		_, err := server.app.Test(request)
+		require.NoError(t, err)
+	}
+
+	expected := `
+# HELP lending_http_requests_total Number of HTTP requests handled, by method, route template and status.
+# TYPE lending_http_requests_total counter
+lending_http_requests_total{method="GET",route="/",status="404"} 1
+lending_http_requests_total{method="GET",route="/api/v1/me",status="401"} 1
+lending_http_requests_total{method="GET",route="/healthz",status="200"} 2
+`
+	err := testutil.GatherAndCompare(server.metrics.Registry, strings.NewReader(expected), "lending_http_requests_total")
+	require.NoError(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rsp, err := server.app.Test(request)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+
+	body, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `lending_http_request_duration_seconds_count{method="GET",route="/healthz",status="200"} 2`)
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	exporter := tracingtest.NewExporter(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocksvc.NewMockHealthService(ctrl)
+	svc.EXPECT().
+		CheckReadiness(gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context) *service.CheckReadinessOutput {
+			// the services are called within the span of the request
+			_, span := tracing.Tracer("test").Start(ctx, "CheckReadiness")
+			span.End()
+			return &service.CheckReadinessOutput{Ready: true}
+		})
+
+	server := newTestServer(t, nil, nil, nil, nil, nil, nil, svc, nil)
+
+	request := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	rsp, err := server.app.Test(request)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	serverSpan := tracingtest.SpanNamed(t, spans, "GET /readyz")
+	require.Equal(t, trace.SpanKindServer, serverSpan.SpanKind)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", serverSpan.SpanContext.TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", serverSpan.Parent.SpanID().String())
+	require.True(t, serverSpan.Parent.IsRemote())
+	require.Contains(t, serverSpan.Attributes, semconv.HTTPRoute("/readyz"))
+	require.Contains(t, serverSpan.Attributes, semconv.HTTPStatusCode(fiber.StatusOK))
+
+	tracingtest.RequireChildOf(t, tracingtest.SpanNamed(t, spans, "CheckReadiness"), serverSpan)
+}
+
+func TestTracingMiddlewareServerError(t *testing.T) {
+	exporter := tracingtest.NewExporter(t)
+
+	bundle, err := i18n.NewBundle()
+	require.NoError(t, err)
+
+	app := fiber.New(fiber.Config{ErrorHandler: problemErrorHandler(bundle)})
+	app.Use(tracingMiddleware())
+	app.Get("/fail", func(ctx *fiber.Ctx) error {
+		return fiber.ErrBadGateway
+	})
+	app.Get("/missing", func(ctx *fiber.Ctx) error {
+		return fiber.ErrNotFound
+	})
+
+	for _, path := range []string{"/fail", "/missing"} {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		_, err := app.Test(request)
+		require.NoError(t, err)
+	}
+
+	spans := exporter.GetSpans()
+	require.Equal(t, codes.Error, tracingtest.SpanNamed(t, spans, "GET /fail").Status.Code)
+	// client errors are not failures of the server
+	require.Equal(t, codes.Unset, tracingtest.SpanNamed(t, spans, "GET /missing").Status.Code)
+}
+
+func TestRequestLogMiddleware(t *testing.T) {
+	userID := uuid.New()
+
+	testCases := []struct {
+		name      string
+		requestID string
+		checkID   func(t *testing.T, requestID string)
+	}{
+		{
+			name:      "KeepRequestID",
+			requestID: "edge-1234.abc_DEF",
+			checkID: func(t *testing.T, requestID string) {
+				require.Equal(t, "edge-1234.abc_DEF", requestID)
+			},
+		},
+		{
+			name: "AssignRequestID",
+			checkID: func(t *testing.T, requestID string) {
+				_, err := uuid.Parse(requestID)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:      "ReplaceMalformedRequestID",
+			requestID: `forged" level=ERROR`,
+			checkID: func(t *testing.T, requestID string) {
+				_, err := uuid.Parse(requestID)
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, err := logging.New(util.Config{RuntimeConfig: util.RuntimeConfig{LogLevel: "debug"}}, new(slog.LevelVar), &buf)
+			require.NoError(t, err)
+
+			var handlerRequestID string
+			app := fiber.New()
+			app.Use(requestLogMiddleware(logger))
+			app.Put("/users/:id", func(ctx *fiber.Ctx) error {
+				ctx.Locals(authorizationPayloadKey, &token.Payload{UserID: userID})
+				handlerRequestID = logging.RequestID(ctx.UserContext())
+				return ctx.SendStatus(fiber.StatusNoContent)
+			})
+
+			body := `{"email":"john.doe@example.com","password":"Secret123!","nickname":"john"}`
+			request := httptest.NewRequest(http.MethodPut, "/users/"+userID.String(), strings.NewReader(body))
+			request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			if tc.requestID != "" {
+				request.Header.Set(requestIDHeaderKey, tc.requestID)
+			}
+
+			rsp, err := app.Test(request)
+			require.NoError(t, err)
+			require.Equal(t, fiber.StatusNoContent, rsp.StatusCode)
+
+			requestID := rsp.Header.Get(requestIDHeaderKey)
+			tc.checkID(t, requestID)
+			require.Equal(t, requestID, handlerRequestID)
+
+			// the personal data and the secrets of the body never reach the logs
+			require.NotContains(t, buf.String(), "john.doe@example.com")
+			require.NotContains(t, buf.String(), "Secret123!")
+
+			records := decodeLogRecords(t, &buf)
+			require.Len(t, records, 2)
+
+			require.Equal(t, "request body", records[0]["msg"])
+			require.Equal(t, map[string]any{
+				"email":    "j***@example.com",
+				"password": logging.Redacted,
+				"nickname": "john",
+			}, records[0]["body"])
+
+			require.Equal(t, "request handled", records[1]["msg"])
+			require.Equal(t, requestID, records[1][logging.RequestIDKey])
+			require.Equal(t, http.MethodPut, records[1]["method"])
+			require.Equal(t, "/users/:id", records[1]["route"])
+			require.Equal(t, float64(fiber.StatusNoContent), records[1]["status"])
+			require.Equal(t, userID.String(), records[1]["user_id"])
+			require.Contains(t, records[1], "latency_ms")
+		})
+	}
+}
+
+func decodeLogRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var records []map[string]any
+
+	decoder := json.NewDecoder(buf)
+	for decoder.More() {
+		var record map[string]any
+		require.NoError(t, decoder.Decode(&record))
+		records = append(records, record)
+	}
+	return records
+}
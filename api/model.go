@@ -1,13 +1,35 @@
 package api
 
-type ErrorResponse struct {
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProblemResponse is an RFC 7807 problem details body, extended with a machine-readable error code
+type ProblemResponse struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	// Errors lists the invalid fields of a request which failed validation
+	Errors []FieldErrorResponse `json:"errors,omitempty"`
+}
+
+// FieldErrorResponse explains why a field of a request failed validation
+type FieldErrorResponse struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
 	Message string `json:"message"`
 }
 
 type SignUpRequest struct {
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
-	LineID   string `json:"line_id" validate:"required,min=1,max=20"`
+	Password string `json:"password" validate:"required"`
+	LineID   string `json:"line_id" validate:"required,line_id"`
 	Nickname string `json:"nickname" validate:"required,alphanum,min=1,max=20"`
 }
 
@@ -17,3 +39,195 @@ type SignUpResponse struct {
 	Nickname string `json:"nickname"`
 	Role     string `json:"role"`
 }
+
+type UserResponse struct {
+	Email           string    `json:"email"`
+	LineID          string    `json:"line_id"`
+	Nickname        string    `json:"nickname"`
+	Role            string    `json:"role"`
+	IsEmailVerified bool      `json:"is_email_verified"`
+	KYCStatus       string    `json:"kyc_status"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type SignInRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+type SignInResponse struct {
+	AccessToken          string       `json:"access_token"`
+	AccessTokenExpiresAt time.Time    `json:"access_token_expires_at"`
+	User                 UserResponse `json:"user"`
+}
+
+type UpdateMeRequest struct {
+	Email    *string `json:"email" validate:"omitempty,email"`
+	LineID   *string `json:"line_id" validate:"omitempty,line_id"`
+	Nickname *string `json:"nickname" validate:"omitempty,alphanum,min=1,max=20"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+type CloseAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type AdminUserResponse struct {
+	ID uuid.UUID `json:"id"`
+	UserResponse
+	Status    string     `json:"status"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+type UserIDParams struct {
+	ID string `params:"id" validate:"required,uuid"`
+}
+
+// PageResponse is embedded in the responses of the list endpoints.
+// NextCursor is passed as the cursor query parameter to fetch the next page, it is null on the last page.
+type PageResponse struct {
+	NextCursor *string `json:"next_cursor"`
+}
+
+type ListUsersResponse struct {
+	Users []AdminUserResponse `json:"users"`
+	PageResponse
+}
+
+// ChangeUserRoleRequest changes the role of a user, admins and partners cannot be granted through it
+type ChangeUserRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=borrower lender"`
+}
+
+type SuspendUserRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+type ReactivateUserRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+type DataExportParams struct {
+	ID string `params:"id" validate:"required,uuid"`
+}
+
+type DataExportResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+type KYCSubmissionResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	UserID       uuid.UUID  `json:"user_id"`
+	Status       string     `json:"status"`
+	ReviewReason string     `json:"review_reason,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+}
+
+type KYCStatusResponse struct {
+	KYCStatus        string                 `json:"kyc_status"`
+	LatestSubmission *KYCSubmissionResponse `json:"latest_submission,omitempty"`
+}
+
+type ListKYCSubmissionsRequest struct {
+	PageID   int32 `query:"page_id" validate:"required,min=1"`
+	PageSize int32 `query:"page_size" validate:"required,min=5,max=100"`
+}
+
+type ListKYCSubmissionsResponse struct {
+	KYCSubmissions []KYCSubmissionResponse `json:"kyc_submissions"`
+}
+
+type KYCSubmissionIDParams struct {
+	ID string `params:"id" validate:"required,uuid"`
+}
+
+type KYCDocumentParams struct {
+	ID       string `params:"id" validate:"required,uuid"`
+	Document string `params:"document" validate:"required,oneof=id_document selfie"`
+}
+
+type ApproveKYCRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+type RejectKYCRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+type APIKeyIDParams struct {
+	ID string `params:"id" validate:"required,uuid"`
+}
+
+type IssueAPIKeyRequest struct {
+	Name      string   `json:"name" validate:"required,max=100"`
+	Scopes    []string `json:"scopes" validate:"required,min=1,unique,dive,oneof=borrowers:read borrowers:write loans:read loans:write"`
+	ExpiresAt string   `json:"expires_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+type RotateAPIKeyRequest struct {
+	// GracePeriodSeconds is how long the rotated key keeps working, at most a week
+	GracePeriodSeconds int64 `json:"grace_period_seconds" validate:"min=0,max=604800"`
+}
+
+type RevokeAPIKeyRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+type APIKeyResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	PartnerID  uuid.UUID  `json:"partner_id"`
+	Name       string     `json:"name"`
+	KeyID      string     `json:"key_id"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// IssuedAPIKeyResponse is only sent when a key is issued, the key and its signing key are never shown again
+type IssuedAPIKeyResponse struct {
+	APIKeyResponse
+	Key        string `json:"key"`
+	SigningKey string `json:"signing_key"`
+}
+
+type RotateAPIKeyResponse struct {
+	OldAPIKey APIKeyResponse       `json:"old_api_key"`
+	APIKey    IssuedAPIKeyResponse `json:"api_key"`
+}
+
+type ListAPIKeysResponse struct {
+	APIKeys []APIKeyResponse `json:"api_keys"`
+}
+
+type PartnerResponse struct {
+	ID       uuid.UUID      `json:"id"`
+	Email    string         `json:"email"`
+	Nickname string         `json:"nickname"`
+	APIKey   APIKeyResponse `json:"api_key"`
+}
+
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+type ReadinessResponse struct {
+	Status string                             `json:"status"`
+	Checks map[string]DependencyCheckResponse `json:"checks,omitempty"`
+}
+
+type DependencyCheckResponse struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+}
@@ -0,0 +1,557 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/DamianZhang/957-lending-platform/listing"
+	"github.com/DamianZhang/957-lending-platform/openapi"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Paths of the OpenAPI document of the API and of the Swagger UI page rendering it
+const (
+	openAPIPath   = "/api/v1/openapi.json"
+	swaggerUIPath = "/api/v1/docs"
+)
+
+// Security schemes of the OpenAPI document
+const (
+	bearerAuthScheme = "bearerAuth"
+	apiKeyAuthScheme = "apiKeyAuth"
+)
+
+// kycSubmissionForm documents the multipart form of a KYC submission, which is read field by field
+type kycSubmissionForm struct {
+	IDDocument openapi.File `form:"id_document" validate:"required"`
+	Selfie     openapi.File `form:"selfie" validate:"required"`
+}
+
+var idempotencyKeyHeader = &openapi.Parameter{
+	Name:        idempotencyKeyHeaderKey,
+	In:          "header",
+	Description: "Unique key of the request, a retry with the same key replays the response of the first attempt",
+	Schema:      &openapi.Schema{Type: "string", Pattern: fmt.Sprintf(`^[!-~]{1,%d}$`, maxIdempotencyKeyLength)},
+}
+
+// listingParameters documents the filters, sort and page of a list endpoint
+func listingParameters(spec *listing.Spec) []*openapi.Parameter {
+	names := make([]string, 0, len(spec.Fields))
+	for name := range spec.Fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var params []*openapi.Parameter
+	for _, name := range names {
+		field := spec.Fields[name]
+
+		schema := &openapi.Schema{Type: "string"}
+		switch field.Kind {
+		case listing.Bool:
+			schema.Type = "boolean"
+		case listing.Time:
+			schema.Format = "date-time"
+		}
+		for _, value := range field.Values {
+			schema.Enum = append(schema.Enum, value)
+		}
+
+		for _, operator := range field.Operators {
+			param := &openapi.Parameter{
+				Name:        fmt.Sprintf("%s[%s]", name, operator),
+				In:          "query",
+				Description: fmt.Sprintf("Filters by %s with the %s operator", name, operator),
+				Schema:      schema,
+			}
+			switch operator {
+			case listing.Eq:
+				param.Name = name
+			case listing.In:
+				param.Description += ", the values are comma-separated"
+				param.Schema = &openapi.Schema{Type: "string"}
+			}
+			params = append(params, param)
+		}
+	}
+
+	sorts := make([]any, 0, 2*len(spec.SortFields))
+	for _, field := range spec.SortFields {
+		sorts = append(sorts, field, "-"+field)
+	}
+	minLimit, maxLimit := float64(1), float64(spec.MaxLimit)
+
+	return append(params,
+		&openapi.Parameter{
+			Name:        listing.SortParam,
+			In:          "query",
+			Description: fmt.Sprintf("Sorts by a field, descending when prefixed with -, defaults to %s", spec.DefaultSort),
+			Schema:      &openapi.Schema{Type: "string", Enum: sorts},
+		},
+		&openapi.Parameter{
+			Name:        listing.LimitParam,
+			In:          "query",
+			Description: fmt.Sprintf("Maximum number of items of the page, defaults to %d", spec.DefaultLimit),
+			Schema:      &openapi.Schema{Type: "integer", Minimum: &minLimit, Maximum: &maxLimit},
+		},
+		&openapi.Parameter{
+			Name:        listing.CursorParam,
+			In:          "query",
+			Description: "next_cursor of the previous page, the first page is returned without it",
+			Schema:      &openapi.Schema{Type: "string"},
+		},
+	)
+}
+
+// newOpenAPIDocument documents every route of the server.
+// Routes missing from here fail the tests, so keep it in sync with the Route methods of the handlers.
+func newOpenAPIDocument() (*openapi.Document, error) {
+	generator := openapi.NewGenerator(openapi.Info{
+		Title:       "957 Lending Platform API",
+		Version:     "v1",
+		Description: "Errors are RFC 7807 problem details, localized by the Accept-Language header.",
+	})
+
+	generator.RegisterRule("line_id", openapi.Pattern(util.LineIDRegexp.String()))
+	generator.RegisterRule("tw_phone", openapi.Pattern(util.TWPhoneRegexp.String()))
+
+	generator.AddSecurityScheme(bearerAuthScheme, &openapi.SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+		Description:  "Access token returned by signing in",
+	})
+	generator.AddSecurityScheme(apiKeyAuthScheme, &openapi.SecurityScheme{
+		Type:   "http",
+		Scheme: "bearer",
+		Description: "API key of a partner. Instead of sending the key, a request may be signed with HMAC-SHA256 " +
+			"and carry the X-API-Key-ID, X-Timestamp, X-Nonce and X-Signature headers.",
+	})
+
+	generator.SetDefaultResponse(openapi.Response{
+		Description: "Problem details of the error",
+		ContentType: problemContentType,
+		Body:        ProblemResponse{},
+	})
+
+	for _, op := range openAPIOperations() {
+		err := generator.Add(op)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return generator.Document(), nil
+}
+
+func openAPIOperations() []openapi.Operation {
+	idempotent := []*openapi.Parameter{idempotencyKeyHeader}
+	bearerAuth := []string{bearerAuthScheme}
+	apiKeyAuth := []string{apiKeyAuthScheme}
+
+	return []openapi.Operation{
+		{
+			Method:  http.MethodGet,
+			Path:    "/healthz",
+			ID:      "healthz",
+			Summary: "Report whether the server is alive",
+			Tags:    []string{"health"},
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Body: HealthResponse{}},
+			},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/readyz",
+			ID:      "readyz",
+			Summary: "Report whether the server and its dependencies can serve requests",
+			Tags:    []string{"health"},
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Body: ReadinessResponse{}},
+				{Status: http.StatusServiceUnavailable, Body: ReadinessResponse{}},
+			},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/api/v1/borrowers/sign_up",
+			ID:      "signUpBorrower",
+			Summary: "Sign up as a borrower",
+			Tags:    []string{"borrowers"},
+			Headers: idempotent,
+			Body:    SignUpRequest{},
+			Responses: []openapi.Response{
+				{Status: http.StatusCreated, Body: SignUpResponse{}},
+			},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/api/v1/users/sign_in",
+			ID:      "signIn",
+			Summary: "Sign in with email and password",
+			Tags:    []string{"users"},
+			Body:    SignInRequest{},
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Body: SignInResponse{}},
+			},
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/api/v1/me",
+			ID:       "getMe",
+			Summary:  "Get the signed in user",
+			Tags:     []string{"me"},
+			Security: bearerAuth,
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Body: UserResponse{}},
+			},
+		},
+		{
+			Method:   http.MethodPatch,
+			Path:     "/api/v1/me",
+			ID:       "updateMe",
+			Summary:  "Update the profile of the signed in user",
+			Tags:     []string{"me"},
+			Security: bearerAuth,
+			Headers:  idempotent,
+			Body:     UpdateMeRequest{},
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Body: UserResponse{}},
+			},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/api/v1/me/change_password",
+			ID:       "changePassword",
+			Summary:  "Change the password of the signed in user",
+			Tags:     []string{"me"},
+			Security: bearerAuth,
+			Headers:  idempotent,
+			Body:     ChangePasswordRequest{},
+			Responses: []openapi.Response{
+				{Status: http.StatusNoContent},
+			},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/api/v1/me/close",
+			ID:       "closeAccount",
+			Summary:  "Close the account of the signed in user",
+			Tags:     []string{"me"},
+			Security: bearerAuth,
+			Headers:  idempotent,
+			Body:     CloseAccountRequest{},
+			Responses: []openapi.Response{
+				{Status: http.StatusNoContent},
+			},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/api/v1/me/data_export",
+			ID:       "requestDataExport",
+			Summary:  "Request an export of the personal data of the signed in user",
+			Tags:     []string{"me"},
+			Security: bearerAuth,
+			Headers:  idempotent,
+			Responses: []openapi.Response{
+				{Status: http.StatusAccepted, Body: DataExportResponse{}},
+			},
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/api/v1/me/data_export/:id",
+			ID:       "getDataExport",
+			Summary:  "Get the status of a data export",
+			Tags:     []string{"me"},
+			Security: bearerAuth,
+			Params:   DataExportParams{},
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Body: DataExportResponse{}},
+			},
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/api/v1/me/data_export/:id/download",
+			ID:       "downloadDataExport",
+			Summary:  "Download a ready data export as a ZIP archive",
+			Tags:     []string{"me"},
+			Security: bearerAuth,
+			Params:   DataExportParams{},
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, ContentType: "application/zip", Body: openapi.File{}},
+			},
+		},
+		{
+			Method:          http.MethodPost,
+			Path:            "/api/v1/me/kyc",
+			ID:              "submitKYC",
+			Summary:         "Submit an ID document and a selfie for identity verification",
+			Description:     "Both documents must be JPEG or PNG images.",
+			Tags:            []string{"me"},
+			Security:        bearerAuth,
+			Headers:         idempotent,
+			Body:            kycSubmissionForm{},
+			BodyContentType: fiber.MIMEMultipartForm,
+			Responses: []openapi.Response{
+				{Status: http.StatusCreated, Body: KYCSubmissionResponse{}},
+			},
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/api/v1/me/kyc",
+			ID:       "getKYC",
+			Summary:  "Get the identity verification status of the signed in user",
+			Tags:     []string{"me"},
+			Security: bearerAuth,
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Body: KYCStatusResponse{}},
+			},
+		},
+		{
+			Method:     http.MethodGet,
+			Path:       "/api/v1/admin/users",
+			ID:         "listUsers",
+			Summary:    "List users",
+			Tags:       []string{"admin"},
+			Security:   bearerAuth,
+			Parameters: listingParameters(listUsersSpec),
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Body: ListUsersResponse{}},
+			},
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/api/v1/admin/users/:id",
+			ID:       "viewUser",
+			Summary:  "Get a user",
+			Tags:     []string{"admin"},
+			Security: bearerAuth,
+			Params:   UserIDParams{},
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Body: AdminUserResponse{}},
+			},
+		},
+		{
+			Method:   http.MethodPatch,
+			Path:     "/api/v1/admin/users/:id/role",
+			ID:       "changeUserRole",
+			Summary:  "Change the role of a user",
+			Tags:     []string{"admin"},
+			Security: bearerAuth,
+			Headers:  idempotent,
+			Params:   UserIDParams{},
+			Body:     ChangeUserRoleRequest{},
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Body: AdminUserResponse{}},
+			},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/api/v1/admin/users/:id/suspend",
+			ID:       "suspendUser",
+			Summary:  "Suspend a user",
+			Tags:     []string{"admin"},
+			Security: bearerAuth,
+			Headers:  idempotent,
+			Params:   UserIDParams{},
+			Body:     SuspendUserRequest{},
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Body: AdminUserResponse{}},
+			},
+		},
+		{
+			Method:       http.MethodPost,
+			Path:         "/api/v1/admin/users/:id/reactivate",
+			ID:           "reactivateUser",
+			Summary:      "Reactivate a suspended user",
+			Tags:         []string{"admin"},
+			Security:     bearerAuth,
+			Headers:      idempotent,
+			Params:       UserIDParams{},
+			Body:         ReactivateUserRequest{},
+			BodyOptional: true,
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Body: AdminUserResponse{}},
+			},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/api/v1/admin/users/:id/anonymize",
+			ID:       "anonymizeUser",
+			Summary:  "Anonymize the personal data of a closed account",
+			Tags:     []string{"admin"},
+			Security: bearerAuth,
+			Headers:  idempotent,
+			Params:   UserIDParams{},
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Body: AdminUserResponse{}},
+			},
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/api/v1/admin/kyc_submissions",
+			ID:       "listKYCSubmissions",
+			Summary:  "List the KYC submissions pending review",
+			Tags:     []string{"admin"},
+			Security: bearerAuth,
+			Query:    ListKYCSubmissionsRequest{},
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Body: ListKYCSubmissionsResponse{}},
+			},
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/api/v1/admin/kyc_submissions/:id/documents/:document",
+			ID:       "getKYCDocument",
+			Summary:  "Download a document of a KYC submission",
+			Tags:     []string{"admin"},
+			Security: bearerAuth,
+			Params:   KYCDocumentParams{},
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, ContentType: "image/*", Body: openapi.File{}},
+			},
+		},
+		{
+			Method:       http.MethodPost,
+			Path:         "/api/v1/admin/kyc_submissions/:id/approve",
+			ID:           "approveKYCSubmission",
+			Summary:      "Approve a KYC submission",
+			Tags:         []string{"admin"},
+			Security:     bearerAuth,
+			Headers:      idempotent,
+			Params:       KYCSubmissionIDParams{},
+			Body:         ApproveKYCRequest{},
+			BodyOptional: true,
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Body: KYCSubmissionResponse{}},
+			},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/api/v1/admin/kyc_submissions/:id/reject",
+			ID:       "rejectKYCSubmission",
+			Summary:  "Reject a KYC submission",
+			Tags:     []string{"admin"},
+			Security: bearerAuth,
+			Headers:  idempotent,
+			Params:   KYCSubmissionIDParams{},
+			Body:     RejectKYCRequest{},
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Body: KYCSubmissionResponse{}},
+			},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/api/v1/admin/users/:id/api_keys",
+			ID:       "issueAPIKey",
+			Summary:  "Issue an API key to a partner",
+			Tags:     []string{"admin"},
+			Security: bearerAuth,
+			Headers:  idempotent,
+			Params:   UserIDParams{},
+			Body:     IssueAPIKeyRequest{},
+			Responses: []openapi.Response{
+				{Status: http.StatusCreated, Body: IssuedAPIKeyResponse{}},
+			},
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/api/v1/admin/users/:id/api_keys",
+			ID:       "listAPIKeys",
+			Summary:  "List the API keys of a partner",
+			Tags:     []string{"admin"},
+			Security: bearerAuth,
+			Params:   UserIDParams{},
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Body: ListAPIKeysResponse{}},
+			},
+		},
+		{
+			Method:       http.MethodPost,
+			Path:         "/api/v1/admin/api_keys/:id/rotate",
+			ID:           "rotateAPIKey",
+			Summary:      "Rotate an API key, keeping the old key working for a grace period",
+			Tags:         []string{"admin"},
+			Security:     bearerAuth,
+			Headers:      idempotent,
+			Params:       APIKeyIDParams{},
+			Body:         RotateAPIKeyRequest{},
+			BodyOptional: true,
+			Responses: []openapi.Response{
+				{Status: http.StatusCreated, Body: RotateAPIKeyResponse{}},
+			},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/api/v1/admin/api_keys/:id/revoke",
+			ID:       "revokeAPIKey",
+			Summary:  "Revoke an API key",
+			Tags:     []string{"admin"},
+			Security: bearerAuth,
+			Headers:  idempotent,
+			Params:   APIKeyIDParams{},
+			Body:     RevokeAPIKeyRequest{},
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Body: APIKeyResponse{}},
+			},
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/api/v1/partner/me",
+			ID:       "getPartner",
+			Summary:  "Get the partner owning the API key",
+			Tags:     []string{"partner"},
+			Security: apiKeyAuth,
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Body: PartnerResponse{}},
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/api/v1/partner/borrowers",
+			ID:          "signUpPartnerBorrower",
+			Summary:     "Sign up a borrower on behalf of the partner",
+			Description: "Requires the borrowers:write scope and a partner whose identity has been verified.",
+			Tags:        []string{"partner"},
+			Security:    apiKeyAuth,
+			Headers:     idempotent,
+			Body:        SignUpRequest{},
+			Responses: []openapi.Response{
+				{Status: http.StatusCreated, Body: SignUpResponse{}},
+			},
+		},
+	}
+}
+
+// swaggerUIPage renders the OpenAPI document with Swagger UI
+var swaggerUIPage = fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>957 Lending Platform API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: %q, dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`, openAPIPath)
+
+func (server *Server) getOpenAPIDocument(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusOK).JSON(server.openAPIDocument)
+}
+
+func (server *Server) getSwaggerUI(ctx *fiber.Ctx) error {
+	// the page loads Swagger UI from a CDN, which the security headers of the API responses forbid
+	ctx.Set(fiber.HeaderContentSecurityPolicy, swaggerUIContentSecurityPolicy)
+	ctx.Set("Cross-Origin-Embedder-Policy", "unsafe-none")
+	ctx.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return ctx.Status(fiber.StatusOK).SendString(swaggerUIPage)
+}
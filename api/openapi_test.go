@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DamianZhang/957-lending-platform/openapi"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOpenAPIDocumentsEveryRoute fails when a route is added without documenting it, or the other way round
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	server := newTestServer(t, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	undocumented := map[string]bool{
+		"/metrics":    true,
+		openAPIPath:   true,
+		swaggerUIPath: true,
+	}
+
+	routes := make(map[string]bool)
+	for _, route := range server.app.GetRoutes(true) {
+		// fiber registers a HEAD route for every GET route
+		if route.Method == fiber.MethodHead || undocumented[route.Path] {
+			continue
+		}
+
+		path := openapi.Path(route.Path)
+		method := strings.ToLower(route.Method)
+		routes[method+" "+path] = true
+
+		item, ok := server.openAPIDocument.Paths[path]
+		require.True(t, ok, "route %s %s is not documented", route.Method, route.Path)
+		require.Contains(t, *item, method, "route %s %s is not documented", route.Method, route.Path)
+	}
+
+	for path, item := range server.openAPIDocument.Paths {
+		for method := range *item {
+			require.True(t, routes[method+" "+path], "operation %s %s has no route", method, path)
+		}
+	}
+}
+
+func TestGetOpenAPIDocument(t *testing.T) {
+	server := newTestServer(t, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	request := httptest.NewRequest(http.MethodGet, openAPIPath, nil)
+	response, err := server.app.Test(request)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	var doc openapi.Document
+	err = json.NewDecoder(response.Body).Decode(&doc)
+	require.NoError(t, err)
+	require.Equal(t, openapi.Version, doc.OpenAPI)
+
+	operation := (*doc.Paths["/api/v1/borrowers/sign_up"])["post"]
+	require.NotNil(t, operation)
+	require.Equal(t, "#/components/schemas/SignUpRequest", operation.RequestBody.Content[openapi.JSONContentType].Schema.Ref)
+	require.Equal(t, idempotencyKeyHeaderKey, operation.Parameters[0].Name)
+
+	// the validate tags of the request become constraints of its schema
+	schema := doc.Components.Schemas["SignUpRequest"]
+	require.Equal(t, []string{"email", "line_id", "nickname", "password"}, schema.Required)
+	require.Equal(t, "email", schema.Properties["email"].Format)
+	require.Equal(t, util.LineIDRegexp.String(), schema.Properties["line_id"].Pattern)
+
+	nickname := schema.Properties["nickname"]
+	require.Equal(t, `^[a-zA-Z0-9]+$`, nickname.Pattern)
+	require.Equal(t, int64(1), *nickname.MinLength)
+	require.Equal(t, int64(20), *nickname.MaxLength)
+
+	// the filters of a list endpoint are documented from its spec
+	params := make(map[string]*openapi.Parameter)
+	for _, param := range (*doc.Paths["/api/v1/admin/users"])["get"].Parameters {
+		params[param.Name] = param
+	}
+	require.Contains(t, params, "role[in]")
+	require.Contains(t, params, "created_at[gte]")
+	require.Equal(t, []any{"active", "suspended", "closed"}, params["status"].Schema.Enum)
+	require.Equal(t, float64(listUsersSpec.MaxLimit), *params["limit"].Schema.Maximum)
+	require.Contains(t, params, "cursor")
+}
+
+func TestGetSwaggerUI(t *testing.T) {
+	server := newTestServer(t, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	request := httptest.NewRequest(http.MethodGet, swaggerUIPath, nil)
+	response, err := server.app.Test(request)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, fiber.MIMETextHTMLCharsetUTF8, response.Header.Get(fiber.HeaderContentType))
+
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), openAPIPath)
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PartnerHandler serves the API used by partners, who authenticate with an API key instead of a user token
+type PartnerHandler struct {
+	validate        *requestValidator
+	borrowerService service.BorrowerService
+}
+
+func NewPartnerHandler(bundle *i18n.Bundle, borrowerService service.BorrowerService) *PartnerHandler {
+	return &PartnerHandler{
+		validate:        newRequestValidator(bundle),
+		borrowerService: borrowerService,
+	}
+}
+
+func (handler *PartnerHandler) Route(app *fiber.App, apiKeyMiddleware fiber.Handler, kycMiddleware fiber.Handler, rateLimitMiddleware func(policy string) fiber.Handler, idempotencyMiddleware fiber.Handler) {
+	router := app.Group("/api/v1/partner", apiKeyMiddleware, rateLimitMiddleware(rateLimitPolicyPartner), idempotencyMiddleware)
+	router.Get("/me", handler.GetMe)
+	// only a partner whose identity has been verified can onboard borrowers
+	router.Post("/borrowers", scopeMiddleware(util.ScopeBorrowersWrite), kycMiddleware, handler.SignUpBorrower)
+}
+
+func (handler *PartnerHandler) GetMe(ctx *fiber.Ctx) error {
+	payload := partnerPayload(ctx)
+
+	rsp := PartnerResponse{
+		ID:       payload.Partner.ID,
+		Email:    payload.Partner.Email,
+		Nickname: payload.Partner.Nickname,
+		APIKey:   newAPIKeyResponse(payload.APIKey),
+	}
+	return ctx.Status(fiber.StatusOK).JSON(rsp)
+}
+
+// SignUpBorrower signs up a borrower onboarded by a partner
+func (handler *PartnerHandler) SignUpBorrower(ctx *fiber.Ctx) error {
+	var req SignUpRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		msg := i18n.NewMessage("request.parse_failed").With("reason", err.Error())
+		return errorResponse(ctx, fiber.StatusBadRequest, msg)
+	}
+
+	if err := handler.validate.Struct(req); err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	input := &service.SignUpInput{
+		Email:    req.Email,
+		Password: req.Password,
+		LineID:   req.LineID,
+		Nickname: req.Nickname,
+	}
+
+	output, err := handler.borrowerService.SignUp(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	rsp := SignUpResponse{
+		Email:    output.Borrower.Email,
+		LineID:   output.Borrower.LineID,
+		Nickname: output.Borrower.Nickname,
+		Role:     output.Borrower.Role,
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(rsp)
+}
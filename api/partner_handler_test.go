@@ -0,0 +1,188 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/service"
+	mocksvc "github.com/DamianZhang/957-lending-platform/service/mock"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartnerGetMeAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	payload := randomPartnerPayload(t, util.ScopeBorrowersRead)
+	key := util.FormatAPIKey(payload.APIKey.KeyID, util.RandomString(43))
+
+	apiKeySvc := mocksvc.NewMockAPIKeyService(ctrl)
+	apiKeySvc.EXPECT().
+		AuthenticateAPIKey(gomock.Any(), gomock.Eq(&service.AuthenticateAPIKeyInput{Key: key})).
+		Times(1).
+		Return(payload, nil)
+
+	server := newTestServer(t, nil, nil, nil, nil, nil, apiKeySvc, nil, nil)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/partner/me", nil)
+	request.Header.Set(authorizationHeaderKey, "Bearer "+key)
+
+	rsp, err := server.app.Test(request)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+
+	var actualRsp PartnerResponse
+	unmarshalRsp(t, rsp, &actualRsp)
+	require.Equal(t, payload.Partner.ID, actualRsp.ID)
+	require.Equal(t, payload.Partner.Email, actualRsp.Email)
+	require.Equal(t, payload.APIKey.KeyID, actualRsp.APIKey.KeyID)
+	require.Equal(t, payload.APIKey.Scopes, actualRsp.APIKey.Scopes)
+}
+
+func TestPartnerSignUpBorrowerAPI(t *testing.T) {
+	borrower, _ := randomUser(t)
+	borrower.Role = util.BorrowerRole
+	password := util.RandomPassword()
+
+	testCases := []struct {
+		name       string
+		scopes     []string
+		buildStubs func(svc *mocksvc.MockBorrowerService, kycSvc *mocksvc.MockKYCService, partnerID uuid.UUID)
+		checkRsp   func(rsp *http.Response)
+	}{
+		{
+			name:   "OK",
+			scopes: []string{util.ScopeBorrowersWrite},
+			buildStubs: func(svc *mocksvc.MockBorrowerService, kycSvc *mocksvc.MockKYCService, partnerID uuid.UUID) {
+				kycSvc.EXPECT().
+					RequireKYCVerified(gomock.Any(), gomock.Eq(partnerID)).
+					Times(1).
+					Return(nil)
+
+				input := &service.SignUpInput{
+					Email:    borrower.Email,
+					Password: password,
+					LineID:   borrower.LineID,
+					Nickname: borrower.Nickname,
+				}
+				svc.EXPECT().
+					SignUp(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(&service.SignUpOutput{Borrower: borrower}, nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusCreated, rsp.StatusCode)
+
+				var actualRsp SignUpResponse
+				unmarshalRsp(t, rsp, &actualRsp)
+				require.Equal(t, borrower.Email, actualRsp.Email)
+				require.Equal(t, util.BorrowerRole, actualRsp.Role)
+			},
+		},
+		{
+			name:   "KYCNotVerified",
+			scopes: []string{util.ScopeBorrowersWrite},
+			buildStubs: func(svc *mocksvc.MockBorrowerService, kycSvc *mocksvc.MockKYCService, partnerID uuid.UUID) {
+				kycSvc.EXPECT().
+					RequireKYCVerified(gomock.Any(), gomock.Eq(partnerID)).
+					Times(1).
+					Return(service.NewError(service.ErrKYCRequired, nil))
+				svc.EXPECT().
+					SignUp(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusForbidden, rsp.StatusCode)
+
+				var problem ProblemResponse
+				unmarshalRsp(t, rsp, &problem)
+				require.Equal(t, "kyc_required", problem.Code)
+			},
+		},
+		{
+			name:   "ScopeMissing",
+			scopes: []string{util.ScopeBorrowersRead},
+			buildStubs: func(svc *mocksvc.MockBorrowerService, kycSvc *mocksvc.MockKYCService, partnerID uuid.UUID) {
+				kycSvc.EXPECT().
+					RequireKYCVerified(gomock.Any(), gomock.Any()).
+					Times(0)
+				svc.EXPECT().
+					SignUp(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusForbidden, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			payload := randomPartnerPayload(t, tc.scopes...)
+			key := util.FormatAPIKey(payload.APIKey.KeyID, util.RandomString(43))
+
+			apiKeySvc := mocksvc.NewMockAPIKeyService(ctrl)
+			apiKeySvc.EXPECT().
+				AuthenticateAPIKey(gomock.Any(), gomock.Any()).
+				Times(1).
+				Return(payload, nil)
+
+			borrowerSvc := mocksvc.NewMockBorrowerService(ctrl)
+			kycSvc := mocksvc.NewMockKYCService(ctrl)
+			tc.buildStubs(borrowerSvc, kycSvc, payload.Partner.ID)
+
+			server := newTestServer(t, borrowerSvc, nil, nil, nil, kycSvc, apiKeySvc, nil, nil)
+
+			data, err := json.Marshal(fiber.Map{
+				"email":    borrower.Email,
+				"password": password,
+				"line_id":  borrower.LineID,
+				"nickname": borrower.Nickname,
+			})
+			require.NoError(t, err)
+
+			request := httptest.NewRequest(http.MethodPost, "/api/v1/partner/borrowers", bytes.NewReader(data))
+			request.Header.Set("Content-Type", "application/json")
+			request.Header.Set(authorizationHeaderKey, "Bearer "+key)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+// randomPartnerPayload returns a partner authenticated with an API key granted the given scopes
+func randomPartnerPayload(t *testing.T, scopes ...string) *service.AuthenticateAPIKeyOutput {
+	partner, _ := randomUser(t)
+	partner.Role = util.PartnerRole
+
+	apiKey := db.APIKey{
+		ID:           uuid.New(),
+		CreatedAt:    time.Now().Truncate(time.Second),
+		PartnerID:    partner.ID,
+		Name:         util.RandomString(8),
+		KeyID:        util.APIKeyIDPrefix + util.RandomString(24),
+		HashedSecret: util.HashAPIKeySecret(util.RandomString(43)),
+		Scopes:       scopes,
+		ExpiresAt:    pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+	}
+
+	return &service.AuthenticateAPIKeyOutput{
+		APIKey:  apiKey,
+		Partner: partner,
+	}
+}
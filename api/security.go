@@ -0,0 +1,85 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/helmet"
+)
+
+// swaggerUIContentSecurityPolicy lets the Swagger UI page load its assets,
+// which the policy of the API responses forbids
+const swaggerUIContentSecurityPolicy = "default-src 'none'; " +
+	"script-src https://unpkg.com 'unsafe-inline'; style-src https://unpkg.com; " +
+	"img-src 'self' data:; connect-src 'self'; frame-ancestors 'none'"
+
+// corsAllowedHeaders are the request headers a web client on another origin may send
+var corsAllowedHeaders = []string{
+	fiber.HeaderAuthorization,
+	fiber.HeaderContentType,
+	fiber.HeaderAcceptLanguage,
+	requestIDHeaderKey,
+	idempotencyKeyHeaderKey,
+	"Traceparent",
+}
+
+// corsExposedHeaders are the response headers a web client on another origin may read
+var corsExposedHeaders = []string{
+	requestIDHeaderKey,
+	fiber.HeaderRetryAfter,
+	rateLimitPolicyHeaderKey,
+	rateLimitLimitHeaderKey,
+	rateLimitRemainingHeaderKey,
+	rateLimitResetHeaderKey,
+	idempotentReplayedHeaderKey,
+}
+
+// securityHeadersMiddleware creates a fiber middleware which sets the security headers on every response,
+// on top of the defaults of helmet such as X-Content-Type-Options: nosniff.
+// HSTS is only sent over HTTPS, which is either served by us or terminated by a trusted proxy.
+func securityHeadersMiddleware(config util.Config) fiber.Handler {
+	return helmet.New(helmet.Config{
+		XFrameOptions:         "DENY",
+		HSTSMaxAge:            int(config.HSTSMaxAge.Seconds()),
+		ContentSecurityPolicy: config.ContentSecurityPolicy,
+	})
+}
+
+// corsMiddleware creates a fiber middleware which lets the web clients on the allowed origins call the API.
+// It allows no origin when none is configured.
+func corsMiddleware(config util.Config) fiber.Handler {
+	if len(config.CORSAllowedOrigins) == 0 {
+		return func(ctx *fiber.Ctx) error {
+			return ctx.Next()
+		}
+	}
+
+	return cors.New(cors.Config{
+		AllowOrigins:     strings.Join(config.CORSAllowedOrigins, ","),
+		AllowMethods:     strings.Join([]string{fiber.MethodGet, fiber.MethodPost, fiber.MethodPatch, fiber.MethodDelete}, ","),
+		AllowHeaders:     strings.Join(corsAllowedHeaders, ","),
+		ExposeHeaders:    strings.Join(corsExposedHeaders, ","),
+		AllowCredentials: config.CORSAllowCredentials,
+		MaxAge:           int(config.CORSMaxAge.Seconds()),
+	})
+}
+
+// bodyLimitMiddleware creates a fiber middleware which rejects the requests whose body exceeds the limit of their route.
+// Routes accept bodies up to the default limit, unless routeLimits, keyed by method and path, gives them another one.
+// The limit of fiber itself has to be the largest of the limits, as it rejects the larger bodies before reading them.
+func bodyLimitMiddleware(defaultLimit int, routeLimits map[string]int) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		limit, ok := routeLimits[ctx.Method()+" "+ctx.Path()]
+		if !ok {
+			limit = defaultLimit
+		}
+
+		// the raw body is measured, as the one returned by ctx.Body is decompressed
+		if ctx.Request().Header.ContentLength() > limit || len(ctx.Request().Body()) > limit {
+			return fiber.ErrRequestEntityTooLarge
+		}
+		return ctx.Next()
+	}
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/metrics"
+	"github.com/DamianZhang/957-lending-platform/ratelimit"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// testClientAddress is the remote address of the requests sent by fiber.App.Test
+const testClientAddress = "0.0.0.0"
+
+func newTestServerWithConfig(t *testing.T, httpConfig util.HTTPConfig) *Server {
+	config := util.Config{HTTPConfig: httpConfig}
+	config.TokenSymmetricKey = util.RandomString(32)
+	config.CursorSigningKey = util.RandomString(32)
+
+	server, err := NewServer(config, nil, nil, nil, nil, nil, nil, nil, nil, metrics.New(), ratelimit.NewLimiter(ratelimit.NewMemoryStore(), nil), nil)
+	require.NoError(t, err)
+
+	return server
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	csp := "default-src 'none'"
+
+	testCases := []struct {
+		name           string
+		trustedProxies []string
+		setUpRequest   func(request *http.Request)
+		expectedHSTS   string
+	}{
+		{
+			name:         "HTTP",
+			setUpRequest: func(request *http.Request) {},
+		},
+		{
+			name:           "HTTPSTerminatedByTrustedProxy",
+			trustedProxies: []string{testClientAddress},
+			setUpRequest: func(request *http.Request) {
+				request.Header.Set(fiber.HeaderXForwardedProto, "https")
+			},
+			expectedHSTS: "max-age=31536000; includeSubDomains",
+		},
+		{
+			name: "HTTPSClaimedByUntrustedClient",
+			setUpRequest: func(request *http.Request) {
+				request.Header.Set(fiber.HeaderXForwardedProto, "https")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServerWithConfig(t, util.HTTPConfig{
+				HTTPTrustedProxies:    tc.trustedProxies,
+				HSTSMaxAge:            365 * 24 * time.Hour,
+				ContentSecurityPolicy: csp,
+			})
+
+			request := httptest.NewRequest(http.MethodGet, openAPIPath, nil)
+			tc.setUpRequest(request)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, rsp.StatusCode)
+
+			require.Equal(t, "nosniff", rsp.Header.Get(fiber.HeaderXContentTypeOptions))
+			require.Equal(t, "DENY", rsp.Header.Get(fiber.HeaderXFrameOptions))
+			require.Equal(t, csp, rsp.Header.Get(fiber.HeaderContentSecurityPolicy))
+			require.Equal(t, tc.expectedHSTS, rsp.Header.Get(fiber.HeaderStrictTransportSecurity))
+		})
+	}
+}
+
+func TestSwaggerUIContentSecurityPolicy(t *testing.T) {
+	server := newTestServerWithConfig(t, util.HTTPConfig{ContentSecurityPolicy: "default-src 'none'"})
+
+	request := httptest.NewRequest(http.MethodGet, swaggerUIPath, nil)
+	rsp, err := server.app.Test(request)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, rsp.StatusCode)
+	require.Equal(t, swaggerUIContentSecurityPolicy, rsp.Header.Get(fiber.HeaderContentSecurityPolicy))
+}
+
+func TestCORS(t *testing.T) {
+	allowedOrigin := "https://app.957.example"
+
+	testCases := []struct {
+		name           string
+		allowedOrigins []string
+		origin         string
+		checkRsp       func(rsp *http.Response)
+	}{
+		{
+			name:           "AllowedOrigin",
+			allowedOrigins: []string{allowedOrigin},
+			origin:         allowedOrigin,
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, http.StatusNoContent, rsp.StatusCode)
+				require.Equal(t, allowedOrigin, rsp.Header.Get(fiber.HeaderAccessControlAllowOrigin))
+				require.Contains(t, rsp.Header.Get(fiber.HeaderAccessControlAllowHeaders), fiber.HeaderAuthorization)
+				require.Contains(t, rsp.Header.Get(fiber.HeaderAccessControlAllowHeaders), idempotencyKeyHeaderKey)
+				require.Equal(t, "600", rsp.Header.Get(fiber.HeaderAccessControlMaxAge))
+			},
+		},
+		{
+			name:           "OtherOrigin",
+			allowedOrigins: []string{allowedOrigin},
+			origin:         "https://evil.example",
+			checkRsp: func(rsp *http.Response) {
+				require.Empty(t, rsp.Header.Get(fiber.HeaderAccessControlAllowOrigin))
+			},
+		},
+		{
+			name:   "NoAllowedOrigins",
+			origin: allowedOrigin,
+			checkRsp: func(rsp *http.Response) {
+				require.Empty(t, rsp.Header.Get(fiber.HeaderAccessControlAllowOrigin))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServerWithConfig(t, util.HTTPConfig{
+				CORSAllowedOrigins: tc.allowedOrigins,
+				CORSMaxAge:         10 * time.Minute,
+			})
+
+			request := httptest.NewRequest(http.MethodOptions, "/api/v1/me", nil)
+			request.Header.Set(fiber.HeaderOrigin, tc.origin)
+			request.Header.Set(fiber.HeaderAccessControlRequestMethod, http.MethodPatch)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+// TestBodyLimit checks the limits of the routes, the bodies over the largest one being rejected by fiber itself
+func TestBodyLimit(t *testing.T) {
+	server := newTestServerWithConfig(t, util.HTTPConfig{
+		HTTPBodyLimit:       16,
+		HTTPUploadBodyLimit: 64,
+	})
+
+	testCases := []struct {
+		name               string
+		path               string
+		bodySize           int
+		expectedStatusCode int
+	}{
+		{
+			name:               "WithinDefaultLimit",
+			path:               "/api/v1/users/sign_in",
+			bodySize:           16,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "OverDefaultLimit",
+			path:               "/api/v1/users/sign_in",
+			bodySize:           17,
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:               "UploadWithinUploadLimit",
+			path:               "/api/v1/me/kyc",
+			bodySize:           64,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, tc.path, bytes.NewReader(make([]byte, tc.bodySize)))
+			request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStatusCode, rsp.StatusCode)
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	clientIP := "203.0.113.7"
+
+	testCases := []struct {
+		name           string
+		trustedProxies []string
+		expectedIP     string
+	}{
+		{
+			name:           "TrustedProxy",
+			trustedProxies: []string{testClientAddress},
+			expectedIP:     clientIP,
+		},
+		{
+			name:       "UntrustedProxy",
+			expectedIP: testClientAddress,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServerWithConfig(t, util.HTTPConfig{
+				HTTPTrustedProxies: tc.trustedProxies,
+				HTTPProxyHeader:    fiber.HeaderXForwardedFor,
+			})
+			server.app.Get("/ip", func(ctx *fiber.Ctx) error {
+				return ctx.SendString(ctx.IP())
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			request.Header.Set(fiber.HeaderXForwardedFor, clientIP+", 198.51.100.1")
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+
+			body, err := io.ReadAll(rsp.Body)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedIP, string(body))
+		})
+	}
+}
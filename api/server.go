@@ -1,36 +1,247 @@
 package api
 
 import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/listing"
+	"github.com/DamianZhang/957-lending-platform/metrics"
+	"github.com/DamianZhang/957-lending-platform/openapi"
+	"github.com/DamianZhang/957-lending-platform/ratelimit"
 	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/token"
+	"github.com/DamianZhang/957-lending-platform/util"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// gatewayPath is the path prefix of the routes of the gRPC gateway
+const gatewayPath = "/gateway"
+
+// Body limits of the routes when they are not configured
+const (
+	defaultBodyLimit = 1 << 20
+	// leave room for the two documents of a KYC submission
+	defaultUploadBodyLimit = 2*maxKYCDocumentSize + 1<<20
 )
 
 // Server serves HTTP requests for our lending platform
 type Server struct {
-	app             *fiber.App
-	borrowerService service.BorrowerService
+	config             util.Config
+	app                *fiber.App
+	tokenMaker         token.Maker
+	cursorCodec        *listing.CursorCodec
+	bundle             *i18n.Bundle
+	borrowerService    service.BorrowerService
+	userService        service.UserService
+	adminService       service.AdminService
+	privacyService     service.PrivacyService
+	kycService         service.KYCService
+	apiKeyService      service.APIKeyService
+	healthService      service.HealthService
+	idempotencyService service.IdempotencyService
+	metrics            *metrics.Metrics
+	rateLimiter        *ratelimit.Limiter
+	openAPIDocument    *openapi.Document
+	gateway            http.Handler
+	// certificates is set when the server serves HTTPS itself
+	certificates *certificateReloader
+	// draining is set once the server starts shutting down
+	draining atomic.Bool
+	// maintenance is set while the API is under maintenance
+	maintenance atomic.Bool
 }
 
 // NewServer creates a new HTTP server and set up routing
-func NewServer(borrowerService service.BorrowerService) (*Server, error) {
+func NewServer(
+	config util.Config,
+	borrowerService service.BorrowerService,
+	userService service.UserService,
+	adminService service.AdminService,
+	privacyService service.PrivacyService,
+	kycService service.KYCService,
+	apiKeyService service.APIKeyService,
+	healthService service.HealthService,
+	idempotencyService service.IdempotencyService,
+	metrics *metrics.Metrics,
+	rateLimiter *ratelimit.Limiter,
+	gateway http.Handler,
+) (*Server, error) {
+	tokenMaker, err := token.NewJWTMaker(config.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %w", err)
+	}
+
+	cursorCodec, err := listing.NewCursorCodec(config.CursorSigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create cursor codec: %w", err)
+	}
+
+	bundle, err := i18n.NewBundle()
+	if err != nil {
+		return nil, fmt.Errorf("cannot load message catalogues: %w", err)
+	}
+
+	openAPIDocument, err := newOpenAPIDocument()
+	if err != nil {
+		return nil, fmt.Errorf("cannot build OpenAPI document: %w", err)
+	}
+
+	var certificates *certificateReloader
+	if config.TLSCertFile != "" || config.TLSKeyFile != "" {
+		certificates, err = newCertificateReloader(config.TLSCertFile, config.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load TLS certificate: %w", err)
+		}
+	}
+
 	server := &Server{
-		borrowerService: borrowerService,
+		config:             config,
+		tokenMaker:         tokenMaker,
+		cursorCodec:        cursorCodec,
+		bundle:             bundle,
+		borrowerService:    borrowerService,
+		userService:        userService,
+		adminService:       adminService,
+		privacyService:     privacyService,
+		kycService:         kycService,
+		apiKeyService:      apiKeyService,
+		healthService:      healthService,
+		idempotencyService: idempotencyService,
+		metrics:            metrics,
+		rateLimiter:        rateLimiter,
+		openAPIDocument:    openAPIDocument,
+		gateway:            gateway,
+		certificates:       certificates,
 	}
 
+	server.maintenance.Store(config.MaintenanceMode)
+
 	server.setUpRoutes()
 	return server, nil
 }
 
+// SetMaintenanceMode turns the maintenance mode of the API on or off while the server runs
+func (server *Server) SetMaintenanceMode(enabled bool) {
+	server.maintenance.Store(enabled)
+}
+
 func (server *Server) setUpRoutes() {
-	app := fiber.New()
+	bodyLimit := server.config.HTTPBodyLimit
+	if bodyLimit <= 0 {
+		bodyLimit = defaultBodyLimit
+	}
+	uploadBodyLimit := server.config.HTTPUploadBodyLimit
+	if uploadBodyLimit <= 0 {
+		uploadBodyLimit = defaultUploadBodyLimit
+	}
+	routeBodyLimits := map[string]int{
+		fiber.MethodPost + " /api/v1/me/kyc": uploadBodyLimit,
+	}
+
+	app := fiber.New(fiber.Config{
+		BodyLimit:    max(bodyLimit, uploadBodyLimit),
+		ReadTimeout:  server.config.HTTPReadTimeout,
+		WriteTimeout: server.config.HTTPWriteTimeout,
+		IdleTimeout:  server.config.HTTPIdleTimeout,
+		// the client IP is only read from the proxy header of the requests sent by a trusted proxy,
+		// which also tells whether the client used HTTPS
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          server.config.HTTPTrustedProxies,
+		ProxyHeader:             server.config.HTTPProxyHeader,
+		EnableIPValidation:      true,
+		ErrorHandler:            problemErrorHandler(server.bundle),
+	})
+	app.Use(metricsMiddleware(server.metrics))
+	app.Use(tracingMiddleware())
+	app.Use(requestLogMiddleware(slog.Default()))
+	app.Use(securityHeadersMiddleware(server.config))
+	app.Use(corsMiddleware(server.config))
+	app.Use(localeMiddleware(server.bundle))
+	app.Use(bodyLimitMiddleware(bodyLimit, routeBodyLimits))
+
+	app.Get("/metrics", adaptor.HTTPHandler(promhttp.HandlerFor(server.metrics.Registry, promhttp.HandlerOpts{})))
 
-	borrowerHandler := NewBorrowerHandler(server.borrowerService)
-	borrowerHandler.Route(app)
+	app.Get(openAPIPath, server.getOpenAPIDocument)
+	app.Get(swaggerUIPath, server.getSwaggerUI)
+
+	healthHandler := NewHealthHandler(server.healthService, &server.draining)
+	healthHandler.Route(app)
+
+	// the routes above keep being served under maintenance, so that the probes and metrics do not fail
+	app.Use(maintenanceMiddleware(&server.maintenance))
+
+	borrowerHandler := NewBorrowerHandler(server.bundle, server.borrowerService)
+	borrowerHandler.Route(app, server.rateLimitMiddleware, idempotencyMiddleware(server.idempotencyService))
+
+	userHandler := NewUserHandler(server.config, server.tokenMaker, server.bundle, server.userService, server.privacyService, server.kycService)
+	userHandler.Route(app, authMiddleware(server.tokenMaker), server.rateLimitMiddleware, idempotencyMiddleware(server.idempotencyService))
+
+	adminHandler := NewAdminHandler(server.bundle, server.cursorCodec, server.adminService, server.privacyService, server.kycService, server.apiKeyService)
+	adminHandler.Route(app, authMiddleware(server.tokenMaker), idempotencyMiddleware(server.idempotencyService))
+
+	partnerHandler := NewPartnerHandler(server.bundle, server.borrowerService)
+	partnerHandler.Route(app, apiKeyMiddleware(server.apiKeyService), kycMiddleware(server.kycService), server.rateLimitMiddleware, idempotencyMiddleware(server.idempotencyService))
+
+	// the gateway serves the gRPC API as JSON, its routes are generated from the protobuf definitions
+	if server.gateway != nil {
+		app.Use(gatewayPath, forwardedForMiddleware(), adaptor.HTTPHandler(http.StripPrefix(gatewayPath, server.gateway)))
+	}
 
 	server.app = app
 }
 
-// Start runs the HTTP server on a specific address
-func (server *Server) Start(address string) error {
-	return server.app.Listen(address)
+// rateLimitMiddleware creates the middleware enforcing the rate limit policy with the given name
+func (server *Server) rateLimitMiddleware(policy string) fiber.Handler {
+	return rateLimitMiddleware(server.rateLimiter, policy)
+}
+
+// Start runs the HTTP server on the configured address until the context is cancelled.
+// It then reports not ready for the drain delay, so that load balancers stop sending it requests,
+// stops accepting connections and drains the in-flight requests,
+// giving up on them once the shutdown timeout has passed.
+func (server *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", server.config.HTTPServerAddress)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s: %w", server.config.HTTPServerAddress, err)
+	}
+	if server.certificates != nil {
+		listener = tls.NewListener(listener, newTLSConfig(server.certificates))
+	}
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- server.app.Listener(listener)
+	}()
+
+	select {
+	case err := <-listenErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	server.draining.Store(true)
+	select {
+	case err := <-listenErr:
+		return err
+	case <-time.After(server.config.ShutdownDrainDelay):
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), server.config.ShutdownTimeout)
+	defer cancel()
+
+	err = server.app.ShutdownWithContext(shutdownCtx)
+	if err != nil {
+		return fmt.Errorf("cannot drain in-flight requests: %w", err)
+	}
+
+	return <-listenErr
 }
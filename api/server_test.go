@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/metrics"
+	"github.com/DamianZhang/957-lending-platform/ratelimit"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerGracefulShutdown(t *testing.T) {
+	server := newTestServer(t, nil, nil, nil, nil, nil, nil, nil, nil)
+	server.config.HTTPServerAddress = freeAddress(t)
+	server.config.ShutdownTimeout = 5 * time.Second
+	server.config.ShutdownDrainDelay = 300 * time.Millisecond
+
+	inFlight := make(chan struct{})
+	server.app.Get("/slow", func(ctx *fiber.Ctx) error {
+		close(inFlight)
+		time.Sleep(time.Second)
+		return ctx.SendStatus(fiber.StatusOK)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Start(ctx)
+	}()
+
+	url := "http://" + server.config.HTTPServerAddress + "/slow"
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", server.config.HTTPServerAddress)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+
+	type result struct {
+		rsp *http.Response
+		err error
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		rsp, err := http.Get(url)
+		resultCh <- result{rsp, err}
+	}()
+
+	// shut down while the request is being served, it must still complete
+	<-inFlight
+	cancel()
+
+	// during the drain delay the server keeps accepting requests but reports not ready
+	require.Eventually(t, server.draining.Load, time.Second, time.Millisecond)
+	rsp, err := http.Get("http://" + server.config.HTTPServerAddress + "/readyz")
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusServiceUnavailable, rsp.StatusCode)
+	rsp.Body.Close()
+
+	// the slow request outlasts the drain delay, so it is drained by the shutdown
+	res := <-resultCh
+	require.NoError(t, res.err)
+	require.Equal(t, fiber.StatusOK, res.rsp.StatusCode)
+	res.rsp.Body.Close()
+
+	select {
+	case err := <-stopped:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop after the context was cancelled")
+	}
+
+	// no new connection is accepted once the server has stopped
+	_, err = net.Dial("tcp", server.config.HTTPServerAddress)
+	require.Error(t, err)
+}
+
+func freeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	return listener.Addr().String()
+}
+
+func TestServerMountsGateway(t *testing.T) {
+	config := util.Config{
+		AuthConfig: util.AuthConfig{
+			TokenSymmetricKey: util.RandomString(32),
+			CursorSigningKey:  util.RandomString(32),
+		},
+	}
+
+	gateway := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Forwarded-For", r.Header.Get("X-Forwarded-For"))
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte(r.URL.Path))
+	})
+
+	server, err := NewServer(config, nil, nil, nil, nil, nil, nil, nil, nil, metrics.New(), ratelimit.NewLimiter(ratelimit.NewMemoryStore(), nil), gateway)
+	require.NoError(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, gatewayPath+"/v1/me", nil)
+	// the client is not a trusted proxy, so the address it claims is not forwarded
+	request.Header.Set("X-Forwarded-For", "203.0.113.7")
+	response, err := server.app.Test(request)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusTeapot, response.StatusCode)
+	require.Equal(t, "0.0.0.0", response.Header.Get("X-Forwarded-For"))
+
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.Equal(t, "/v1/me", string(body))
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// certificateReloader serves the TLS certificate of a key pair on disk,
+// loading it again once either file has been modified, so that a renewed certificate is served without a restart
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+
+	mu          sync.Mutex
+	certificate *tls.Certificate
+	modTime     time.Time
+}
+
+// newCertificateReloader creates a new certificateReloader, failing when the key pair cannot be loaded
+func newCertificateReloader(certFile, keyFile string) (*certificateReloader, error) {
+	reloader := &certificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+
+	if _, err := reloader.load(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+// GetCertificate is meant for tls.Config.GetCertificate.
+// When the modified key pair cannot be loaded, such as when only one of its files has been written yet,
+// the previous certificate keeps being served.
+func (reloader *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	certificate, err := reloader.load()
+	if err != nil {
+		slog.Error("cannot reload TLS certificate", "err", err)
+	}
+	return certificate, nil
+}
+
+func (reloader *certificateReloader) load() (*tls.Certificate, error) {
+	reloader.mu.Lock()
+	defer reloader.mu.Unlock()
+
+	modTime, err := reloader.lastModified()
+	if err != nil {
+		return reloader.certificate, err
+	}
+	if reloader.certificate != nil && modTime.Equal(reloader.modTime) {
+		return reloader.certificate, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(reloader.certFile, reloader.keyFile)
+	if err != nil {
+		return reloader.certificate, fmt.Errorf("cannot load key pair: %w", err)
+	}
+
+	reloader.certificate = &certificate
+	reloader.modTime = modTime
+	return reloader.certificate, nil
+}
+
+// lastModified returns the latest modification time of the files of the key pair
+func (reloader *certificateReloader) lastModified() (time.Time, error) {
+	var modTime time.Time
+	for _, file := range []string{reloader.certFile, reloader.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cannot stat %s: %w", file, err)
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	return modTime, nil
+}
+
+// newTLSConfig creates the TLS configuration serving the certificate of the reloader
+func newTLSConfig(reloader *certificateReloader) *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+}
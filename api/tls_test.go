@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeyPair writes a new self-signed certificate for the local host and its key,
+// dated at modTime so that successive key pairs are told apart
+func writeKeyPair(t *testing.T, certFile, keyFile string, modTime time.Time) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	require.NoError(t, err)
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	require.NoError(t, err)
+
+	for _, file := range []string{certFile, keyFile} {
+		require.NoError(t, os.Chtimes(file, modTime, modTime))
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return certificate
+}
+
+func TestCertificateReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	modTime := time.Now().Add(-time.Minute)
+	first := writeKeyPair(t, certFile, keyFile, modTime)
+
+	reloader, err := newCertificateReloader(certFile, keyFile)
+	require.NoError(t, err)
+
+	certificate, err := reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, first.Raw, certificate.Certificate[0])
+
+	// a renewed key pair is served from the next handshake on
+	second := writeKeyPair(t, certFile, keyFile, modTime.Add(time.Second))
+
+	certificate, err = reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, second.Raw, certificate.Certificate[0])
+
+	// a broken key pair leaves the previous certificate served
+	require.NoError(t, os.WriteFile(keyFile, []byte("broken"), 0o600))
+
+	certificate, err = reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, second.Raw, certificate.Certificate[0])
+}
+
+func TestNewServerInvalidCertificate(t *testing.T) {
+	dir := t.TempDir()
+
+	config := util.Config{
+		HTTPConfig: util.HTTPConfig{
+			TLSCertFile: filepath.Join(dir, "missing.pem"),
+			TLSKeyFile:  filepath.Join(dir, "missing.key"),
+		},
+		AuthConfig: util.AuthConfig{
+			TokenSymmetricKey: util.RandomString(32),
+			CursorSigningKey:  util.RandomString(32),
+		},
+	}
+
+	server, err := NewServer(config, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	require.Error(t, err)
+	require.Nil(t, server)
+}
+
+func TestServerServesHTTPS(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	certificate := writeKeyPair(t, certFile, keyFile, time.Now())
+
+	server := newTestServerWithConfig(t, util.HTTPConfig{
+		HTTPServerAddress: freeAddress(t),
+		TLSCertFile:       certFile,
+		TLSKeyFile:        keyFile,
+		HSTSMaxAge:        time.Hour,
+		ShutdownTimeout:   5 * time.Second,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Start(ctx)
+	}()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(certificate)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+
+	url := "https://" + server.config.HTTPServerAddress + openAPIPath
+	var rsp *http.Response
+	require.Eventually(t, func() bool {
+		var err error
+		rsp, err = client.Get(url)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	defer rsp.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+	require.Equal(t, "max-age=3600; includeSubDomains", rsp.Header.Get(fiber.HeaderStrictTransportSecurity))
+
+	cancel()
+	require.NoError(t, <-stopped)
+}
@@ -0,0 +1,360 @@
+package api
+
+import (
+	"fmt"
+
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/token"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type UserHandler struct {
+	config         util.Config
+	validate       *requestValidator
+	tokenMaker     token.Maker
+	userService    service.UserService
+	privacyService service.PrivacyService
+	kycService     service.KYCService
+}
+
+func NewUserHandler(
+	config util.Config,
+	tokenMaker token.Maker,
+	bundle *i18n.Bundle,
+	userService service.UserService,
+	privacyService service.PrivacyService,
+	kycService service.KYCService,
+) *UserHandler {
+	return &UserHandler{
+		config:         config,
+		validate:       newRequestValidator(bundle),
+		tokenMaker:     tokenMaker,
+		userService:    userService,
+		privacyService: privacyService,
+		kycService:     kycService,
+	}
+}
+
+func (handler *UserHandler) Route(app *fiber.App, authMiddleware fiber.Handler, rateLimitMiddleware func(policy string) fiber.Handler, idempotencyMiddleware fiber.Handler) {
+	router := app.Group("/api/v1/users")
+	router.Post("/sign_in", rateLimitMiddleware(rateLimitPolicySignIn), handler.SignIn)
+
+	me := app.Group("/api/v1/me", authMiddleware, rateLimitMiddleware(rateLimitPolicyMe), idempotencyMiddleware)
+	me.Get("", handler.GetMe)
+	me.Patch("", handler.UpdateMe)
+	me.Post("/change_password", handler.ChangePassword)
+	me.Post("/close", handler.CloseAccount)
+	me.Post("/data_export", handler.RequestDataExport)
+	me.Get("/data_export/:id", handler.GetDataExport)
+	me.Get("/data_export/:id/download", handler.DownloadDataExport)
+	me.Post("/kyc", handler.SubmitKYC)
+	me.Get("/kyc", handler.GetKYC)
+}
+
+func (handler *UserHandler) SignIn(ctx *fiber.Ctx) error {
+	var req SignInRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		msg := i18n.NewMessage("request.parse_failed").With("reason", err.Error())
+		return errorResponse(ctx, fiber.StatusBadRequest, msg)
+	}
+
+	if err := handler.validate.Struct(req); err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	input := &service.SignInInput{
+		Email:    req.Email,
+		Password: req.Password,
+	}
+
+	output, err := handler.userService.SignIn(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	accessToken, accessPayload, err := handler.tokenMaker.CreateToken(
+		output.User.ID,
+		output.User.Role,
+		handler.config.AccessTokenDuration,
+	)
+	if err != nil {
+		msg := i18n.NewMessage("token.create_failed").With("reason", err.Error())
+		return errorResponse(ctx, fiber.StatusInternalServerError, msg)
+	}
+
+	rsp := SignInResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+		User:                 newUserResponse(output.User),
+	}
+	return ctx.Status(fiber.StatusOK).JSON(rsp)
+}
+
+func (handler *UserHandler) GetMe(ctx *fiber.Ctx) error {
+	payload := authPayload(ctx)
+
+	input := &service.GetUserInput{
+		UserID: payload.UserID,
+	}
+
+	output, err := handler.userService.GetUser(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	rsp := newUserResponse(output.User)
+	return ctx.Status(fiber.StatusOK).JSON(rsp)
+}
+
+func (handler *UserHandler) UpdateMe(ctx *fiber.Ctx) error {
+	var req UpdateMeRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		msg := i18n.NewMessage("request.parse_failed").With("reason", err.Error())
+		return errorResponse(ctx, fiber.StatusBadRequest, msg)
+	}
+
+	if err := handler.validate.Struct(req); err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	payload := authPayload(ctx)
+
+	input := &service.UpdateUserInput{
+		UserID:   payload.UserID,
+		Email:    req.Email,
+		LineID:   req.LineID,
+		Nickname: req.Nickname,
+	}
+
+	output, err := handler.userService.UpdateUser(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	rsp := newUserResponse(output.User)
+	return ctx.Status(fiber.StatusOK).JSON(rsp)
+}
+
+func (handler *UserHandler) ChangePassword(ctx *fiber.Ctx) error {
+	var req ChangePasswordRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		msg := i18n.NewMessage("request.parse_failed").With("reason", err.Error())
+		return errorResponse(ctx, fiber.StatusBadRequest, msg)
+	}
+
+	if err := handler.validate.Struct(req); err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	payload := authPayload(ctx)
+
+	input := &service.ChangePasswordInput{
+		UserID:          payload.UserID,
+		CurrentPassword: req.CurrentPassword,
+		NewPassword:     req.NewPassword,
+	}
+
+	err := handler.userService.ChangePassword(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (handler *UserHandler) CloseAccount(ctx *fiber.Ctx) error {
+	var req CloseAccountRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		msg := i18n.NewMessage("request.parse_failed").With("reason", err.Error())
+		return errorResponse(ctx, fiber.StatusBadRequest, msg)
+	}
+
+	if err := handler.validate.Struct(req); err != nil {
+		return handler.validate.errorResponse(ctx, err)
+	}
+
+	payload := authPayload(ctx)
+
+	input := &service.CloseAccountInput{
+		UserID:   payload.UserID,
+		Password: req.Password,
+	}
+
+	err := handler.userService.CloseAccount(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (handler *UserHandler) RequestDataExport(ctx *fiber.Ctx) error {
+	input := &service.RequestDataExportInput{
+		UserID: authPayload(ctx).UserID,
+	}
+
+	output, err := handler.privacyService.RequestDataExport(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	rsp := newDataExportResponse(output.DataExport)
+	return ctx.Status(fiber.StatusAccepted).JSON(rsp)
+}
+
+func (handler *UserHandler) GetDataExport(ctx *fiber.Ctx) error {
+	dataExport, err := handler.getDataExport(ctx)
+	if err != nil {
+		return err
+	}
+	if dataExport == nil {
+		return nil
+	}
+
+	rsp := newDataExportResponse(*dataExport)
+	return ctx.Status(fiber.StatusOK).JSON(rsp)
+}
+
+func (handler *UserHandler) DownloadDataExport(ctx *fiber.Ctx) error {
+	dataExport, err := handler.getDataExport(ctx)
+	if err != nil {
+		return err
+	}
+	if dataExport == nil {
+		return nil
+	}
+
+	if dataExport.Status != util.DataExportReady {
+		msg := i18n.NewMessage("data_export.not_ready").With("status", dataExport.Status)
+		return errorResponse(ctx, fiber.StatusConflict, msg)
+	}
+
+	fileName := fmt.Sprintf("data-export-%s.zip", dataExport.CreatedAt.Format("20060102"))
+	return ctx.Download(dataExport.FilePath, fileName)
+}
+
+// getDataExport looks up the data export in the path for the authenticated user.
+// It writes the error response itself and returns a nil export when the lookup fails.
+func (handler *UserHandler) getDataExport(ctx *fiber.Ctx) (*db.DataExport, error) {
+	var params DataExportParams
+
+	if err := ctx.ParamsParser(&params); err != nil {
+		msg := i18n.NewMessage("request.parse_failed").With("reason", err.Error())
+		return nil, errorResponse(ctx, fiber.StatusBadRequest, msg)
+	}
+
+	if err := handler.validate.Struct(params); err != nil {
+		return nil, handler.validate.errorResponse(ctx, err)
+	}
+
+	input := &service.GetDataExportInput{
+		UserID:       authPayload(ctx).UserID,
+		DataExportID: uuid.MustParse(params.ID),
+	}
+
+	output, err := handler.privacyService.GetDataExport(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return nil, problemResponse(ctx, apiError)
+	}
+
+	return &output.DataExport, nil
+}
+
+func newDataExportResponse(dataExport db.DataExport) DataExportResponse {
+	rsp := DataExportResponse{
+		ID:        dataExport.ID,
+		Status:    dataExport.Status,
+		CreatedAt: dataExport.CreatedAt,
+	}
+	if dataExport.CompletedAt.Valid {
+		rsp.CompletedAt = &dataExport.CompletedAt.Time
+	}
+	if dataExport.ExpiresAt.Valid {
+		rsp.ExpiresAt = &dataExport.ExpiresAt.Time
+	}
+	return rsp
+}
+
+func newUserResponse(user db.User) UserResponse {
+	return UserResponse{
+		Email:           user.Email,
+		LineID:          user.LineID,
+		Nickname:        user.Nickname,
+		Role:            user.Role,
+		IsEmailVerified: user.IsEmailVerified,
+		KYCStatus:       user.KYCStatus,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}
+}
+
+func (handler *UserHandler) SubmitKYC(ctx *fiber.Ctx) error {
+	idDocument, err := formKYCDocument(ctx, util.KYCDocumentID)
+	if err != nil {
+		return kycDocumentErrorResponse(ctx, err)
+	}
+	defer idDocument.Close()
+
+	selfie, err := formKYCDocument(ctx, util.KYCDocumentSelfie)
+	if err != nil {
+		return kycDocumentErrorResponse(ctx, err)
+	}
+	defer selfie.Close()
+
+	input := &service.SubmitKYCInput{
+		UserID: authPayload(ctx).UserID,
+		IDDocument: service.KYCDocument{
+			ContentType: idDocument.contentType,
+			Content:     idDocument,
+		},
+		Selfie: service.KYCDocument{
+			ContentType: selfie.contentType,
+			Content:     selfie,
+		},
+	}
+
+	output, err := handler.kycService.SubmitKYC(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	rsp := newKYCSubmissionResponse(output.KYCSubmission)
+	return ctx.Status(fiber.StatusCreated).JSON(rsp)
+}
+
+func (handler *UserHandler) GetKYC(ctx *fiber.Ctx) error {
+	input := &service.GetKYCStatusInput{
+		UserID: authPayload(ctx).UserID,
+	}
+
+	output, err := handler.kycService.GetKYCStatus(ctx.UserContext(), input)
+	if err != nil {
+		apiError := FromServiceError(err)
+		return problemResponse(ctx, apiError)
+	}
+
+	rsp := KYCStatusResponse{
+		KYCStatus: output.KYCStatus,
+	}
+	if output.KYCSubmission != nil {
+		submission := newKYCSubmissionResponse(*output.KYCSubmission)
+		rsp.LatestSubmission = &submission
+	}
+	return ctx.Status(fiber.StatusOK).JSON(rsp)
+}
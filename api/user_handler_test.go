@@ -0,0 +1,806 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/service"
+	mocksvc "github.com/DamianZhang/957-lending-platform/service/mock"
+	"github.com/DamianZhang/957-lending-platform/token"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignInAPI(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		reqBody    SignInRequest
+		buildStubs func(svc *mocksvc.MockUserService)
+		checkRsp   func(rsp *http.Response)
+	}{
+		{
+			name: "OK",
+			reqBody: SignInRequest{
+				Email:    user.Email,
+				Password: password,
+			},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				input := &service.SignInInput{
+					Email:    user.Email,
+					Password: password,
+				}
+				svc.EXPECT().
+					SignIn(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(&service.SignInOutput{User: user}, nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+
+				var actualRsp SignInResponse
+				unmarshalRsp(t, rsp, &actualRsp)
+				require.NotEmpty(t, actualRsp.AccessToken)
+				require.Equal(t, user.Email, actualRsp.User.Email)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			reqBody: SignInRequest{
+				Email:    "invalid-email",
+				Password: password,
+			},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				svc.EXPECT().
+					SignIn(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name: "ServiceUnauthenticated",
+			reqBody: SignInRequest{
+				Email:    user.Email,
+				Password: password,
+			},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				svc.EXPECT().
+					SignIn(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewError(service.ErrUnauthenticated, nil))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusUnauthorized, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockUserService(ctrl)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, svc, nil, nil, nil, nil, nil, nil)
+
+			data, err := json.Marshal(tc.reqBody)
+			require.NoError(t, err)
+
+			url := "/api/v1/users/sign_in"
+			request := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			request.Header.Set("Content-Type", "application/json")
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+func TestGetMeAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		setUpAuth  func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs func(svc *mocksvc.MockUserService)
+		checkRsp   func(rsp *http.Response)
+	}{
+		{
+			name: "OK",
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Role, time.Minute)
+			},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				input := &service.GetUserInput{UserID: user.ID}
+				svc.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(&service.GetUserOutput{User: user}, nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+
+				var actualRsp UserResponse
+				unmarshalRsp(t, rsp, &actualRsp)
+				require.Equal(t, user.Email, actualRsp.Email)
+				require.Equal(t, user.LineID, actualRsp.LineID)
+				require.Equal(t, user.Nickname, actualRsp.Nickname)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				svc.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusUnauthorized, rsp.StatusCode)
+			},
+		},
+		{
+			name: "ServiceNotFound",
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Role, time.Minute)
+			},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				svc.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewError(service.ErrNotFound, nil))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusNotFound, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockUserService(ctrl)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, svc, nil, nil, nil, nil, nil, nil)
+
+			request := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+			tc.setUpAuth(t, request, server.tokenMaker)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+func TestUpdateMeAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	newNickname := util.RandomString(6)
+	newEmail := util.RandomEmail()
+
+	testCases := []struct {
+		name       string
+		reqBody    fiber.Map
+		buildStubs func(svc *mocksvc.MockUserService)
+		checkRsp   func(rsp *http.Response)
+	}{
+		{
+			name: "OK",
+			reqBody: fiber.Map{
+				"nickname": newNickname,
+				"email":    newEmail,
+			},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				input := &service.UpdateUserInput{
+					UserID:   user.ID,
+					Email:    &newEmail,
+					Nickname: &newNickname,
+				}
+				updatedUser := user
+				updatedUser.Email = newEmail
+				updatedUser.Nickname = newNickname
+				updatedUser.IsEmailVerified = false
+				svc.EXPECT().
+					UpdateUser(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(&service.UpdateUserOutput{User: updatedUser}, nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+
+				var actualRsp UserResponse
+				unmarshalRsp(t, rsp, &actualRsp)
+				require.Equal(t, newEmail, actualRsp.Email)
+				require.Equal(t, newNickname, actualRsp.Nickname)
+				require.False(t, actualRsp.IsEmailVerified)
+			},
+		},
+		{
+			name: "InvalidNickname",
+			reqBody: fiber.Map{
+				"nickname": "#invalid",
+			},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				svc.EXPECT().
+					UpdateUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name: "EmptyLineID",
+			reqBody: fiber.Map{
+				"line_id": "",
+			},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				svc.EXPECT().
+					UpdateUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockUserService(ctrl)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, svc, nil, nil, nil, nil, nil, nil)
+
+			data, err := json.Marshal(tc.reqBody)
+			require.NoError(t, err)
+
+			request := httptest.NewRequest(http.MethodPatch, "/api/v1/me", bytes.NewReader(data))
+			request.Header.Set("Content-Type", "application/json")
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.Role, time.Minute)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+func TestChangePasswordAPI(t *testing.T) {
+	user, password := randomUser(t)
+	newPassword := util.RandomPassword()
+
+	testCases := []struct {
+		name       string
+		reqBody    ChangePasswordRequest
+		buildStubs func(svc *mocksvc.MockUserService)
+		checkRsp   func(rsp *http.Response)
+	}{
+		{
+			name: "OK",
+			reqBody: ChangePasswordRequest{
+				CurrentPassword: password,
+				NewPassword:     newPassword,
+			},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				input := &service.ChangePasswordInput{
+					UserID:          user.ID,
+					CurrentPassword: password,
+					NewPassword:     newPassword,
+				}
+				svc.EXPECT().
+					ChangePassword(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusNoContent, rsp.StatusCode)
+			},
+		},
+		{
+			name: "NewPasswordRejectedByPolicy",
+			reqBody: ChangePasswordRequest{
+				CurrentPassword: password,
+				NewPassword:     "12345",
+			},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				svc.EXPECT().
+					ChangePassword(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(service.NewError(service.ErrValidation, nil))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name: "WrongCurrentPassword",
+			reqBody: ChangePasswordRequest{
+				CurrentPassword: util.RandomString(6),
+				NewPassword:     newPassword,
+			},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				svc.EXPECT().
+					ChangePassword(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(service.NewError(service.ErrUnauthenticated, nil))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusUnauthorized, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockUserService(ctrl)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, svc, nil, nil, nil, nil, nil, nil)
+
+			data, err := json.Marshal(tc.reqBody)
+			require.NoError(t, err)
+
+			url := "/api/v1/me/change_password"
+			request := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			request.Header.Set("Content-Type", "application/json")
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.Role, time.Minute)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+func TestCloseAccountAPI(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		reqBody    CloseAccountRequest
+		buildStubs func(svc *mocksvc.MockUserService)
+		checkRsp   func(rsp *http.Response)
+	}{
+		{
+			name:    "OK",
+			reqBody: CloseAccountRequest{Password: password},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				input := &service.CloseAccountInput{
+					UserID:   user.ID,
+					Password: password,
+				}
+				svc.EXPECT().
+					CloseAccount(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusNoContent, rsp.StatusCode)
+			},
+		},
+		{
+			name:    "MissingPassword",
+			reqBody: CloseAccountRequest{},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				svc.EXPECT().
+					CloseAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name:    "ServiceAccountInactive",
+			reqBody: CloseAccountRequest{Password: password},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				svc.EXPECT().
+					CloseAccount(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(service.NewError(service.ErrAccountInactive, nil))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusForbidden, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockUserService(ctrl)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, svc, nil, nil, nil, nil, nil, nil)
+
+			data, err := json.Marshal(tc.reqBody)
+			require.NoError(t, err)
+
+			request := httptest.NewRequest(http.MethodPost, "/api/v1/me/close", bytes.NewReader(data))
+			request.Header.Set("Content-Type", "application/json")
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.Role, time.Minute)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+func TestRequestDataExportAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	dataExport := randomDataExport(user.ID)
+
+	testCases := []struct {
+		name       string
+		buildStubs func(svc *mocksvc.MockPrivacyService)
+		checkRsp   func(rsp *http.Response)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(svc *mocksvc.MockPrivacyService) {
+				input := &service.RequestDataExportInput{UserID: user.ID}
+				svc.EXPECT().
+					RequestDataExport(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(&service.RequestDataExportOutput{DataExport: dataExport}, nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusAccepted, rsp.StatusCode)
+
+				var actualRsp DataExportResponse
+				unmarshalRsp(t, rsp, &actualRsp)
+				require.Equal(t, dataExport.ID, actualRsp.ID)
+				require.Equal(t, util.DataExportPending, actualRsp.Status)
+				require.Nil(t, actualRsp.ExpiresAt)
+			},
+		},
+		{
+			name: "ServiceAccountInactive",
+			buildStubs: func(svc *mocksvc.MockPrivacyService) {
+				svc.EXPECT().
+					RequestDataExport(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewError(service.ErrAccountInactive, nil))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusForbidden, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockPrivacyService(ctrl)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, nil, nil, svc, nil, nil, nil, nil)
+
+			request := httptest.NewRequest(http.MethodPost, "/api/v1/me/data_export", nil)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.Role, time.Minute)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+func TestDownloadDataExportAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	readyDataExport := randomDataExport(user.ID)
+	readyDataExport.Status = util.DataExportReady
+	readyDataExport.FilePath = filepath.Join(t.TempDir(), readyDataExport.ID.String()+".zip")
+	require.NoError(t, os.WriteFile(readyDataExport.FilePath, []byte("bundle"), 0o600))
+
+	testCases := []struct {
+		name         string
+		dataExportID string
+		buildStubs   func(svc *mocksvc.MockPrivacyService)
+		checkRsp     func(rsp *http.Response)
+	}{
+		{
+			name:         "OK",
+			dataExportID: readyDataExport.ID.String(),
+			buildStubs: func(svc *mocksvc.MockPrivacyService) {
+				input := &service.GetDataExportInput{
+					UserID:       user.ID,
+					DataExportID: readyDataExport.ID,
+				}
+				svc.EXPECT().
+					GetDataExport(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(&service.GetDataExportOutput{DataExport: readyDataExport}, nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusOK, rsp.StatusCode)
+				require.Contains(t, rsp.Header.Get(fiber.HeaderContentDisposition), "attachment")
+
+				data, err := io.ReadAll(rsp.Body)
+				require.NoError(t, err)
+				require.Equal(t, "bundle", string(data))
+			},
+		},
+		{
+			name:         "NotReady",
+			dataExportID: readyDataExport.ID.String(),
+			buildStubs: func(svc *mocksvc.MockPrivacyService) {
+				pendingDataExport := randomDataExport(user.ID)
+				svc.EXPECT().
+					GetDataExport(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(&service.GetDataExportOutput{DataExport: pendingDataExport}, nil)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusConflict, rsp.StatusCode)
+			},
+		},
+		{
+			name:         "InvalidID",
+			dataExportID: "invalid",
+			buildStubs: func(svc *mocksvc.MockPrivacyService) {
+				svc.EXPECT().
+					GetDataExport(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name:         "ServiceNotFound",
+			dataExportID: readyDataExport.ID.String(),
+			buildStubs: func(svc *mocksvc.MockPrivacyService) {
+				svc.EXPECT().
+					GetDataExport(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewError(service.ErrNotFound, nil))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusNotFound, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockPrivacyService(ctrl)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, nil, nil, svc, nil, nil, nil, nil)
+
+			url := fmt.Sprintf("/api/v1/me/data_export/%s/download", tc.dataExportID)
+			request := httptest.NewRequest(http.MethodGet, url, nil)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.Role, time.Minute)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+func TestSubmitKYCAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	submission := randomKYCSubmission(user.ID)
+
+	pngImage := []byte("\x89PNG\r\n\x1a\n" + util.RandomString(32))
+	jpegImage := []byte("\xff\xd8\xff" + util.RandomString(32))
+
+	testCases := []struct {
+		name       string
+		files      map[string][]byte
+		buildStubs func(svc *mocksvc.MockKYCService)
+		checkRsp   func(rsp *http.Response)
+	}{
+		{
+			name: "OK",
+			files: map[string][]byte{
+				util.KYCDocumentID:     jpegImage,
+				util.KYCDocumentSelfie: pngImage,
+			},
+			buildStubs: func(svc *mocksvc.MockKYCService) {
+				svc.EXPECT().
+					SubmitKYC(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, input *service.SubmitKYCInput) (*service.SubmitKYCOutput, error) {
+						require.Equal(t, user.ID, input.UserID)
+						require.Equal(t, "image/jpeg", input.IDDocument.ContentType)
+						require.Equal(t, "image/png", input.Selfie.ContentType)
+
+						// the sniffed bytes must still be part of the stored document
+						data, err := io.ReadAll(input.IDDocument.Content)
+						require.NoError(t, err)
+						require.Equal(t, jpegImage, data)
+
+						return &service.SubmitKYCOutput{KYCSubmission: submission}, nil
+					})
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusCreated, rsp.StatusCode)
+
+				var actualRsp KYCSubmissionResponse
+				unmarshalRsp(t, rsp, &actualRsp)
+				require.Equal(t, submission.ID, actualRsp.ID)
+				require.Equal(t, util.KYCSubmissionPending, actualRsp.Status)
+			},
+		},
+		{
+			name: "MissingSelfie",
+			files: map[string][]byte{
+				util.KYCDocumentID: jpegImage,
+			},
+			buildStubs: func(svc *mocksvc.MockKYCService) {
+				svc.EXPECT().
+					SubmitKYC(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			},
+		},
+		{
+			name: "UnsupportedMediaType",
+			files: map[string][]byte{
+				util.KYCDocumentID:     []byte("%PDF-1.7 not an image"),
+				util.KYCDocumentSelfie: pngImage,
+			},
+			buildStubs: func(svc *mocksvc.MockKYCService) {
+				svc.EXPECT().
+					SubmitKYC(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusUnsupportedMediaType, rsp.StatusCode)
+			},
+		},
+		{
+			name: "TooLarge",
+			files: map[string][]byte{
+				util.KYCDocumentID:     append(jpegImage, make([]byte, maxKYCDocumentSize)...),
+				util.KYCDocumentSelfie: pngImage,
+			},
+			buildStubs: func(svc *mocksvc.MockKYCService) {
+				svc.EXPECT().
+					SubmitKYC(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusRequestEntityTooLarge, rsp.StatusCode)
+			},
+		},
+		{
+			name: "ServiceConflict",
+			files: map[string][]byte{
+				util.KYCDocumentID:     jpegImage,
+				util.KYCDocumentSelfie: pngImage,
+			},
+			buildStubs: func(svc *mocksvc.MockKYCService) {
+				svc.EXPECT().
+					SubmitKYC(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewError(service.ErrConflict, nil))
+			},
+			checkRsp: func(rsp *http.Response) {
+				require.Equal(t, fiber.StatusConflict, rsp.StatusCode)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockKYCService(ctrl)
+			tc.buildStubs(svc)
+
+			server := newTestServer(t, nil, nil, nil, nil, svc, nil, nil, nil)
+
+			var body bytes.Buffer
+			writer := multipart.NewWriter(&body)
+			for field, content := range tc.files {
+				part, err := writer.CreateFormFile(field, field)
+				require.NoError(t, err)
+				_, err = part.Write(content)
+				require.NoError(t, err)
+			}
+			require.NoError(t, writer.Close())
+
+			request := httptest.NewRequest(http.MethodPost, "/api/v1/me/kyc", &body)
+			request.Header.Set("Content-Type", writer.FormDataContentType())
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.Role, time.Minute)
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			tc.checkRsp(rsp)
+		})
+	}
+}
+
+func randomUser(t *testing.T) (user db.User, password string) {
+	password = util.RandomString(6)
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+
+	user = db.User{
+		ID:       uuid.New(),
+		Email:    util.RandomEmail(),
+		LineID:   util.RandomString(6),
+		Nickname: util.RandomString(6),
+
+		HashedPassword: hashedPassword,
+		Role:           util.RandomRole(),
+		Status:         util.ActiveStatus,
+		KYCStatus:      util.KYCUnverified,
+	}
+	return
+}
+
+func randomDataExport(userID uuid.UUID) db.DataExport {
+	return db.DataExport{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    userID,
+		Status:    util.DataExportPending,
+	}
+}
+
+func randomKYCSubmission(userID uuid.UUID) db.KYCSubmission {
+	return db.KYCSubmission{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    userID,
+		Status:    util.KYCSubmissionPending,
+	}
+}
+
+func unmarshalRsp(t *testing.T, rsp *http.Response, v any) {
+	data, err := io.ReadAll(rsp.Body)
+	defer rsp.Body.Close()
+	require.NoError(t, err)
+
+	err = json.Unmarshal(data, v)
+	require.NoError(t, err)
+}
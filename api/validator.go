@@ -0,0 +1,151 @@
+package api
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh_Hans_CN"
+	"github.com/go-playground/locales/zh_Hant_TW"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	zhtranslations "github.com/go-playground/validator/v10/translations/zh"
+	zhtwtranslations "github.com/go-playground/validator/v10/translations/zh_tw"
+	"github.com/gofiber/fiber/v2"
+)
+
+// customValidations are registered on top of the built-in rules of the validator.
+// A rule is used in the validate tag of a request by its tag, e.g. `validate:"required,line_id"`,
+// and its message is looked up in the catalogues as "validation.<tag>".
+var customValidations = map[string]validator.Func{
+	"line_id": func(fl validator.FieldLevel) bool {
+		return util.LineIDRegexp.MatchString(fl.Field().String())
+	},
+	"tw_phone": func(fl validator.FieldLevel) bool {
+		return util.TWPhoneRegexp.MatchString(fl.Field().String())
+	},
+}
+
+// validatorLocales maps the locales of the catalogues to the ones of the validator translations
+var validatorLocales = map[string]string{
+	i18n.EN:   "en",
+	i18n.ZHTW: "zh_Hant_TW",
+	i18n.ZHCN: "zh_Hans_CN",
+}
+
+// requestValidator validates the requests of the API and explains the failures field by field
+type requestValidator struct {
+	validate *validator.Validate
+	uni      *ut.UniversalTranslator
+}
+
+// newRequestValidator creates a requestValidator with the custom rules and the messages of all locales registered
+func newRequestValidator(bundle *i18n.Bundle) *requestValidator {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(fieldName)
+
+	enLocale := en.New()
+	uni := ut.New(enLocale, enLocale, zh_Hant_TW.New(), zh_Hans_CN.New())
+
+	enTrans, _ := uni.GetTranslator(validatorLocales[i18n.EN])
+	zhTWTrans, _ := uni.GetTranslator(validatorLocales[i18n.ZHTW])
+	zhCNTrans, _ := uni.GetTranslator(validatorLocales[i18n.ZHCN])
+
+	// the built-in translations and rules are static, failing to register them is a programming error
+	must(entranslations.RegisterDefaultTranslations(validate, enTrans))
+	must(zhtwtranslations.RegisterDefaultTranslations(validate, zhTWTrans))
+	must(zhtranslations.RegisterDefaultTranslations(validate, zhCNTrans))
+
+	for tag, fn := range customValidations {
+		must(validate.RegisterValidation(tag, fn))
+
+		for locale, validatorLocale := range validatorLocales {
+			// the field name is filled in by the translator through its {0} placeholder
+			msg := bundle.Localizer(locale).Localize(i18n.NewMessage("validation."+tag).With("field", "{0}"))
+
+			trans, _ := uni.GetTranslator(validatorLocale)
+			must(validate.RegisterTranslation(tag, trans, registerMessage(tag, msg), translateMessage))
+		}
+	}
+
+	return &requestValidator{
+		validate: validate,
+		uni:      uni,
+	}
+}
+
+// Struct validates a request
+func (v *requestValidator) Struct(s any) error {
+	return v.validate.Struct(s)
+}
+
+// errorResponse reports a failed validation as a problem response listing the errors of every field,
+// in the language negotiated with the client. Any other error is reported as an unparsable request.
+func (v *requestValidator) errorResponse(ctx *fiber.Ctx, err error) error {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		msg := i18n.NewMessage("request.parse_failed").With("reason", err.Error())
+		return errorResponse(ctx, fiber.StatusBadRequest, msg)
+	}
+
+	loc := localizer(ctx)
+	trans, _ := v.uni.GetTranslator(validatorLocales[loc.Locale()])
+
+	rsp := ProblemResponse{
+		Type:     "about:blank",
+		Title:    "Bad Request",
+		Status:   fiber.StatusBadRequest,
+		Detail:   loc.T("request.validation_failed"),
+		Instance: ctx.OriginalURL(),
+		Code:     "validation_failed",
+		Errors:   make([]FieldErrorResponse, 0, len(validationErrors)),
+	}
+	for _, fieldErr := range validationErrors {
+		rsp.Errors = append(rsp.Errors, FieldErrorResponse{
+			Field:   fieldErr.Field(),
+			Rule:    fieldErr.Tag(),
+			Param:   fieldErr.Param(),
+			Message: fieldErr.Translate(trans),
+		})
+	}
+
+	return ctx.Status(fiber.StatusBadRequest).JSON(rsp, problemContentType)
+}
+
+// fieldName names a field after its json, query, params or form tag, which is how clients know it
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "query", "params", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func registerMessage(tag, message string) validator.RegisterTranslationsFunc {
+	return func(trans ut.Translator) error {
+		return trans.Add(tag, message, true)
+	}
+}
+
+func translateMessage(trans ut.Translator, fieldErr validator.FieldError) string {
+	message, err := trans.T(fieldErr.Tag(), fieldErr.Field())
+	if err != nil {
+		return fieldErr.Error()
+	}
+	return message
+}
+
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
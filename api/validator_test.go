@@ -0,0 +1,163 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	mocksvc "github.com/DamianZhang/957-lending-platform/service/mock"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomValidations(t *testing.T) {
+	type request struct {
+		LineID string `json:"line_id" validate:"omitempty,line_id"`
+		Phone  string `json:"phone" validate:"omitempty,tw_phone"`
+	}
+
+	testCases := []struct {
+		name      string
+		req       request
+		wantField string
+		wantRule  string
+	}{
+		{name: "ValidLineID", req: request{LineID: "line.id_957"}},
+		{name: "LineIDTooShort", req: request{LineID: "abc"}, wantField: "line_id", wantRule: "line_id"},
+		{name: "LineIDUppercase", req: request{LineID: "LineID957"}, wantField: "line_id", wantRule: "line_id"},
+		{name: "ValidLocalPhone", req: request{Phone: "0912345678"}},
+		{name: "ValidInternationalPhone", req: request{Phone: "+886912345678"}},
+		{name: "LandlinePhone", req: request{Phone: "0223456789"}, wantField: "phone", wantRule: "tw_phone"},
+		{name: "PhoneTooLong", req: request{Phone: "09123456789"}, wantField: "phone", wantRule: "tw_phone"},
+	}
+
+	bundle, err := i18n.NewBundle()
+	require.NoError(t, err)
+
+	v := newRequestValidator(bundle)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(localeMiddleware(bundle))
+			app.Post("/", func(ctx *fiber.Ctx) error {
+				if err := v.Struct(tc.req); err != nil {
+					return v.errorResponse(ctx, err)
+				}
+				return ctx.SendStatus(fiber.StatusNoContent)
+			})
+
+			rsp, err := app.Test(httptest.NewRequest(http.MethodPost, "/", nil))
+			require.NoError(t, err)
+
+			if tc.wantRule == "" {
+				require.Equal(t, fiber.StatusNoContent, rsp.StatusCode)
+				return
+			}
+
+			require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+
+			var problem ProblemResponse
+			unmarshalRsp(t, rsp, &problem)
+			require.Equal(t, "validation_failed", problem.Code)
+			require.Len(t, problem.Errors, 1)
+			require.Equal(t, tc.wantField, problem.Errors[0].Field)
+			require.Equal(t, tc.wantRule, problem.Errors[0].Rule)
+			require.NotEmpty(t, problem.Errors[0].Message)
+		})
+	}
+}
+
+func TestValidationErrorResponse(t *testing.T) {
+	reqBody := SignUpRequest{
+		Email:    "invalid-email",
+		Password: "",
+		LineID:   util.RandomString(6),
+		Nickname: util.RandomString(6),
+	}
+
+	testCases := []struct {
+		name           string
+		acceptLanguage string
+		wantDetail     string
+		wantMessages   []string
+	}{
+		{
+			name:       "English",
+			wantDetail: "the request has invalid fields",
+			wantMessages: []string{
+				"email must be a valid email address",
+				"password is a required field",
+			},
+		},
+		{
+			name:           "TraditionalChinese",
+			acceptLanguage: "zh-TW,zh;q=0.9,en;q=0.8",
+			wantDetail:     "請求中有欄位格式錯誤",
+			wantMessages: []string{
+				"email必須是一個有效的信箱",
+				"password為必填欄位",
+			},
+		},
+		{
+			name:           "SimplifiedChinese",
+			acceptLanguage: "zh-CN",
+			wantDetail:     "请求中有字段格式错误",
+			wantMessages: []string{
+				"email必须是一个有效的邮箱",
+				"password为必填字段",
+			},
+		},
+		{
+			name:           "UnsupportedLanguage",
+			acceptLanguage: "fr-FR",
+			wantDetail:     "the request has invalid fields",
+			wantMessages: []string{
+				"email must be a valid email address",
+				"password is a required field",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mocksvc.NewMockBorrowerService(ctrl)
+			svc.EXPECT().
+				SignUp(gomock.Any(), gomock.Any()).
+				Times(0)
+
+			server := newTestServer(t, svc, nil, nil, nil, nil, nil, nil, nil)
+
+			data, err := json.Marshal(reqBody)
+			require.NoError(t, err)
+
+			request := httptest.NewRequest(http.MethodPost, "/api/v1/borrowers/sign_up", bytes.NewReader(data))
+			request.Header.Set("Content-Type", "application/json")
+			if tc.acceptLanguage != "" {
+				request.Header.Set("Accept-Language", tc.acceptLanguage)
+			}
+
+			rsp, err := server.app.Test(request)
+			require.NoError(t, err)
+			require.Equal(t, fiber.StatusBadRequest, rsp.StatusCode)
+			require.Equal(t, problemContentType, rsp.Header.Get(fiber.HeaderContentType))
+
+			var problem ProblemResponse
+			unmarshalRsp(t, rsp, &problem)
+			require.Equal(t, "validation_failed", problem.Code)
+			require.Equal(t, tc.wantDetail, problem.Detail)
+			require.Equal(t, []FieldErrorResponse{
+				{Field: "email", Rule: "email", Message: tc.wantMessages[0]},
+				{Field: "password", Rule: "required", Message: tc.wantMessages[1]},
+			}, problem.Errors)
+		})
+	}
+}
@@ -0,0 +1,3 @@
+ALTER TABLE "users" ADD COLUMN "status" varchar NOT NULL DEFAULT 'active';
+
+CREATE INDEX ON "users" ("created_at");
@@ -0,0 +1,16 @@
+CREATE TABLE "audit_logs" (
+  "id" bigserial PRIMARY KEY,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "actor_id" uuid NOT NULL,
+  "action" varchar NOT NULL,
+  "target_user_id" uuid,
+  "details" jsonb NOT NULL DEFAULT '{}'
+);
+
+ALTER TABLE "audit_logs" ADD FOREIGN KEY ("actor_id") REFERENCES "users" ("id");
+
+ALTER TABLE "audit_logs" ADD FOREIGN KEY ("target_user_id") REFERENCES "users" ("id");
+
+CREATE INDEX ON "audit_logs" ("actor_id");
+
+CREATE INDEX ON "audit_logs" ("target_user_id");
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS "users_email_key";
+
+-- an email reused after its account was closed is held by several rows, the closed ones are renamed
+-- so that the email can be unique again, which cannot be undone by migrating up
+UPDATE "users" SET "email" = 'closed+' || "id" || '+' || "email"
+WHERE "deleted_at" IS NOT NULL AND "email" IN (
+  SELECT "email" FROM "users" GROUP BY "email" HAVING count(*) > 1
+);
+
+ALTER TABLE "users" ADD CONSTRAINT "users_email_key" UNIQUE ("email");
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "deleted_at";
@@ -0,0 +1,6 @@
+ALTER TABLE "users" ADD COLUMN "deleted_at" timestamptz;
+
+-- closed accounts keep their row for financial records, but must free their email for reuse
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "users_email_key";
+
+CREATE UNIQUE INDEX "users_email_key" ON "users" ("email") WHERE "deleted_at" IS NULL;
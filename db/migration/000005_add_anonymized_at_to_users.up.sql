@@ -0,0 +1 @@
+ALTER TABLE "users" ADD COLUMN "anonymized_at" timestamptz;
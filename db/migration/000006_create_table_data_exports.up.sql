@@ -0,0 +1,17 @@
+CREATE TABLE "data_exports" (
+  "id" uuid PRIMARY KEY DEFAULT (uuid_generate_v4()),
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  "user_id" uuid NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "file_path" varchar NOT NULL DEFAULT '',
+  "failure_reason" varchar NOT NULL DEFAULT '',
+  "completed_at" timestamptz,
+  "expires_at" timestamptz
+);
+
+ALTER TABLE "data_exports" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+CREATE INDEX ON "data_exports" ("user_id");
+
+CREATE INDEX ON "data_exports" ("status");
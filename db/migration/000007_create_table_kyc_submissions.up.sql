@@ -0,0 +1,25 @@
+ALTER TABLE "users" ADD COLUMN "kyc_status" varchar NOT NULL DEFAULT 'unverified';
+
+CREATE TABLE "kyc_submissions" (
+  "id" uuid PRIMARY KEY DEFAULT (uuid_generate_v4()),
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  "user_id" uuid NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "id_document_key" varchar NOT NULL,
+  "selfie_key" varchar NOT NULL,
+  "reviewer_id" uuid,
+  "review_reason" varchar NOT NULL DEFAULT '',
+  "reviewed_at" timestamptz
+);
+
+ALTER TABLE "kyc_submissions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "kyc_submissions" ADD FOREIGN KEY ("reviewer_id") REFERENCES "users" ("id");
+
+CREATE INDEX ON "kyc_submissions" ("user_id");
+
+CREATE INDEX ON "kyc_submissions" ("status", "created_at");
+
+-- a user can only wait for one review at a time
+CREATE UNIQUE INDEX "kyc_submissions_pending_user_id_key" ON "kyc_submissions" ("user_id") WHERE "status" = 'pending';
@@ -0,0 +1,28 @@
+CREATE TABLE "api_keys" (
+  "id" uuid PRIMARY KEY DEFAULT (uuid_generate_v4()),
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "partner_id" uuid NOT NULL,
+  "name" varchar NOT NULL,
+  "key_id" varchar UNIQUE NOT NULL,
+  "hashed_secret" varchar NOT NULL,
+  "scopes" varchar[] NOT NULL,
+  "expires_at" timestamptz,
+  "revoked_at" timestamptz,
+  "last_used_at" timestamptz
+);
+
+ALTER TABLE "api_keys" ADD FOREIGN KEY ("partner_id") REFERENCES "users" ("id");
+
+CREATE INDEX ON "api_keys" ("partner_id");
+
+-- nonces of the signed requests seen within the replay window
+CREATE TABLE "api_key_nonces" (
+  "api_key_id" uuid NOT NULL,
+  "nonce" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("api_key_id", "nonce")
+);
+
+ALTER TABLE "api_key_nonces" ADD FOREIGN KEY ("api_key_id") REFERENCES "api_keys" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "api_key_nonces" ("created_at");
@@ -0,0 +1,10 @@
+-- requests counted by the rate limiter in fixed windows, shared by all the instances of the application
+CREATE TABLE "rate_limit_counters" (
+  "key" varchar NOT NULL,
+  "window_start" timestamptz NOT NULL,
+  "count" bigint NOT NULL DEFAULT 1,
+  "expires_at" timestamptz NOT NULL,
+  PRIMARY KEY ("key", "window_start")
+);
+
+CREATE INDEX ON "rate_limit_counters" ("expires_at");
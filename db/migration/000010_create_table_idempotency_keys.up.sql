@@ -0,0 +1,17 @@
+-- responses of the state-changing requests sent with an Idempotency-Key header,
+-- replayed when a client retries a request with the same key
+CREATE TABLE "idempotency_keys" (
+  "scope" varchar NOT NULL,
+  "key" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  "fingerprint" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'processing',
+  "response_status_code" integer NOT NULL DEFAULT 0,
+  "response_content_type" varchar NOT NULL DEFAULT '',
+  "response_body" bytea NOT NULL DEFAULT '',
+  "expires_at" timestamptz NOT NULL,
+  PRIMARY KEY ("scope", "key")
+);
+
+CREATE INDEX ON "idempotency_keys" ("expires_at");
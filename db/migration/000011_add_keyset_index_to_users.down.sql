@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "users_created_at_id_idx";
+
+CREATE INDEX ON "users" ("created_at");
@@ -0,0 +1,4 @@
+-- the users are listed by keyset pagination on ("created_at", "id"), which needs both columns in the index
+DROP INDEX IF EXISTS "users_created_at_idx";
+
+CREATE INDEX ON "users" ("created_at", "id");
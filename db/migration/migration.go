@@ -0,0 +1,159 @@
+// Package migration applies the migrations of the database schema, which are embedded in the binary.
+// The migrations keep the layout of the migrate CLI, so that either can be used on the same database.
+package migration
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Status is the state of the migrations applied to the database
+type Status struct {
+	// Version is the version of the latest migration applied, zero when none has been
+	Version uint
+	// Dirty is set when migration Version failed halfway and the database must be fixed by hand
+	Dirty bool
+	// Latest is the version of the latest migration embedded in the binary
+	Latest uint
+}
+
+// Pending tells whether some embedded migrations have not been applied yet
+func (status Status) Pending() bool {
+	return status.Version < status.Latest
+}
+
+// Check fails when the binary must not run against the database, because a migration failed halfway,
+// some migrations have not been applied yet, or the database has been migrated by a newer binary
+func (status Status) Check() error {
+	if status.Dirty {
+		return fmt.Errorf("migration %d failed halfway, the database must be fixed by hand", status.Version)
+	}
+	if status.Version > status.Latest {
+		return fmt.Errorf("schema version %d is ahead of the latest migration %d of this binary", status.Version, status.Latest)
+	}
+	if status.Pending() {
+		return fmt.Errorf("schema version %d is behind the latest migration %d, run the migrate up subcommand", status.Version, status.Latest)
+	}
+	return nil
+}
+
+// Migrator applies the embedded migrations to a database.
+// Every migration run holds a Postgres advisory lock, so that the instances started together
+// wait for the first one to migrate the database instead of racing it.
+type Migrator struct {
+	migrate *migrate.Migrate
+	latest  uint
+}
+
+// NewMigrator connects to the database of the DB source
+func NewMigrator(dbSource string) (*Migrator, error) {
+	latest, err := LatestVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := iofs.New(files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read migrations: %w", err)
+	}
+
+	conn, err := sql.Open("pgx", dbSource)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open DB: %w", err)
+	}
+
+	driver, err := pgx.WithInstance(conn, &pgx.Config{})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("cannot connect to DB: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "pgx5", driver)
+	if err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("cannot create migrator: %w", err)
+	}
+	m.Log = logger{}
+
+	return &Migrator{migrate: m, latest: latest}, nil
+}
+
+// Up applies the pending migrations, it does nothing when there are none
+func (migrator *Migrator) Up() error {
+	err := migrator.migrate.Up()
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
+
+// Down rolls back the given number of the latest migrations applied
+func (migrator *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("cannot roll back %d migrations", steps)
+	}
+	return migrator.migrate.Steps(-steps)
+}
+
+// Status returns the state of the migrations applied to the database
+func (migrator *Migrator) Status() (Status, error) {
+	status := Status{Latest: migrator.latest}
+
+	version, dirty, err := migrator.migrate.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return status, err
+	}
+
+	status.Version = version
+	status.Dirty = dirty
+	return status, nil
+}
+
+// Close closes the connection to the database
+func (migrator *Migrator) Close() error {
+	sourceErr, dbErr := migrator.migrate.Close()
+	return errors.Join(sourceErr, dbErr)
+}
+
+// LatestVersion returns the version of the latest migration embedded in the binary
+func LatestVersion() (uint, error) {
+	names, err := fs.Glob(files, "*.up.sql")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, name := range names {
+		m, err := source.DefaultParse(name)
+		if err != nil {
+			return 0, fmt.Errorf("cannot parse migration %s: %w", name, err)
+		}
+		latest = max(latest, m.Version)
+	}
+	return latest, nil
+}
+
+// logger logs the progress of the migrations
+type logger struct{}
+
+func (logger) Printf(format string, v ...interface{}) {
+	slog.Info(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (logger) Verbose() bool {
+	return false
+}
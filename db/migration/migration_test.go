@@ -0,0 +1,79 @@
+package migration
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestVersion(t *testing.T) {
+	ups, err := fs.Glob(files, "*.up.sql")
+	require.NoError(t, err)
+
+	// the versions are numbered without gaps, so the latest one is the number of migrations
+	latest, err := LatestVersion()
+	require.NoError(t, err)
+	require.Equal(t, uint(len(ups)), latest)
+}
+
+func TestEveryMigrationCanBeRolledBack(t *testing.T) {
+	ups, err := fs.Glob(files, "*.up.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, ups)
+
+	for _, up := range ups {
+		_, err := fs.Stat(files, strings.TrimSuffix(up, ".up.sql")+".down.sql")
+		require.NoError(t, err, up)
+	}
+}
+
+func TestStatusCheck(t *testing.T) {
+	testCases := []struct {
+		name        string
+		status      Status
+		pending     bool
+		expectedErr string
+	}{
+		{
+			name:   "UpToDate",
+			status: Status{Version: 11, Latest: 11},
+		},
+		{
+			name:        "Behind",
+			status:      Status{Version: 10, Latest: 11},
+			pending:     true,
+			expectedErr: "schema version 10 is behind the latest migration 11, run the migrate up subcommand",
+		},
+		{
+			name:        "Empty",
+			status:      Status{Latest: 11},
+			pending:     true,
+			expectedErr: "schema version 0 is behind the latest migration 11, run the migrate up subcommand",
+		},
+		{
+			name:        "Dirty",
+			status:      Status{Version: 11, Dirty: true, Latest: 11},
+			expectedErr: "migration 11 failed halfway, the database must be fixed by hand",
+		},
+		{
+			name:        "Ahead",
+			status:      Status{Version: 12, Latest: 11},
+			expectedErr: "schema version 12 is ahead of the latest migration 11 of this binary",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.pending, tc.status.Pending())
+
+			err := tc.status.Check()
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
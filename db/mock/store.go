@@ -7,9 +7,11 @@ package mockdb
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
 	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
 )
 
 // MockStore is a mock of Store interface.
@@ -35,6 +37,246 @@ func (m *MockStore) EXPECT() *MockStoreMockRecorder {
 	return m.recorder
 }
 
+// AcquireIdempotencyKey mocks base method.
+func (m *MockStore) AcquireIdempotencyKey(arg0 context.Context, arg1 db.AcquireIdempotencyKeyParams) (db.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcquireIdempotencyKey", arg0, arg1)
+	ret0, _ := ret[0].(db.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcquireIdempotencyKey indicates an expected call of AcquireIdempotencyKey.
+func (mr *MockStoreMockRecorder) AcquireIdempotencyKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquireIdempotencyKey", reflect.TypeOf((*MockStore)(nil).AcquireIdempotencyKey), arg0, arg1)
+}
+
+// AnonymizeUser mocks base method.
+func (m *MockStore) AnonymizeUser(arg0 context.Context, arg1 db.AnonymizeUserParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnonymizeUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AnonymizeUser indicates an expected call of AnonymizeUser.
+func (mr *MockStoreMockRecorder) AnonymizeUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnonymizeUser", reflect.TypeOf((*MockStore)(nil).AnonymizeUser), arg0, arg1)
+}
+
+// AnonymizeUserTx mocks base method.
+func (m *MockStore) AnonymizeUserTx(arg0 context.Context, arg1 db.AnonymizeUserTxParams) (db.AnonymizeUserTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnonymizeUserTx", arg0, arg1)
+	ret0, _ := ret[0].(db.AnonymizeUserTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AnonymizeUserTx indicates an expected call of AnonymizeUserTx.
+func (mr *MockStoreMockRecorder) AnonymizeUserTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnonymizeUserTx", reflect.TypeOf((*MockStore)(nil).AnonymizeUserTx), arg0, arg1)
+}
+
+// ClaimDataExport mocks base method.
+func (m *MockStore) ClaimDataExport(arg0 context.Context, arg1 time.Time) (db.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDataExport", arg0, arg1)
+	ret0, _ := ret[0].(db.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDataExport indicates an expected call of ClaimDataExport.
+func (mr *MockStoreMockRecorder) ClaimDataExport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDataExport", reflect.TypeOf((*MockStore)(nil).ClaimDataExport), arg0, arg1)
+}
+
+// CloseUser mocks base method.
+func (m *MockStore) CloseUser(arg0 context.Context, arg1 db.CloseUserParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloseUser indicates an expected call of CloseUser.
+func (mr *MockStoreMockRecorder) CloseUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseUser", reflect.TypeOf((*MockStore)(nil).CloseUser), arg0, arg1)
+}
+
+// CloseUserTx mocks base method.
+func (m *MockStore) CloseUserTx(arg0 context.Context, arg1 db.CloseUserTxParams) (db.AuditedUserTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseUserTx", arg0, arg1)
+	ret0, _ := ret[0].(db.AuditedUserTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloseUserTx indicates an expected call of CloseUserTx.
+func (mr *MockStoreMockRecorder) CloseUserTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseUserTx", reflect.TypeOf((*MockStore)(nil).CloseUserTx), arg0, arg1)
+}
+
+// CompleteDataExport mocks base method.
+func (m *MockStore) CompleteDataExport(arg0 context.Context, arg1 db.CompleteDataExportParams) (db.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteDataExport", arg0, arg1)
+	ret0, _ := ret[0].(db.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteDataExport indicates an expected call of CompleteDataExport.
+func (mr *MockStoreMockRecorder) CompleteDataExport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteDataExport", reflect.TypeOf((*MockStore)(nil).CompleteDataExport), arg0, arg1)
+}
+
+// CompleteIdempotencyKey mocks base method.
+func (m *MockStore) CompleteIdempotencyKey(arg0 context.Context, arg1 db.CompleteIdempotencyKeyParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteIdempotencyKey", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteIdempotencyKey indicates an expected call of CompleteIdempotencyKey.
+func (mr *MockStoreMockRecorder) CompleteIdempotencyKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteIdempotencyKey", reflect.TypeOf((*MockStore)(nil).CompleteIdempotencyKey), arg0, arg1)
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockStore) CreateAPIKey(arg0 context.Context, arg1 db.CreateAPIKeyParams) (db.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(db.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockStoreMockRecorder) CreateAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockStore)(nil).CreateAPIKey), arg0, arg1)
+}
+
+// CreateAPIKeyNonce mocks base method.
+func (m *MockStore) CreateAPIKeyNonce(arg0 context.Context, arg1 db.CreateAPIKeyNonceParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKeyNonce", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKeyNonce indicates an expected call of CreateAPIKeyNonce.
+func (mr *MockStoreMockRecorder) CreateAPIKeyNonce(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKeyNonce", reflect.TypeOf((*MockStore)(nil).CreateAPIKeyNonce), arg0, arg1)
+}
+
+// CreateAPIKeyTx mocks base method.
+func (m *MockStore) CreateAPIKeyTx(arg0 context.Context, arg1 db.CreateAPIKeyTxParams) (db.APIKeyTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKeyTx", arg0, arg1)
+	ret0, _ := ret[0].(db.APIKeyTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKeyTx indicates an expected call of CreateAPIKeyTx.
+func (mr *MockStoreMockRecorder) CreateAPIKeyTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKeyTx", reflect.TypeOf((*MockStore)(nil).CreateAPIKeyTx), arg0, arg1)
+}
+
+// CreateAdminTx mocks base method.
+func (m *MockStore) CreateAdminTx(arg0 context.Context, arg1 db.CreateAdminTxParams) (db.AuditedUserTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAdminTx", arg0, arg1)
+	ret0, _ := ret[0].(db.AuditedUserTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAdminTx indicates an expected call of CreateAdminTx.
+func (mr *MockStoreMockRecorder) CreateAdminTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAdminTx", reflect.TypeOf((*MockStore)(nil).CreateAdminTx), arg0, arg1)
+}
+
+// CreateAuditLog mocks base method.
+func (m *MockStore) CreateAuditLog(arg0 context.Context, arg1 db.CreateAuditLogParams) (db.AuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditLog", arg0, arg1)
+	ret0, _ := ret[0].(db.AuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAuditLog indicates an expected call of CreateAuditLog.
+func (mr *MockStoreMockRecorder) CreateAuditLog(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditLog", reflect.TypeOf((*MockStore)(nil).CreateAuditLog), arg0, arg1)
+}
+
+// CreateDataExport mocks base method.
+func (m *MockStore) CreateDataExport(arg0 context.Context, arg1 uuid.UUID) (db.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDataExport", arg0, arg1)
+	ret0, _ := ret[0].(db.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDataExport indicates an expected call of CreateDataExport.
+func (mr *MockStoreMockRecorder) CreateDataExport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDataExport", reflect.TypeOf((*MockStore)(nil).CreateDataExport), arg0, arg1)
+}
+
+// CreateKYCSubmission mocks base method.
+func (m *MockStore) CreateKYCSubmission(arg0 context.Context, arg1 db.CreateKYCSubmissionParams) (db.KYCSubmission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateKYCSubmission", arg0, arg1)
+	ret0, _ := ret[0].(db.KYCSubmission)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateKYCSubmission indicates an expected call of CreateKYCSubmission.
+func (mr *MockStoreMockRecorder) CreateKYCSubmission(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateKYCSubmission", reflect.TypeOf((*MockStore)(nil).CreateKYCSubmission), arg0, arg1)
+}
+
+// CreateKYCSubmissionTx mocks base method.
+func (m *MockStore) CreateKYCSubmissionTx(arg0 context.Context, arg1 db.CreateKYCSubmissionTxParams) (db.KYCSubmissionTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateKYCSubmissionTx", arg0, arg1)
+	ret0, _ := ret[0].(db.KYCSubmissionTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateKYCSubmissionTx indicates an expected call of CreateKYCSubmissionTx.
+func (mr *MockStoreMockRecorder) CreateKYCSubmissionTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateKYCSubmissionTx", reflect.TypeOf((*MockStore)(nil).CreateKYCSubmissionTx), arg0, arg1)
+}
+
 // CreateUser mocks base method.
 func (m *MockStore) CreateUser(arg0 context.Context, arg1 db.CreateUserParams) (db.User, error) {
 	m.ctrl.T.Helper()
@@ -50,6 +292,350 @@ func (mr *MockStoreMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0, arg1)
 }
 
+// DeleteAPIKeyNoncesBefore mocks base method.
+func (m *MockStore) DeleteAPIKeyNoncesBefore(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAPIKeyNoncesBefore", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAPIKeyNoncesBefore indicates an expected call of DeleteAPIKeyNoncesBefore.
+func (mr *MockStoreMockRecorder) DeleteAPIKeyNoncesBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAPIKeyNoncesBefore", reflect.TypeOf((*MockStore)(nil).DeleteAPIKeyNoncesBefore), arg0, arg1)
+}
+
+// DeleteDataExportsByUser mocks base method.
+func (m *MockStore) DeleteDataExportsByUser(arg0 context.Context, arg1 uuid.UUID) ([]db.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDataExportsByUser", arg0, arg1)
+	ret0, _ := ret[0].([]db.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteDataExportsByUser indicates an expected call of DeleteDataExportsByUser.
+func (mr *MockStoreMockRecorder) DeleteDataExportsByUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDataExportsByUser", reflect.TypeOf((*MockStore)(nil).DeleteDataExportsByUser), arg0, arg1)
+}
+
+// DeleteIdempotencyKey mocks base method.
+func (m *MockStore) DeleteIdempotencyKey(arg0 context.Context, arg1 db.DeleteIdempotencyKeyParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdempotencyKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdempotencyKey indicates an expected call of DeleteIdempotencyKey.
+func (mr *MockStoreMockRecorder) DeleteIdempotencyKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdempotencyKey", reflect.TypeOf((*MockStore)(nil).DeleteIdempotencyKey), arg0, arg1)
+}
+
+// DeleteIdempotencyKeysBefore mocks base method.
+func (m *MockStore) DeleteIdempotencyKeysBefore(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdempotencyKeysBefore", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteIdempotencyKeysBefore indicates an expected call of DeleteIdempotencyKeysBefore.
+func (mr *MockStoreMockRecorder) DeleteIdempotencyKeysBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdempotencyKeysBefore", reflect.TypeOf((*MockStore)(nil).DeleteIdempotencyKeysBefore), arg0, arg1)
+}
+
+// DeleteKYCSubmissionsByUser mocks base method.
+func (m *MockStore) DeleteKYCSubmissionsByUser(arg0 context.Context, arg1 uuid.UUID) ([]db.KYCSubmission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteKYCSubmissionsByUser", arg0, arg1)
+	ret0, _ := ret[0].([]db.KYCSubmission)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteKYCSubmissionsByUser indicates an expected call of DeleteKYCSubmissionsByUser.
+func (mr *MockStoreMockRecorder) DeleteKYCSubmissionsByUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteKYCSubmissionsByUser", reflect.TypeOf((*MockStore)(nil).DeleteKYCSubmissionsByUser), arg0, arg1)
+}
+
+// DeleteRateLimitCountersBefore mocks base method.
+func (m *MockStore) DeleteRateLimitCountersBefore(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRateLimitCountersBefore", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteRateLimitCountersBefore indicates an expected call of DeleteRateLimitCountersBefore.
+func (mr *MockStoreMockRecorder) DeleteRateLimitCountersBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRateLimitCountersBefore", reflect.TypeOf((*MockStore)(nil).DeleteRateLimitCountersBefore), arg0, arg1)
+}
+
+// ExpireAPIKey mocks base method.
+func (m *MockStore) ExpireAPIKey(arg0 context.Context, arg1 db.ExpireAPIKeyParams) (db.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(db.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireAPIKey indicates an expected call of ExpireAPIKey.
+func (mr *MockStoreMockRecorder) ExpireAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireAPIKey", reflect.TypeOf((*MockStore)(nil).ExpireAPIKey), arg0, arg1)
+}
+
+// ExpireDataExport mocks base method.
+func (m *MockStore) ExpireDataExport(arg0 context.Context, arg1 uuid.UUID) (db.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireDataExport", arg0, arg1)
+	ret0, _ := ret[0].(db.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireDataExport indicates an expected call of ExpireDataExport.
+func (mr *MockStoreMockRecorder) ExpireDataExport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireDataExport", reflect.TypeOf((*MockStore)(nil).ExpireDataExport), arg0, arg1)
+}
+
+// FailDataExport mocks base method.
+func (m *MockStore) FailDataExport(arg0 context.Context, arg1 db.FailDataExportParams) (db.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailDataExport", arg0, arg1)
+	ret0, _ := ret[0].(db.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FailDataExport indicates an expected call of FailDataExport.
+func (mr *MockStoreMockRecorder) FailDataExport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailDataExport", reflect.TypeOf((*MockStore)(nil).FailDataExport), arg0, arg1)
+}
+
+// GetAPIKey mocks base method.
+func (m *MockStore) GetAPIKey(arg0 context.Context, arg1 uuid.UUID) (db.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(db.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKey indicates an expected call of GetAPIKey.
+func (mr *MockStoreMockRecorder) GetAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKey", reflect.TypeOf((*MockStore)(nil).GetAPIKey), arg0, arg1)
+}
+
+// GetAPIKeyByKeyID mocks base method.
+func (m *MockStore) GetAPIKeyByKeyID(arg0 context.Context, arg1 string) (db.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyByKeyID", arg0, arg1)
+	ret0, _ := ret[0].(db.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyByKeyID indicates an expected call of GetAPIKeyByKeyID.
+func (mr *MockStoreMockRecorder) GetAPIKeyByKeyID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByKeyID", reflect.TypeOf((*MockStore)(nil).GetAPIKeyByKeyID), arg0, arg1)
+}
+
+// GetAPIKeysByPartner mocks base method.
+func (m *MockStore) GetAPIKeysByPartner(arg0 context.Context, arg1 uuid.UUID) ([]db.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeysByPartner", arg0, arg1)
+	ret0, _ := ret[0].([]db.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeysByPartner indicates an expected call of GetAPIKeysByPartner.
+func (mr *MockStoreMockRecorder) GetAPIKeysByPartner(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeysByPartner", reflect.TypeOf((*MockStore)(nil).GetAPIKeysByPartner), arg0, arg1)
+}
+
+// GetAuditLogsByTargetUser mocks base method.
+func (m *MockStore) GetAuditLogsByTargetUser(arg0 context.Context, arg1 db.GetAuditLogsByTargetUserParams) ([]db.AuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditLogsByTargetUser", arg0, arg1)
+	ret0, _ := ret[0].([]db.AuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditLogsByTargetUser indicates an expected call of GetAuditLogsByTargetUser.
+func (mr *MockStoreMockRecorder) GetAuditLogsByTargetUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditLogsByTargetUser", reflect.TypeOf((*MockStore)(nil).GetAuditLogsByTargetUser), arg0, arg1)
+}
+
+// GetAuditLogsByUser mocks base method.
+func (m *MockStore) GetAuditLogsByUser(arg0 context.Context, arg1 uuid.UUID) ([]db.AuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditLogsByUser", arg0, arg1)
+	ret0, _ := ret[0].([]db.AuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditLogsByUser indicates an expected call of GetAuditLogsByUser.
+func (mr *MockStoreMockRecorder) GetAuditLogsByUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditLogsByUser", reflect.TypeOf((*MockStore)(nil).GetAuditLogsByUser), arg0, arg1)
+}
+
+// GetDataExport mocks base method.
+func (m *MockStore) GetDataExport(arg0 context.Context, arg1 uuid.UUID) (db.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDataExport", arg0, arg1)
+	ret0, _ := ret[0].(db.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDataExport indicates an expected call of GetDataExport.
+func (mr *MockStoreMockRecorder) GetDataExport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataExport", reflect.TypeOf((*MockStore)(nil).GetDataExport), arg0, arg1)
+}
+
+// GetDataExportsByUser mocks base method.
+func (m *MockStore) GetDataExportsByUser(arg0 context.Context, arg1 uuid.UUID) ([]db.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDataExportsByUser", arg0, arg1)
+	ret0, _ := ret[0].([]db.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDataExportsByUser indicates an expected call of GetDataExportsByUser.
+func (mr *MockStoreMockRecorder) GetDataExportsByUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataExportsByUser", reflect.TypeOf((*MockStore)(nil).GetDataExportsByUser), arg0, arg1)
+}
+
+// GetExpiredDataExports mocks base method.
+func (m *MockStore) GetExpiredDataExports(arg0 context.Context, arg1 time.Time) ([]db.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiredDataExports", arg0, arg1)
+	ret0, _ := ret[0].([]db.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiredDataExports indicates an expected call of GetExpiredDataExports.
+func (mr *MockStoreMockRecorder) GetExpiredDataExports(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredDataExports", reflect.TypeOf((*MockStore)(nil).GetExpiredDataExports), arg0, arg1)
+}
+
+// GetIdempotencyKey mocks base method.
+func (m *MockStore) GetIdempotencyKey(arg0 context.Context, arg1 db.GetIdempotencyKeyParams) (db.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdempotencyKey", arg0, arg1)
+	ret0, _ := ret[0].(db.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdempotencyKey indicates an expected call of GetIdempotencyKey.
+func (mr *MockStoreMockRecorder) GetIdempotencyKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotencyKey", reflect.TypeOf((*MockStore)(nil).GetIdempotencyKey), arg0, arg1)
+}
+
+// GetKYCSubmission mocks base method.
+func (m *MockStore) GetKYCSubmission(arg0 context.Context, arg1 uuid.UUID) (db.KYCSubmission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKYCSubmission", arg0, arg1)
+	ret0, _ := ret[0].(db.KYCSubmission)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKYCSubmission indicates an expected call of GetKYCSubmission.
+func (mr *MockStoreMockRecorder) GetKYCSubmission(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKYCSubmission", reflect.TypeOf((*MockStore)(nil).GetKYCSubmission), arg0, arg1)
+}
+
+// GetKYCSubmissionsByUser mocks base method.
+func (m *MockStore) GetKYCSubmissionsByUser(arg0 context.Context, arg1 uuid.UUID) ([]db.KYCSubmission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKYCSubmissionsByUser", arg0, arg1)
+	ret0, _ := ret[0].([]db.KYCSubmission)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKYCSubmissionsByUser indicates an expected call of GetKYCSubmissionsByUser.
+func (mr *MockStoreMockRecorder) GetKYCSubmissionsByUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKYCSubmissionsByUser", reflect.TypeOf((*MockStore)(nil).GetKYCSubmissionsByUser), arg0, arg1)
+}
+
+// GetLatestKYCSubmissionByUser mocks base method.
+func (m *MockStore) GetLatestKYCSubmissionByUser(arg0 context.Context, arg1 uuid.UUID) (db.KYCSubmission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestKYCSubmissionByUser", arg0, arg1)
+	ret0, _ := ret[0].(db.KYCSubmission)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestKYCSubmissionByUser indicates an expected call of GetLatestKYCSubmissionByUser.
+func (mr *MockStoreMockRecorder) GetLatestKYCSubmissionByUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestKYCSubmissionByUser", reflect.TypeOf((*MockStore)(nil).GetLatestKYCSubmissionByUser), arg0, arg1)
+}
+
+// GetPendingKYCSubmissions mocks base method.
+func (m *MockStore) GetPendingKYCSubmissions(arg0 context.Context, arg1 db.GetPendingKYCSubmissionsParams) ([]db.KYCSubmission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingKYCSubmissions", arg0, arg1)
+	ret0, _ := ret[0].([]db.KYCSubmission)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingKYCSubmissions indicates an expected call of GetPendingKYCSubmissions.
+func (mr *MockStoreMockRecorder) GetPendingKYCSubmissions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingKYCSubmissions", reflect.TypeOf((*MockStore)(nil).GetPendingKYCSubmissions), arg0, arg1)
+}
+
+// GetSchemaMigration mocks base method.
+func (m *MockStore) GetSchemaMigration(arg0 context.Context) (db.SchemaMigration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchemaMigration", arg0)
+	ret0, _ := ret[0].(db.SchemaMigration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchemaMigration indicates an expected call of GetSchemaMigration.
+func (mr *MockStoreMockRecorder) GetSchemaMigration(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchemaMigration", reflect.TypeOf((*MockStore)(nil).GetSchemaMigration), arg0)
+}
+
 // GetUserByEmail mocks base method.
 func (m *MockStore) GetUserByEmail(arg0 context.Context, arg1 string) (db.User, error) {
 	m.ctrl.T.Helper()
@@ -65,19 +651,167 @@ func (mr *MockStoreMockRecorder) GetUserByEmail(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockStore)(nil).GetUserByEmail), arg0, arg1)
 }
 
-// GetUsers mocks base method.
-func (m *MockStore) GetUsers(arg0 context.Context, arg1 db.GetUsersParams) ([]db.User, error) {
+// GetUserByID mocks base method.
+func (m *MockStore) GetUserByID(arg0 context.Context, arg1 uuid.UUID) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockStoreMockRecorder) GetUserByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockStore)(nil).GetUserByID), arg0, arg1)
+}
+
+// IncrementRateLimitCounter mocks base method.
+func (m *MockStore) IncrementRateLimitCounter(arg0 context.Context, arg1 db.IncrementRateLimitCounterParams) (db.IncrementRateLimitCounterRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementRateLimitCounter", arg0, arg1)
+	ret0, _ := ret[0].(db.IncrementRateLimitCounterRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementRateLimitCounter indicates an expected call of IncrementRateLimitCounter.
+func (mr *MockStoreMockRecorder) IncrementRateLimitCounter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementRateLimitCounter", reflect.TypeOf((*MockStore)(nil).IncrementRateLimitCounter), arg0, arg1)
+}
+
+// ListUsers mocks base method.
+func (m *MockStore) ListUsers(arg0 context.Context, arg1 db.ListUsersParams) ([]db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", arg0, arg1)
+	ret0, _ := ret[0].([]db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockStoreMockRecorder) ListUsers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockStore)(nil).ListUsers), arg0, arg1)
+}
+
+// ListUsersDesc mocks base method.
+func (m *MockStore) ListUsersDesc(arg0 context.Context, arg1 db.ListUsersDescParams) ([]db.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsers", arg0, arg1)
+	ret := m.ctrl.Call(m, "ListUsersDesc", arg0, arg1)
 	ret0, _ := ret[0].([]db.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUsers indicates an expected call of GetUsers.
-func (mr *MockStoreMockRecorder) GetUsers(arg0, arg1 interface{}) *gomock.Call {
+// ListUsersDesc indicates an expected call of ListUsersDesc.
+func (mr *MockStoreMockRecorder) ListUsersDesc(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockStore)(nil).GetUsers), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsersDesc", reflect.TypeOf((*MockStore)(nil).ListUsersDesc), arg0, arg1)
+}
+
+// Ping mocks base method.
+func (m *MockStore) Ping(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockStoreMockRecorder) Ping(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockStore)(nil).Ping), arg0)
+}
+
+// ReviewKYCSubmission mocks base method.
+func (m *MockStore) ReviewKYCSubmission(arg0 context.Context, arg1 db.ReviewKYCSubmissionParams) (db.KYCSubmission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReviewKYCSubmission", arg0, arg1)
+	ret0, _ := ret[0].(db.KYCSubmission)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReviewKYCSubmission indicates an expected call of ReviewKYCSubmission.
+func (mr *MockStoreMockRecorder) ReviewKYCSubmission(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReviewKYCSubmission", reflect.TypeOf((*MockStore)(nil).ReviewKYCSubmission), arg0, arg1)
+}
+
+// ReviewKYCSubmissionTx mocks base method.
+func (m *MockStore) ReviewKYCSubmissionTx(arg0 context.Context, arg1 db.ReviewKYCSubmissionTxParams) (db.KYCSubmissionTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReviewKYCSubmissionTx", arg0, arg1)
+	ret0, _ := ret[0].(db.KYCSubmissionTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReviewKYCSubmissionTx indicates an expected call of ReviewKYCSubmissionTx.
+func (mr *MockStoreMockRecorder) ReviewKYCSubmissionTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReviewKYCSubmissionTx", reflect.TypeOf((*MockStore)(nil).ReviewKYCSubmissionTx), arg0, arg1)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockStore) RevokeAPIKey(arg0 context.Context, arg1 db.RevokeAPIKeyParams) (db.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(db.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockStoreMockRecorder) RevokeAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockStore)(nil).RevokeAPIKey), arg0, arg1)
+}
+
+// RevokeAPIKeyTx mocks base method.
+func (m *MockStore) RevokeAPIKeyTx(arg0 context.Context, arg1 db.RevokeAPIKeyTxParams) (db.APIKeyTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKeyTx", arg0, arg1)
+	ret0, _ := ret[0].(db.APIKeyTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAPIKeyTx indicates an expected call of RevokeAPIKeyTx.
+func (mr *MockStoreMockRecorder) RevokeAPIKeyTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKeyTx", reflect.TypeOf((*MockStore)(nil).RevokeAPIKeyTx), arg0, arg1)
+}
+
+// RotateAPIKeyTx mocks base method.
+func (m *MockStore) RotateAPIKeyTx(arg0 context.Context, arg1 db.RotateAPIKeyTxParams) (db.RotateAPIKeyTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateAPIKeyTx", arg0, arg1)
+	ret0, _ := ret[0].(db.RotateAPIKeyTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateAPIKeyTx indicates an expected call of RotateAPIKeyTx.
+func (mr *MockStoreMockRecorder) RotateAPIKeyTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateAPIKeyTx", reflect.TypeOf((*MockStore)(nil).RotateAPIKeyTx), arg0, arg1)
+}
+
+// TouchAPIKey mocks base method.
+func (m *MockStore) TouchAPIKey(arg0 context.Context, arg1 db.TouchAPIKeyParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchAPIKey indicates an expected call of TouchAPIKey.
+func (mr *MockStoreMockRecorder) TouchAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchAPIKey", reflect.TypeOf((*MockStore)(nil).TouchAPIKey), arg0, arg1)
 }
 
 // UpdateUserByEmail mocks base method.
@@ -94,3 +828,108 @@ func (mr *MockStoreMockRecorder) UpdateUserByEmail(arg0, arg1 interface{}) *gomo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserByEmail", reflect.TypeOf((*MockStore)(nil).UpdateUserByEmail), arg0, arg1)
 }
+
+// UpdateUserEmail mocks base method.
+func (m *MockStore) UpdateUserEmail(arg0 context.Context, arg1 db.UpdateUserEmailParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserEmail", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserEmail indicates an expected call of UpdateUserEmail.
+func (mr *MockStoreMockRecorder) UpdateUserEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserEmail", reflect.TypeOf((*MockStore)(nil).UpdateUserEmail), arg0, arg1)
+}
+
+// UpdateUserKYCStatus mocks base method.
+func (m *MockStore) UpdateUserKYCStatus(arg0 context.Context, arg1 db.UpdateUserKYCStatusParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserKYCStatus", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserKYCStatus indicates an expected call of UpdateUserKYCStatus.
+func (mr *MockStoreMockRecorder) UpdateUserKYCStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserKYCStatus", reflect.TypeOf((*MockStore)(nil).UpdateUserKYCStatus), arg0, arg1)
+}
+
+// UpdateUserRole mocks base method.
+func (m *MockStore) UpdateUserRole(arg0 context.Context, arg1 db.UpdateUserRoleParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserRole", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserRole indicates an expected call of UpdateUserRole.
+func (mr *MockStoreMockRecorder) UpdateUserRole(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserRole", reflect.TypeOf((*MockStore)(nil).UpdateUserRole), arg0, arg1)
+}
+
+// UpdateUserRoleTx mocks base method.
+func (m *MockStore) UpdateUserRoleTx(arg0 context.Context, arg1 db.UpdateUserRoleTxParams) (db.AuditedUserTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserRoleTx", arg0, arg1)
+	ret0, _ := ret[0].(db.AuditedUserTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserRoleTx indicates an expected call of UpdateUserRoleTx.
+func (mr *MockStoreMockRecorder) UpdateUserRoleTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserRoleTx", reflect.TypeOf((*MockStore)(nil).UpdateUserRoleTx), arg0, arg1)
+}
+
+// UpdateUserStatus mocks base method.
+func (m *MockStore) UpdateUserStatus(arg0 context.Context, arg1 db.UpdateUserStatusParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserStatus", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserStatus indicates an expected call of UpdateUserStatus.
+func (mr *MockStoreMockRecorder) UpdateUserStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserStatus", reflect.TypeOf((*MockStore)(nil).UpdateUserStatus), arg0, arg1)
+}
+
+// UpdateUserStatusTx mocks base method.
+func (m *MockStore) UpdateUserStatusTx(arg0 context.Context, arg1 db.UpdateUserStatusTxParams) (db.AuditedUserTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserStatusTx", arg0, arg1)
+	ret0, _ := ret[0].(db.AuditedUserTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserStatusTx indicates an expected call of UpdateUserStatusTx.
+func (mr *MockStoreMockRecorder) UpdateUserStatusTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserStatusTx", reflect.TypeOf((*MockStore)(nil).UpdateUserStatusTx), arg0, arg1)
+}
+
+// UpdateUserTx mocks base method.
+func (m *MockStore) UpdateUserTx(arg0 context.Context, arg1 db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserTx", arg0, arg1)
+	ret0, _ := ret[0].(db.UpdateUserTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserTx indicates an expected call of UpdateUserTx.
+func (mr *MockStoreMockRecorder) UpdateUserTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserTx", reflect.TypeOf((*MockStore)(nil).UpdateUserTx), arg0, arg1)
+}
@@ -0,0 +1,53 @@
+-- name: CreateAPIKey :one
+INSERT INTO "api_keys" (
+  "partner_id",
+  "name",
+  "key_id",
+  "hashed_secret",
+  "scopes",
+  "expires_at"
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetAPIKey :one
+SELECT * FROM "api_keys"
+WHERE "id" = $1 LIMIT 1;
+
+-- name: GetAPIKeyByKeyID :one
+SELECT * FROM "api_keys"
+WHERE "key_id" = $1 LIMIT 1;
+
+-- name: GetAPIKeysByPartner :many
+SELECT * FROM "api_keys"
+WHERE "partner_id" = $1
+ORDER BY "created_at";
+
+-- name: RevokeAPIKey :one
+UPDATE "api_keys"
+SET "revoked_at" = sqlc.arg('revoked_at')::timestamptz
+WHERE "id" = sqlc.arg('id') AND "revoked_at" IS NULL
+RETURNING *;
+
+-- name: ExpireAPIKey :one
+UPDATE "api_keys"
+SET "expires_at" = LEAST("expires_at", sqlc.arg('expires_at')::timestamptz)
+WHERE "id" = sqlc.arg('id') AND "revoked_at" IS NULL
+RETURNING *;
+
+-- name: TouchAPIKey :exec
+UPDATE "api_keys"
+SET "last_used_at" = sqlc.arg('last_used_at')::timestamptz
+WHERE "id" = sqlc.arg('id');
+
+-- name: CreateAPIKeyNonce :execrows
+INSERT INTO "api_key_nonces" (
+  "api_key_id",
+  "nonce"
+) VALUES (
+  $1, $2
+) ON CONFLICT DO NOTHING;
+
+-- name: DeleteAPIKeyNoncesBefore :execrows
+DELETE FROM "api_key_nonces"
+WHERE "created_at" < sqlc.arg('before')::timestamptz;
@@ -0,0 +1,21 @@
+-- name: CreateAuditLog :one
+INSERT INTO "audit_logs" (
+  "actor_id",
+  "action",
+  "target_user_id",
+  "details"
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetAuditLogsByTargetUser :many
+SELECT * FROM "audit_logs"
+WHERE "target_user_id" = $1
+ORDER BY "id"
+LIMIT $2
+OFFSET $3;
+
+-- name: GetAuditLogsByUser :many
+SELECT * FROM "audit_logs"
+WHERE "actor_id" = sqlc.arg('user_id') OR "target_user_id" = sqlc.arg('user_id')
+ORDER BY "id";
@@ -0,0 +1,72 @@
+-- name: CreateDataExport :one
+INSERT INTO "data_exports" (
+  "user_id"
+) VALUES (
+  $1
+) RETURNING *;
+
+-- name: GetDataExport :one
+SELECT * FROM "data_exports"
+WHERE "id" = $1 LIMIT 1;
+
+-- name: GetDataExportsByUser :many
+SELECT * FROM "data_exports"
+WHERE "user_id" = $1
+ORDER BY "created_at";
+
+-- name: ClaimDataExport :one
+-- ClaimDataExport picks the oldest pending export, or one whose processing
+-- has stalled since before the given time, and marks it as processing.
+UPDATE "data_exports"
+SET
+  "status" = 'processing',
+  "updated_at" = now()
+WHERE "id" = (
+  SELECT "id" FROM "data_exports"
+  WHERE
+    "status" = 'pending' OR
+    ("status" = 'processing' AND "updated_at" < sqlc.arg('stalled_before')::timestamptz)
+  ORDER BY "created_at"
+  LIMIT 1
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteDataExport :one
+UPDATE "data_exports"
+SET
+  "status" = 'ready',
+  "file_path" = sqlc.arg('file_path'),
+  "completed_at" = sqlc.arg('completed_at')::timestamptz,
+  "expires_at" = sqlc.arg('expires_at')::timestamptz,
+  "updated_at" = sqlc.arg('completed_at')::timestamptz
+WHERE "id" = sqlc.arg('id')
+RETURNING *;
+
+-- name: FailDataExport :one
+UPDATE "data_exports"
+SET
+  "status" = 'failed',
+  "failure_reason" = sqlc.arg('failure_reason'),
+  "updated_at" = now()
+WHERE "id" = sqlc.arg('id')
+RETURNING *;
+
+-- name: DeleteDataExportsByUser :many
+DELETE FROM "data_exports"
+WHERE "user_id" = $1
+RETURNING *;
+
+-- name: GetExpiredDataExports :many
+SELECT * FROM "data_exports"
+WHERE "status" = 'ready' AND "expires_at" < sqlc.arg('now')::timestamptz
+ORDER BY "expires_at";
+
+-- name: ExpireDataExport :one
+UPDATE "data_exports"
+SET
+  "status" = 'expired',
+  "file_path" = '',
+  "updated_at" = now()
+WHERE "id" = $1
+RETURNING *;
@@ -0,0 +1,53 @@
+-- name: AcquireIdempotencyKey :one
+-- AcquireIdempotencyKey records that a request is being processed with the key.
+-- A key which has expired, or whose processing has stalled since before the given time
+-- for the same request, is taken over. No row is returned when the key is held.
+INSERT INTO "idempotency_keys" (
+  "scope",
+  "key",
+  "fingerprint",
+  "expires_at"
+) VALUES (
+  sqlc.arg('scope'), sqlc.arg('key'), sqlc.arg('fingerprint'), sqlc.arg('expires_at')
+)
+ON CONFLICT ("scope", "key") DO UPDATE
+SET
+  "created_at" = now(),
+  "updated_at" = now(),
+  "fingerprint" = EXCLUDED."fingerprint",
+  "status" = 'processing',
+  "response_status_code" = 0,
+  "response_content_type" = '',
+  "response_body" = '',
+  "expires_at" = EXCLUDED."expires_at"
+WHERE
+  "idempotency_keys"."expires_at" < sqlc.arg('now')::timestamptz OR
+  (
+    "idempotency_keys"."status" = 'processing' AND
+    "idempotency_keys"."updated_at" < sqlc.arg('stalled_before')::timestamptz AND
+    "idempotency_keys"."fingerprint" = EXCLUDED."fingerprint"
+  )
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM "idempotency_keys"
+WHERE "scope" = $1 AND "key" = $2
+LIMIT 1;
+
+-- name: CompleteIdempotencyKey :execrows
+UPDATE "idempotency_keys"
+SET
+  "status" = 'completed',
+  "updated_at" = now(),
+  "response_status_code" = sqlc.arg('response_status_code'),
+  "response_content_type" = sqlc.arg('response_content_type'),
+  "response_body" = sqlc.arg('response_body')
+WHERE "scope" = sqlc.arg('scope') AND "key" = sqlc.arg('key') AND "status" = 'processing';
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM "idempotency_keys"
+WHERE "scope" = $1 AND "key" = $2 AND "status" = 'processing';
+
+-- name: DeleteIdempotencyKeysBefore :execrows
+DELETE FROM "idempotency_keys"
+WHERE "expires_at" < sqlc.arg('before')::timestamptz;
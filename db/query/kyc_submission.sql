@@ -0,0 +1,48 @@
+-- name: CreateKYCSubmission :one
+INSERT INTO "kyc_submissions" (
+  "id",
+  "user_id",
+  "id_document_key",
+  "selfie_key"
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetKYCSubmission :one
+SELECT * FROM "kyc_submissions"
+WHERE "id" = $1 LIMIT 1;
+
+-- name: GetLatestKYCSubmissionByUser :one
+SELECT * FROM "kyc_submissions"
+WHERE "user_id" = $1
+ORDER BY "created_at" DESC
+LIMIT 1;
+
+-- name: GetKYCSubmissionsByUser :many
+SELECT * FROM "kyc_submissions"
+WHERE "user_id" = $1
+ORDER BY "created_at";
+
+-- name: GetPendingKYCSubmissions :many
+SELECT * FROM "kyc_submissions"
+WHERE "status" = 'pending'
+ORDER BY "created_at"
+LIMIT $1
+OFFSET $2;
+
+-- name: ReviewKYCSubmission :one
+UPDATE "kyc_submissions"
+SET
+  "status" = sqlc.arg('status'),
+  "reviewer_id" = sqlc.arg('reviewer_id')::uuid,
+  "review_reason" = sqlc.arg('review_reason'),
+  "reviewed_at" = sqlc.arg('reviewed_at')::timestamptz,
+  "updated_at" = sqlc.arg('reviewed_at')::timestamptz
+WHERE
+  "id" = sqlc.arg('id') AND "status" = 'pending'
+RETURNING *;
+
+-- name: DeleteKYCSubmissionsByUser :many
+DELETE FROM "kyc_submissions"
+WHERE "user_id" = $1
+RETURNING *;
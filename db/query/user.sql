@@ -11,12 +11,40 @@ INSERT INTO "users" (
 
 -- name: GetUserByEmail :one
 SELECT * FROM "users"
-WHERE "email" = $1 LIMIT 1;
+WHERE "email" = $1 AND "deleted_at" IS NULL
+LIMIT 1;
 
--- name: GetUsers :many
+-- name: ListUsers :many
+-- ListUsers lists the users oldest first, starting after the given (created_at, id) keyset if any
 SELECT * FROM "users"
-LIMIT $1
-OFFSET $2;
+WHERE
+  (sqlc.narg('email')::varchar IS NULL OR "email" ILIKE '%' || sqlc.narg('email') || '%') AND
+  (sqlc.narg('roles')::varchar[] IS NULL OR "role" = ANY(sqlc.narg('roles')::varchar[])) AND
+  (sqlc.narg('statuses')::varchar[] IS NULL OR "status" = ANY(sqlc.narg('statuses')::varchar[])) AND
+  (sqlc.narg('is_email_verified')::bool IS NULL OR "is_email_verified" = sqlc.narg('is_email_verified')) AND
+  (sqlc.narg('created_from')::timestamptz IS NULL OR "created_at" >= sqlc.narg('created_from')) AND
+  (sqlc.narg('created_to')::timestamptz IS NULL OR "created_at" <= sqlc.narg('created_to')) AND
+  (sqlc.arg('include_deleted')::bool OR "deleted_at" IS NULL) AND
+  (sqlc.narg('after_created_at')::timestamptz IS NULL OR
+    ("created_at", "id") > (sqlc.narg('after_created_at')::timestamptz, sqlc.narg('after_id')::uuid))
+ORDER BY "created_at", "id"
+LIMIT sqlc.arg('limit');
+
+-- name: ListUsersDesc :many
+-- ListUsersDesc lists the users newest first, starting after the given (created_at, id) keyset if any
+SELECT * FROM "users"
+WHERE
+  (sqlc.narg('email')::varchar IS NULL OR "email" ILIKE '%' || sqlc.narg('email') || '%') AND
+  (sqlc.narg('roles')::varchar[] IS NULL OR "role" = ANY(sqlc.narg('roles')::varchar[])) AND
+  (sqlc.narg('statuses')::varchar[] IS NULL OR "status" = ANY(sqlc.narg('statuses')::varchar[])) AND
+  (sqlc.narg('is_email_verified')::bool IS NULL OR "is_email_verified" = sqlc.narg('is_email_verified')) AND
+  (sqlc.narg('created_from')::timestamptz IS NULL OR "created_at" >= sqlc.narg('created_from')) AND
+  (sqlc.narg('created_to')::timestamptz IS NULL OR "created_at" <= sqlc.narg('created_to')) AND
+  (sqlc.arg('include_deleted')::bool OR "deleted_at" IS NULL) AND
+  (sqlc.narg('after_created_at')::timestamptz IS NULL OR
+    ("created_at", "id") < (sqlc.narg('after_created_at')::timestamptz, sqlc.narg('after_id')::uuid))
+ORDER BY "created_at" DESC, "id" DESC
+LIMIT sqlc.arg('limit');
 
 -- name: UpdateUserByEmail :one
 UPDATE "users"
@@ -27,5 +55,72 @@ SET
   "is_email_verified" = COALESCE(sqlc.narg('is_email_verified'), "is_email_verified"),
   "updated_at" = COALESCE(sqlc.arg('updated_at'), "updated_at")
 WHERE
-  "email" = sqlc.arg('email')
+  "email" = sqlc.arg('email') AND "deleted_at" IS NULL
+RETURNING *;
+
+-- name: GetUserByID :one
+SELECT * FROM "users"
+WHERE "id" = $1 LIMIT 1;
+
+-- name: UpdateUserEmail :one
+UPDATE "users"
+SET
+  "email" = sqlc.arg('new_email'),
+  "is_email_verified" = false,
+  "updated_at" = sqlc.arg('updated_at')
+WHERE
+  "email" = sqlc.arg('email') AND "deleted_at" IS NULL
+RETURNING *;
+
+-- name: UpdateUserRole :one
+UPDATE "users"
+SET
+  "role" = sqlc.arg('role'),
+  "updated_at" = sqlc.arg('updated_at')
+WHERE
+  "id" = sqlc.arg('id')
+RETURNING *;
+
+-- name: UpdateUserStatus :one
+UPDATE "users"
+SET
+  "status" = sqlc.arg('status'),
+  "updated_at" = sqlc.arg('updated_at')
+WHERE
+  "id" = sqlc.arg('id')
+RETURNING *;
+
+-- name: CloseUser :one
+UPDATE "users"
+SET
+  "status" = 'closed',
+  "deleted_at" = sqlc.arg('closed_at')::timestamptz,
+  "updated_at" = sqlc.arg('closed_at')::timestamptz
+WHERE
+  "id" = sqlc.arg('id') AND "deleted_at" IS NULL
+RETURNING *;
+
+-- name: AnonymizeUser :one
+UPDATE "users"
+SET
+  "email" = sqlc.arg('email'),
+  "hashed_password" = '',
+  "line_id" = '',
+  "nickname" = sqlc.arg('nickname'),
+  "is_email_verified" = false,
+  "status" = 'closed',
+  "deleted_at" = COALESCE("deleted_at", sqlc.arg('anonymized_at')::timestamptz),
+  "anonymized_at" = sqlc.arg('anonymized_at')::timestamptz,
+  "updated_at" = sqlc.arg('anonymized_at')::timestamptz
+WHERE
+  "id" = sqlc.arg('id') AND "anonymized_at" IS NULL
+RETURNING *;
+
+-- name: UpdateUserKYCStatus :one
+UPDATE "users"
+SET
+  "kyc_status" = sqlc.arg('kyc_status'),
+  "updated_at" = sqlc.arg('updated_at')
+WHERE
+  "id" = sqlc.arg('id')
 RETURNING *;
@@ -0,0 +1,255 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.24.0
+// source: api_key.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createAPIKey = `-- name: CreateAPIKey :one
+INSERT INTO "api_keys" (
+  "partner_id",
+  "name",
+  "key_id",
+  "hashed_secret",
+  "scopes",
+  "expires_at"
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING id, created_at, partner_id, name, key_id, hashed_secret, scopes, expires_at, revoked_at, last_used_at
+`
+
+type CreateAPIKeyParams struct {
+	PartnerID    uuid.UUID          `json:"partner_id"`
+	Name         string             `json:"name"`
+	KeyID        string             `json:"key_id"`
+	HashedSecret string             `json:"hashed_secret"`
+	Scopes       []string           `json:"scopes"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+}
+
+func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (APIKey, error) {
+	row := q.db.QueryRow(ctx, createAPIKey,
+		arg.PartnerID,
+		arg.Name,
+		arg.KeyID,
+		arg.HashedSecret,
+		arg.Scopes,
+		arg.ExpiresAt,
+	)
+	var i APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.PartnerID,
+		&i.Name,
+		&i.KeyID,
+		&i.HashedSecret,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const createAPIKeyNonce = `-- name: CreateAPIKeyNonce :execrows
+INSERT INTO "api_key_nonces" (
+  "api_key_id",
+  "nonce"
+) VALUES (
+  $1, $2
+) ON CONFLICT DO NOTHING
+`
+
+type CreateAPIKeyNonceParams struct {
+	APIKeyID uuid.UUID `json:"api_key_id"`
+	Nonce    string    `json:"nonce"`
+}
+
+func (q *Queries) CreateAPIKeyNonce(ctx context.Context, arg CreateAPIKeyNonceParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createAPIKeyNonce, arg.APIKeyID, arg.Nonce)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteAPIKeyNoncesBefore = `-- name: DeleteAPIKeyNoncesBefore :execrows
+DELETE FROM "api_key_nonces"
+WHERE "created_at" < $1::timestamptz
+`
+
+func (q *Queries) DeleteAPIKeyNoncesBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteAPIKeyNoncesBefore, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const expireAPIKey = `-- name: ExpireAPIKey :one
+UPDATE "api_keys"
+SET "expires_at" = LEAST("expires_at", $1::timestamptz)
+WHERE "id" = $2 AND "revoked_at" IS NULL
+RETURNING id, created_at, partner_id, name, key_id, hashed_secret, scopes, expires_at, revoked_at, last_used_at
+`
+
+type ExpireAPIKeyParams struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	ID        uuid.UUID `json:"id"`
+}
+
+func (q *Queries) ExpireAPIKey(ctx context.Context, arg ExpireAPIKeyParams) (APIKey, error) {
+	row := q.db.QueryRow(ctx, expireAPIKey, arg.ExpiresAt, arg.ID)
+	var i APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.PartnerID,
+		&i.Name,
+		&i.KeyID,
+		&i.HashedSecret,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const getAPIKey = `-- name: GetAPIKey :one
+SELECT id, created_at, partner_id, name, key_id, hashed_secret, scopes, expires_at, revoked_at, last_used_at FROM "api_keys"
+WHERE "id" = $1 LIMIT 1
+`
+
+func (q *Queries) GetAPIKey(ctx context.Context, id uuid.UUID) (APIKey, error) {
+	row := q.db.QueryRow(ctx, getAPIKey, id)
+	var i APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.PartnerID,
+		&i.Name,
+		&i.KeyID,
+		&i.HashedSecret,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const getAPIKeyByKeyID = `-- name: GetAPIKeyByKeyID :one
+SELECT id, created_at, partner_id, name, key_id, hashed_secret, scopes, expires_at, revoked_at, last_used_at FROM "api_keys"
+WHERE "key_id" = $1 LIMIT 1
+`
+
+func (q *Queries) GetAPIKeyByKeyID(ctx context.Context, keyID string) (APIKey, error) {
+	row := q.db.QueryRow(ctx, getAPIKeyByKeyID, keyID)
+	var i APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.PartnerID,
+		&i.Name,
+		&i.KeyID,
+		&i.HashedSecret,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const getAPIKeysByPartner = `-- name: GetAPIKeysByPartner :many
+SELECT id, created_at, partner_id, name, key_id, hashed_secret, scopes, expires_at, revoked_at, last_used_at FROM "api_keys"
+WHERE "partner_id" = $1
+ORDER BY "created_at"
+`
+
+func (q *Queries) GetAPIKeysByPartner(ctx context.Context, partnerID uuid.UUID) ([]APIKey, error) {
+	rows, err := q.db.Query(ctx, getAPIKeysByPartner, partnerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []APIKey{}
+	for rows.Next() {
+		var i APIKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.PartnerID,
+			&i.Name,
+			&i.KeyID,
+			&i.HashedSecret,
+			&i.Scopes,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeAPIKey = `-- name: RevokeAPIKey :one
+UPDATE "api_keys"
+SET "revoked_at" = $1::timestamptz
+WHERE "id" = $2 AND "revoked_at" IS NULL
+RETURNING id, created_at, partner_id, name, key_id, hashed_secret, scopes, expires_at, revoked_at, last_used_at
+`
+
+type RevokeAPIKeyParams struct {
+	RevokedAt time.Time `json:"revoked_at"`
+	ID        uuid.UUID `json:"id"`
+}
+
+func (q *Queries) RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyParams) (APIKey, error) {
+	row := q.db.QueryRow(ctx, revokeAPIKey, arg.RevokedAt, arg.ID)
+	var i APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.PartnerID,
+		&i.Name,
+		&i.KeyID,
+		&i.HashedSecret,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const touchAPIKey = `-- name: TouchAPIKey :exec
+UPDATE "api_keys"
+SET "last_used_at" = $1::timestamptz
+WHERE "id" = $2
+`
+
+type TouchAPIKeyParams struct {
+	LastUsedAt time.Time `json:"last_used_at"`
+	ID         uuid.UUID `json:"id"`
+}
+
+func (q *Queries) TouchAPIKey(ctx context.Context, arg TouchAPIKeyParams) error {
+	_, err := q.db.Exec(ctx, touchAPIKey, arg.LastUsedAt, arg.ID)
+	return err
+}
@@ -0,0 +1,150 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAPIKey(t *testing.T, partner User) APIKey {
+	wanted := CreateAPIKeyParams{
+		PartnerID:    partner.ID,
+		Name:         util.RandomString(8),
+		KeyID:        util.APIKeyIDPrefix + util.RandomString(24),
+		HashedSecret: util.HashAPIKeySecret(util.RandomString(32)),
+		Scopes:       []string{util.ScopeBorrowersRead, util.ScopeBorrowersWrite},
+		ExpiresAt:    pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+	}
+
+	got, err := testStore.CreateAPIKey(context.Background(), wanted)
+	require.NoError(t, err)
+	require.NotEmpty(t, got)
+
+	require.Equal(t, wanted.PartnerID, got.PartnerID)
+	require.Equal(t, wanted.Name, got.Name)
+	require.Equal(t, wanted.KeyID, got.KeyID)
+	require.Equal(t, wanted.HashedSecret, got.HashedSecret)
+	require.Equal(t, wanted.Scopes, got.Scopes)
+	require.WithinDuration(t, wanted.ExpiresAt.Time, got.ExpiresAt.Time, time.Second)
+	require.False(t, got.RevokedAt.Valid)
+	require.False(t, got.LastUsedAt.Valid)
+	require.NotZero(t, got.CreatedAt)
+
+	return got
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	createRandomAPIKey(t, createRandomUser(t))
+}
+
+func TestGetAPIKeyByKeyID(t *testing.T) {
+	apiKey := createRandomAPIKey(t, createRandomUser(t))
+
+	got, err := testStore.GetAPIKeyByKeyID(context.Background(), apiKey.KeyID)
+	require.NoError(t, err)
+	require.Equal(t, apiKey.ID, got.ID)
+
+	_, err = testStore.GetAPIKeyByKeyID(context.Background(), util.APIKeyIDPrefix+util.RandomString(24))
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestGetAPIKeysByPartner(t *testing.T) {
+	partner := createRandomUser(t)
+	for i := 0; i < 3; i++ {
+		createRandomAPIKey(t, partner)
+	}
+	createRandomAPIKey(t, createRandomUser(t))
+
+	apiKeys, err := testStore.GetAPIKeysByPartner(context.Background(), partner.ID)
+	require.NoError(t, err)
+	require.Len(t, apiKeys, 3)
+	for _, apiKey := range apiKeys {
+		require.Equal(t, partner.ID, apiKey.PartnerID)
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	apiKey := createRandomAPIKey(t, createRandomUser(t))
+
+	revokedAt := time.Now()
+	got, err := testStore.RevokeAPIKey(context.Background(), RevokeAPIKeyParams{
+		ID:        apiKey.ID,
+		RevokedAt: revokedAt,
+	})
+	require.NoError(t, err)
+	require.True(t, got.RevokedAt.Valid)
+	require.WithinDuration(t, revokedAt, got.RevokedAt.Time, time.Second)
+
+	// a revoked key can not be revoked again
+	_, err = testStore.RevokeAPIKey(context.Background(), RevokeAPIKeyParams{
+		ID:        apiKey.ID,
+		RevokedAt: time.Now(),
+	})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestExpireAPIKey(t *testing.T) {
+	apiKey := createRandomAPIKey(t, createRandomUser(t))
+
+	// an expiry later than the current one does not extend the key
+	got, err := testStore.ExpireAPIKey(context.Background(), ExpireAPIKeyParams{
+		ID:        apiKey.ID,
+		ExpiresAt: apiKey.ExpiresAt.Time.Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.WithinDuration(t, apiKey.ExpiresAt.Time, got.ExpiresAt.Time, time.Second)
+
+	expiresAt := time.Now().Add(time.Minute)
+	got, err = testStore.ExpireAPIKey(context.Background(), ExpireAPIKeyParams{
+		ID:        apiKey.ID,
+		ExpiresAt: expiresAt,
+	})
+	require.NoError(t, err)
+	require.WithinDuration(t, expiresAt, got.ExpiresAt.Time, time.Second)
+}
+
+func TestTouchAPIKey(t *testing.T) {
+	apiKey := createRandomAPIKey(t, createRandomUser(t))
+
+	lastUsedAt := time.Now()
+	err := testStore.TouchAPIKey(context.Background(), TouchAPIKeyParams{
+		ID:         apiKey.ID,
+		LastUsedAt: lastUsedAt,
+	})
+	require.NoError(t, err)
+
+	got, err := testStore.GetAPIKey(context.Background(), apiKey.ID)
+	require.NoError(t, err)
+	require.True(t, got.LastUsedAt.Valid)
+	require.WithinDuration(t, lastUsedAt, got.LastUsedAt.Time, time.Second)
+}
+
+func TestCreateAPIKeyNonce(t *testing.T) {
+	apiKey := createRandomAPIKey(t, createRandomUser(t))
+	arg := CreateAPIKeyNonceParams{
+		APIKeyID: apiKey.ID,
+		Nonce:    util.RandomString(16),
+	}
+
+	rows, err := testStore.CreateAPIKeyNonce(context.Background(), arg)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, rows)
+
+	// a replayed nonce is not recorded
+	rows, err = testStore.CreateAPIKeyNonce(context.Background(), arg)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	deleted, err := testStore.DeleteAPIKeyNoncesBefore(context.Background(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+
+	rows, err = testStore.CreateAPIKeyNonce(context.Background(), arg)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, rows)
+}
@@ -0,0 +1,124 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.24.0
+// source: audit_log.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createAuditLog = `-- name: CreateAuditLog :one
+INSERT INTO "audit_logs" (
+  "actor_id",
+  "action",
+  "target_user_id",
+  "details"
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING id, created_at, actor_id, action, target_user_id, details
+`
+
+type CreateAuditLogParams struct {
+	ActorID      uuid.UUID   `json:"actor_id"`
+	Action       string      `json:"action"`
+	TargetUserID pgtype.UUID `json:"target_user_id"`
+	Details      []byte      `json:"details"`
+}
+
+func (q *Queries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error) {
+	row := q.db.QueryRow(ctx, createAuditLog,
+		arg.ActorID,
+		arg.Action,
+		arg.TargetUserID,
+		arg.Details,
+	)
+	var i AuditLog
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.ActorID,
+		&i.Action,
+		&i.TargetUserID,
+		&i.Details,
+	)
+	return i, err
+}
+
+const getAuditLogsByTargetUser = `-- name: GetAuditLogsByTargetUser :many
+SELECT id, created_at, actor_id, action, target_user_id, details FROM "audit_logs"
+WHERE "target_user_id" = $1
+ORDER BY "id"
+LIMIT $2
+OFFSET $3
+`
+
+type GetAuditLogsByTargetUserParams struct {
+	TargetUserID pgtype.UUID `json:"target_user_id"`
+	Limit        int32       `json:"limit"`
+	Offset       int32       `json:"offset"`
+}
+
+func (q *Queries) GetAuditLogsByTargetUser(ctx context.Context, arg GetAuditLogsByTargetUserParams) ([]AuditLog, error) {
+	rows, err := q.db.Query(ctx, getAuditLogsByTargetUser, arg.TargetUserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditLog{}
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.ActorID,
+			&i.Action,
+			&i.TargetUserID,
+			&i.Details,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAuditLogsByUser = `-- name: GetAuditLogsByUser :many
+SELECT id, created_at, actor_id, action, target_user_id, details FROM "audit_logs"
+WHERE "actor_id" = $1 OR "target_user_id" = $1
+ORDER BY "id"
+`
+
+func (q *Queries) GetAuditLogsByUser(ctx context.Context, userID uuid.UUID) ([]AuditLog, error) {
+	rows, err := q.db.Query(ctx, getAuditLogsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditLog{}
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.ActorID,
+			&i.Action,
+			&i.TargetUserID,
+			&i.Details,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAuditLog(t *testing.T, actor, target User) AuditLog {
+	wanted := CreateAuditLogParams{
+		ActorID: actor.ID,
+		Action:  util.AuditActionViewUser,
+		TargetUserID: pgtype.UUID{
+			Bytes: target.ID,
+			Valid: true,
+		},
+		Details: []byte(`{"reason": "test"}`),
+	}
+
+	got, err := testStore.CreateAuditLog(context.Background(), wanted)
+	require.NoError(t, err)
+	require.NotEmpty(t, got)
+
+	require.Equal(t, wanted.ActorID, got.ActorID)
+	require.Equal(t, wanted.Action, got.Action)
+	require.Equal(t, wanted.TargetUserID, got.TargetUserID)
+	require.JSONEq(t, string(wanted.Details), string(got.Details))
+	require.NotZero(t, got.ID)
+	require.NotZero(t, got.CreatedAt)
+
+	return got
+}
+
+func TestCreateAuditLog(t *testing.T) {
+	createRandomAuditLog(t, createRandomUser(t), createRandomUser(t))
+}
+
+func TestGetAuditLogsByTargetUser(t *testing.T) {
+	actor := createRandomUser(t)
+	target := createRandomUser(t)
+
+	wanted := 3
+	for i := 0; i < wanted; i++ {
+		createRandomAuditLog(t, actor, target)
+	}
+
+	auditLogs, err := testStore.GetAuditLogsByTargetUser(context.Background(), GetAuditLogsByTargetUserParams{
+		TargetUserID: pgtype.UUID{
+			Bytes: target.ID,
+			Valid: true,
+		},
+		Limit:  int32(wanted),
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, auditLogs, wanted)
+
+	for _, auditLog := range auditLogs {
+		require.Equal(t, actor.ID, auditLog.ActorID)
+		require.Equal(t, target.ID, uuid.UUID(auditLog.TargetUserID.Bytes))
+	}
+}
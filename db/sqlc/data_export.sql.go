@@ -0,0 +1,304 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.24.0
+// source: data_export.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const claimDataExport = `-- name: ClaimDataExport :one
+UPDATE "data_exports"
+SET
+  "status" = 'processing',
+  "updated_at" = now()
+WHERE "id" = (
+  SELECT "id" FROM "data_exports"
+  WHERE
+    "status" = 'pending' OR
+    ("status" = 'processing' AND "updated_at" < $1::timestamptz)
+  ORDER BY "created_at"
+  LIMIT 1
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, user_id, status, file_path, failure_reason, completed_at, expires_at
+`
+
+// ClaimDataExport picks the oldest pending export, or one whose processing
+// has stalled since before the given time, and marks it as processing.
+func (q *Queries) ClaimDataExport(ctx context.Context, stalledBefore time.Time) (DataExport, error) {
+	row := q.db.QueryRow(ctx, claimDataExport, stalledBefore)
+	var i DataExport
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Status,
+		&i.FilePath,
+		&i.FailureReason,
+		&i.CompletedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const completeDataExport = `-- name: CompleteDataExport :one
+UPDATE "data_exports"
+SET
+  "status" = 'ready',
+  "file_path" = $1,
+  "completed_at" = $2::timestamptz,
+  "expires_at" = $3::timestamptz,
+  "updated_at" = $2::timestamptz
+WHERE "id" = $4
+RETURNING id, created_at, updated_at, user_id, status, file_path, failure_reason, completed_at, expires_at
+`
+
+type CompleteDataExportParams struct {
+	FilePath    string    `json:"file_path"`
+	CompletedAt time.Time `json:"completed_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	ID          uuid.UUID `json:"id"`
+}
+
+func (q *Queries) CompleteDataExport(ctx context.Context, arg CompleteDataExportParams) (DataExport, error) {
+	row := q.db.QueryRow(ctx, completeDataExport,
+		arg.FilePath,
+		arg.CompletedAt,
+		arg.ExpiresAt,
+		arg.ID,
+	)
+	var i DataExport
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Status,
+		&i.FilePath,
+		&i.FailureReason,
+		&i.CompletedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const createDataExport = `-- name: CreateDataExport :one
+INSERT INTO "data_exports" (
+  "user_id"
+) VALUES (
+  $1
+) RETURNING id, created_at, updated_at, user_id, status, file_path, failure_reason, completed_at, expires_at
+`
+
+func (q *Queries) CreateDataExport(ctx context.Context, userID uuid.UUID) (DataExport, error) {
+	row := q.db.QueryRow(ctx, createDataExport, userID)
+	var i DataExport
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Status,
+		&i.FilePath,
+		&i.FailureReason,
+		&i.CompletedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const deleteDataExportsByUser = `-- name: DeleteDataExportsByUser :many
+DELETE FROM "data_exports"
+WHERE "user_id" = $1
+RETURNING id, created_at, updated_at, user_id, status, file_path, failure_reason, completed_at, expires_at
+`
+
+func (q *Queries) DeleteDataExportsByUser(ctx context.Context, userID uuid.UUID) ([]DataExport, error) {
+	rows, err := q.db.Query(ctx, deleteDataExportsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []DataExport{}
+	for rows.Next() {
+		var i DataExport
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Status,
+			&i.FilePath,
+			&i.FailureReason,
+			&i.CompletedAt,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const expireDataExport = `-- name: ExpireDataExport :one
+UPDATE "data_exports"
+SET
+  "status" = 'expired',
+  "file_path" = '',
+  "updated_at" = now()
+WHERE "id" = $1
+RETURNING id, created_at, updated_at, user_id, status, file_path, failure_reason, completed_at, expires_at
+`
+
+func (q *Queries) ExpireDataExport(ctx context.Context, id uuid.UUID) (DataExport, error) {
+	row := q.db.QueryRow(ctx, expireDataExport, id)
+	var i DataExport
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Status,
+		&i.FilePath,
+		&i.FailureReason,
+		&i.CompletedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const failDataExport = `-- name: FailDataExport :one
+UPDATE "data_exports"
+SET
+  "status" = 'failed',
+  "failure_reason" = $1,
+  "updated_at" = now()
+WHERE "id" = $2
+RETURNING id, created_at, updated_at, user_id, status, file_path, failure_reason, completed_at, expires_at
+`
+
+type FailDataExportParams struct {
+	FailureReason string    `json:"failure_reason"`
+	ID            uuid.UUID `json:"id"`
+}
+
+func (q *Queries) FailDataExport(ctx context.Context, arg FailDataExportParams) (DataExport, error) {
+	row := q.db.QueryRow(ctx, failDataExport, arg.FailureReason, arg.ID)
+	var i DataExport
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Status,
+		&i.FilePath,
+		&i.FailureReason,
+		&i.CompletedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const getDataExport = `-- name: GetDataExport :one
+SELECT id, created_at, updated_at, user_id, status, file_path, failure_reason, completed_at, expires_at FROM "data_exports"
+WHERE "id" = $1 LIMIT 1
+`
+
+func (q *Queries) GetDataExport(ctx context.Context, id uuid.UUID) (DataExport, error) {
+	row := q.db.QueryRow(ctx, getDataExport, id)
+	var i DataExport
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Status,
+		&i.FilePath,
+		&i.FailureReason,
+		&i.CompletedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const getDataExportsByUser = `-- name: GetDataExportsByUser :many
+SELECT id, created_at, updated_at, user_id, status, file_path, failure_reason, completed_at, expires_at FROM "data_exports"
+WHERE "user_id" = $1
+ORDER BY "created_at"
+`
+
+func (q *Queries) GetDataExportsByUser(ctx context.Context, userID uuid.UUID) ([]DataExport, error) {
+	rows, err := q.db.Query(ctx, getDataExportsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []DataExport{}
+	for rows.Next() {
+		var i DataExport
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Status,
+			&i.FilePath,
+			&i.FailureReason,
+			&i.CompletedAt,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getExpiredDataExports = `-- name: GetExpiredDataExports :many
+SELECT id, created_at, updated_at, user_id, status, file_path, failure_reason, completed_at, expires_at FROM "data_exports"
+WHERE "status" = 'ready' AND "expires_at" < $1::timestamptz
+ORDER BY "expires_at"
+`
+
+func (q *Queries) GetExpiredDataExports(ctx context.Context, now time.Time) ([]DataExport, error) {
+	rows, err := q.db.Query(ctx, getExpiredDataExports, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []DataExport{}
+	for rows.Next() {
+		var i DataExport
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Status,
+			&i.FilePath,
+			&i.FailureReason,
+			&i.CompletedAt,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomDataExport(t *testing.T, user User) DataExport {
+	got, err := testStore.CreateDataExport(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, got)
+
+	require.Equal(t, user.ID, got.UserID)
+	require.Equal(t, util.DataExportPending, got.Status)
+	require.Empty(t, got.FilePath)
+	require.False(t, got.CompletedAt.Valid)
+	require.False(t, got.ExpiresAt.Valid)
+	require.NotZero(t, got.ID)
+	require.NotZero(t, got.CreatedAt)
+
+	return got
+}
+
+func TestCreateDataExport(t *testing.T) {
+	createRandomDataExport(t, createRandomUser(t))
+}
+
+func TestGetDataExport(t *testing.T) {
+	wanted := createRandomDataExport(t, createRandomUser(t))
+
+	got, err := testStore.GetDataExport(context.Background(), wanted.ID)
+	require.NoError(t, err)
+	require.Equal(t, wanted, got)
+}
+
+func TestClaimDataExport(t *testing.T) {
+	wanted := createRandomDataExport(t, createRandomUser(t))
+
+	// older exports of other tests may be claimed first, so drain until ours shows up
+	for {
+		got, err := testStore.ClaimDataExport(context.Background(), time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, util.DataExportProcessing, got.Status)
+
+		if got.ID == wanted.ID {
+			break
+		}
+	}
+
+	got, err := testStore.GetDataExport(context.Background(), wanted.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.DataExportProcessing, got.Status)
+}
+
+func TestCompleteDataExport(t *testing.T) {
+	dataExport := createRandomDataExport(t, createRandomUser(t))
+
+	completedAt := time.Now()
+	arg := CompleteDataExportParams{
+		ID:          dataExport.ID,
+		FilePath:    util.RandomString(10),
+		CompletedAt: completedAt,
+		ExpiresAt:   completedAt.Add(time.Hour),
+	}
+
+	got, err := testStore.CompleteDataExport(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, util.DataExportReady, got.Status)
+	require.Equal(t, arg.FilePath, got.FilePath)
+	require.WithinDuration(t, arg.CompletedAt, got.CompletedAt.Time, time.Second)
+	require.WithinDuration(t, arg.ExpiresAt, got.ExpiresAt.Time, time.Second)
+}
+
+func TestFailDataExport(t *testing.T) {
+	dataExport := createRandomDataExport(t, createRandomUser(t))
+
+	arg := FailDataExportParams{
+		ID:            dataExport.ID,
+		FailureReason: util.RandomString(10),
+	}
+
+	got, err := testStore.FailDataExport(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, util.DataExportFailed, got.Status)
+	require.Equal(t, arg.FailureReason, got.FailureReason)
+}
+
+func TestExpireDataExport(t *testing.T) {
+	dataExport := createRandomDataExport(t, createRandomUser(t))
+
+	completedAt := time.Now().Add(-2 * time.Hour)
+	dataExport, err := testStore.CompleteDataExport(context.Background(), CompleteDataExportParams{
+		ID:          dataExport.ID,
+		FilePath:    util.RandomString(10),
+		CompletedAt: completedAt,
+		ExpiresAt:   completedAt.Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	dataExports, err := testStore.GetExpiredDataExports(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Contains(t, dataExports, dataExport)
+
+	got, err := testStore.ExpireDataExport(context.Background(), dataExport.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.DataExportExpired, got.Status)
+	require.Empty(t, got.FilePath)
+
+	dataExports, err = testStore.GetExpiredDataExports(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.NotContains(t, dataExports, got)
+}
+
+func TestDeleteDataExportsByUser(t *testing.T) {
+	user := createRandomUser(t)
+	for i := 0; i < 3; i++ {
+		createRandomDataExport(t, user)
+	}
+
+	dataExports, err := testStore.GetDataExportsByUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Len(t, dataExports, 3)
+
+	deleted, err := testStore.DeleteDataExportsByUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, dataExports, deleted)
+
+	dataExports, err = testStore.GetDataExportsByUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Empty(t, dataExports)
+}
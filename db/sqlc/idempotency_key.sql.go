@@ -0,0 +1,167 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.24.0
+// source: idempotency_key.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const acquireIdempotencyKey = `-- name: AcquireIdempotencyKey :one
+INSERT INTO "idempotency_keys" (
+  "scope",
+  "key",
+  "fingerprint",
+  "expires_at"
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT ("scope", "key") DO UPDATE
+SET
+  "created_at" = now(),
+  "updated_at" = now(),
+  "fingerprint" = EXCLUDED."fingerprint",
+  "status" = 'processing',
+  "response_status_code" = 0,
+  "response_content_type" = '',
+  "response_body" = '',
+  "expires_at" = EXCLUDED."expires_at"
+WHERE
+  "idempotency_keys"."expires_at" < $5::timestamptz OR
+  (
+    "idempotency_keys"."status" = 'processing' AND
+    "idempotency_keys"."updated_at" < $6::timestamptz AND
+    "idempotency_keys"."fingerprint" = EXCLUDED."fingerprint"
+  )
+RETURNING scope, key, created_at, updated_at, fingerprint, status, response_status_code, response_content_type, response_body, expires_at
+`
+
+type AcquireIdempotencyKeyParams struct {
+	Scope         string    `json:"scope"`
+	Key           string    `json:"key"`
+	Fingerprint   string    `json:"fingerprint"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	Now           time.Time `json:"now"`
+	StalledBefore time.Time `json:"stalled_before"`
+}
+
+// AcquireIdempotencyKey records that a request is being processed with the key.
+// A key which has expired, or whose processing has stalled since before the given time
+// for the same request, is taken over. No row is returned when the key is held.
+func (q *Queries) AcquireIdempotencyKey(ctx context.Context, arg AcquireIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRow(ctx, acquireIdempotencyKey,
+		arg.Scope,
+		arg.Key,
+		arg.Fingerprint,
+		arg.ExpiresAt,
+		arg.Now,
+		arg.StalledBefore,
+	)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Scope,
+		&i.Key,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Fingerprint,
+		&i.Status,
+		&i.ResponseStatusCode,
+		&i.ResponseContentType,
+		&i.ResponseBody,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const completeIdempotencyKey = `-- name: CompleteIdempotencyKey :execrows
+UPDATE "idempotency_keys"
+SET
+  "status" = 'completed',
+  "updated_at" = now(),
+  "response_status_code" = $1,
+  "response_content_type" = $2,
+  "response_body" = $3
+WHERE "scope" = $4 AND "key" = $5 AND "status" = 'processing'
+`
+
+type CompleteIdempotencyKeyParams struct {
+	ResponseStatusCode  int32  `json:"response_status_code"`
+	ResponseContentType string `json:"response_content_type"`
+	ResponseBody        []byte `json:"response_body"`
+	Scope               string `json:"scope"`
+	Key                 string `json:"key"`
+}
+
+func (q *Queries) CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) (int64, error) {
+	result, err := q.db.Exec(ctx, completeIdempotencyKey,
+		arg.ResponseStatusCode,
+		arg.ResponseContentType,
+		arg.ResponseBody,
+		arg.Scope,
+		arg.Key,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteIdempotencyKey = `-- name: DeleteIdempotencyKey :exec
+DELETE FROM "idempotency_keys"
+WHERE "scope" = $1 AND "key" = $2 AND "status" = 'processing'
+`
+
+type DeleteIdempotencyKeyParams struct {
+	Scope string `json:"scope"`
+	Key   string `json:"key"`
+}
+
+func (q *Queries) DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) error {
+	_, err := q.db.Exec(ctx, deleteIdempotencyKey, arg.Scope, arg.Key)
+	return err
+}
+
+const deleteIdempotencyKeysBefore = `-- name: DeleteIdempotencyKeysBefore :execrows
+DELETE FROM "idempotency_keys"
+WHERE "expires_at" < $1::timestamptz
+`
+
+func (q *Queries) DeleteIdempotencyKeysBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteIdempotencyKeysBefore, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT scope, key, created_at, updated_at, fingerprint, status, response_status_code, response_content_type, response_body, expires_at FROM "idempotency_keys"
+WHERE "scope" = $1 AND "key" = $2
+LIMIT 1
+`
+
+type GetIdempotencyKeyParams struct {
+	Scope string `json:"scope"`
+	Key   string `json:"key"`
+}
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRow(ctx, getIdempotencyKey, arg.Scope, arg.Key)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Scope,
+		&i.Key,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Fingerprint,
+		&i.Status,
+		&i.ResponseStatusCode,
+		&i.ResponseContentType,
+		&i.ResponseBody,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomIdempotencyKey(t *testing.T) IdempotencyKey {
+	now := time.Now()
+	arg := AcquireIdempotencyKeyParams{
+		Scope:         "user:" + util.RandomString(12),
+		Key:           util.RandomString(24),
+		Fingerprint:   util.RandomString(64),
+		ExpiresAt:     now.Add(time.Hour),
+		Now:           now,
+		StalledBefore: now.Add(-time.Minute),
+	}
+
+	idempotencyKey, err := testStore.AcquireIdempotencyKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Scope, idempotencyKey.Scope)
+	require.Equal(t, arg.Key, idempotencyKey.Key)
+	require.Equal(t, arg.Fingerprint, idempotencyKey.Fingerprint)
+	require.Equal(t, util.IdempotencyKeyProcessing, idempotencyKey.Status)
+	require.Zero(t, idempotencyKey.ResponseStatusCode)
+	require.Empty(t, idempotencyKey.ResponseBody)
+	require.WithinDuration(t, arg.ExpiresAt, idempotencyKey.ExpiresAt, time.Second)
+
+	return idempotencyKey
+}
+
+func TestAcquireIdempotencyKey(t *testing.T) {
+	idempotencyKey := createRandomIdempotencyKey(t)
+	now := time.Now()
+
+	arg := AcquireIdempotencyKeyParams{
+		Scope:         idempotencyKey.Scope,
+		Key:           idempotencyKey.Key,
+		Fingerprint:   idempotencyKey.Fingerprint,
+		ExpiresAt:     now.Add(time.Hour),
+		Now:           now,
+		StalledBefore: now.Add(-time.Minute),
+	}
+
+	// the key is held while its request is being processed
+	_, err := testStore.AcquireIdempotencyKey(context.Background(), arg)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	// a stalled request is only taken over by the same request
+	arg.StalledBefore = now.Add(time.Minute)
+	arg.Fingerprint = util.RandomString(64)
+	_, err = testStore.AcquireIdempotencyKey(context.Background(), arg)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	arg.Fingerprint = idempotencyKey.Fingerprint
+	_, err = testStore.AcquireIdempotencyKey(context.Background(), arg)
+	require.NoError(t, err)
+
+	// an expired key is taken over by any request
+	arg.Now = now.Add(2 * time.Hour)
+	arg.StalledBefore = now.Add(-time.Minute)
+	arg.Fingerprint = util.RandomString(64)
+	taken, err := testStore.AcquireIdempotencyKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Fingerprint, taken.Fingerprint)
+}
+
+func TestCompleteIdempotencyKey(t *testing.T) {
+	idempotencyKey := createRandomIdempotencyKey(t)
+
+	arg := CompleteIdempotencyKeyParams{
+		Scope:               idempotencyKey.Scope,
+		Key:                 idempotencyKey.Key,
+		ResponseStatusCode:  201,
+		ResponseContentType: "application/json",
+		ResponseBody:        []byte(`{"email":"john.doe@example.com"}`),
+	}
+
+	rows, err := testStore.CompleteIdempotencyKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, rows)
+
+	completed, err := testStore.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Scope: idempotencyKey.Scope,
+		Key:   idempotencyKey.Key,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.IdempotencyKeyCompleted, completed.Status)
+	require.Equal(t, arg.ResponseStatusCode, completed.ResponseStatusCode)
+	require.Equal(t, arg.ResponseContentType, completed.ResponseContentType)
+	require.Equal(t, arg.ResponseBody, completed.ResponseBody)
+
+	// a completed request is never completed again
+	rows, err = testStore.CompleteIdempotencyKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+func TestDeleteIdempotencyKey(t *testing.T) {
+	idempotencyKey := createRandomIdempotencyKey(t)
+
+	err := testStore.DeleteIdempotencyKey(context.Background(), DeleteIdempotencyKeyParams{
+		Scope: idempotencyKey.Scope,
+		Key:   idempotencyKey.Key,
+	})
+	require.NoError(t, err)
+
+	_, err = testStore.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Scope: idempotencyKey.Scope,
+		Key:   idempotencyKey.Key,
+	})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestDeleteIdempotencyKeysBefore(t *testing.T) {
+	idempotencyKey := createRandomIdempotencyKey(t)
+
+	deleted, err := testStore.DeleteIdempotencyKeysBefore(context.Background(), idempotencyKey.ExpiresAt.Add(time.Second))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+
+	_, err = testStore.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Scope: idempotencyKey.Scope,
+		Key:   idempotencyKey.Key,
+	})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
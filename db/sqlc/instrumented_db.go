@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/tracing"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/DamianZhang/957-lending-platform/db/sqlc"
+
+// unnamedQuery labels the queries which do not start with a sqlc name comment
+const unnamedQuery = "unnamed"
+
+// QueryObserver is notified of every query executed by the store
+type QueryObserver interface {
+	ObserveQuery(name string, duration time.Duration, err error)
+}
+
+// instrumentedDBTX records a span for every query, named after the sqlc query,
+// and reports its duration to an observer when there is one
+type instrumentedDBTX struct {
+	db       DBTX
+	observer QueryObserver
+}
+
+func instrument(db DBTX, observer QueryObserver) DBTX {
+	return &instrumentedDBTX{db: db, observer: observer}
+}
+
+func (i *instrumentedDBTX) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	ctx, q := i.start(ctx, query)
+	tag, err := i.db.Exec(ctx, query, args...)
+	q.end(err)
+	return tag, err
+}
+
+// Query ends the query once its rows are closed, as they are read from the connection until then
+func (i *instrumentedDBTX) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	ctx, q := i.start(ctx, query)
+	rows, err := i.db.Query(ctx, query, args...)
+	if err != nil {
+		q.end(err)
+		return nil, err
+	}
+	return &instrumentedRows{Rows: rows, query: q}, nil
+}
+
+// QueryRow ends the query once its row is scanned, as errors are deferred until then
+func (i *instrumentedDBTX) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	ctx, q := i.start(ctx, query)
+	row := i.db.QueryRow(ctx, query, args...)
+	return &instrumentedRow{row: row, query: q}
+}
+
+// start starts the span of a query, its statement is recorded without the arguments
+func (i *instrumentedDBTX) start(ctx context.Context, query string) (context.Context, *instrumentedQuery) {
+	name := queryName(query)
+	ctx, span := tracing.Tracer(tracerName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperation(name),
+			semconv.DBStatement(query),
+		),
+	)
+
+	return ctx, &instrumentedQuery{
+		name:     name,
+		start:    time.Now(),
+		span:     span,
+		observer: i.observer,
+	}
+}
+
+// instrumentedQuery is a query in progress
+type instrumentedQuery struct {
+	name     string
+	start    time.Time
+	span     trace.Span
+	observer QueryObserver
+}
+
+func (q *instrumentedQuery) end(err error) {
+	// no rows is an answer of the database, not a failure of the query
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = nil
+	}
+
+	if err != nil {
+		q.span.RecordError(err)
+		q.span.SetStatus(codes.Error, err.Error())
+	}
+	q.span.End()
+
+	if q.observer != nil {
+		q.observer.ObserveQuery(q.name, time.Since(q.start), err)
+	}
+}
+
+type instrumentedRows struct {
+	pgx.Rows
+	query  *instrumentedQuery
+	closed bool
+}
+
+func (r *instrumentedRows) Close() {
+	r.Rows.Close()
+	if r.closed {
+		return
+	}
+	r.closed = true
+	r.query.end(r.Rows.Err())
+}
+
+type instrumentedRow struct {
+	row   pgx.Row
+	query *instrumentedQuery
+}
+
+func (r *instrumentedRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	r.query.end(err)
+	return err
+}
+
+// queryName extracts the name of a query generated by sqlc, which starts with a comment like
+// "-- name: GetUser :one"
+func queryName(query string) string {
+	const prefix = "-- name: "
+	if !strings.HasPrefix(query, prefix) {
+		return unnamedQuery
+	}
+
+	fields := strings.Fields(query[len(prefix):])
+	if len(fields) == 0 {
+		return unnamedQuery
+	}
+	return fields[0]
+}
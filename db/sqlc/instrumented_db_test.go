@@ -0,0 +1,178 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/tracing"
+	"github.com/DamianZhang/957-lending-platform/tracing/tracingtest"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type observedQuery struct {
+	name string
+	err  error
+}
+
+type recordingObserver struct {
+	queries []observedQuery
+}
+
+func (o *recordingObserver) ObserveQuery(name string, duration time.Duration, err error) {
+	o.queries = append(o.queries, observedQuery{name: name, err: err})
+}
+
+type stubRow struct {
+	err error
+}
+
+func (r stubRow) Scan(dest ...any) error {
+	return r.err
+}
+
+// stubDBTX answers every query with the same error, without a database
+type stubDBTX struct {
+	err error
+}
+
+func (db stubDBTX) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, db.err
+}
+
+func (db stubDBTX) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return nil, db.err
+}
+
+func (db stubDBTX) QueryRow(context.Context, string, ...interface{}) pgx.Row {
+	return stubRow{err: db.err}
+}
+
+func TestQueryName(t *testing.T) {
+	require.Equal(t, "GetUserByID", queryName(getUserByID))
+	require.Equal(t, "GetSchemaMigration", queryName(getSchemaMigration))
+	require.Equal(t, unnamedQuery, queryName(`SELECT 1`))
+	require.Equal(t, unnamedQuery, queryName(`-- name: `))
+}
+
+func TestInstrumentedDBTX(t *testing.T) {
+	queryErr := errors.New("connection reset")
+
+	testCases := []struct {
+		name          string
+		err           error
+		query         func(db DBTX) error
+		checkObserved func(t *testing.T, queries []observedQuery, err error)
+	}{
+		{
+			name: "Exec",
+			query: func(db DBTX) error {
+				_, err := db.Exec(context.Background(), touchAPIKey)
+				return err
+			},
+			checkObserved: func(t *testing.T, queries []observedQuery, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []observedQuery{{name: "TouchAPIKey"}}, queries)
+			},
+		},
+		{
+			name: "ExecError",
+			err:  queryErr,
+			query: func(db DBTX) error {
+				_, err := db.Exec(context.Background(), touchAPIKey)
+				return err
+			},
+			checkObserved: func(t *testing.T, queries []observedQuery, err error) {
+				require.ErrorIs(t, err, queryErr)
+				require.Equal(t, []observedQuery{{name: "TouchAPIKey", err: queryErr}}, queries)
+			},
+		},
+		{
+			name: "QueryError",
+			err:  queryErr,
+			query: func(db DBTX) error {
+				_, err := db.Query(context.Background(), listUsers)
+				return err
+			},
+			checkObserved: func(t *testing.T, queries []observedQuery, err error) {
+				require.ErrorIs(t, err, queryErr)
+				require.Equal(t, []observedQuery{{name: "ListUsers", err: queryErr}}, queries)
+			},
+		},
+		{
+			name: "QueryRowNotObservedUntilScanned",
+			query: func(db DBTX) error {
+				db.QueryRow(context.Background(), getUserByID)
+				return nil
+			},
+			checkObserved: func(t *testing.T, queries []observedQuery, err error) {
+				require.NoError(t, err)
+				require.Empty(t, queries)
+			},
+		},
+		{
+			name: "QueryRowNoRows",
+			err:  pgx.ErrNoRows,
+			query: func(db DBTX) error {
+				var id int
+				return db.QueryRow(context.Background(), getUserByID).Scan(&id)
+			},
+			checkObserved: func(t *testing.T, queries []observedQuery, err error) {
+				require.ErrorIs(t, err, pgx.ErrNoRows)
+				require.Equal(t, []observedQuery{{name: "GetUserByID"}}, queries)
+			},
+		},
+		{
+			name: "QueryRowError",
+			err:  queryErr,
+			query: func(db DBTX) error {
+				var id int
+				return db.QueryRow(context.Background(), getUserByID).Scan(&id)
+			},
+			checkObserved: func(t *testing.T, queries []observedQuery, err error) {
+				require.ErrorIs(t, err, queryErr)
+				require.Equal(t, []observedQuery{{name: "GetUserByID", err: queryErr}}, queries)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			observer := &recordingObserver{}
+			db := instrument(stubDBTX{err: tc.err}, observer)
+
+			err := tc.query(db)
+			tc.checkObserved(t, observer.queries, err)
+		})
+	}
+}
+
+func TestInstrumentedDBTXSpans(t *testing.T) {
+	exporter := tracingtest.NewExporter(t)
+	queryErr := errors.New("connection reset")
+
+	// queries are traced even when nobody observes them
+	db := instrument(stubDBTX{err: queryErr}, nil)
+
+	ctx, parent := tracing.Tracer("test").Start(context.Background(), "parent")
+	var id int
+	err := db.QueryRow(ctx, getUserByID).Scan(&id)
+	require.ErrorIs(t, err, queryErr)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	span := tracingtest.SpanNamed(t, spans, "GetUserByID")
+	tracingtest.RequireChildOf(t, span, tracingtest.SpanNamed(t, spans, "parent"))
+	require.Equal(t, trace.SpanKindClient, span.SpanKind)
+	require.Equal(t, codes.Error, span.Status.Code)
+	require.Contains(t, span.Attributes, semconv.DBSystemPostgreSQL)
+	require.Contains(t, span.Attributes, semconv.DBStatement(getUserByID))
+}
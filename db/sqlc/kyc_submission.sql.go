@@ -0,0 +1,265 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.24.0
+// source: kyc_submission.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createKYCSubmission = `-- name: CreateKYCSubmission :one
+INSERT INTO "kyc_submissions" (
+  "id",
+  "user_id",
+  "id_document_key",
+  "selfie_key"
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING id, created_at, updated_at, user_id, status, id_document_key, selfie_key, reviewer_id, review_reason, reviewed_at
+`
+
+type CreateKYCSubmissionParams struct {
+	ID            uuid.UUID `json:"id"`
+	UserID        uuid.UUID `json:"user_id"`
+	IDDocumentKey string    `json:"id_document_key"`
+	SelfieKey     string    `json:"selfie_key"`
+}
+
+func (q *Queries) CreateKYCSubmission(ctx context.Context, arg CreateKYCSubmissionParams) (KYCSubmission, error) {
+	row := q.db.QueryRow(ctx, createKYCSubmission,
+		arg.ID,
+		arg.UserID,
+		arg.IDDocumentKey,
+		arg.SelfieKey,
+	)
+	var i KYCSubmission
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Status,
+		&i.IDDocumentKey,
+		&i.SelfieKey,
+		&i.ReviewerID,
+		&i.ReviewReason,
+		&i.ReviewedAt,
+	)
+	return i, err
+}
+
+const deleteKYCSubmissionsByUser = `-- name: DeleteKYCSubmissionsByUser :many
+DELETE FROM "kyc_submissions"
+WHERE "user_id" = $1
+RETURNING id, created_at, updated_at, user_id, status, id_document_key, selfie_key, reviewer_id, review_reason, reviewed_at
+`
+
+func (q *Queries) DeleteKYCSubmissionsByUser(ctx context.Context, userID uuid.UUID) ([]KYCSubmission, error) {
+	rows, err := q.db.Query(ctx, deleteKYCSubmissionsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []KYCSubmission{}
+	for rows.Next() {
+		var i KYCSubmission
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Status,
+			&i.IDDocumentKey,
+			&i.SelfieKey,
+			&i.ReviewerID,
+			&i.ReviewReason,
+			&i.ReviewedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getKYCSubmission = `-- name: GetKYCSubmission :one
+SELECT id, created_at, updated_at, user_id, status, id_document_key, selfie_key, reviewer_id, review_reason, reviewed_at FROM "kyc_submissions"
+WHERE "id" = $1 LIMIT 1
+`
+
+func (q *Queries) GetKYCSubmission(ctx context.Context, id uuid.UUID) (KYCSubmission, error) {
+	row := q.db.QueryRow(ctx, getKYCSubmission, id)
+	var i KYCSubmission
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Status,
+		&i.IDDocumentKey,
+		&i.SelfieKey,
+		&i.ReviewerID,
+		&i.ReviewReason,
+		&i.ReviewedAt,
+	)
+	return i, err
+}
+
+const getKYCSubmissionsByUser = `-- name: GetKYCSubmissionsByUser :many
+SELECT id, created_at, updated_at, user_id, status, id_document_key, selfie_key, reviewer_id, review_reason, reviewed_at FROM "kyc_submissions"
+WHERE "user_id" = $1
+ORDER BY "created_at"
+`
+
+func (q *Queries) GetKYCSubmissionsByUser(ctx context.Context, userID uuid.UUID) ([]KYCSubmission, error) {
+	rows, err := q.db.Query(ctx, getKYCSubmissionsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []KYCSubmission{}
+	for rows.Next() {
+		var i KYCSubmission
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Status,
+			&i.IDDocumentKey,
+			&i.SelfieKey,
+			&i.ReviewerID,
+			&i.ReviewReason,
+			&i.ReviewedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLatestKYCSubmissionByUser = `-- name: GetLatestKYCSubmissionByUser :one
+SELECT id, created_at, updated_at, user_id, status, id_document_key, selfie_key, reviewer_id, review_reason, reviewed_at FROM "kyc_submissions"
+WHERE "user_id" = $1
+ORDER BY "created_at" DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestKYCSubmissionByUser(ctx context.Context, userID uuid.UUID) (KYCSubmission, error) {
+	row := q.db.QueryRow(ctx, getLatestKYCSubmissionByUser, userID)
+	var i KYCSubmission
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Status,
+		&i.IDDocumentKey,
+		&i.SelfieKey,
+		&i.ReviewerID,
+		&i.ReviewReason,
+		&i.ReviewedAt,
+	)
+	return i, err
+}
+
+const getPendingKYCSubmissions = `-- name: GetPendingKYCSubmissions :many
+SELECT id, created_at, updated_at, user_id, status, id_document_key, selfie_key, reviewer_id, review_reason, reviewed_at FROM "kyc_submissions"
+WHERE "status" = 'pending'
+ORDER BY "created_at"
+LIMIT $1
+OFFSET $2
+`
+
+type GetPendingKYCSubmissionsParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) GetPendingKYCSubmissions(ctx context.Context, arg GetPendingKYCSubmissionsParams) ([]KYCSubmission, error) {
+	rows, err := q.db.Query(ctx, getPendingKYCSubmissions, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []KYCSubmission{}
+	for rows.Next() {
+		var i KYCSubmission
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Status,
+			&i.IDDocumentKey,
+			&i.SelfieKey,
+			&i.ReviewerID,
+			&i.ReviewReason,
+			&i.ReviewedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const reviewKYCSubmission = `-- name: ReviewKYCSubmission :one
+UPDATE "kyc_submissions"
+SET
+  "status" = $1,
+  "reviewer_id" = $2::uuid,
+  "review_reason" = $3,
+  "reviewed_at" = $4::timestamptz,
+  "updated_at" = $4::timestamptz
+WHERE
+  "id" = $5 AND "status" = 'pending'
+RETURNING id, created_at, updated_at, user_id, status, id_document_key, selfie_key, reviewer_id, review_reason, reviewed_at
+`
+
+type ReviewKYCSubmissionParams struct {
+	Status       string    `json:"status"`
+	ReviewerID   uuid.UUID `json:"reviewer_id"`
+	ReviewReason string    `json:"review_reason"`
+	ReviewedAt   time.Time `json:"reviewed_at"`
+	ID           uuid.UUID `json:"id"`
+}
+
+func (q *Queries) ReviewKYCSubmission(ctx context.Context, arg ReviewKYCSubmissionParams) (KYCSubmission, error) {
+	row := q.db.QueryRow(ctx, reviewKYCSubmission,
+		arg.Status,
+		arg.ReviewerID,
+		arg.ReviewReason,
+		arg.ReviewedAt,
+		arg.ID,
+	)
+	var i KYCSubmission
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Status,
+		&i.IDDocumentKey,
+		&i.SelfieKey,
+		&i.ReviewerID,
+		&i.ReviewReason,
+		&i.ReviewedAt,
+	)
+	return i, err
+}
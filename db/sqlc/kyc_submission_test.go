@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomKYCSubmission(t *testing.T, user User) KYCSubmission {
+	id := uuid.New()
+	wanted := CreateKYCSubmissionParams{
+		ID:            id,
+		UserID:        user.ID,
+		IDDocumentKey: path.Join("kyc", user.ID.String(), id.String(), "id_document.jpg"),
+		SelfieKey:     path.Join("kyc", user.ID.String(), id.String(), "selfie.png"),
+	}
+
+	got, err := testStore.CreateKYCSubmission(context.Background(), wanted)
+	require.NoError(t, err)
+	require.NotEmpty(t, got)
+
+	require.Equal(t, wanted.ID, got.ID)
+	require.Equal(t, wanted.UserID, got.UserID)
+	require.Equal(t, wanted.IDDocumentKey, got.IDDocumentKey)
+	require.Equal(t, wanted.SelfieKey, got.SelfieKey)
+	require.Equal(t, util.KYCSubmissionPending, got.Status)
+	require.False(t, got.ReviewerID.Valid)
+	require.False(t, got.ReviewedAt.Valid)
+	require.NotZero(t, got.CreatedAt)
+
+	return got
+}
+
+func TestCreateKYCSubmission(t *testing.T) {
+	user := createRandomUser(t)
+	require.Equal(t, util.KYCUnverified, user.KYCStatus)
+
+	createRandomKYCSubmission(t, user)
+}
+
+func TestCreateKYCSubmissionOnlyOnePending(t *testing.T) {
+	user := createRandomUser(t)
+	createRandomKYCSubmission(t, user)
+
+	_, err := testStore.CreateKYCSubmission(context.Background(), CreateKYCSubmissionParams{
+		ID:            uuid.New(),
+		UserID:        user.ID,
+		IDDocumentKey: util.RandomString(10),
+		SelfieKey:     util.RandomString(10),
+	})
+
+	var pgErr *pgconn.PgError
+	require.ErrorAs(t, err, &pgErr)
+	require.Equal(t, "23505", pgErr.Code)
+}
+
+func TestGetLatestKYCSubmissionByUser(t *testing.T) {
+	user := createRandomUser(t)
+
+	_, err := testStore.GetLatestKYCSubmissionByUser(context.Background(), user.ID)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	wanted := createRandomKYCSubmission(t, user)
+
+	got, err := testStore.GetLatestKYCSubmissionByUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Equal(t, wanted, got)
+}
+
+func TestGetKYCSubmissionsByUser(t *testing.T) {
+	user := createRandomUser(t)
+	wanted := createRandomKYCSubmission(t, user)
+	createRandomKYCSubmission(t, createRandomUser(t))
+
+	got, err := testStore.GetKYCSubmissionsByUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Equal(t, []KYCSubmission{wanted}, got)
+}
+
+func TestReviewKYCSubmission(t *testing.T) {
+	admin := createRandomUser(t)
+	submission := createRandomKYCSubmission(t, createRandomUser(t))
+
+	arg := ReviewKYCSubmissionParams{
+		ID:           submission.ID,
+		Status:       util.KYCSubmissionRejected,
+		ReviewerID:   admin.ID,
+		ReviewReason: util.RandomString(20),
+		ReviewedAt:   time.Now(),
+	}
+
+	got, err := testStore.ReviewKYCSubmission(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, util.KYCSubmissionRejected, got.Status)
+	require.Equal(t, admin.ID, uuid.UUID(got.ReviewerID.Bytes))
+	require.Equal(t, arg.ReviewReason, got.ReviewReason)
+	require.WithinDuration(t, arg.ReviewedAt, got.ReviewedAt.Time, time.Second)
+
+	// a submission can only be reviewed once
+	_, err = testStore.ReviewKYCSubmission(context.Background(), arg)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	submissions, err := testStore.GetPendingKYCSubmissions(context.Background(), GetPendingKYCSubmissionsParams{
+		Limit:  100,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	for _, pending := range submissions {
+		require.NotEqual(t, submission.ID, pending.ID)
+	}
+}
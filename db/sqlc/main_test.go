@@ -23,7 +23,7 @@ func TestMain(m *testing.M) {
 		log.Fatal("can not connect to db:", err)
 	}
 
-	testStore = NewStore(connPool)
+	testStore = NewStore(connPool, nil)
 
 	os.Exit(m.Run())
 }
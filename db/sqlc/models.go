@@ -8,16 +8,94 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type APIKey struct {
+	ID           uuid.UUID          `json:"id"`
+	CreatedAt    time.Time          `json:"created_at"`
+	PartnerID    uuid.UUID          `json:"partner_id"`
+	Name         string             `json:"name"`
+	KeyID        string             `json:"key_id"`
+	HashedSecret string             `json:"hashed_secret"`
+	Scopes       []string           `json:"scopes"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	RevokedAt    pgtype.Timestamptz `json:"revoked_at"`
+	LastUsedAt   pgtype.Timestamptz `json:"last_used_at"`
+}
+
+type APIKeyNonce struct {
+	APIKeyID  uuid.UUID `json:"api_key_id"`
+	Nonce     string    `json:"nonce"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AuditLog struct {
+	ID           int64       `json:"id"`
+	CreatedAt    time.Time   `json:"created_at"`
+	ActorID      uuid.UUID   `json:"actor_id"`
+	Action       string      `json:"action"`
+	TargetUserID pgtype.UUID `json:"target_user_id"`
+	Details      []byte      `json:"details"`
+}
+
+type DataExport struct {
+	ID            uuid.UUID          `json:"id"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+	UserID        uuid.UUID          `json:"user_id"`
+	Status        string             `json:"status"`
+	FilePath      string             `json:"file_path"`
+	FailureReason string             `json:"failure_reason"`
+	CompletedAt   pgtype.Timestamptz `json:"completed_at"`
+	ExpiresAt     pgtype.Timestamptz `json:"expires_at"`
+}
+
+type IdempotencyKey struct {
+	Scope               string    `json:"scope"`
+	Key                 string    `json:"key"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+	Fingerprint         string    `json:"fingerprint"`
+	Status              string    `json:"status"`
+	ResponseStatusCode  int32     `json:"response_status_code"`
+	ResponseContentType string    `json:"response_content_type"`
+	ResponseBody        []byte    `json:"response_body"`
+	ExpiresAt           time.Time `json:"expires_at"`
+}
+
+type KYCSubmission struct {
+	ID            uuid.UUID          `json:"id"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+	UserID        uuid.UUID          `json:"user_id"`
+	Status        string             `json:"status"`
+	IDDocumentKey string             `json:"id_document_key"`
+	SelfieKey     string             `json:"selfie_key"`
+	ReviewerID    pgtype.UUID        `json:"reviewer_id"`
+	ReviewReason  string             `json:"review_reason"`
+	ReviewedAt    pgtype.Timestamptz `json:"reviewed_at"`
+}
+
+type RateLimitCounter struct {
+	Key         string    `json:"key"`
+	WindowStart time.Time `json:"window_start"`
+	Count       int64     `json:"count"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
 type User struct {
-	ID              uuid.UUID `json:"id"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
-	Email           string    `json:"email"`
-	HashedPassword  string    `json:"hashed_password"`
-	LineID          string    `json:"line_id"`
-	Nickname        string    `json:"nickname"`
-	IsEmailVerified bool      `json:"is_email_verified"`
-	Role            string    `json:"role"`
+	ID              uuid.UUID          `json:"id"`
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+	Email           string             `json:"email"`
+	HashedPassword  string             `json:"hashed_password"`
+	LineID          string             `json:"line_id"`
+	Nickname        string             `json:"nickname"`
+	IsEmailVerified bool               `json:"is_email_verified"`
+	Role            string             `json:"role"`
+	Status          string             `json:"status"`
+	DeletedAt       pgtype.Timestamptz `json:"deleted_at"`
+	AnonymizedAt    pgtype.Timestamptz `json:"anonymized_at"`
+	KYCStatus       string             `json:"kyc_status"`
 }
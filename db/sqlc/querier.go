@@ -6,13 +6,66 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
+	// AcquireIdempotencyKey records that a request is being processed with the key.
+	// A key which has expired, or whose processing has stalled since before the given time
+	// for the same request, is taken over. No row is returned when the key is held.
+	AcquireIdempotencyKey(ctx context.Context, arg AcquireIdempotencyKeyParams) (IdempotencyKey, error)
+	AnonymizeUser(ctx context.Context, arg AnonymizeUserParams) (User, error)
+	// ClaimDataExport picks the oldest pending export, or one whose processing
+	// has stalled since before the given time, and marks it as processing.
+	ClaimDataExport(ctx context.Context, stalledBefore time.Time) (DataExport, error)
+	CloseUser(ctx context.Context, arg CloseUserParams) (User, error)
+	CompleteDataExport(ctx context.Context, arg CompleteDataExportParams) (DataExport, error)
+	CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) (int64, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (APIKey, error)
+	CreateAPIKeyNonce(ctx context.Context, arg CreateAPIKeyNonceParams) (int64, error)
+	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error)
+	CreateDataExport(ctx context.Context, userID uuid.UUID) (DataExport, error)
+	CreateKYCSubmission(ctx context.Context, arg CreateKYCSubmissionParams) (KYCSubmission, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DeleteAPIKeyNoncesBefore(ctx context.Context, before time.Time) (int64, error)
+	DeleteDataExportsByUser(ctx context.Context, userID uuid.UUID) ([]DataExport, error)
+	DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) error
+	DeleteIdempotencyKeysBefore(ctx context.Context, before time.Time) (int64, error)
+	DeleteKYCSubmissionsByUser(ctx context.Context, userID uuid.UUID) ([]KYCSubmission, error)
+	DeleteRateLimitCountersBefore(ctx context.Context, before time.Time) (int64, error)
+	ExpireAPIKey(ctx context.Context, arg ExpireAPIKeyParams) (APIKey, error)
+	ExpireDataExport(ctx context.Context, id uuid.UUID) (DataExport, error)
+	FailDataExport(ctx context.Context, arg FailDataExportParams) (DataExport, error)
+	GetAPIKey(ctx context.Context, id uuid.UUID) (APIKey, error)
+	GetAPIKeyByKeyID(ctx context.Context, keyID string) (APIKey, error)
+	GetAPIKeysByPartner(ctx context.Context, partnerID uuid.UUID) ([]APIKey, error)
+	GetAuditLogsByTargetUser(ctx context.Context, arg GetAuditLogsByTargetUserParams) ([]AuditLog, error)
+	GetAuditLogsByUser(ctx context.Context, userID uuid.UUID) ([]AuditLog, error)
+	GetDataExport(ctx context.Context, id uuid.UUID) (DataExport, error)
+	GetDataExportsByUser(ctx context.Context, userID uuid.UUID) ([]DataExport, error)
+	GetExpiredDataExports(ctx context.Context, now time.Time) ([]DataExport, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetKYCSubmission(ctx context.Context, id uuid.UUID) (KYCSubmission, error)
+	GetKYCSubmissionsByUser(ctx context.Context, userID uuid.UUID) ([]KYCSubmission, error)
+	GetLatestKYCSubmissionByUser(ctx context.Context, userID uuid.UUID) (KYCSubmission, error)
+	GetPendingKYCSubmissions(ctx context.Context, arg GetPendingKYCSubmissionsParams) ([]KYCSubmission, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
-	GetUsers(ctx context.Context, arg GetUsersParams) ([]User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (User, error)
+	IncrementRateLimitCounter(ctx context.Context, arg IncrementRateLimitCounterParams) (IncrementRateLimitCounterRow, error)
+	// ListUsers lists the users oldest first, starting after the given (created_at, id) keyset if any
+	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
+	// ListUsersDesc lists the users newest first, starting after the given (created_at, id) keyset if any
+	ListUsersDesc(ctx context.Context, arg ListUsersDescParams) ([]User, error)
+	ReviewKYCSubmission(ctx context.Context, arg ReviewKYCSubmissionParams) (KYCSubmission, error)
+	RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyParams) (APIKey, error)
+	TouchAPIKey(ctx context.Context, arg TouchAPIKeyParams) error
 	UpdateUserByEmail(ctx context.Context, arg UpdateUserByEmailParams) (User, error)
+	UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) (User, error)
+	UpdateUserKYCStatus(ctx context.Context, arg UpdateUserKYCStatusParams) (User, error)
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
+	UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,72 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.24.0
+// source: rate_limit_counter.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const deleteRateLimitCountersBefore = `-- name: DeleteRateLimitCountersBefore :execrows
+DELETE FROM "rate_limit_counters"
+WHERE "expires_at" < $1::timestamptz
+`
+
+func (q *Queries) DeleteRateLimitCountersBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteRateLimitCountersBefore, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const incrementRateLimitCounter = `-- name: IncrementRateLimitCounter :one
+WITH "current_window" AS (
+  INSERT INTO "rate_limit_counters" (
+    "key",
+    "window_start",
+    "expires_at"
+  ) VALUES (
+    $1, $3, $4
+  )
+  ON CONFLICT ("key", "window_start") DO UPDATE
+  SET "count" = "rate_limit_counters"."count" + 1
+  RETURNING "count"
+)
+SELECT
+  "current_window"."count" AS "current_count",
+  COALESCE((
+    SELECT "previous_window"."count"
+    FROM "rate_limit_counters" AS "previous_window"
+    WHERE "previous_window"."key" = $1
+      AND "previous_window"."window_start" = $2
+  ), 0)::bigint AS "previous_count"
+FROM "current_window"
+`
+
+type IncrementRateLimitCounterParams struct {
+	Key                 string    `json:"key"`
+	PreviousWindowStart time.Time `json:"previous_window_start"`
+	WindowStart         time.Time `json:"window_start"`
+	ExpiresAt           time.Time `json:"expires_at"`
+}
+
+type IncrementRateLimitCounterRow struct {
+	CurrentCount  int64 `json:"current_count"`
+	PreviousCount int64 `json:"previous_count"`
+}
+
+func (q *Queries) IncrementRateLimitCounter(ctx context.Context, arg IncrementRateLimitCounterParams) (IncrementRateLimitCounterRow, error) {
+	row := q.db.QueryRow(ctx, incrementRateLimitCounter,
+		arg.Key,
+		arg.PreviousWindowStart,
+		arg.WindowStart,
+		arg.ExpiresAt,
+	)
+	var i IncrementRateLimitCounterRow
+	err := row.Scan(&i.CurrentCount, &i.PreviousCount)
+	return i, err
+}
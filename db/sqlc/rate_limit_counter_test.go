@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncrementRateLimitCounter(t *testing.T) {
+	window := time.Minute
+	previousWindowStart := time.Now().Truncate(window).Add(-window)
+	windowStart := previousWindowStart.Add(window)
+	key := "sign_up:" + util.RandomString(12)
+
+	arg := IncrementRateLimitCounterParams{
+		Key:                 key,
+		PreviousWindowStart: previousWindowStart.Add(-window),
+		WindowStart:         previousWindowStart,
+		ExpiresAt:           previousWindowStart.Add(2 * window),
+	}
+	for i := 1; i <= 3; i++ {
+		row, err := testStore.IncrementRateLimitCounter(context.Background(), arg)
+		require.NoError(t, err)
+		require.EqualValues(t, i, row.CurrentCount)
+		require.Zero(t, row.PreviousCount)
+	}
+
+	// the next window starts from scratch, but knows how many requests were counted in the previous one
+	row, err := testStore.IncrementRateLimitCounter(context.Background(), IncrementRateLimitCounterParams{
+		Key:                 key,
+		PreviousWindowStart: previousWindowStart,
+		WindowStart:         windowStart,
+		ExpiresAt:           windowStart.Add(2 * window),
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, row.CurrentCount)
+	require.EqualValues(t, 3, row.PreviousCount)
+}
+
+func TestDeleteRateLimitCountersBefore(t *testing.T) {
+	window := time.Minute
+	windowStart := time.Now().Truncate(window)
+	arg := IncrementRateLimitCounterParams{
+		Key:                 "sign_in:" + util.RandomString(12),
+		PreviousWindowStart: windowStart.Add(-window),
+		WindowStart:         windowStart,
+		ExpiresAt:           windowStart.Add(2 * window),
+	}
+
+	_, err := testStore.IncrementRateLimitCounter(context.Background(), arg)
+	require.NoError(t, err)
+
+	deleted, err := testStore.DeleteRateLimitCountersBefore(context.Background(), arg.ExpiresAt.Add(time.Second))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+
+	row, err := testStore.IncrementRateLimitCounter(context.Background(), arg)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, row.CurrentCount)
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// replicaQueries are the read-only queries sent to the read replica, when there is one.
+// Queries whose result must reflect the latest writes, such as the lookups of API keys which may have just been revoked
+// or of idempotency keys, and those run within transactions, always go to the primary.
+var replicaQueries = map[string]bool{
+	"GetUserByEmail":               true,
+	"GetUserByID":                  true,
+	"ListUsers":                    true,
+	"ListUsersDesc":                true,
+	"GetAPIKeysByPartner":          true,
+	"GetAuditLogsByUser":           true,
+	"GetAuditLogsByTargetUser":     true,
+	"GetDataExportsByUser":         true,
+	"GetKYCSubmission":             true,
+	"GetLatestKYCSubmissionByUser": true,
+	"GetKYCSubmissionsByUser":      true,
+	"GetPendingKYCSubmissions":     true,
+}
+
+type primaryKey struct{}
+
+// WithPrimary returns a copy of the context whose queries are all sent to the primary.
+// It is meant for the paths reading what they, or the request before, have just written,
+// which the replica may not have replicated yet.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// UsesPrimary tells whether the queries of the context are all sent to the primary, see WithPrimary
+func UsesPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+// replicatedDBTX sends the read-only queries to the replica and all the others to the primary
+type replicatedDBTX struct {
+	primary DBTX
+	replica DBTX
+}
+
+func replicate(primary DBTX, replica DBTX) DBTX {
+	return &replicatedDBTX{primary: primary, replica: replica}
+}
+
+func (r *replicatedDBTX) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	return r.primary.Exec(ctx, query, args...)
+}
+
+func (r *replicatedDBTX) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	return r.route(ctx, query).Query(ctx, query, args...)
+}
+
+func (r *replicatedDBTX) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	return r.route(ctx, query).QueryRow(ctx, query, args...)
+}
+
+// route picks where a query is sent to, by its sqlc name
+func (r *replicatedDBTX) route(ctx context.Context, query string) DBTX {
+	if !UsesPrimary(ctx) && replicaQueries[queryName(query)] {
+		return r.replica
+	}
+	return r.primary
+}
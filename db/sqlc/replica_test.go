@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicatedDBTX(t *testing.T) {
+	errPrimary := errors.New("primary")
+	errReplica := errors.New("replica")
+	q := New(replicate(stubDBTX{err: errPrimary}, stubDBTX{err: errReplica}))
+
+	testCases := []struct {
+		name        string
+		query       func(ctx context.Context) error
+		expectedErr error
+	}{
+		{
+			name: "ReadOnlyQueryRow",
+			query: func(ctx context.Context) error {
+				_, err := q.GetUserByID(ctx, uuid.New())
+				return err
+			},
+			expectedErr: errReplica,
+		},
+		{
+			name: "ReadOnlyQuery",
+			query: func(ctx context.Context) error {
+				_, err := q.ListUsers(ctx, ListUsersParams{Limit: 1})
+				return err
+			},
+			expectedErr: errReplica,
+		},
+		{
+			name: "ReadOnlyQueryWithPrimary",
+			query: func(ctx context.Context) error {
+				_, err := q.GetUserByID(WithPrimary(ctx), uuid.New())
+				return err
+			},
+			expectedErr: errPrimary,
+		},
+		{
+			name: "ConsistentRead",
+			query: func(ctx context.Context) error {
+				_, err := q.GetAPIKeyByKeyID(ctx, "key")
+				return err
+			},
+			expectedErr: errPrimary,
+		},
+		{
+			name: "Write",
+			query: func(ctx context.Context) error {
+				_, err := q.UpdateUserRole(ctx, UpdateUserRoleParams{ID: uuid.New()})
+				return err
+			},
+			expectedErr: errPrimary,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.query(context.Background())
+			require.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
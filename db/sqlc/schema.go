@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// SchemaMigration is the state of the migrations applied to the database by golang-migrate
+type SchemaMigration struct {
+	Version int64 `json:"version"`
+	// Dirty is set when a migration failed halfway and the database must be fixed by hand
+	Dirty bool `json:"dirty"`
+}
+
+const getSchemaMigration = `-- name: GetSchemaMigration :one
+SELECT "version", "dirty" FROM "schema_migrations" LIMIT 1`
+
+// Ping checks that the database, and its read replica if there is one, can be reached
+func (store *PostgresStore) Ping(ctx context.Context) error {
+	err := store.connPool.Ping(ctx)
+	if err != nil {
+		return err
+	}
+
+	if store.replicaPool != nil {
+		err = store.replicaPool.Ping(ctx)
+		if err != nil {
+			return fmt.Errorf("cannot reach read replica: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetSchemaMigration returns the state of the migrations applied to the database.
+// The schema_migrations table is managed by golang-migrate, which is why it is not queried through sqlc.
+func (store *PostgresStore) GetSchemaMigration(ctx context.Context) (SchemaMigration, error) {
+	var migration SchemaMigration
+	err := store.db.QueryRow(ctx, getSchemaMigration).Scan(&migration.Version, &migration.Dirty)
+	return migration, err
+}
@@ -0,0 +1,24 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DamianZhang/957-lending-platform/db/migration"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPing(t *testing.T) {
+	err := testStore.Ping(context.Background())
+	require.NoError(t, err)
+}
+
+func TestGetSchemaMigration(t *testing.T) {
+	latest, err := migration.LatestVersion()
+	require.NoError(t, err)
+
+	schemaMigration, err := testStore.GetSchemaMigration(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, latest, schemaMigration.Version)
+	require.False(t, schemaMigration.Dirty)
+}
@@ -1,22 +1,75 @@
 package db
 
-import "github.com/jackc/pgx/v5/pgxpool"
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
 
 // Store defines all functions to execute SQL queries and transactions
 type Store interface {
 	Querier
+	UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error)
+	CreateAdminTx(ctx context.Context, arg CreateAdminTxParams) (AuditedUserTxResult, error)
+	UpdateUserRoleTx(ctx context.Context, arg UpdateUserRoleTxParams) (AuditedUserTxResult, error)
+	UpdateUserStatusTx(ctx context.Context, arg UpdateUserStatusTxParams) (AuditedUserTxResult, error)
+	CloseUserTx(ctx context.Context, arg CloseUserTxParams) (AuditedUserTxResult, error)
+	AnonymizeUserTx(ctx context.Context, arg AnonymizeUserTxParams) (AnonymizeUserTxResult, error)
+	CreateKYCSubmissionTx(ctx context.Context, arg CreateKYCSubmissionTxParams) (KYCSubmissionTxResult, error)
+	ReviewKYCSubmissionTx(ctx context.Context, arg ReviewKYCSubmissionTxParams) (KYCSubmissionTxResult, error)
+	CreateAPIKeyTx(ctx context.Context, arg CreateAPIKeyTxParams) (APIKeyTxResult, error)
+	RotateAPIKeyTx(ctx context.Context, arg RotateAPIKeyTxParams) (RotateAPIKeyTxResult, error)
+	RevokeAPIKeyTx(ctx context.Context, arg RevokeAPIKeyTxParams) (APIKeyTxResult, error)
+	Ping(ctx context.Context) error
+	GetSchemaMigration(ctx context.Context) (SchemaMigration, error)
 }
 
 // PostgresStore provides all functions to execute SQL queries and transactions
 type PostgresStore struct {
 	connPool *pgxpool.Pool
+	// replicaPool is set when the read-only queries are sent to a read replica
+	replicaPool *pgxpool.Pool
+	observer    QueryObserver
 	*Queries
 }
 
-// NewStore creates a new Store
-func NewStore(connPool *pgxpool.Pool) Store {
+// NewStore creates a new Store tracing its queries, and reporting them to the observer unless it is nil
+func NewStore(connPool *pgxpool.Pool, observer QueryObserver) Store {
+	return NewReplicatedStore(connPool, nil, observer)
+}
+
+// NewReplicatedStore creates a new Store like NewStore, sending the read-only queries to the read replica
+// unless it is nil, see WithPrimary. Transactions always run on the primary.
+func NewReplicatedStore(connPool *pgxpool.Pool, replicaPool *pgxpool.Pool, observer QueryObserver) Store {
+	db := instrument(connPool, observer)
+	if replicaPool != nil {
+		db = replicate(db, instrument(replicaPool, observer))
+	}
+
 	return &PostgresStore{
-		connPool: connPool,
-		Queries:  New(connPool),
+		connPool:    connPool,
+		replicaPool: replicaPool,
+		observer:    observer,
+		Queries:     New(db),
 	}
 }
+
+// execTx executes a function within a database transaction
+func (store *PostgresStore) execTx(ctx context.Context, fn func(*Queries) error) error {
+	tx, err := store.connPool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	q := New(instrument(tx, store.observer))
+	err = fn(q)
+	if err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,337 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateUserTx(t *testing.T) {
+	oldUser := createRandomUser(t)
+
+	newUpdatedAt := time.Now()
+	newEmail := util.RandomEmail()
+	newNickname := util.RandomString(6)
+
+	result, err := testStore.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		UpdateUserByEmailParams: UpdateUserByEmailParams{
+			Email:     oldUser.Email,
+			UpdatedAt: newUpdatedAt,
+			Nickname: pgtype.Text{
+				String: newNickname,
+				Valid:  true,
+			},
+		},
+		NewEmail: pgtype.Text{
+			String: newEmail,
+			Valid:  true,
+		},
+	})
+	require.NoError(t, err)
+
+	updatedUser := result.User
+	require.Equal(t, oldUser.ID, updatedUser.ID)
+	require.Equal(t, newEmail, updatedUser.Email)
+	require.Equal(t, newNickname, updatedUser.Nickname)
+	require.Equal(t, oldUser.LineID, updatedUser.LineID)
+	require.False(t, updatedUser.IsEmailVerified)
+	require.WithinDuration(t, newUpdatedAt, updatedUser.UpdatedAt, time.Second)
+}
+
+func TestUpdateUserTxDuplicateEmail(t *testing.T) {
+	user1 := createRandomUser(t)
+	user2 := createRandomUser(t)
+
+	_, err := testStore.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		UpdateUserByEmailParams: UpdateUserByEmailParams{
+			Email:     user1.Email,
+			UpdatedAt: time.Now(),
+			Nickname: pgtype.Text{
+				String: util.RandomString(6),
+				Valid:  true,
+			},
+		},
+		NewEmail: pgtype.Text{
+			String: user2.Email,
+			Valid:  true,
+		},
+	})
+	require.Error(t, err)
+
+	// the nickname change must be rolled back together with the email change
+	got, err := testStore.GetUserByEmail(context.Background(), user1.Email)
+	require.NoError(t, err)
+	require.Equal(t, user1.Nickname, got.Nickname)
+}
+
+func TestCreateAdminTx(t *testing.T) {
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	result, err := testStore.CreateAdminTx(context.Background(), CreateAdminTxParams{
+		CreateUserParams: CreateUserParams{
+			Email:          util.RandomEmail(),
+			HashedPassword: hashedPassword,
+			LineID:         util.RandomString(6),
+			Nickname:       util.RandomString(6),
+			Role:           util.AdminRole,
+		},
+		AuditAction: util.AuditActionCreateAdmin,
+	})
+	require.NoError(t, err)
+
+	require.NotZero(t, result.User.ID)
+	require.Equal(t, util.AdminRole, result.User.Role)
+
+	require.NotZero(t, result.AuditLog.ID)
+	require.Equal(t, result.User.ID, result.AuditLog.ActorID)
+	require.Equal(t, util.AuditActionCreateAdmin, result.AuditLog.Action)
+	require.Equal(t, result.User.ID, uuid.UUID(result.AuditLog.TargetUserID.Bytes))
+}
+
+func TestUpdateUserRoleTx(t *testing.T) {
+	admin := createRandomUser(t)
+	user := createRandomUser(t)
+
+	result, err := testStore.UpdateUserRoleTx(context.Background(), UpdateUserRoleTxParams{
+		UpdateUserRoleParams: UpdateUserRoleParams{
+			ID:        user.ID,
+			Role:      util.LenderRole,
+			UpdatedAt: time.Now(),
+		},
+		Audit: AuditParams{
+			ActorID: admin.ID,
+			Action:  util.AuditActionChangeUserRole,
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, user.ID, result.User.ID)
+	require.Equal(t, util.LenderRole, result.User.Role)
+
+	require.NotZero(t, result.AuditLog.ID)
+	require.Equal(t, admin.ID, result.AuditLog.ActorID)
+	require.Equal(t, util.AuditActionChangeUserRole, result.AuditLog.Action)
+	require.Equal(t, user.ID, uuid.UUID(result.AuditLog.TargetUserID.Bytes))
+	require.JSONEq(t, "{}", string(result.AuditLog.Details))
+}
+
+func TestUpdateUserStatusTx(t *testing.T) {
+	admin := createRandomUser(t)
+	user := createRandomUser(t)
+
+	result, err := testStore.UpdateUserStatusTx(context.Background(), UpdateUserStatusTxParams{
+		UpdateUserStatusParams: UpdateUserStatusParams{
+			ID:        user.ID,
+			Status:    util.SuspendedStatus,
+			UpdatedAt: time.Now(),
+		},
+		Audit: AuditParams{
+			ActorID: admin.ID,
+			Action:  util.AuditActionSuspendUser,
+			Details: []byte(`{"reason": "fraud"}`),
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, user.ID, result.User.ID)
+	require.Equal(t, util.SuspendedStatus, result.User.Status)
+
+	require.Equal(t, admin.ID, result.AuditLog.ActorID)
+	require.Equal(t, util.AuditActionSuspendUser, result.AuditLog.Action)
+	require.JSONEq(t, `{"reason": "fraud"}`, string(result.AuditLog.Details))
+}
+
+func TestCloseUserTx(t *testing.T) {
+	user := createRandomUser(t)
+
+	result, err := testStore.CloseUserTx(context.Background(), CloseUserTxParams{
+		CloseUserParams: CloseUserParams{
+			ID:       user.ID,
+			ClosedAt: time.Now(),
+		},
+		Audit: AuditParams{
+			ActorID: user.ID,
+			Action:  util.AuditActionCloseAccount,
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, util.ClosedStatus, result.User.Status)
+	require.True(t, result.User.DeletedAt.Valid)
+	require.Equal(t, user.ID, result.AuditLog.ActorID)
+	require.Equal(t, util.AuditActionCloseAccount, result.AuditLog.Action)
+}
+
+func TestAnonymizeUserTx(t *testing.T) {
+	admin := createRandomUser(t)
+	user := createRandomUser(t)
+	dataExport := createRandomDataExport(t, user)
+	submission := createRandomKYCSubmission(t, user)
+
+	result, err := testStore.AnonymizeUserTx(context.Background(), AnonymizeUserTxParams{
+		AnonymizeUserParams: AnonymizeUserParams{
+			ID:           user.ID,
+			Email:        user.ID.String() + "@anonymized.invalid",
+			Nickname:     "anonymized",
+			AnonymizedAt: time.Now(),
+		},
+		Audit: AuditParams{
+			ActorID: admin.ID,
+			Action:  util.AuditActionAnonymizeUser,
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, util.ClosedStatus, result.User.Status)
+	require.True(t, result.User.AnonymizedAt.Valid)
+	require.Equal(t, admin.ID, result.AuditLog.ActorID)
+	require.Equal(t, util.AuditActionAnonymizeUser, result.AuditLog.Action)
+
+	require.Len(t, result.DataExports, 1)
+	require.Equal(t, dataExport.ID, result.DataExports[0].ID)
+
+	dataExports, err := testStore.GetDataExportsByUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Empty(t, dataExports)
+
+	require.Len(t, result.KYCSubmissions, 1)
+	require.Equal(t, submission.ID, result.KYCSubmissions[0].ID)
+
+	_, err = testStore.GetKYCSubmission(context.Background(), submission.ID)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestKYCSubmissionTx(t *testing.T) {
+	admin := createRandomUser(t)
+	user := createRandomUser(t)
+
+	id := uuid.New()
+	created, err := testStore.CreateKYCSubmissionTx(context.Background(), CreateKYCSubmissionTxParams{
+		CreateKYCSubmissionParams: CreateKYCSubmissionParams{
+			ID:            id,
+			UserID:        user.ID,
+			IDDocumentKey: util.RandomString(10),
+			SelfieKey:     util.RandomString(10),
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, id, created.KYCSubmission.ID)
+	require.Equal(t, util.KYCPending, created.User.KYCStatus)
+
+	reviewed, err := testStore.ReviewKYCSubmissionTx(context.Background(), ReviewKYCSubmissionTxParams{
+		ReviewKYCSubmissionParams: ReviewKYCSubmissionParams{
+			ID:         id,
+			Status:     util.KYCSubmissionApproved,
+			ReviewerID: admin.ID,
+			ReviewedAt: time.Now(),
+		},
+		KYCStatus: util.KYCVerified,
+		Audit: AuditParams{
+			ActorID: admin.ID,
+			Action:  util.AuditActionApproveKYC,
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, util.KYCSubmissionApproved, reviewed.KYCSubmission.Status)
+	require.Equal(t, util.KYCVerified, reviewed.User.KYCStatus)
+	require.Equal(t, admin.ID, reviewed.AuditLog.ActorID)
+	require.Equal(t, user.ID, uuid.UUID(reviewed.AuditLog.TargetUserID.Bytes))
+	require.Equal(t, util.AuditActionApproveKYC, reviewed.AuditLog.Action)
+}
+
+func TestAPIKeyTx(t *testing.T) {
+	admin := createRandomUser(t)
+	partner := createRandomUser(t)
+
+	issued, err := testStore.CreateAPIKeyTx(context.Background(), CreateAPIKeyTxParams{
+		CreateAPIKeyParams: CreateAPIKeyParams{
+			PartnerID:    partner.ID,
+			Name:         util.RandomString(8),
+			KeyID:        util.APIKeyIDPrefix + util.RandomString(24),
+			HashedSecret: util.HashAPIKeySecret(util.RandomString(32)),
+			Scopes:       []string{util.ScopeLoansRead},
+		},
+		Audit: AuditParams{
+			ActorID: admin.ID,
+			Action:  util.AuditActionIssueAPIKey,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, partner.ID, issued.APIKey.PartnerID)
+	require.False(t, issued.APIKey.ExpiresAt.Valid)
+	require.Equal(t, util.AuditActionIssueAPIKey, issued.AuditLog.Action)
+	require.Equal(t, partner.ID, uuid.UUID(issued.AuditLog.TargetUserID.Bytes))
+
+	graceEndsAt := time.Now().Add(time.Hour)
+	rotated, err := testStore.RotateAPIKeyTx(context.Background(), RotateAPIKeyTxParams{
+		ExpireAPIKeyParams: ExpireAPIKeyParams{
+			ID:        issued.APIKey.ID,
+			ExpiresAt: graceEndsAt,
+		},
+		NewAPIKey: CreateAPIKeyParams{
+			PartnerID:    partner.ID,
+			Name:         issued.APIKey.Name,
+			KeyID:        util.APIKeyIDPrefix + util.RandomString(24),
+			HashedSecret: util.HashAPIKeySecret(util.RandomString(32)),
+			Scopes:       issued.APIKey.Scopes,
+		},
+		Audit: AuditParams{
+			ActorID: admin.ID,
+			Action:  util.AuditActionRotateAPIKey,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, issued.APIKey.ID, rotated.OldAPIKey.ID)
+	require.WithinDuration(t, graceEndsAt, rotated.OldAPIKey.ExpiresAt.Time, time.Second)
+	require.NotEqual(t, issued.APIKey.ID, rotated.APIKey.ID)
+	require.Equal(t, issued.APIKey.Scopes, rotated.APIKey.Scopes)
+	require.Equal(t, util.AuditActionRotateAPIKey, rotated.AuditLog.Action)
+	require.Equal(t, partner.ID, uuid.UUID(rotated.AuditLog.TargetUserID.Bytes))
+
+	revoked, err := testStore.RevokeAPIKeyTx(context.Background(), RevokeAPIKeyTxParams{
+		RevokeAPIKeyParams: RevokeAPIKeyParams{
+			ID:        rotated.APIKey.ID,
+			RevokedAt: time.Now(),
+		},
+		Audit: AuditParams{
+			ActorID: admin.ID,
+			Action:  util.AuditActionRevokeAPIKey,
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, revoked.APIKey.RevokedAt.Valid)
+	require.Equal(t, util.AuditActionRevokeAPIKey, revoked.AuditLog.Action)
+
+	// a revoked key can not be rotated, and nothing is written when the transaction fails
+	_, err = testStore.RotateAPIKeyTx(context.Background(), RotateAPIKeyTxParams{
+		ExpireAPIKeyParams: ExpireAPIKeyParams{
+			ID:        rotated.APIKey.ID,
+			ExpiresAt: time.Now(),
+		},
+		NewAPIKey: CreateAPIKeyParams{
+			PartnerID:    partner.ID,
+			Name:         issued.APIKey.Name,
+			KeyID:        util.APIKeyIDPrefix + util.RandomString(24),
+			HashedSecret: util.HashAPIKeySecret(util.RandomString(32)),
+			Scopes:       issued.APIKey.Scopes,
+		},
+		Audit: AuditParams{
+			ActorID: admin.ID,
+			Action:  util.AuditActionRotateAPIKey,
+		},
+	})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	apiKeys, err := testStore.GetAPIKeysByPartner(context.Background(), partner.ID)
+	require.NoError(t, err)
+	require.Len(t, apiKeys, 2)
+}
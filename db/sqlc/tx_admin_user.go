@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// AuditParams describes who performed an audited action and why
+type AuditParams struct {
+	ActorID uuid.UUID `json:"actor_id"`
+	Action  string    `json:"action"`
+	Details []byte    `json:"details"`
+}
+
+// CreateAdminTxParams contains the input parameters of the create admin transaction
+type CreateAdminTxParams struct {
+	CreateUserParams
+	// AuditAction is the action the creation is recorded as, the admin being the actor of its own creation
+	AuditAction string `json:"audit_action"`
+}
+
+// UpdateUserRoleTxParams contains the input parameters of the update user role transaction
+type UpdateUserRoleTxParams struct {
+	UpdateUserRoleParams
+	Audit AuditParams `json:"audit"`
+}
+
+// UpdateUserStatusTxParams contains the input parameters of the update user status transaction
+type UpdateUserStatusTxParams struct {
+	UpdateUserStatusParams
+	Audit AuditParams `json:"audit"`
+}
+
+// AuditedUserTxResult is the result of a transaction changing a user and writing an audit log
+type AuditedUserTxResult struct {
+	User     User     `json:"user"`
+	AuditLog AuditLog `json:"audit_log"`
+}
+
+// CreateAdminTx creates an admin and records its creation in the audit logs
+func (store *PostgresStore) CreateAdminTx(ctx context.Context, arg CreateAdminTxParams) (AuditedUserTxResult, error) {
+	var result AuditedUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		audit := AuditParams{ActorID: result.User.ID, Action: arg.AuditAction}
+		result.AuditLog, err = q.CreateAuditLog(ctx, newCreateAuditLogParams(audit, result.User.ID))
+		return err
+	})
+
+	return result, err
+}
+
+// UpdateUserRoleTx changes the role of a user and records the change in the audit logs
+func (store *PostgresStore) UpdateUserRoleTx(ctx context.Context, arg UpdateUserRoleTxParams) (AuditedUserTxResult, error) {
+	var result AuditedUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.UpdateUserRole(ctx, arg.UpdateUserRoleParams)
+		if err != nil {
+			return err
+		}
+
+		result.AuditLog, err = q.CreateAuditLog(ctx, newCreateAuditLogParams(arg.Audit, arg.ID))
+		return err
+	})
+
+	return result, err
+}
+
+// UpdateUserStatusTx changes the status of a user and records the change in the audit logs
+func (store *PostgresStore) UpdateUserStatusTx(ctx context.Context, arg UpdateUserStatusTxParams) (AuditedUserTxResult, error) {
+	var result AuditedUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.UpdateUserStatus(ctx, arg.UpdateUserStatusParams)
+		if err != nil {
+			return err
+		}
+
+		result.AuditLog, err = q.CreateAuditLog(ctx, newCreateAuditLogParams(arg.Audit, arg.ID))
+		return err
+	})
+
+	return result, err
+}
+
+func newCreateAuditLogParams(audit AuditParams, targetUserID uuid.UUID) CreateAuditLogParams {
+	details := audit.Details
+	if details == nil {
+		details = []byte("{}")
+	}
+
+	return CreateAuditLogParams{
+		ActorID: audit.ActorID,
+		Action:  audit.Action,
+		TargetUserID: pgtype.UUID{
+			Bytes: targetUserID,
+			Valid: true,
+		},
+		Details: details,
+	}
+}
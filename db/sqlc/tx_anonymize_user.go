@@ -0,0 +1,49 @@
+package db
+
+import "context"
+
+// AnonymizeUserTxParams contains the input parameters of the anonymize user transaction
+type AnonymizeUserTxParams struct {
+	AnonymizeUserParams
+	Audit AuditParams `json:"audit"`
+}
+
+// AnonymizeUserTxResult is the result of the anonymize user transaction
+type AnonymizeUserTxResult struct {
+	AuditedUserTxResult
+	// DataExports are the deleted data exports, whose bundles are left for the caller to remove
+	DataExports []DataExport `json:"data_exports"`
+	// KYCSubmissions are the deleted submissions, whose documents are left for the caller to remove
+	KYCSubmissions []KYCSubmission `json:"kyc_submissions"`
+}
+
+// AnonymizeUserTx scrubs the personal data of a user, drops the records of its data exports
+// and KYC submissions, and records the anonymisation in the audit logs. The row of the user is kept
+// so that the financial records referencing it stay intact.
+func (store *PostgresStore) AnonymizeUserTx(ctx context.Context, arg AnonymizeUserTxParams) (AnonymizeUserTxResult, error) {
+	var result AnonymizeUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.AnonymizeUser(ctx, arg.AnonymizeUserParams)
+		if err != nil {
+			return err
+		}
+
+		result.DataExports, err = q.DeleteDataExportsByUser(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		result.KYCSubmissions, err = q.DeleteKYCSubmissionsByUser(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		result.AuditLog, err = q.CreateAuditLog(ctx, newCreateAuditLogParams(arg.Audit, arg.ID))
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,100 @@
+package db
+
+import "context"
+
+// CreateAPIKeyTxParams contains the input parameters of the create API key transaction
+type CreateAPIKeyTxParams struct {
+	CreateAPIKeyParams
+	Audit AuditParams `json:"audit"`
+}
+
+// RotateAPIKeyTxParams contains the input parameters of the rotate API key transaction
+type RotateAPIKeyTxParams struct {
+	// ExpireAPIKeyParams sets when the rotated key stops working
+	ExpireAPIKeyParams
+	// NewAPIKey is the key replacing the rotated one
+	NewAPIKey CreateAPIKeyParams `json:"new_api_key"`
+	Audit     AuditParams        `json:"audit"`
+}
+
+// RevokeAPIKeyTxParams contains the input parameters of the revoke API key transaction
+type RevokeAPIKeyTxParams struct {
+	RevokeAPIKeyParams
+	Audit AuditParams `json:"audit"`
+}
+
+// APIKeyTxResult is the result of a transaction changing an API key and writing an audit log
+type APIKeyTxResult struct {
+	APIKey   APIKey   `json:"api_key"`
+	AuditLog AuditLog `json:"audit_log"`
+}
+
+// RotateAPIKeyTxResult is the result of the rotate API key transaction
+type RotateAPIKeyTxResult struct {
+	OldAPIKey APIKey   `json:"old_api_key"`
+	APIKey    APIKey   `json:"api_key"`
+	AuditLog  AuditLog `json:"audit_log"`
+}
+
+// CreateAPIKeyTx issues an API key to a partner and records it in the audit logs
+func (store *PostgresStore) CreateAPIKeyTx(ctx context.Context, arg CreateAPIKeyTxParams) (APIKeyTxResult, error) {
+	var result APIKeyTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.APIKey, err = q.CreateAPIKey(ctx, arg.CreateAPIKeyParams)
+		if err != nil {
+			return err
+		}
+
+		result.AuditLog, err = q.CreateAuditLog(ctx, newCreateAuditLogParams(arg.Audit, arg.PartnerID))
+		return err
+	})
+
+	return result, err
+}
+
+// RotateAPIKeyTx issues a new API key with the scopes of an existing one, which stops working
+// at the given time, and records the rotation in the audit logs
+func (store *PostgresStore) RotateAPIKeyTx(ctx context.Context, arg RotateAPIKeyTxParams) (RotateAPIKeyTxResult, error) {
+	var result RotateAPIKeyTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.OldAPIKey, err = q.ExpireAPIKey(ctx, arg.ExpireAPIKeyParams)
+		if err != nil {
+			return err
+		}
+
+		result.APIKey, err = q.CreateAPIKey(ctx, arg.NewAPIKey)
+		if err != nil {
+			return err
+		}
+
+		result.AuditLog, err = q.CreateAuditLog(ctx, newCreateAuditLogParams(arg.Audit, result.OldAPIKey.PartnerID))
+		return err
+	})
+
+	return result, err
+}
+
+// RevokeAPIKeyTx revokes an API key for good and records it in the audit logs
+func (store *PostgresStore) RevokeAPIKeyTx(ctx context.Context, arg RevokeAPIKeyTxParams) (APIKeyTxResult, error) {
+	var result APIKeyTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.APIKey, err = q.RevokeAPIKey(ctx, arg.RevokeAPIKeyParams)
+		if err != nil {
+			return err
+		}
+
+		result.AuditLog, err = q.CreateAuditLog(ctx, newCreateAuditLogParams(arg.Audit, result.APIKey.PartnerID))
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,29 @@
+package db
+
+import "context"
+
+// CloseUserTxParams contains the input parameters of the close user transaction
+type CloseUserTxParams struct {
+	CloseUserParams
+	Audit AuditParams `json:"audit"`
+}
+
+// CloseUserTx closes the account of a user and records it in the audit logs.
+// The row of the user is kept so that the financial records referencing it stay intact.
+func (store *PostgresStore) CloseUserTx(ctx context.Context, arg CloseUserTxParams) (AuditedUserTxResult, error) {
+	var result AuditedUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.CloseUser(ctx, arg.CloseUserParams)
+		if err != nil {
+			return err
+		}
+
+		result.AuditLog, err = q.CreateAuditLog(ctx, newCreateAuditLogParams(arg.Audit, arg.ID))
+		return err
+	})
+
+	return result, err
+}
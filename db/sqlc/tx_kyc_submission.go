@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// CreateKYCSubmissionTxParams contains the input parameters of the create KYC submission transaction
+type CreateKYCSubmissionTxParams struct {
+	CreateKYCSubmissionParams
+}
+
+// ReviewKYCSubmissionTxParams contains the input parameters of the review KYC submission transaction
+type ReviewKYCSubmissionTxParams struct {
+	ReviewKYCSubmissionParams
+	// KYCStatus is the KYC status the user ends up with after the review
+	KYCStatus string      `json:"kyc_status"`
+	Audit     AuditParams `json:"audit"`
+}
+
+// KYCSubmissionTxResult is the result of a transaction changing a KYC submission and its user
+type KYCSubmissionTxResult struct {
+	KYCSubmission KYCSubmission `json:"kyc_submission"`
+	User          User          `json:"user"`
+	AuditLog      AuditLog      `json:"audit_log"`
+}
+
+// CreateKYCSubmissionTx records the documents a user submitted for identity verification
+// and puts the user in the pending KYC status
+func (store *PostgresStore) CreateKYCSubmissionTx(ctx context.Context, arg CreateKYCSubmissionTxParams) (KYCSubmissionTxResult, error) {
+	var result KYCSubmissionTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.KYCSubmission, err = q.CreateKYCSubmission(ctx, arg.CreateKYCSubmissionParams)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.UpdateUserKYCStatus(ctx, UpdateUserKYCStatusParams{
+			ID:        arg.UserID,
+			KYCStatus: "pending",
+			UpdatedAt: result.KYCSubmission.CreatedAt,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// ReviewKYCSubmissionTx settles a pending KYC submission, updates the KYC status of its user
+// and records the decision in the audit logs
+func (store *PostgresStore) ReviewKYCSubmissionTx(ctx context.Context, arg ReviewKYCSubmissionTxParams) (KYCSubmissionTxResult, error) {
+	var result KYCSubmissionTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.KYCSubmission, err = q.ReviewKYCSubmission(ctx, arg.ReviewKYCSubmissionParams)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.UpdateUserKYCStatus(ctx, UpdateUserKYCStatusParams{
+			ID:        result.KYCSubmission.UserID,
+			KYCStatus: arg.KYCStatus,
+			UpdatedAt: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+
+		result.AuditLog, err = q.CreateAuditLog(ctx, newCreateAuditLogParams(arg.Audit, result.KYCSubmission.UserID))
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// UpdateUserTxParams contains the input parameters of the update user transaction
+type UpdateUserTxParams struct {
+	UpdateUserByEmailParams
+	NewEmail pgtype.Text `json:"new_email"`
+}
+
+// UpdateUserTxResult is the result of the update user transaction
+type UpdateUserTxResult struct {
+	User User `json:"user"`
+}
+
+// UpdateUserTx updates the profile of a user and, if a new email is given,
+// replaces the email and marks it as unverified within a single transaction
+func (store *PostgresStore) UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error) {
+	var result UpdateUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.UpdateUserByEmail(ctx, arg.UpdateUserByEmailParams)
+		if err != nil {
+			return err
+		}
+
+		if !arg.NewEmail.Valid || arg.NewEmail.String == result.User.Email {
+			return nil
+		}
+
+		result.User, err = q.UpdateUserEmail(ctx, UpdateUserEmailParams{
+			NewEmail:  arg.NewEmail.String,
+			UpdatedAt: arg.UpdatedAt,
+			Email:     result.User.Email,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -9,9 +9,97 @@ import (
 	"context"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const anonymizeUser = `-- name: AnonymizeUser :one
+UPDATE "users"
+SET
+  "email" = $1,
+  "hashed_password" = '',
+  "line_id" = '',
+  "nickname" = $2,
+  "is_email_verified" = false,
+  "status" = 'closed',
+  "deleted_at" = COALESCE("deleted_at", $3::timestamptz),
+  "anonymized_at" = $3::timestamptz,
+  "updated_at" = $3::timestamptz
+WHERE
+  "id" = $4 AND "anonymized_at" IS NULL
+RETURNING id, created_at, updated_at, email, hashed_password, line_id, nickname, is_email_verified, role, status, deleted_at, anonymized_at, kyc_status
+`
+
+type AnonymizeUserParams struct {
+	Email        string    `json:"email"`
+	Nickname     string    `json:"nickname"`
+	AnonymizedAt time.Time `json:"anonymized_at"`
+	ID           uuid.UUID `json:"id"`
+}
+
+func (q *Queries) AnonymizeUser(ctx context.Context, arg AnonymizeUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, anonymizeUser,
+		arg.Email,
+		arg.Nickname,
+		arg.AnonymizedAt,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.LineID,
+		&i.Nickname,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.Status,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+		&i.KYCStatus,
+	)
+	return i, err
+}
+
+const closeUser = `-- name: CloseUser :one
+UPDATE "users"
+SET
+  "status" = 'closed',
+  "deleted_at" = $1::timestamptz,
+  "updated_at" = $1::timestamptz
+WHERE
+  "id" = $2 AND "deleted_at" IS NULL
+RETURNING id, created_at, updated_at, email, hashed_password, line_id, nickname, is_email_verified, role, status, deleted_at, anonymized_at, kyc_status
+`
+
+type CloseUserParams struct {
+	ClosedAt time.Time `json:"closed_at"`
+	ID       uuid.UUID `json:"id"`
+}
+
+func (q *Queries) CloseUser(ctx context.Context, arg CloseUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, closeUser, arg.ClosedAt, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.LineID,
+		&i.Nickname,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.Status,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+		&i.KYCStatus,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO "users" (
   "email",
@@ -21,7 +109,7 @@ INSERT INTO "users" (
   "role"
 ) VALUES (
   $1, $2, $3, $4, $5
-) RETURNING id, created_at, updated_at, email, hashed_password, line_id, nickname, is_email_verified, role
+) RETURNING id, created_at, updated_at, email, hashed_password, line_id, nickname, is_email_verified, role, status, deleted_at, anonymized_at, kyc_status
 `
 
 type CreateUserParams struct {
@@ -51,13 +139,18 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Nickname,
 		&i.IsEmailVerified,
 		&i.Role,
+		&i.Status,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+		&i.KYCStatus,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, line_id, nickname, is_email_verified, role FROM "users"
-WHERE "email" = $1 LIMIT 1
+SELECT id, created_at, updated_at, email, hashed_password, line_id, nickname, is_email_verified, role, status, deleted_at, anonymized_at, kyc_status FROM "users"
+WHERE "email" = $1 AND "deleted_at" IS NULL
+LIMIT 1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -73,23 +166,158 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Nickname,
 		&i.IsEmailVerified,
 		&i.Role,
+		&i.Status,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+		&i.KYCStatus,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, line_id, nickname, is_email_verified, role, status, deleted_at, anonymized_at, kyc_status FROM "users"
+WHERE "id" = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.LineID,
+		&i.Nickname,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.Status,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+		&i.KYCStatus,
 	)
 	return i, err
 }
 
-const getUsers = `-- name: GetUsers :many
-SELECT id, created_at, updated_at, email, hashed_password, line_id, nickname, is_email_verified, role FROM "users"
-LIMIT $1
-OFFSET $2
+const listUsers = `-- name: ListUsers :many
+SELECT id, created_at, updated_at, email, hashed_password, line_id, nickname, is_email_verified, role, status, deleted_at, anonymized_at, kyc_status FROM "users"
+WHERE
+  ($1::varchar IS NULL OR "email" ILIKE '%' || $1 || '%') AND
+  ($2::varchar[] IS NULL OR "role" = ANY($2::varchar[])) AND
+  ($3::varchar[] IS NULL OR "status" = ANY($3::varchar[])) AND
+  ($4::bool IS NULL OR "is_email_verified" = $4) AND
+  ($5::timestamptz IS NULL OR "created_at" >= $5) AND
+  ($6::timestamptz IS NULL OR "created_at" <= $6) AND
+  ($7::bool OR "deleted_at" IS NULL) AND
+  ($8::timestamptz IS NULL OR
+    ("created_at", "id") > ($8::timestamptz, $9::uuid))
+ORDER BY "created_at", "id"
+LIMIT $10
+`
+
+type ListUsersParams struct {
+	Email           pgtype.Text        `json:"email"`
+	Roles           []string           `json:"roles"`
+	Statuses        []string           `json:"statuses"`
+	IsEmailVerified pgtype.Bool        `json:"is_email_verified"`
+	CreatedFrom     pgtype.Timestamptz `json:"created_from"`
+	CreatedTo       pgtype.Timestamptz `json:"created_to"`
+	IncludeDeleted  bool               `json:"include_deleted"`
+	AfterCreatedAt  pgtype.Timestamptz `json:"after_created_at"`
+	AfterID         pgtype.UUID        `json:"after_id"`
+	Limit           int32              `json:"limit"`
+}
+
+// ListUsers lists the users oldest first, starting after the given (created_at, id) keyset if any
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsers,
+		arg.Email,
+		arg.Roles,
+		arg.Statuses,
+		arg.IsEmailVerified,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.IncludeDeleted,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.LineID,
+			&i.Nickname,
+			&i.IsEmailVerified,
+			&i.Role,
+			&i.Status,
+			&i.DeletedAt,
+			&i.AnonymizedAt,
+			&i.KYCStatus,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersDesc = `-- name: ListUsersDesc :many
+SELECT id, created_at, updated_at, email, hashed_password, line_id, nickname, is_email_verified, role, status, deleted_at, anonymized_at, kyc_status FROM "users"
+WHERE
+  ($1::varchar IS NULL OR "email" ILIKE '%' || $1 || '%') AND
+  ($2::varchar[] IS NULL OR "role" = ANY($2::varchar[])) AND
+  ($3::varchar[] IS NULL OR "status" = ANY($3::varchar[])) AND
+  ($4::bool IS NULL OR "is_email_verified" = $4) AND
+  ($5::timestamptz IS NULL OR "created_at" >= $5) AND
+  ($6::timestamptz IS NULL OR "created_at" <= $6) AND
+  ($7::bool OR "deleted_at" IS NULL) AND
+  ($8::timestamptz IS NULL OR
+    ("created_at", "id") < ($8::timestamptz, $9::uuid))
+ORDER BY "created_at" DESC, "id" DESC
+LIMIT $10
 `
 
-type GetUsersParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+type ListUsersDescParams struct {
+	Email           pgtype.Text        `json:"email"`
+	Roles           []string           `json:"roles"`
+	Statuses        []string           `json:"statuses"`
+	IsEmailVerified pgtype.Bool        `json:"is_email_verified"`
+	CreatedFrom     pgtype.Timestamptz `json:"created_from"`
+	CreatedTo       pgtype.Timestamptz `json:"created_to"`
+	IncludeDeleted  bool               `json:"include_deleted"`
+	AfterCreatedAt  pgtype.Timestamptz `json:"after_created_at"`
+	AfterID         pgtype.UUID        `json:"after_id"`
+	Limit           int32              `json:"limit"`
 }
 
-func (q *Queries) GetUsers(ctx context.Context, arg GetUsersParams) ([]User, error) {
-	rows, err := q.db.Query(ctx, getUsers, arg.Limit, arg.Offset)
+// ListUsersDesc lists the users newest first, starting after the given (created_at, id) keyset if any
+func (q *Queries) ListUsersDesc(ctx context.Context, arg ListUsersDescParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsersDesc,
+		arg.Email,
+		arg.Roles,
+		arg.Statuses,
+		arg.IsEmailVerified,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.IncludeDeleted,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -107,6 +335,10 @@ func (q *Queries) GetUsers(ctx context.Context, arg GetUsersParams) ([]User, err
 			&i.Nickname,
 			&i.IsEmailVerified,
 			&i.Role,
+			&i.Status,
+			&i.DeletedAt,
+			&i.AnonymizedAt,
+			&i.KYCStatus,
 		); err != nil {
 			return nil, err
 		}
@@ -127,8 +359,8 @@ SET
   "is_email_verified" = COALESCE($4, "is_email_verified"),
   "updated_at" = COALESCE($5, "updated_at")
 WHERE
-  "email" = $6
-RETURNING id, created_at, updated_at, email, hashed_password, line_id, nickname, is_email_verified, role
+  "email" = $6 AND "deleted_at" IS NULL
+RETURNING id, created_at, updated_at, email, hashed_password, line_id, nickname, is_email_verified, role, status, deleted_at, anonymized_at, kyc_status
 `
 
 type UpdateUserByEmailParams struct {
@@ -160,6 +392,159 @@ func (q *Queries) UpdateUserByEmail(ctx context.Context, arg UpdateUserByEmailPa
 		&i.Nickname,
 		&i.IsEmailVerified,
 		&i.Role,
+		&i.Status,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+		&i.KYCStatus,
+	)
+	return i, err
+}
+
+const updateUserEmail = `-- name: UpdateUserEmail :one
+UPDATE "users"
+SET
+  "email" = $1,
+  "is_email_verified" = false,
+  "updated_at" = $2
+WHERE
+  "email" = $3 AND "deleted_at" IS NULL
+RETURNING id, created_at, updated_at, email, hashed_password, line_id, nickname, is_email_verified, role, status, deleted_at, anonymized_at, kyc_status
+`
+
+type UpdateUserEmailParams struct {
+	NewEmail  string    `json:"new_email"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Email     string    `json:"email"`
+}
+
+func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserEmail, arg.NewEmail, arg.UpdatedAt, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.LineID,
+		&i.Nickname,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.Status,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+		&i.KYCStatus,
+	)
+	return i, err
+}
+
+const updateUserKYCStatus = `-- name: UpdateUserKYCStatus :one
+UPDATE "users"
+SET
+  "kyc_status" = $1,
+  "updated_at" = $2
+WHERE
+  "id" = $3
+RETURNING id, created_at, updated_at, email, hashed_password, line_id, nickname, is_email_verified, role, status, deleted_at, anonymized_at, kyc_status
+`
+
+type UpdateUserKYCStatusParams struct {
+	KYCStatus string    `json:"kyc_status"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ID        uuid.UUID `json:"id"`
+}
+
+func (q *Queries) UpdateUserKYCStatus(ctx context.Context, arg UpdateUserKYCStatusParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserKYCStatus, arg.KYCStatus, arg.UpdatedAt, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.LineID,
+		&i.Nickname,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.Status,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+		&i.KYCStatus,
+	)
+	return i, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE "users"
+SET
+  "role" = $1,
+  "updated_at" = $2
+WHERE
+  "id" = $3
+RETURNING id, created_at, updated_at, email, hashed_password, line_id, nickname, is_email_verified, role, status, deleted_at, anonymized_at, kyc_status
+`
+
+type UpdateUserRoleParams struct {
+	Role      string    `json:"role"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ID        uuid.UUID `json:"id"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserRole, arg.Role, arg.UpdatedAt, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.LineID,
+		&i.Nickname,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.Status,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+		&i.KYCStatus,
+	)
+	return i, err
+}
+
+const updateUserStatus = `-- name: UpdateUserStatus :one
+UPDATE "users"
+SET
+  "status" = $1,
+  "updated_at" = $2
+WHERE
+  "id" = $3
+RETURNING id, created_at, updated_at, email, hashed_password, line_id, nickname, is_email_verified, role, status, deleted_at, anonymized_at, kyc_status
+`
+
+type UpdateUserStatusParams struct {
+	Status    string    `json:"status"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ID        uuid.UUID `json:"id"`
+}
+
+func (q *Queries) UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserStatus, arg.Status, arg.UpdatedAt, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.LineID,
+		&i.Nickname,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.Status,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+		&i.KYCStatus,
 	)
 	return i, err
 }
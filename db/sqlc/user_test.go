@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 )
@@ -32,6 +34,8 @@ func createRandomUser(t *testing.T) User {
 	require.Equal(t, wanted.Nickname, got.Nickname)
 	require.Equal(t, wanted.Role, got.Role)
 	require.False(t, got.IsEmailVerified)
+	require.Equal(t, util.ActiveStatus, got.Status)
+	require.False(t, got.DeletedAt.Valid)
 	require.NotZero(t, got.ID)
 	require.NotZero(t, got.CreatedAt)
 	require.NotZero(t, got.UpdatedAt)
@@ -60,21 +64,79 @@ func TestGetUserByEmail(t *testing.T) {
 	require.WithinDuration(t, wanted.UpdatedAt, got.UpdatedAt, time.Second)
 }
 
-func TestGetUsers(t *testing.T) {
+func TestListUsers(t *testing.T) {
+	createdFrom := time.Now().Add(-time.Second)
 	wanted := 5
 	for i := 0; i < wanted; i++ {
 		createRandomUser(t)
 	}
 
-	users, err := testStore.GetUsers(context.Background(), GetUsersParams{
-		Limit:  int32(wanted),
-		Offset: 0,
+	arg := ListUsersParams{
+		CreatedFrom: pgtype.Timestamptz{
+			Time:  createdFrom,
+			Valid: true,
+		},
+		Limit: 2,
+	}
+
+	// page through the users, each page starting after the last user of the previous one
+	var users []User
+	for {
+		page, err := testStore.ListUsers(context.Background(), arg)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+
+		users = append(users, page...)
+		last := page[len(page)-1]
+		arg.AfterCreatedAt = pgtype.Timestamptz{Time: last.CreatedAt, Valid: true}
+		arg.AfterID = pgtype.UUID{Bytes: last.ID, Valid: true}
+	}
+	require.GreaterOrEqual(t, len(users), wanted)
+
+	seen := make(map[uuid.UUID]bool)
+	for i, user := range users {
+		require.False(t, seen[user.ID])
+		seen[user.ID] = true
+
+		if i > 0 {
+			previous := users[i-1]
+			require.False(t, user.CreatedAt.Before(previous.CreatedAt))
+			if user.CreatedAt.Equal(previous.CreatedAt) {
+				require.Greater(t, user.ID.String(), previous.ID.String())
+			}
+		}
+	}
+}
+
+func TestListUsersDesc(t *testing.T) {
+	older := createRandomUser(t)
+	newer := createRandomUser(t)
+
+	users, err := testStore.ListUsersDesc(context.Background(), ListUsersDescParams{
+		CreatedFrom: pgtype.Timestamptz{
+			Time:  older.CreatedAt,
+			Valid: true,
+		},
+		Limit: 100,
 	})
 	require.NoError(t, err)
-	require.Len(t, users, wanted)
+	require.NotEmpty(t, users)
 
+	for i := 1; i < len(users); i++ {
+		require.False(t, users[i].CreatedAt.After(users[i-1].CreatedAt))
+	}
+
+	users, err = testStore.ListUsersDesc(context.Background(), ListUsersDescParams{
+		AfterCreatedAt: pgtype.Timestamptz{Time: newer.CreatedAt, Valid: true},
+		AfterID:        pgtype.UUID{Bytes: newer.ID, Valid: true},
+		Limit:          100,
+	})
+	require.NoError(t, err)
 	for _, user := range users {
-		require.NotEmpty(t, user)
+		require.NotEqual(t, newer.ID, user.ID)
+		require.False(t, user.CreatedAt.After(newer.CreatedAt))
 	}
 }
 
@@ -190,3 +252,247 @@ func TestUpdateUserByEmailAllFields(t *testing.T) {
 	require.WithinDuration(t, oldUser.CreatedAt, updatedUser.CreatedAt, time.Second)
 	require.WithinDuration(t, newUpdatedAt, updatedUser.UpdatedAt, time.Second)
 }
+
+func TestGetUserByID(t *testing.T) {
+	wanted := createRandomUser(t)
+	got, err := testStore.GetUserByID(context.Background(), wanted.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, got)
+
+	require.Equal(t, wanted.ID, got.ID)
+	require.Equal(t, wanted.Email, got.Email)
+	require.Equal(t, wanted.HashedPassword, got.HashedPassword)
+	require.Equal(t, wanted.LineID, got.LineID)
+	require.Equal(t, wanted.Nickname, got.Nickname)
+	require.Equal(t, wanted.IsEmailVerified, got.IsEmailVerified)
+	require.Equal(t, wanted.Role, got.Role)
+	require.WithinDuration(t, wanted.CreatedAt, got.CreatedAt, time.Second)
+	require.WithinDuration(t, wanted.UpdatedAt, got.UpdatedAt, time.Second)
+}
+
+func TestUpdateUserEmail(t *testing.T) {
+	oldUser := createRandomUser(t)
+
+	_, err := testStore.UpdateUserByEmail(context.Background(), UpdateUserByEmailParams{
+		Email:     oldUser.Email,
+		UpdatedAt: time.Now(),
+		IsEmailVerified: pgtype.Bool{
+			Bool:  true,
+			Valid: true,
+		},
+	})
+	require.NoError(t, err)
+
+	newUpdatedAt := time.Now()
+	newEmail := util.RandomEmail()
+
+	updatedUser, err := testStore.UpdateUserEmail(context.Background(), UpdateUserEmailParams{
+		NewEmail:  newEmail,
+		UpdatedAt: newUpdatedAt,
+		Email:     oldUser.Email,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, updatedUser)
+
+	require.Equal(t, oldUser.ID, updatedUser.ID)
+	require.Equal(t, newEmail, updatedUser.Email)
+	require.False(t, updatedUser.IsEmailVerified)
+	require.Equal(t, oldUser.HashedPassword, updatedUser.HashedPassword)
+	require.Equal(t, oldUser.LineID, updatedUser.LineID)
+	require.Equal(t, oldUser.Nickname, updatedUser.Nickname)
+	require.Equal(t, oldUser.Role, updatedUser.Role)
+	require.WithinDuration(t, newUpdatedAt, updatedUser.UpdatedAt, time.Second)
+}
+
+func TestListUsersWithFilters(t *testing.T) {
+	createdFrom := time.Now().Add(-time.Second)
+	wanted := createRandomUser(t)
+
+	users, err := testStore.ListUsers(context.Background(), ListUsersParams{
+		Email: pgtype.Text{
+			String: wanted.Email[:4],
+			Valid:  true,
+		},
+		Roles:    []string{wanted.Role, util.LenderRole},
+		Statuses: []string{wanted.Status},
+		IsEmailVerified: pgtype.Bool{
+			Bool:  false,
+			Valid: true,
+		},
+		CreatedFrom: pgtype.Timestamptz{
+			Time:  createdFrom,
+			Valid: true,
+		},
+		Limit: 100,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, users)
+
+	found := false
+	for _, user := range users {
+		require.Contains(t, user.Email, wanted.Email[:4])
+		require.Contains(t, []string{wanted.Role, util.LenderRole}, user.Role)
+		require.Equal(t, wanted.Status, user.Status)
+		require.False(t, user.IsEmailVerified)
+		require.True(t, !user.CreatedAt.Before(createdFrom))
+		if user.ID == wanted.ID {
+			found = true
+		}
+	}
+	require.True(t, found)
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	oldUser := createRandomUser(t)
+
+	newUpdatedAt := time.Now()
+	updatedUser, err := testStore.UpdateUserRole(context.Background(), UpdateUserRoleParams{
+		ID:        oldUser.ID,
+		Role:      util.AdminRole,
+		UpdatedAt: newUpdatedAt,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, oldUser.ID, updatedUser.ID)
+	require.Equal(t, util.AdminRole, updatedUser.Role)
+	require.Equal(t, oldUser.Email, updatedUser.Email)
+	require.Equal(t, oldUser.Status, updatedUser.Status)
+	require.WithinDuration(t, newUpdatedAt, updatedUser.UpdatedAt, time.Second)
+}
+
+func TestUpdateUserStatus(t *testing.T) {
+	oldUser := createRandomUser(t)
+
+	newUpdatedAt := time.Now()
+	updatedUser, err := testStore.UpdateUserStatus(context.Background(), UpdateUserStatusParams{
+		ID:        oldUser.ID,
+		Status:    util.SuspendedStatus,
+		UpdatedAt: newUpdatedAt,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, oldUser.ID, updatedUser.ID)
+	require.Equal(t, util.SuspendedStatus, updatedUser.Status)
+	require.Equal(t, oldUser.Email, updatedUser.Email)
+	require.Equal(t, oldUser.Role, updatedUser.Role)
+	require.WithinDuration(t, newUpdatedAt, updatedUser.UpdatedAt, time.Second)
+}
+
+func TestCloseUser(t *testing.T) {
+	oldUser := createRandomUser(t)
+
+	closedAt := time.Now()
+	closedUser, err := testStore.CloseUser(context.Background(), CloseUserParams{
+		ID:       oldUser.ID,
+		ClosedAt: closedAt,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, oldUser.ID, closedUser.ID)
+	require.Equal(t, util.ClosedStatus, closedUser.Status)
+	require.True(t, closedUser.DeletedAt.Valid)
+	require.WithinDuration(t, closedAt, closedUser.DeletedAt.Time, time.Second)
+	require.WithinDuration(t, closedAt, closedUser.UpdatedAt, time.Second)
+
+	// a closed user is hidden from lookups by email but kept for its records
+	_, err = testStore.GetUserByEmail(context.Background(), oldUser.Email)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	got, err := testStore.GetUserByID(context.Background(), oldUser.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.ClosedStatus, got.Status)
+
+	// closing twice is not possible
+	_, err = testStore.CloseUser(context.Background(), CloseUserParams{
+		ID:       oldUser.ID,
+		ClosedAt: time.Now(),
+	})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestReuseEmailOfClosedUser(t *testing.T) {
+	oldUser := createRandomUser(t)
+
+	_, err := testStore.CloseUser(context.Background(), CloseUserParams{
+		ID:       oldUser.ID,
+		ClosedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	newUser, err := testStore.CreateUser(context.Background(), CreateUserParams{
+		Email:          oldUser.Email,
+		HashedPassword: oldUser.HashedPassword,
+		LineID:         util.RandomString(6),
+		Nickname:       util.RandomString(6),
+		Role:           util.RandomRole(),
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, oldUser.ID, newUser.ID)
+
+	got, err := testStore.GetUserByEmail(context.Background(), oldUser.Email)
+	require.NoError(t, err)
+	require.Equal(t, newUser.ID, got.ID)
+}
+
+func TestListUsersExcludesDeleted(t *testing.T) {
+	closedUser := createRandomUser(t)
+	_, err := testStore.CloseUser(context.Background(), CloseUserParams{
+		ID:       closedUser.ID,
+		ClosedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	arg := ListUsersParams{
+		Email: pgtype.Text{
+			String: closedUser.Email,
+			Valid:  true,
+		},
+		Limit: 10,
+	}
+
+	users, err := testStore.ListUsers(context.Background(), arg)
+	require.NoError(t, err)
+	for _, user := range users {
+		require.NotEqual(t, closedUser.ID, user.ID)
+	}
+
+	arg.IncludeDeleted = true
+	users, err = testStore.ListUsers(context.Background(), arg)
+	require.NoError(t, err)
+
+	found := false
+	for _, user := range users {
+		if user.ID == closedUser.ID {
+			found = true
+		}
+	}
+	require.True(t, found)
+}
+
+func TestAnonymizeUser(t *testing.T) {
+	oldUser := createRandomUser(t)
+
+	anonymizedAt := time.Now()
+	arg := AnonymizeUserParams{
+		ID:           oldUser.ID,
+		Email:        oldUser.ID.String() + "@anonymized.invalid",
+		Nickname:     "anonymized",
+		AnonymizedAt: anonymizedAt,
+	}
+
+	anonymizedUser, err := testStore.AnonymizeUser(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, oldUser.ID, anonymizedUser.ID)
+	require.Equal(t, arg.Email, anonymizedUser.Email)
+	require.Equal(t, arg.Nickname, anonymizedUser.Nickname)
+	require.Empty(t, anonymizedUser.HashedPassword)
+	require.Empty(t, anonymizedUser.LineID)
+	require.Equal(t, util.ClosedStatus, anonymizedUser.Status)
+	require.True(t, anonymizedUser.DeletedAt.Valid)
+	require.WithinDuration(t, anonymizedAt, anonymizedUser.AnonymizedAt.Time, time.Second)
+
+	// anonymising twice is not possible
+	_, err = testStore.AnonymizeUser(context.Background(), arg)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
@@ -0,0 +1,40 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/DamianZhang/957-lending-platform/pb"
+	"github.com/DamianZhang/957-lending-platform/service"
+)
+
+func (server *Server) SignUp(ctx context.Context, req *pb.SignUpRequest) (*pb.SignUpResponse, error) {
+	if err := validateSignUpRequest(ctx, req); err != nil {
+		return nil, err
+	}
+
+	input := &service.SignUpInput{
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+		LineID:   req.GetLineId(),
+		Nickname: req.GetNickname(),
+	}
+
+	output, err := server.borrowerService.SignUp(ctx, input)
+	if err != nil {
+		return nil, fromServiceError(ctx, err)
+	}
+
+	rsp := &pb.SignUpResponse{
+		Borrower: convertUser(output.Borrower),
+	}
+	return rsp, nil
+}
+
+func validateSignUpRequest(ctx context.Context, req *pb.SignUpRequest) error {
+	v := newFieldValidator(ctx)
+	v.email("email", req.GetEmail())
+	v.required("password", req.GetPassword())
+	v.lineID("line_id", req.GetLineId())
+	v.nickname("nickname", req.GetNickname())
+	return v.err(ctx)
+}
@@ -0,0 +1,153 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/pb"
+	"github.com/DamianZhang/957-lending-platform/service"
+	mocksvc "github.com/DamianZhang/957-lending-platform/service/mock"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSignUpRPC(t *testing.T) {
+	expectedReq := &pb.SignUpRequest{
+		Email:    util.RandomEmail(),
+		Password: "secret123",
+		LineId:   util.RandomString(6),
+		Nickname: util.RandomString(6),
+	}
+
+	testCases := []struct {
+		name       string
+		req        *pb.SignUpRequest
+		buildStubs func(svc *mocksvc.MockBorrowerService)
+		checkRsp   func(t *testing.T, rsp *pb.SignUpResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  expectedReq,
+			buildStubs: func(svc *mocksvc.MockBorrowerService) {
+				input := &service.SignUpInput{
+					Email:    expectedReq.Email,
+					Password: expectedReq.Password,
+					LineID:   expectedReq.LineId,
+					Nickname: expectedReq.Nickname,
+				}
+				output := &service.SignUpOutput{
+					Borrower: db.User{
+						Email:    expectedReq.Email,
+						LineID:   expectedReq.LineId,
+						Nickname: expectedReq.Nickname,
+						Role:     util.BorrowerRole,
+					},
+				}
+				svc.EXPECT().
+					SignUp(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(output, nil)
+			},
+			checkRsp: func(t *testing.T, rsp *pb.SignUpResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, expectedReq.Email, rsp.GetBorrower().GetEmail())
+				require.Equal(t, expectedReq.LineId, rsp.GetBorrower().GetLineId())
+				require.Equal(t, expectedReq.Nickname, rsp.GetBorrower().GetNickname())
+				require.Equal(t, util.BorrowerRole, rsp.GetBorrower().GetRole())
+			},
+		},
+		{
+			name: "InvalidArguments",
+			req: &pb.SignUpRequest{
+				Email:    "invalid-email",
+				Password: "",
+				LineId:   "A",
+				Nickname: "nick name",
+			},
+			buildStubs: func(svc *mocksvc.MockBorrowerService) {
+				svc.EXPECT().
+					SignUp(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(t *testing.T, rsp *pb.SignUpResponse, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+
+				var fields []string
+				for _, detail := range st.Details() {
+					if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+						for _, violation := range badRequest.GetFieldViolations() {
+							fields = append(fields, violation.GetField())
+						}
+					}
+				}
+				require.Equal(t, []string{"email", "password", "line_id", "nickname"}, fields)
+			},
+		},
+		{
+			name: "ServiceInternalFailure",
+			req:  expectedReq,
+			buildStubs: func(svc *mocksvc.MockBorrowerService) {
+				svc.EXPECT().
+					SignUp(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewError(service.ErrInternalFailure, nil))
+			},
+			checkRsp: func(t *testing.T, rsp *pb.SignUpResponse, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+		{
+			name: "ServiceConflict",
+			req:  expectedReq,
+			buildStubs: func(svc *mocksvc.MockBorrowerService) {
+				svc.EXPECT().
+					SignUp(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewErrorWithDetail(service.ErrConflict, i18n.NewMessage("user.email_in_use")))
+			},
+			checkRsp: func(t *testing.T, rsp *pb.SignUpResponse, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.AlreadyExists, st.Code())
+				require.Equal(t, "email is already in use", st.Message())
+				requireReason(t, st, "conflict")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			borrowerSvc := mocksvc.NewMockBorrowerService(ctrl)
+			tc.buildStubs(borrowerSvc)
+
+			server := newTestServer(t, borrowerSvc, nil, nil)
+			client := pb.NewBorrowerServiceClient(dialTestServer(t, server))
+
+			rsp, err := client.SignUp(context.Background(), tc.req)
+			tc.checkRsp(t, rsp, err)
+		})
+	}
+}
+
+// requireReason checks the status carries an ErrorInfo detail with the given reason
+func requireReason(t *testing.T, st *status.Status, reason string) {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			require.Equal(t, reason, info.GetReason())
+			require.Equal(t, errorDomain, info.GetDomain())
+			return
+		}
+	}
+	require.Fail(t, "status has no ErrorInfo detail")
+}
@@ -0,0 +1,20 @@
+package gapi
+
+import (
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func convertUser(user db.User) *pb.User {
+	return &pb.User{
+		Email:           user.Email,
+		LineId:          user.LineID,
+		Nickname:        user.Nickname,
+		Role:            user.Role,
+		IsEmailVerified: user.IsEmailVerified,
+		KycStatus:       user.KYCStatus,
+		CreatedAt:       timestamppb.New(user.CreatedAt),
+		UpdatedAt:       timestamppb.New(user.UpdatedAt),
+	}
+}
@@ -0,0 +1,86 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain is the domain of the ErrorInfo details attached to the errors of the platform
+const errorDomain = "957-lending-platform"
+
+// statusCodes maps the service errors to the gRPC status codes they are reported with
+var statusCodes = map[error]codes.Code{
+	service.ErrInternalFailure:    codes.Internal,
+	service.ErrNotFound:           codes.NotFound,
+	service.ErrConflict:           codes.AlreadyExists,
+	service.ErrValidation:         codes.InvalidArgument,
+	service.ErrUnauthenticated:    codes.Unauthenticated,
+	service.ErrForbidden:          codes.PermissionDenied,
+	service.ErrRateLimited:        codes.ResourceExhausted,
+	service.ErrPreconditionFailed: codes.FailedPrecondition,
+	service.ErrAccountInactive:    codes.PermissionDenied,
+	service.ErrKYCRequired:        codes.PermissionDenied,
+	service.ErrRequestInProgress:  codes.Aborted,
+}
+
+// fromServiceError turns an error of the service layer into the status reported to the client.
+// The stable code of the service error is attached as the reason of an ErrorInfo detail,
+// and the message is the detail meant for clients in the language negotiated with them.
+func fromServiceError(ctx context.Context, err error) error {
+	var svcError service.Error
+	if !errors.As(err, &svcError) {
+		slog.ErrorContext(ctx, "unexpected error", "err", err)
+		return status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	code, ok := statusCodes[svcError.SvcErr()]
+	if !ok {
+		code = codes.Internal
+	}
+	if code == codes.Internal {
+		slog.ErrorContext(ctx, "internal error", "err", err)
+	}
+
+	msg := code.String()
+	if !svcError.Detail().IsZero() {
+		msg = localizer(ctx).Localize(svcError.Detail())
+	}
+
+	return statusWithReason(code, msg, svcError.Code())
+}
+
+// errorStatus reports an error raised by the gRPC layer itself, such as a missing access token
+func errorStatus(ctx context.Context, code codes.Code, reason string, msg i18n.Message) error {
+	return statusWithReason(code, localizer(ctx).Localize(msg), reason)
+}
+
+// invalidArgumentError reports the fields of a request which failed validation
+func invalidArgumentError(ctx context.Context, violations []*errdetails.BadRequest_FieldViolation) error {
+	st := status.New(codes.InvalidArgument, localizer(ctx).T("request.validation_failed"))
+
+	st, err := st.WithDetails(
+		&errdetails.ErrorInfo{Reason: "invalid_request", Domain: errorDomain},
+		&errdetails.BadRequest{FieldViolations: violations},
+	)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, st.Message())
+	}
+
+	return st.Err()
+}
+
+func statusWithReason(code codes.Code, msg, reason string) error {
+	st, err := status.New(code, msg).WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: errorDomain})
+	if err != nil {
+		return status.Error(code, msg)
+	}
+
+	return st.Err()
+}
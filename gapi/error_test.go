@@ -0,0 +1,127 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFromServiceError(t *testing.T) {
+	cause := errors.New(`duplicate key value violates unique constraint "users_email_key"`)
+
+	bundle, err := i18n.NewBundle()
+	require.NoError(t, err)
+	ctx := context.WithValue(context.Background(), localizerKey, bundle.Localizer(i18n.EN))
+
+	testCases := []struct {
+		name            string
+		err             error
+		expectedCode    codes.Code
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name:            "InternalFailure",
+			err:             service.NewError(service.ErrInternalFailure, cause),
+			expectedCode:    codes.Internal,
+			expectedReason:  "internal_failure",
+			expectedMessage: "Internal",
+		},
+		{
+			name:            "NotFound",
+			err:             service.NewError(service.ErrNotFound, cause),
+			expectedCode:    codes.NotFound,
+			expectedReason:  "not_found",
+			expectedMessage: "NotFound",
+		},
+		{
+			name:            "ConflictWithDetail",
+			err:             service.NewErrorWithDetail(service.ErrConflict, i18n.NewMessage("user.email_in_use")),
+			expectedCode:    codes.AlreadyExists,
+			expectedReason:  "conflict",
+			expectedMessage: "email is already in use",
+		},
+		{
+			name:            "Validation",
+			err:             service.NewError(service.ErrValidation, cause),
+			expectedCode:    codes.InvalidArgument,
+			expectedReason:  "validation_failed",
+			expectedMessage: "InvalidArgument",
+		},
+		{
+			name:            "Unauthenticated",
+			err:             service.NewError(service.ErrUnauthenticated, cause),
+			expectedCode:    codes.Unauthenticated,
+			expectedReason:  "unauthenticated",
+			expectedMessage: "Unauthenticated",
+		},
+		{
+			name:            "Forbidden",
+			err:             service.NewError(service.ErrForbidden, cause),
+			expectedCode:    codes.PermissionDenied,
+			expectedReason:  "forbidden",
+			expectedMessage: "PermissionDenied",
+		},
+		{
+			name:            "RateLimited",
+			err:             service.NewError(service.ErrRateLimited, cause),
+			expectedCode:    codes.ResourceExhausted,
+			expectedReason:  "rate_limited",
+			expectedMessage: "ResourceExhausted",
+		},
+		{
+			name:            "PreconditionFailed",
+			err:             service.NewError(service.ErrPreconditionFailed, cause),
+			expectedCode:    codes.FailedPrecondition,
+			expectedReason:  "precondition_failed",
+			expectedMessage: "FailedPrecondition",
+		},
+		{
+			name:            "AccountInactive",
+			err:             service.NewErrorWithDetail(service.ErrAccountInactive, i18n.NewMessage("user.account_inactive").With("status", "suspended")),
+			expectedCode:    codes.PermissionDenied,
+			expectedReason:  "account_inactive",
+			expectedMessage: "account is suspended",
+		},
+		{
+			name:            "KYCRequired",
+			err:             service.NewError(service.ErrKYCRequired, cause),
+			expectedCode:    codes.PermissionDenied,
+			expectedReason:  "kyc_required",
+			expectedMessage: "PermissionDenied",
+		},
+		{
+			name:            "RequestInProgress",
+			err:             service.NewError(service.ErrRequestInProgress, cause),
+			expectedCode:    codes.Aborted,
+			expectedReason:  "request_in_progress",
+			expectedMessage: "Aborted",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st, ok := status.FromError(fromServiceError(ctx, tc.err))
+			require.True(t, ok)
+			require.Equal(t, tc.expectedCode, st.Code())
+			require.Equal(t, tc.expectedMessage, st.Message())
+			requireReason(t, st, tc.expectedReason)
+
+			// the cause is never reported to the client
+			require.NotContains(t, st.Message(), "users_email_key")
+		})
+	}
+
+	t.Run("NotServiceError", func(t *testing.T) {
+		st, ok := status.FromError(fromServiceError(ctx, cause))
+		require.True(t, ok)
+		require.Equal(t, codes.Internal, st.Code())
+		require.NotContains(t, st.Message(), "users_email_key")
+	})
+}
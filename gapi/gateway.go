@@ -0,0 +1,69 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/textproto"
+
+	"github.com/DamianZhang/957-lending-platform/pb"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// NewGateway creates the HTTP handler translating JSON requests into calls to the gRPC server at the given address.
+// The calls go through the gRPC server rather than straight to the services, so that its interceptors apply.
+func NewGateway(ctx context.Context, grpcServerAddress string) (http.Handler, error) {
+	conn, err := grpc.DialContext(ctx, grpcServerAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("cannot dial gRPC server: %w", err)
+	}
+
+	mux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+		runtime.WithOutgoingHeaderMatcher(gatewayOutgoingHeaderMatcher),
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions: protojson.MarshalOptions{
+				UseProtoNames:   true,
+				EmitUnpopulated: true,
+			},
+			UnmarshalOptions: protojson.UnmarshalOptions{
+				DiscardUnknown: true,
+			},
+		}),
+	)
+
+	err = pb.RegisterBorrowerServiceHandler(ctx, mux, conn)
+	if err != nil {
+		return nil, fmt.Errorf("cannot register borrower service handler: %w", err)
+	}
+
+	err = pb.RegisterUserServiceHandler(ctx, mux, conn)
+	if err != nil {
+		return nil, fmt.Errorf("cannot register user service handler: %w", err)
+	}
+
+	return mux, nil
+}
+
+// gatewayHeaderMatcher passes the headers read by the interceptors on to the gRPC server as they are
+func gatewayHeaderMatcher(key string) (string, bool) {
+	switch textproto.CanonicalMIMEHeaderKey(key) {
+	case "X-Request-Id", "Accept-Language", "Idempotency-Key":
+		return key, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// gatewayOutgoingHeaderMatcher sends the metadata set by the interceptors back as the headers of the HTTP API,
+// the other metadata keep the prefix given by default
+func gatewayOutgoingHeaderMatcher(key string) (string, bool) {
+	switch key {
+	case rateLimitPolicyMetadataKey, rateLimitLimitMetadataKey, rateLimitRemainingMetadataKey, rateLimitResetMetadataKey,
+		retryAfterMetadataKey, idempotentReplayedMetadataKey:
+		return textproto.CanonicalMIMEHeaderKey(key), true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
@@ -0,0 +1,154 @@
+package gapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/ratelimit"
+	"github.com/DamianZhang/957-lending-platform/service"
+	mocksvc "github.com/DamianZhang/957-lending-platform/service/mock"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGateway(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user, password := randomUser(t)
+
+	userSvc := mocksvc.NewMockUserService(ctrl)
+	userSvc.EXPECT().
+		SignIn(gomock.Any(), gomock.Eq(&service.SignInInput{Email: user.Email, Password: password})).
+		Times(1).
+		Return(&service.SignInOutput{User: user}, nil)
+	userSvc.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(&service.GetUserInput{UserID: user.ID})).
+		Times(1).
+		Return(&service.GetUserOutput{User: user}, nil)
+
+	server := newTestServer(t, nil, userSvc, nil)
+	server.config.GRPCServerAddress = freeAddress(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Start(ctx)
+	}()
+	defer func() {
+		cancel()
+		require.NoError(t, <-stopped)
+	}()
+
+	gateway, err := NewGateway(ctx, server.config.GRPCServerAddress)
+	require.NoError(t, err)
+
+	// the gateway speaks JSON with the field names of the protobuf definitions
+	body := `{"email":"` + user.Email + `","password":"` + password + `"}`
+	var recorder *httptest.ResponseRecorder
+	// retry until the server listens
+	require.Eventually(t, func() bool {
+		request := httptest.NewRequest(http.MethodPost, "/v1/users/sign_in", strings.NewReader(body))
+		recorder = httptest.NewRecorder()
+		gateway.ServeHTTP(recorder, request)
+		return recorder.Code != http.StatusServiceUnavailable
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var signInRsp map[string]any
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &signInRsp))
+	accessToken, ok := signInRsp["access_token"].(string)
+	require.True(t, ok)
+
+	// the authorization header reaches the auth interceptor
+	request := httptest.NewRequest(http.MethodGet, "/v1/me", nil)
+	recorder = httptest.NewRecorder()
+	gateway.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	request = httptest.NewRequest(http.MethodGet, "/v1/me", nil)
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+	recorder = httptest.NewRecorder()
+	gateway.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var getMeRsp map[string]map[string]any
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &getMeRsp))
+	require.Equal(t, user.LineID, getMeRsp["user"]["line_id"])
+}
+
+func TestGatewayRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userSvc := mocksvc.NewMockUserService(ctrl)
+	userSvc.EXPECT().
+		SignIn(gomock.Any(), gomock.Any()).
+		Times(2).
+		Return(nil, service.NewError(service.ErrUnauthenticated, errors.New("wrong password")))
+
+	server := newTestServer(t, nil, userSvc, nil)
+	server.config.GRPCServerAddress = freeAddress(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Start(ctx)
+	}()
+	defer func() {
+		cancel()
+		require.NoError(t, <-stopped)
+	}()
+
+	gateway, err := NewGateway(ctx, server.config.GRPCServerAddress)
+	require.NoError(t, err)
+
+	signIn := func(clientIP string) *httptest.ResponseRecorder {
+		body := `{"email":"` + util.RandomEmail() + `","password":"secret123"}`
+		request := httptest.NewRequest(http.MethodPost, "/v1/users/sign_in", strings.NewReader(body))
+		request.Header.Set("X-Forwarded-For", clientIP)
+		recorder := httptest.NewRecorder()
+		gateway.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// retry until the server listens, before the policy counts the calls
+	require.Eventually(t, func() bool {
+		request := httptest.NewRequest(http.MethodGet, "/v1/me", nil)
+		recorder := httptest.NewRecorder()
+		gateway.ServeHTTP(recorder, request)
+		return recorder.Code != http.StatusServiceUnavailable
+	}, 5*time.Second, 10*time.Millisecond)
+
+	server.rateLimiter.SetPolicies(map[string]ratelimit.Policy{
+		rateLimitPolicySignIn: {Name: rateLimitPolicySignIn, Limit: 1, Window: time.Hour, Key: ratelimit.KeyIP},
+	})
+
+	recorder := signIn("203.0.113.1")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Equal(t, "0", recorder.Header().Get("RateLimit-Remaining"))
+
+	recorder = signIn("203.0.113.1")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+
+	// the calls are counted by the client IP forwarded by the gateway, not by the address of the gateway
+	recorder = signIn("203.0.113.2")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func freeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	return listener.Addr().String()
+}
@@ -0,0 +1,440 @@
+package gapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/logging"
+	"github.com/DamianZhang/957-lending-platform/pb"
+	"github.com/DamianZhang/957-lending-platform/ratelimit"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/token"
+	"github.com/google/uuid"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Metadata keys read by the interceptors, gRPC metadata keys are lowercase
+const (
+	authorizationMetadataKey  = "authorization"
+	authorizationTypeBearer   = "bearer"
+	requestIDMetadataKey      = "x-request-id"
+	acceptLanguageMetadataKey = "accept-language"
+	idempotencyKeyMetadataKey = "idempotency-key"
+	// forwardedForMetadataKey carries the IP of the clients of the requests proxied by the gateway
+	forwardedForMetadataKey = "x-forwarded-for"
+)
+
+// Metadata keys of the responses, as the headers of the HTTP API
+const (
+	rateLimitPolicyMetadataKey    = "ratelimit-policy"
+	rateLimitLimitMetadataKey     = "ratelimit-limit"
+	rateLimitRemainingMetadataKey = "ratelimit-remaining"
+	rateLimitResetMetadataKey     = "ratelimit-reset"
+	retryAfterMetadataKey         = "retry-after"
+	// idempotentReplayedMetadataKey marks the responses replayed from a previous attempt of the request
+	idempotentReplayedMetadataKey = "idempotent-replayed"
+)
+
+// Names of the rate limit policies of the methods, which are the policies of their HTTP routes
+const (
+	rateLimitPolicySignUp = "sign_up"
+	rateLimitPolicySignIn = "sign_in"
+)
+
+// maxIdempotencyKeyLength bounds the keys chosen by clients, UUIDs are recommended
+const maxIdempotencyKeyLength = 255
+
+// idempotentResponseContentType is the content type of the responses stored for the retries of a method,
+// which are messages in the protobuf wire format
+const idempotentResponseContentType = "application/x-protobuf"
+
+// requestIDPattern restricts the request IDs accepted from clients, so that they cannot forge log lines
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// publicMethods can be called without an access token
+var publicMethods = map[string]bool{
+	pb.BorrowerService_SignUp_FullMethodName: true,
+	pb.UserService_SignIn_FullMethodName:     true,
+}
+
+// methodRateLimitPolicies maps the methods to the name of the rate limit policy they are limited by
+var methodRateLimitPolicies = map[string]string{
+	pb.BorrowerService_SignUp_FullMethodName: rateLimitPolicySignUp,
+	pb.UserService_SignIn_FullMethodName:     rateLimitPolicySignIn,
+}
+
+// idempotentMethods maps the methods which clients can retry with an idempotency key
+// to the constructor of their response, which the stored responses are replayed into
+var idempotentMethods = map[string]func() proto.Message{
+	pb.BorrowerService_SignUp_FullMethodName: func() proto.Message { return &pb.SignUpResponse{} },
+}
+
+type contextKey int
+
+const (
+	authorizationPayloadKey contextKey = iota
+	localizerKey
+)
+
+// requestLogInterceptor logs every handled request with its status and latency,
+// tagging the records logged while handling it with its request ID
+func requestLogInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		requestID := metadataValue(ctx, requestIDMetadataKey)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+		ctx = logging.WithRequestID(ctx, requestID)
+		grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadataKey, requestID))
+
+		rsp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		level := slog.LevelInfo
+		if code == codes.Internal || code == codes.Unknown {
+			level = slog.LevelError
+		}
+
+		logger.LogAttrs(ctx, level, "request handled",
+			slog.String("method", info.FullMethod),
+			slog.String("status", code.String()),
+			slog.Float64("latency_ms", float64(time.Since(start))/float64(time.Millisecond)),
+		)
+		return rsp, err
+	}
+}
+
+// localeInterceptor negotiates the language of the messages of the errors reported to the client
+func localeInterceptor(bundle *i18n.Bundle) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		loc := bundle.Localizer(i18n.Negotiate(metadataValue(ctx, acceptLanguageMetadataKey)))
+		return handler(context.WithValue(ctx, localizerKey, loc), req)
+	}
+}
+
+// localizer returns the localizer stored by localeInterceptor
+func localizer(ctx context.Context) *i18n.Localizer {
+	return ctx.Value(localizerKey).(*i18n.Localizer)
+}
+
+// maintenanceInterceptor rejects the requests while the maintenance mode is on,
+// it must come after localeInterceptor to localize the error
+func maintenanceInterceptor(maintenance *atomic.Bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if maintenance.Load() {
+			return nil, errorStatus(ctx, codes.Unavailable, "unavailable", i18n.NewMessage("request.maintenance"))
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authInterceptor authenticates the requests to the methods which are not public by their access token,
+// which is sent as a bearer credential of the authorization metadata
+func authInterceptor(tokenMaker token.Maker) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		accessToken, msg := bearerToken(ctx)
+		if !msg.IsZero() {
+			return nil, errorStatus(ctx, codes.Unauthenticated, "unauthenticated", msg)
+		}
+
+		payload, err := tokenMaker.VerifyToken(accessToken)
+		if err != nil {
+			msg := i18n.NewMessage("auth.token_invalid")
+			if errors.Is(err, token.ErrExpiredToken) {
+				msg = i18n.NewMessage("auth.token_expired")
+			}
+			return nil, errorStatus(ctx, codes.Unauthenticated, "unauthenticated", msg)
+		}
+
+		return handler(context.WithValue(ctx, authorizationPayloadKey, payload), req)
+	}
+}
+
+// rateLimitInterceptor enforces the rate limit policies of the methods with the same limiter as the HTTP routes,
+// so that a client is counted once whichever API it calls. It must come after authInterceptor
+// when the requests are counted by user. Requests are not limited when the policy is not configured,
+// nor when the limiter cannot count them, as the HTTP routes are not either.
+func rateLimitInterceptor(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		policyName, ok := methodRateLimitPolicies[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		policy, ok := limiter.Policy(policyName)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		result, err := limiter.Allow(ctx, policy, rateLimitKey(ctx, policy.Key))
+		if err != nil {
+			slog.ErrorContext(ctx, "can not apply rate limit", "policy", policy.Name, "err", err)
+			return handler(ctx, req)
+		}
+
+		md := metadata.Pairs(
+			rateLimitPolicyMetadataKey, policy.String(),
+			rateLimitLimitMetadataKey, strconv.FormatInt(result.Limit, 10),
+			rateLimitRemainingMetadataKey, strconv.FormatInt(result.Remaining, 10),
+			rateLimitResetMetadataKey, strconv.FormatInt(ceilSeconds(result.Reset), 10),
+		)
+
+		if !result.Allowed {
+			retryAfter := ceilSeconds(result.RetryAfter)
+			md.Set(retryAfterMetadataKey, strconv.FormatInt(retryAfter, 10))
+			grpc.SetHeader(ctx, md)
+
+			msg := i18n.NewMessage("request.rate_limited").With("retry_after", retryAfter)
+			return nil, errorStatus(ctx, codes.ResourceExhausted, "rate_limited", msg)
+		}
+
+		grpc.SetHeader(ctx, md)
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitKey returns what the requests are counted by, as the HTTP routes count them.
+// Anonymous requests are counted by IP even when the policy counts them by user or API key.
+func rateLimitKey(ctx context.Context, key string) string {
+	if key == ratelimit.KeyUser {
+		if payload, ok := ctx.Value(authorizationPayloadKey).(*token.Payload); ok {
+			return "user:" + payload.UserID.String()
+		}
+	}
+	return "ip:" + clientIP(ctx)
+}
+
+// clientIP returns the IP of the client of a request. The gateway runs in the same process
+// and dials the gRPC server over loopback, so the client IP it forwards is only trusted from a loopback peer.
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		if forwardedFor := metadataValue(ctx, forwardedForMetadataKey); forwardedFor != "" {
+			client, _, _ := strings.Cut(forwardedFor, ",")
+			return strings.TrimSpace(client)
+		}
+	}
+	return host
+}
+
+// ceilSeconds rounds a duration up to whole seconds, as the RateLimit headers expect
+func ceilSeconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
+}
+
+// idempotencyInterceptor lets clients retry the calls of the idempotent methods with an idempotency-key metadata
+// without applying them twice, sharing the stored responses and their rules with the HTTP routes.
+// The outcome of the first attempt is stored and replayed to the retries, while a key reused for another request
+// or a retry sent while the first attempt is still being handled is rejected.
+// It must come after authInterceptor, as the keys belong to the client sending them.
+// The outcomes of server errors are not stored, so that the call can be retried once the server has recovered.
+func idempotencyInterceptor(idempotencyService service.IdempotencyService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		newResponse, ok := idempotentMethods[info.FullMethod]
+		key := metadataValue(ctx, idempotencyKeyMetadataKey)
+		if !ok || key == "" {
+			return handler(ctx, req)
+		}
+
+		if len(key) > maxIdempotencyKeyLength || !isVisibleASCII(key) {
+			msg := i18n.NewMessage("idempotency.key_invalid").With("max", maxIdempotencyKeyLength)
+			return nil, errorStatus(ctx, codes.InvalidArgument, "invalid_request", msg)
+		}
+
+		fingerprint, err := requestFingerprint(info.FullMethod, req)
+		if err != nil {
+			return nil, fromServiceError(ctx, err)
+		}
+
+		scope := idempotencyScope(ctx)
+		output, err := idempotencyService.BeginRequest(ctx, &service.BeginIdempotentRequestInput{
+			Scope:       scope,
+			Key:         key,
+			Fingerprint: fingerprint,
+		})
+		if err != nil {
+			if errors.Is(err, service.ErrRequestInProgress) {
+				grpc.SetHeader(ctx, metadata.Pairs(retryAfterMetadataKey, "1"))
+			}
+			return nil, fromServiceError(ctx, err)
+		}
+
+		if replay := output.Replay; replay != nil {
+			grpc.SetHeader(ctx, metadata.Pairs(idempotentReplayedMetadataKey, "true"))
+			return replayedResponse(ctx, replay, newResponse)
+		}
+
+		rsp, err := handler(ctx, req)
+
+		response, marshalErr := idempotentResponse(rsp, err)
+		if marshalErr != nil {
+			slog.ErrorContext(ctx, "can not store idempotent response", "err", marshalErr)
+		}
+		if marshalErr != nil || response.StatusCode >= http.StatusInternalServerError {
+			abandonErr := idempotencyService.AbandonRequest(ctx, &service.AbandonIdempotentRequestInput{
+				Scope: scope,
+				Key:   key,
+			})
+			if abandonErr != nil {
+				slog.ErrorContext(ctx, "can not abandon idempotent request", "err", abandonErr)
+			}
+			return rsp, err
+		}
+
+		completeErr := idempotencyService.CompleteRequest(ctx, &service.CompleteIdempotentRequestInput{
+			Scope:    scope,
+			Key:      key,
+			Response: response,
+		})
+		if completeErr != nil {
+			slog.ErrorContext(ctx, "can not complete idempotent request", "err", completeErr)
+		}
+		return rsp, err
+	}
+}
+
+// idempotencyScope returns the client an idempotency key belongs to, so that clients never replay
+// the responses of each other
+func idempotencyScope(ctx context.Context) string {
+	if payload, ok := ctx.Value(authorizationPayloadKey).(*token.Payload); ok {
+		return "user:" + payload.UserID.String()
+	}
+	return "ip:" + clientIP(ctx)
+}
+
+// requestFingerprint identifies a request by its method and message
+func requestFingerprint(method string, req any) (string, error) {
+	message, err := proto.MarshalOptions{Deterministic: true}.Marshal(req.(proto.Message))
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(method + "\n"))
+	hash.Write(message)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// idempotentResponse turns the outcome of a call into the response stored for its retries,
+// which holds the message of the response when the call succeeds, or else its status.
+// It is stored with the HTTP status code of the outcome, which tells the server errors apart.
+func idempotentResponse(rsp any, err error) (service.IdempotentResponse, error) {
+	st := status.Convert(err)
+
+	var message proto.Message = st.Proto()
+	if err == nil {
+		message = rsp.(proto.Message)
+	}
+
+	body, marshalErr := proto.Marshal(message)
+	if marshalErr != nil {
+		return service.IdempotentResponse{}, marshalErr
+	}
+
+	response := service.IdempotentResponse{
+		StatusCode:  runtime.HTTPStatusFromCode(st.Code()),
+		ContentType: idempotentResponseContentType,
+		Body:        body,
+	}
+	return response, nil
+}
+
+// replayedResponse turns a stored response back into the outcome of the call
+func replayedResponse(ctx context.Context, replay *service.IdempotentResponse, newResponse func() proto.Message) (any, error) {
+	if replay.StatusCode == http.StatusOK {
+		rsp := newResponse()
+		err := proto.Unmarshal(replay.Body, rsp)
+		if err != nil {
+			return nil, fromServiceError(ctx, err)
+		}
+		return rsp, nil
+	}
+
+	st := &spb.Status{}
+	err := proto.Unmarshal(replay.Body, st)
+	if err != nil {
+		return nil, fromServiceError(ctx, err)
+	}
+	return nil, status.ErrorProto(st)
+}
+
+func isVisibleASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '!' || s[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// bearerToken extracts the bearer credential of the authorization metadata,
+// or explains why the metadata does not hold one
+func bearerToken(ctx context.Context) (string, i18n.Message) {
+	authorization := metadataValue(ctx, authorizationMetadataKey)
+	if len(authorization) == 0 {
+		return "", i18n.NewMessage("auth.header_missing")
+	}
+
+	fields := strings.Fields(authorization)
+	if len(fields) < 2 {
+		return "", i18n.NewMessage("auth.header_invalid_format")
+	}
+
+	authorizationType := strings.ToLower(fields[0])
+	if authorizationType != authorizationTypeBearer {
+		return "", i18n.NewMessage("auth.unsupported_type").With("type", authorizationType)
+	}
+
+	return fields[1], i18n.Message{}
+}
+
+// authPayload returns the token payload stored by authInterceptor
+func authPayload(ctx context.Context) *token.Payload {
+	return ctx.Value(authorizationPayloadKey).(*token.Payload)
+}
+
+// metadataValue returns the first value of the incoming metadata with the given key, if any
+func metadataValue(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
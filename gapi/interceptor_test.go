@@ -0,0 +1,271 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/pb"
+	"github.com/DamianZhang/957-lending-platform/ratelimit"
+	"github.com/DamianZhang/957-lending-platform/service"
+	mocksvc "github.com/DamianZhang/957-lending-platform/service/mock"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRateLimitRPC(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	req := &pb.SignInRequest{Email: util.RandomEmail(), Password: "secret123"}
+
+	userSvc := mocksvc.NewMockUserService(ctrl)
+	userSvc.EXPECT().
+		SignIn(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil, service.NewError(service.ErrUnauthenticated, errors.New("wrong password")))
+
+	server := newTestServer(t, nil, userSvc, nil)
+	server.rateLimiter.SetPolicies(map[string]ratelimit.Policy{
+		rateLimitPolicySignIn: {Name: rateLimitPolicySignIn, Limit: 1, Window: time.Hour, Key: ratelimit.KeyIP},
+	})
+	client := pb.NewUserServiceClient(dialTestServer(t, server))
+
+	var header metadata.MD
+	_, err := client.SignIn(context.Background(), req, grpc.Header(&header))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.Equal(t, []string{"1;w=3600"}, header.Get(rateLimitPolicyMetadataKey))
+	require.Equal(t, []string{"0"}, header.Get(rateLimitRemainingMetadataKey))
+
+	// the second attempt never reaches the service
+	_, err = client.SignIn(context.Background(), req, grpc.Header(&header))
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.ResourceExhausted, st.Code())
+	requireReason(t, st, "rate_limited")
+	require.Len(t, header.Get(retryAfterMetadataKey), 1)
+}
+
+func TestIdempotencyRPC(t *testing.T) {
+	key := util.RandomString(16)
+	req := &pb.SignUpRequest{
+		Email:    util.RandomEmail(),
+		Password: "secret123",
+		LineId:   util.RandomString(6),
+		Nickname: util.RandomString(6),
+	}
+	borrower := db.User{
+		Email:    req.Email,
+		LineID:   req.LineId,
+		Nickname: req.Nickname,
+		Role:     util.BorrowerRole,
+	}
+
+	storedRsp, err := proto.Marshal(&pb.SignUpResponse{Borrower: convertUser(borrower)})
+	require.NoError(t, err)
+	storedStatus, err := proto.Marshal(status.New(codes.AlreadyExists, "email already exists").Proto())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		key        string
+		buildStubs func(borrowerSvc *mocksvc.MockBorrowerService, idempotencySvc *mocksvc.MockIdempotencyService)
+		checkRsp   func(t *testing.T, rsp *pb.SignUpResponse, header metadata.MD, err error)
+	}{
+		{
+			name: "OK",
+			key:  key,
+			buildStubs: func(borrowerSvc *mocksvc.MockBorrowerService, idempotencySvc *mocksvc.MockIdempotencyService) {
+				idempotencySvc.EXPECT().
+					BeginRequest(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, input *service.BeginIdempotentRequestInput) (*service.BeginIdempotentRequestOutput, error) {
+						require.Equal(t, key, input.Key)
+						require.Equal(t, "ip:bufconn", input.Scope)
+						require.NotEmpty(t, input.Fingerprint)
+						return &service.BeginIdempotentRequestOutput{}, nil
+					})
+				borrowerSvc.EXPECT().
+					SignUp(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(&service.SignUpOutput{Borrower: borrower}, nil)
+				idempotencySvc.EXPECT().
+					CompleteRequest(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, input *service.CompleteIdempotentRequestInput) error {
+						require.Equal(t, http.StatusOK, input.Response.StatusCode)
+
+						var rsp pb.SignUpResponse
+						require.NoError(t, proto.Unmarshal(input.Response.Body, &rsp))
+						require.Equal(t, req.Email, rsp.GetBorrower().GetEmail())
+						return nil
+					})
+			},
+			checkRsp: func(t *testing.T, rsp *pb.SignUpResponse, header metadata.MD, err error) {
+				require.NoError(t, err)
+				require.Equal(t, req.Email, rsp.GetBorrower().GetEmail())
+				require.Empty(t, header.Get(idempotentReplayedMetadataKey))
+			},
+		},
+		{
+			name: "NoKey",
+			buildStubs: func(borrowerSvc *mocksvc.MockBorrowerService, idempotencySvc *mocksvc.MockIdempotencyService) {
+				idempotencySvc.EXPECT().
+					BeginRequest(gomock.Any(), gomock.Any()).
+					Times(0)
+				borrowerSvc.EXPECT().
+					SignUp(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(&service.SignUpOutput{Borrower: borrower}, nil)
+			},
+			checkRsp: func(t *testing.T, rsp *pb.SignUpResponse, header metadata.MD, err error) {
+				require.NoError(t, err)
+				require.Equal(t, req.Email, rsp.GetBorrower().GetEmail())
+			},
+		},
+		{
+			name: "KeyInvalid",
+			key:  "not a key",
+			buildStubs: func(borrowerSvc *mocksvc.MockBorrowerService, idempotencySvc *mocksvc.MockIdempotencyService) {
+				idempotencySvc.EXPECT().
+					BeginRequest(gomock.Any(), gomock.Any()).
+					Times(0)
+				borrowerSvc.EXPECT().
+					SignUp(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(t *testing.T, rsp *pb.SignUpResponse, header metadata.MD, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+				requireReason(t, st, "invalid_request")
+			},
+		},
+		{
+			name: "Replayed",
+			key:  key,
+			buildStubs: func(borrowerSvc *mocksvc.MockBorrowerService, idempotencySvc *mocksvc.MockIdempotencyService) {
+				idempotencySvc.EXPECT().
+					BeginRequest(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(&service.BeginIdempotentRequestOutput{
+						Replay: &service.IdempotentResponse{
+							StatusCode:  http.StatusOK,
+							ContentType: idempotentResponseContentType,
+							Body:        storedRsp,
+						},
+					}, nil)
+				borrowerSvc.EXPECT().
+					SignUp(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(t *testing.T, rsp *pb.SignUpResponse, header metadata.MD, err error) {
+				require.NoError(t, err)
+				require.Equal(t, req.Email, rsp.GetBorrower().GetEmail())
+				require.Equal(t, []string{"true"}, header.Get(idempotentReplayedMetadataKey))
+			},
+		},
+		{
+			name: "ReplayedError",
+			key:  key,
+			buildStubs: func(borrowerSvc *mocksvc.MockBorrowerService, idempotencySvc *mocksvc.MockIdempotencyService) {
+				idempotencySvc.EXPECT().
+					BeginRequest(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(&service.BeginIdempotentRequestOutput{
+						Replay: &service.IdempotentResponse{
+							StatusCode:  http.StatusConflict,
+							ContentType: idempotentResponseContentType,
+							Body:        storedStatus,
+						},
+					}, nil)
+				borrowerSvc.EXPECT().
+					SignUp(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(t *testing.T, rsp *pb.SignUpResponse, header metadata.MD, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.AlreadyExists, st.Code())
+				require.Equal(t, "email already exists", st.Message())
+				require.Equal(t, []string{"true"}, header.Get(idempotentReplayedMetadataKey))
+			},
+		},
+		{
+			name: "InProgress",
+			key:  key,
+			buildStubs: func(borrowerSvc *mocksvc.MockBorrowerService, idempotencySvc *mocksvc.MockIdempotencyService) {
+				idempotencySvc.EXPECT().
+					BeginRequest(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewError(service.ErrRequestInProgress, errors.New("request in progress")))
+				borrowerSvc.EXPECT().
+					SignUp(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(t *testing.T, rsp *pb.SignUpResponse, header metadata.MD, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Aborted, st.Code())
+				requireReason(t, st, "request_in_progress")
+				require.Equal(t, []string{"1"}, header.Get(retryAfterMetadataKey))
+			},
+		},
+		{
+			name: "ServerError",
+			key:  key,
+			buildStubs: func(borrowerSvc *mocksvc.MockBorrowerService, idempotencySvc *mocksvc.MockIdempotencyService) {
+				idempotencySvc.EXPECT().
+					BeginRequest(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(&service.BeginIdempotentRequestOutput{}, nil)
+				borrowerSvc.EXPECT().
+					SignUp(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewError(service.ErrInternalFailure, errors.New("connection done")))
+				idempotencySvc.EXPECT().
+					AbandonRequest(gomock.Any(), gomock.Eq(&service.AbandonIdempotentRequestInput{Scope: "ip:bufconn", Key: key})).
+					Times(1).
+					Return(nil)
+				idempotencySvc.EXPECT().
+					CompleteRequest(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(t *testing.T, rsp *pb.SignUpResponse, header metadata.MD, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			borrowerSvc := mocksvc.NewMockBorrowerService(ctrl)
+			idempotencySvc := mocksvc.NewMockIdempotencyService(ctrl)
+			tc.buildStubs(borrowerSvc, idempotencySvc)
+
+			server := newTestServer(t, borrowerSvc, nil, idempotencySvc)
+			client := pb.NewBorrowerServiceClient(dialTestServer(t, server))
+
+			ctx := context.Background()
+			if tc.key != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, idempotencyKeyMetadataKey, tc.key)
+			}
+
+			var header metadata.MD
+			rsp, err := client.SignUp(ctx, req, grpc.Header(&header))
+			tc.checkRsp(t, rsp, header, err)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/ratelimit"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestServer(
+	t *testing.T,
+	borrowerService service.BorrowerService,
+	userService service.UserService,
+	idempotencyService service.IdempotencyService,
+) *Server {
+	config := util.Config{
+		HTTPConfig: util.HTTPConfig{
+			ShutdownTimeout: time.Second,
+		},
+		AuthConfig: util.AuthConfig{
+			TokenSymmetricKey:   util.RandomString(32),
+			AccessTokenDuration: time.Minute,
+		},
+	}
+
+	server, err := NewServer(config, borrowerService, userService, idempotencyService, ratelimit.NewLimiter(ratelimit.NewMemoryStore(), nil))
+	require.NoError(t, err)
+
+	return server
+}
+
+// dialTestServer serves the server over an in-memory connection until the test ends
+// and returns a client connection to it
+func dialTestServer(t *testing.T, server *Server) *grpc.ClientConn {
+	listener := bufconn.Listen(1 << 20)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.serve(ctx, listener)
+	}()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+		require.NoError(t, <-stopped)
+	})
+
+	return conn
+}
@@ -0,0 +1,128 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/pb"
+	"github.com/DamianZhang/957-lending-platform/ratelimit"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/token"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// Server serves gRPC requests for our lending platform.
+// It delegates to the same services as the HTTP server.
+type Server struct {
+	pb.UnimplementedBorrowerServiceServer
+	pb.UnimplementedUserServiceServer
+	config          util.Config
+	grpcServer      *grpc.Server
+	tokenMaker      token.Maker
+	bundle          *i18n.Bundle
+	borrowerService service.BorrowerService
+	userService     service.UserService
+	// idempotencyService and rateLimiter are shared with the HTTP server
+	idempotencyService service.IdempotencyService
+	rateLimiter        *ratelimit.Limiter
+	// maintenance is set while the API is under maintenance
+	maintenance atomic.Bool
+}
+
+// NewServer creates a new gRPC server and registers its services
+func NewServer(
+	config util.Config,
+	borrowerService service.BorrowerService,
+	userService service.UserService,
+	idempotencyService service.IdempotencyService,
+	rateLimiter *ratelimit.Limiter,
+) (*Server, error) {
+	tokenMaker, err := token.NewJWTMaker(config.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %w", err)
+	}
+
+	bundle, err := i18n.NewBundle()
+	if err != nil {
+		return nil, fmt.Errorf("cannot load message catalogues: %w", err)
+	}
+
+	server := &Server{
+		config:             config,
+		tokenMaker:         tokenMaker,
+		bundle:             bundle,
+		borrowerService:    borrowerService,
+		userService:        userService,
+		idempotencyService: idempotencyService,
+		rateLimiter:        rateLimiter,
+	}
+	server.maintenance.Store(config.MaintenanceMode)
+
+	server.grpcServer = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			requestLogInterceptor(slog.Default()),
+			localeInterceptor(bundle),
+			maintenanceInterceptor(&server.maintenance),
+			authInterceptor(tokenMaker),
+			rateLimitInterceptor(rateLimiter),
+			idempotencyInterceptor(idempotencyService),
+		),
+	)
+	pb.RegisterBorrowerServiceServer(server.grpcServer, server)
+	pb.RegisterUserServiceServer(server.grpcServer, server)
+	reflection.Register(server.grpcServer)
+
+	return server, nil
+}
+
+// SetMaintenanceMode turns the maintenance mode of the API on or off while the server runs
+func (server *Server) SetMaintenanceMode(enabled bool) {
+	server.maintenance.Store(enabled)
+}
+
+// Start runs the gRPC server on the configured address until the context is cancelled.
+// It then stops accepting connections and drains the in-flight requests,
+// giving up on them once the shutdown timeout has passed.
+func (server *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", server.config.GRPCServerAddress)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s: %w", server.config.GRPCServerAddress, err)
+	}
+
+	return server.serve(ctx, listener)
+}
+
+func (server *Server) serve(ctx context.Context, listener net.Listener) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.grpcServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		server.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(server.config.ShutdownTimeout):
+		server.grpcServer.Stop()
+		return fmt.Errorf("cannot drain in-flight requests within %s", server.config.ShutdownTimeout)
+	}
+
+	return <-serveErr
+}
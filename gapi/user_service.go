@@ -0,0 +1,69 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/pb"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (server *Server) SignIn(ctx context.Context, req *pb.SignInRequest) (*pb.SignInResponse, error) {
+	if err := validateSignInRequest(ctx, req); err != nil {
+		return nil, err
+	}
+
+	input := &service.SignInInput{
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+	}
+
+	output, err := server.userService.SignIn(ctx, input)
+	if err != nil {
+		return nil, fromServiceError(ctx, err)
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
+		output.User.ID,
+		output.User.Role,
+		server.config.AccessTokenDuration,
+	)
+	if err != nil {
+		msg := i18n.NewMessage("token.create_failed").With("reason", err.Error())
+		return nil, errorStatus(ctx, codes.Internal, "internal_failure", msg)
+	}
+
+	rsp := &pb.SignInResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: timestamppb.New(accessPayload.ExpiredAt),
+		User:                 convertUser(output.User),
+	}
+	return rsp, nil
+}
+
+func (server *Server) GetMe(ctx context.Context, req *pb.GetMeRequest) (*pb.GetMeResponse, error) {
+	payload := authPayload(ctx)
+
+	input := &service.GetUserInput{
+		UserID: payload.UserID,
+	}
+
+	output, err := server.userService.GetUser(ctx, input)
+	if err != nil {
+		return nil, fromServiceError(ctx, err)
+	}
+
+	rsp := &pb.GetMeResponse{
+		User: convertUser(output.User),
+	}
+	return rsp, nil
+}
+
+func validateSignInRequest(ctx context.Context, req *pb.SignInRequest) error {
+	v := newFieldValidator(ctx)
+	v.email("email", req.GetEmail())
+	v.required("password", req.GetPassword())
+	return v.err(ctx)
+}
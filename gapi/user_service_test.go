@@ -0,0 +1,275 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/pb"
+	"github.com/DamianZhang/957-lending-platform/service"
+	mocksvc "github.com/DamianZhang/957-lending-platform/service/mock"
+	"github.com/DamianZhang/957-lending-platform/token"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestSignInRPC(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		req        *pb.SignInRequest
+		buildStubs func(svc *mocksvc.MockUserService)
+		checkRsp   func(t *testing.T, server *Server, rsp *pb.SignInResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.SignInRequest{Email: user.Email, Password: password},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				input := &service.SignInInput{
+					Email:    user.Email,
+					Password: password,
+				}
+				svc.EXPECT().
+					SignIn(gomock.Any(), gomock.Eq(input)).
+					Times(1).
+					Return(&service.SignInOutput{User: user}, nil)
+			},
+			checkRsp: func(t *testing.T, server *Server, rsp *pb.SignInResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user.Email, rsp.GetUser().GetEmail())
+
+				payload, err := server.tokenMaker.VerifyToken(rsp.GetAccessToken())
+				require.NoError(t, err)
+				require.Equal(t, user.ID, payload.UserID)
+				require.WithinDuration(t, payload.ExpiredAt, rsp.GetAccessTokenExpiresAt().AsTime(), time.Second)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			req:  &pb.SignInRequest{Email: "invalid-email", Password: password},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				svc.EXPECT().
+					SignIn(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(t *testing.T, server *Server, rsp *pb.SignInResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "ServiceUnauthenticated",
+			req:  &pb.SignInRequest{Email: user.Email, Password: password},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				svc.EXPECT().
+					SignIn(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewError(service.ErrUnauthenticated, nil))
+			},
+			checkRsp: func(t *testing.T, server *Server, rsp *pb.SignInResponse, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+				requireReason(t, st, "unauthenticated")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc := mocksvc.NewMockUserService(ctrl)
+			tc.buildStubs(userSvc)
+
+			server := newTestServer(t, nil, userSvc, nil)
+			client := pb.NewUserServiceClient(dialTestServer(t, server))
+
+			rsp, err := client.SignIn(context.Background(), tc.req)
+			tc.checkRsp(t, server, rsp, err)
+		})
+	}
+}
+
+func TestMaintenanceRPC(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userSvc := mocksvc.NewMockUserService(ctrl)
+	userSvc.EXPECT().
+		SignIn(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	server := newTestServer(t, nil, userSvc, nil)
+	server.SetMaintenanceMode(true)
+	client := pb.NewUserServiceClient(dialTestServer(t, server))
+
+	_, err := client.SignIn(context.Background(), &pb.SignInRequest{Email: util.RandomEmail(), Password: "secret123"})
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.Unavailable, st.Code())
+	requireReason(t, st, "unavailable")
+}
+
+func TestGetMeRPC(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		setupAuth  func(t *testing.T, ctx context.Context, tokenMaker token.Maker) context.Context
+		buildStubs func(svc *mocksvc.MockUserService)
+		checkRsp   func(t *testing.T, rsp *pb.GetMeResponse, err error)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, ctx context.Context, tokenMaker token.Maker) context.Context {
+				return addAuthorization(t, ctx, tokenMaker, authorizationTypeBearer, user.ID, user.Role, time.Minute)
+			},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				svc.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(&service.GetUserInput{UserID: user.ID})).
+					Times(1).
+					Return(&service.GetUserOutput{User: user}, nil)
+			},
+			checkRsp: func(t *testing.T, rsp *pb.GetMeResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user.Email, rsp.GetUser().GetEmail())
+				require.Equal(t, user.Role, rsp.GetUser().GetRole())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, ctx context.Context, tokenMaker token.Maker) context.Context {
+				return ctx
+			},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				svc.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(t *testing.T, rsp *pb.GetMeResponse, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+				require.Equal(t, "authorization header is not provided", st.Message())
+			},
+		},
+		{
+			name: "UnsupportedAuthorization",
+			setupAuth: func(t *testing.T, ctx context.Context, tokenMaker token.Maker) context.Context {
+				return addAuthorization(t, ctx, tokenMaker, "unsupported", user.ID, user.Role, time.Minute)
+			},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				svc.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(t *testing.T, rsp *pb.GetMeResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "ExpiredToken",
+			setupAuth: func(t *testing.T, ctx context.Context, tokenMaker token.Maker) context.Context {
+				return addAuthorization(t, ctx, tokenMaker, authorizationTypeBearer, user.ID, user.Role, -time.Minute)
+			},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				svc.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkRsp: func(t *testing.T, rsp *pb.GetMeResponse, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+				require.Equal(t, "token has expired", st.Message())
+			},
+		},
+		{
+			name: "ServiceAccountInactiveLocalized",
+			setupAuth: func(t *testing.T, ctx context.Context, tokenMaker token.Maker) context.Context {
+				ctx = metadata.AppendToOutgoingContext(ctx, acceptLanguageMetadataKey, "zh-TW")
+				return addAuthorization(t, ctx, tokenMaker, authorizationTypeBearer, user.ID, user.Role, time.Minute)
+			},
+			buildStubs: func(svc *mocksvc.MockUserService) {
+				svc.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NewErrorWithDetail(service.ErrAccountInactive, i18n.NewMessage("user.account_closed")))
+			},
+			checkRsp: func(t *testing.T, rsp *pb.GetMeResponse, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+				requireReason(t, st, "account_inactive")
+
+				bundle, err := i18n.NewBundle()
+				require.NoError(t, err)
+				require.Equal(t, bundle.Localizer(i18n.ZHTW).T("user.account_closed"), st.Message())
+				require.NotEqual(t, "account is closed", st.Message())
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc := mocksvc.NewMockUserService(ctrl)
+			tc.buildStubs(userSvc)
+
+			server := newTestServer(t, nil, userSvc, nil)
+			client := pb.NewUserServiceClient(dialTestServer(t, server))
+
+			ctx := tc.setupAuth(t, context.Background(), server.tokenMaker)
+			rsp, err := client.GetMe(ctx, &pb.GetMeRequest{})
+			tc.checkRsp(t, rsp, err)
+		})
+	}
+}
+
+func addAuthorization(
+	t *testing.T,
+	ctx context.Context,
+	tokenMaker token.Maker,
+	authorizationType string,
+	userID uuid.UUID,
+	role string,
+	duration time.Duration,
+) context.Context {
+	token, payload, err := tokenMaker.CreateToken(userID, role, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	authorization := fmt.Sprintf("%s %s", authorizationType, token)
+	return metadata.AppendToOutgoingContext(ctx, authorizationMetadataKey, authorization)
+}
+
+func randomUser(t *testing.T) (user db.User, password string) {
+	password = util.RandomString(6)
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+
+	user = db.User{
+		ID:       uuid.New(),
+		Email:    util.RandomEmail(),
+		LineID:   util.RandomString(6),
+		Nickname: util.RandomString(6),
+
+		HashedPassword: hashedPassword,
+		Role:           util.RandomRole(),
+		Status:         util.ActiveStatus,
+		KYCStatus:      util.KYCUnverified,
+	}
+	return
+}
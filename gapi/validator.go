@@ -0,0 +1,84 @@
+package gapi
+
+import (
+	"context"
+	"net/mail"
+
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// Bounds of the fields of the requests, the same as the ones of the HTTP API
+const (
+	minNicknameLength = 1
+	maxNicknameLength = 20
+)
+
+// fieldValidator collects the fields of a request which failed validation,
+// with their messages in the language negotiated with the client
+type fieldValidator struct {
+	loc        *i18n.Localizer
+	violations []*errdetails.BadRequest_FieldViolation
+}
+
+func newFieldValidator(ctx context.Context) *fieldValidator {
+	return &fieldValidator{loc: localizer(ctx)}
+}
+
+func (v *fieldValidator) fail(field string, msg i18n.Message) {
+	v.violations = append(v.violations, &errdetails.BadRequest_FieldViolation{
+		Field:       field,
+		Description: v.loc.Localize(msg.With("field", field)),
+	})
+}
+
+func (v *fieldValidator) required(field, value string) bool {
+	if value == "" {
+		v.fail(field, i18n.NewMessage("validation.required"))
+		return false
+	}
+	return true
+}
+
+func (v *fieldValidator) email(field, value string) {
+	if !v.required(field, value) {
+		return
+	}
+
+	address, err := mail.ParseAddress(value)
+	if err != nil || address.Address != value {
+		v.fail(field, i18n.NewMessage("validation.email"))
+	}
+}
+
+func (v *fieldValidator) lineID(field, value string) {
+	if v.required(field, value) && !util.LineIDRegexp.MatchString(value) {
+		v.fail(field, i18n.NewMessage("validation.line_id"))
+	}
+}
+
+func (v *fieldValidator) nickname(field, value string) {
+	if !v.required(field, value) {
+		return
+	}
+
+	for _, r := range value {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			v.fail(field, i18n.NewMessage("validation.alphanum"))
+			return
+		}
+	}
+
+	if n := len([]rune(value)); n < minNicknameLength || n > maxNicknameLength {
+		v.fail(field, i18n.NewMessage("validation.length").With("min", minNicknameLength).With("max", maxNicknameLength))
+	}
+}
+
+// err reports the fields which failed validation, if any
+func (v *fieldValidator) err(ctx context.Context) error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return invalidArgumentError(ctx, v.violations)
+}
@@ -0,0 +1,174 @@
+package i18n
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Supported locales
+const (
+	EN   = "en"
+	ZHTW = "zh-TW"
+	ZHCN = "zh-CN"
+)
+
+// DefaultLocale is used when the client accepts none of the supported locales,
+// and is the fallback of a message missing from another catalogue
+const DefaultLocale = EN
+
+// Locales are the locales a catalogue is shipped for
+var Locales = []string{EN, ZHTW, ZHCN}
+
+//go:embed locales/*.json
+var catalogueFS embed.FS
+
+// Args are the named arguments of a message, referred to as {{.name}} in the catalogues
+type Args map[string]any
+
+// Message is a message to be localised later on, once the locale of the client is known
+type Message struct {
+	Key  string
+	Args Args
+}
+
+// NewMessage creates a message looked up by key in the catalogues
+func NewMessage(key string) Message {
+	return Message{Key: key}
+}
+
+// With returns a copy of the message with the named argument set
+func (m Message) With(name string, value any) Message {
+	args := make(Args, len(m.Args)+1)
+	for k, v := range m.Args {
+		args[k] = v
+	}
+	args[name] = value
+
+	return Message{Key: m.Key, Args: args}
+}
+
+// IsZero reports whether the message is empty
+func (m Message) IsZero() bool {
+	return m.Key == ""
+}
+
+// String describes the message without localising it, which is meant for logs
+func (m Message) String() string {
+	if len(m.Args) == 0 {
+		return m.Key
+	}
+
+	names := make([]string, 0, len(m.Args))
+	for name := range m.Args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString(m.Key)
+	for _, name := range names {
+		fmt.Fprintf(&sb, " %s=%v", name, m.Args[name])
+	}
+	return sb.String()
+}
+
+// catalogue holds the message templates of a locale by key
+type catalogue map[string]*template.Template
+
+// Bundle holds the message catalogues of all supported locales
+type Bundle struct {
+	catalogues map[string]catalogue
+}
+
+// NewBundle loads the message catalogues embedded in the binary.
+// Every message is parsed up front so that a broken template fails the startup rather than a request.
+func NewBundle() (*Bundle, error) {
+	bundle := &Bundle{
+		catalogues: make(map[string]catalogue, len(Locales)),
+	}
+
+	for _, locale := range Locales {
+		cat, err := loadCatalogue(locale)
+		if err != nil {
+			return nil, err
+		}
+		bundle.catalogues[locale] = cat
+	}
+
+	return bundle, nil
+}
+
+func loadCatalogue(locale string) (catalogue, error) {
+	data, err := catalogueFS.ReadFile(path.Join("locales", locale+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s catalogue: %w", locale, err)
+	}
+
+	var messages map[string]string
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("cannot parse %s catalogue: %w", locale, err)
+	}
+
+	cat := make(catalogue, len(messages))
+	for key, text := range messages {
+		tmpl, err := template.New(key).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %s message %s: %w", locale, key, err)
+		}
+		cat[key] = tmpl
+	}
+
+	return cat, nil
+}
+
+// Localizer localises messages into a single locale
+func (b *Bundle) Localizer(locale string) *Localizer {
+	if _, ok := b.catalogues[locale]; !ok {
+		locale = DefaultLocale
+	}
+
+	return &Localizer{
+		bundle: b,
+		locale: locale,
+	}
+}
+
+// Localizer localises messages into the locale negotiated with a client
+type Localizer struct {
+	bundle *Bundle
+	locale string
+}
+
+// Locale returns the locale messages are localised into
+func (l *Localizer) Locale() string {
+	return l.locale
+}
+
+// Localize renders a message in the locale of the localizer.
+// A message missing from the catalogue falls back to the default locale, then to its key.
+func (l *Localizer) Localize(msg Message) string {
+	tmpl, ok := l.bundle.catalogues[l.locale][msg.Key]
+	if !ok {
+		tmpl, ok = l.bundle.catalogues[DefaultLocale][msg.Key]
+	}
+	if !ok {
+		return msg.Key
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, msg.Args); err != nil {
+		return msg.String()
+	}
+	return buf.String()
+}
+
+// T localises the message of the given key
+func (l *Localizer) T(key string) string {
+	return l.Localize(NewMessage(key))
+}
@@ -0,0 +1,168 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var argRegexp = regexp.MustCompile(`{{\s*\.(\w+)\s*}}`)
+
+// readCatalogue returns the raw messages of a catalogue by key
+func readCatalogue(t *testing.T, locale string) map[string]string {
+	bundle, err := NewBundle()
+	require.NoError(t, err)
+
+	cat, ok := bundle.catalogues[locale]
+	require.True(t, ok, "no catalogue for %s", locale)
+
+	messages := make(map[string]string, len(cat))
+	for key, tmpl := range cat {
+		messages[key] = tmpl.Root.String()
+	}
+	return messages
+}
+
+func messageArgs(text string) []string {
+	var args []string
+	for _, match := range argRegexp.FindAllStringSubmatch(text, -1) {
+		args = append(args, match[1])
+	}
+	sort.Strings(args)
+	return args
+}
+
+func TestCataloguesHaveSameKeys(t *testing.T) {
+	defaultMessages := readCatalogue(t, DefaultLocale)
+
+	for _, locale := range Locales {
+		t.Run(locale, func(t *testing.T) {
+			messages := readCatalogue(t, locale)
+
+			for key, text := range defaultMessages {
+				localized, ok := messages[key]
+				if !assertKey(t, ok, "%s catalogue is missing %s", locale, key) {
+					continue
+				}
+				require.Equal(t, messageArgs(text), messageArgs(localized), "arguments of %s differ in %s catalogue", key, locale)
+			}
+
+			for key := range messages {
+				_, ok := defaultMessages[key]
+				assertKey(t, ok, "%s catalogue has %s which is missing from the %s catalogue", locale, key, DefaultLocale)
+			}
+		})
+	}
+}
+
+func assertKey(t *testing.T, ok bool, format string, args ...any) bool {
+	if !ok {
+		t.Errorf(format, args...)
+	}
+	return ok
+}
+
+// TestMessageKeysInCatalogue makes sure every message created in the code base can be localised
+func TestMessageKeysInCatalogue(t *testing.T) {
+	messages := readCatalogue(t, DefaultLocale)
+
+	keys := make(map[string]string)
+	err := filepath.WalkDir("..", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil {
+			return err
+		}
+
+		ast.Inspect(file, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok || len(call.Args) != 1 {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || sel.Sel.Name != "NewMessage" {
+				return true
+			}
+			lit, ok := call.Args[0].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			key, err := strconv.Unquote(lit.Value)
+			if err == nil {
+				keys[key] = path
+			}
+			return true
+		})
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, keys)
+
+	for key, path := range keys {
+		_, ok := messages[key]
+		assertKey(t, ok, "%s uses %s which is missing from the %s catalogue", path, key, DefaultLocale)
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	bundle, err := NewBundle()
+	require.NoError(t, err)
+
+	msg := NewMessage("user.account_inactive").With("status", "suspended")
+
+	require.Equal(t, "account is suspended", bundle.Localizer(EN).Localize(msg))
+	require.Equal(t, "帳號狀態為 suspended", bundle.Localizer(ZHTW).Localize(msg))
+	require.Equal(t, "账户状态为 suspended", bundle.Localizer(ZHCN).Localize(msg))
+
+	// unsupported locales fall back to the default one
+	loc := bundle.Localizer("fr")
+	require.Equal(t, DefaultLocale, loc.Locale())
+	require.Equal(t, "account is suspended", loc.Localize(msg))
+
+	// unknown keys are reported as is
+	require.Equal(t, "unknown.key", loc.T("unknown.key"))
+
+	// missing arguments do not break the response
+	require.Equal(t, "user.account_inactive", loc.T("user.account_inactive"))
+}
+
+func TestNegotiate(t *testing.T) {
+	testCases := []struct {
+		acceptLanguage string
+		expected       string
+	}{
+		{acceptLanguage: "", expected: EN},
+		{acceptLanguage: "en-US,en;q=0.9", expected: EN},
+		{acceptLanguage: "zh-TW,zh;q=0.9,en;q=0.8", expected: ZHTW},
+		{acceptLanguage: "zh-Hant-HK", expected: ZHTW},
+		{acceptLanguage: "zh", expected: ZHTW},
+		{acceptLanguage: "zh-CN", expected: ZHCN},
+		{acceptLanguage: "zh-Hans", expected: ZHCN},
+		{acceptLanguage: "fr-FR,fr;q=0.9", expected: EN},
+		{acceptLanguage: "fr-FR,zh-CN;q=0.5", expected: ZHCN},
+		{acceptLanguage: "en;q=0.3,zh-TW;q=0.7", expected: ZHTW},
+		{acceptLanguage: "zh-TW;q=0,en", expected: EN},
+		{acceptLanguage: "*", expected: EN},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.acceptLanguage, func(t *testing.T) {
+			require.Equal(t, tc.expected, Negotiate(tc.acceptLanguage))
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// chineseScripts maps the regions and scripts of Chinese to the catalogue written in the same script
+var chineseScripts = map[string]string{
+	"hant": ZHTW,
+	"tw":   ZHTW,
+	"hk":   ZHTW,
+	"mo":   ZHTW,
+	"hans": ZHCN,
+	"cn":   ZHCN,
+	"sg":   ZHCN,
+	"my":   ZHCN,
+}
+
+// languageRange is a language of an Accept-Language header along with its weight
+type languageRange struct {
+	tag     string
+	quality float64
+}
+
+// Negotiate picks the supported locale preferred by a client from its Accept-Language header.
+// A bare "zh" is served in Traditional Chinese, which most of our users read.
+func Negotiate(acceptLanguage string) string {
+	for _, lr := range parseAcceptLanguage(acceptLanguage) {
+		if locale, ok := matchLocale(lr.tag); ok {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
+
+func matchLocale(tag string) (string, bool) {
+	if tag == "*" {
+		return DefaultLocale, true
+	}
+
+	subtags := strings.Split(strings.ToLower(tag), "-")
+	switch subtags[0] {
+	case "en":
+		return EN, true
+	case "zh":
+		for _, subtag := range subtags[1:] {
+			if locale, ok := chineseScripts[subtag]; ok {
+				return locale, true
+			}
+		}
+		return ZHTW, true
+	}
+
+	return "", false
+}
+
+// parseAcceptLanguage parses the language ranges of the header, most preferred first.
+// Ranges with a zero or malformed weight are left out.
+func parseAcceptLanguage(header string) []languageRange {
+	var ranges []languageRange
+
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality <= 0 {
+			continue
+		}
+
+		ranges = append(ranges, languageRange{tag: tag, quality: quality})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+	return ranges
+}
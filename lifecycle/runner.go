@@ -0,0 +1,111 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Component is a long-running part of the application, such as a server or a background worker.
+// Start blocks until the context is cancelled, then stops the component and returns.
+type Component interface {
+	Start(ctx context.Context) error
+}
+
+type namedComponent struct {
+	name      string
+	component Component
+}
+
+type namedCloser struct {
+	name  string
+	close func()
+}
+
+// Runner runs the components of the application until it receives SIGINT or SIGTERM,
+// or until one of them fails, then shuts the application down in order:
+// the components are stopped one by one in the reverse order they were added,
+// then the closers are run in the reverse order they were added.
+type Runner struct {
+	components []namedComponent
+	closers    []namedCloser
+}
+
+// NewRunner creates a new Runner
+func NewRunner() *Runner {
+	return &Runner{}
+}
+
+// Add adds a component to run. Components added last are stopped first,
+// so servers should be added after the workers they hand work to.
+func (runner *Runner) Add(name string, component Component) {
+	runner.components = append(runner.components, namedComponent{name: name, component: component})
+}
+
+// AddCloser adds a resource to release once all components have stopped, such as the DB pool
+func (runner *Runner) AddCloser(name string, close func()) {
+	runner.closers = append(runner.closers, namedCloser{name: name, close: close})
+}
+
+// Run runs all components until the context is cancelled, a termination signal is received
+// or a component fails, then shuts the application down. It returns the error of the first failed component.
+func (runner *Runner) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	defer runner.close()
+
+	group, groupCtx := errgroup.WithContext(ctx)
+
+	cancels := make([]context.CancelFunc, len(runner.components))
+	stopped := make([]chan struct{}, len(runner.components))
+
+	for i, c := range runner.components {
+		i, c := i, c
+
+		// each component has its own context so that they can be stopped one at a time
+		componentCtx, cancel := context.WithCancel(context.Background())
+		cancels[i] = cancel
+		stopped[i] = make(chan struct{})
+
+		group.Go(func() error {
+			defer close(stopped[i])
+
+			err := c.component.Start(componentCtx)
+			if err != nil {
+				return fmt.Errorf("%s: %w", c.name, err)
+			}
+			if componentCtx.Err() == nil {
+				return fmt.Errorf("%s stopped unexpectedly", c.name)
+			}
+			return nil
+		})
+	}
+
+	group.Go(func() error {
+		<-groupCtx.Done()
+		slog.Info("shutting down")
+
+		for i := len(runner.components) - 1; i >= 0; i-- {
+			cancels[i]()
+			<-stopped[i]
+			slog.Info("component stopped", "component", runner.components[i].name)
+		}
+		return nil
+	})
+
+	return group.Wait()
+}
+
+// close releases the resources in the reverse order they were added
+func (runner *Runner) close() {
+	for i := len(runner.closers) - 1; i >= 0; i-- {
+		runner.closers[i].close()
+		slog.Info("resource closed", "resource", runner.closers[i].name)
+	}
+}
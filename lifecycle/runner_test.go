@@ -0,0 +1,153 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recorder records the order in which components are stopped and resources are closed
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) Events() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+// testComponent runs until its context is cancelled, unless it is told to fail
+type testComponent struct {
+	name     string
+	recorder *recorder
+	started  chan struct{}
+	err      error
+}
+
+func newTestComponent(name string, recorder *recorder) *testComponent {
+	return &testComponent{
+		name:     name,
+		recorder: recorder,
+		started:  make(chan struct{}),
+	}
+}
+
+func (c *testComponent) Start(ctx context.Context) error {
+	close(c.started)
+	if c.err != nil {
+		return c.err
+	}
+
+	<-ctx.Done()
+	c.recorder.record("stop " + c.name)
+	return nil
+}
+
+func runInBackground(ctx context.Context, runner *Runner) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		done <- runner.Run(ctx)
+	}()
+	return done
+}
+
+func waitRun(t *testing.T, done <-chan error) error {
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("runner did not stop")
+		return nil
+	}
+}
+
+func TestRunnerShutdownOrder(t *testing.T) {
+	recorder := &recorder{}
+	worker := newTestComponent("worker", recorder)
+	server := newTestComponent("server", recorder)
+
+	runner := NewRunner()
+	runner.AddCloser("pool", func() { recorder.record("close pool") })
+	runner.AddCloser("cache", func() { recorder.record("close cache") })
+	runner.Add("worker", worker)
+	runner.Add("server", server)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := runInBackground(ctx, runner)
+
+	<-worker.started
+	<-server.started
+	cancel()
+
+	require.NoError(t, waitRun(t, done))
+	require.Equal(t, []string{"stop server", "stop worker", "close cache", "close pool"}, recorder.Events())
+}
+
+func TestRunnerComponentFails(t *testing.T) {
+	recorder := &recorder{}
+	worker := newTestComponent("worker", recorder)
+	server := newTestComponent("server", recorder)
+	server.err = errors.New("address already in use")
+
+	runner := NewRunner()
+	runner.AddCloser("pool", func() { recorder.record("close pool") })
+	runner.Add("worker", worker)
+	runner.Add("server", server)
+
+	done := runInBackground(context.Background(), runner)
+
+	err := waitRun(t, done)
+	require.ErrorIs(t, err, server.err)
+	require.ErrorContains(t, err, "server: address already in use")
+	require.Equal(t, []string{"stop worker", "close pool"}, recorder.Events())
+}
+
+type returningComponent struct{}
+
+func (returningComponent) Start(ctx context.Context) error {
+	return nil
+}
+
+func TestRunnerComponentStopsUnexpectedly(t *testing.T) {
+	recorder := &recorder{}
+	worker := newTestComponent("worker", recorder)
+
+	runner := NewRunner()
+	runner.Add("worker", worker)
+	runner.Add("server", returningComponent{})
+
+	done := runInBackground(context.Background(), runner)
+
+	err := waitRun(t, done)
+	require.EqualError(t, err, "server stopped unexpectedly")
+	require.Equal(t, []string{"stop worker"}, recorder.Events())
+}
+
+func TestRunnerSignal(t *testing.T) {
+	recorder := &recorder{}
+	server := newTestComponent("server", recorder)
+
+	runner := NewRunner()
+	runner.Add("server", server)
+
+	done := runInBackground(context.Background(), runner)
+
+	<-server.started
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGTERM))
+
+	require.NoError(t, waitRun(t, done))
+	require.Equal(t, []string{"stop server"}, recorder.Events())
+}
@@ -0,0 +1,93 @@
+package listing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/google/uuid"
+)
+
+const minSigningKeySize = 32
+
+// Key is the position of a row in a list ordered by creation time,
+// the ID breaking the ties between rows created at the same time
+type Key struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        uuid.UUID `json:"id"`
+}
+
+// Cursor points past the last row of a page.
+// It records the sort of the list, so that it cannot be used to page through the list in another order.
+type Cursor struct {
+	Sort string `json:"sort"`
+	Key  Key    `json:"key"`
+}
+
+// CursorCodec encodes cursors into opaque strings signed with HMAC-SHA256,
+// so that clients can neither read nor forge them
+type CursorCodec struct {
+	signingKey []byte
+}
+
+// NewCursorCodec creates a new CursorCodec
+func NewCursorCodec(signingKey string) (*CursorCodec, error) {
+	if len(signingKey) < minSigningKeySize {
+		return nil, fmt.Errorf("invalid key size: must be at least %d characters", minSigningKeySize)
+	}
+	return &CursorCodec{signingKey: []byte(signingKey)}, nil
+}
+
+// Encode encodes the cursor pointing past the key of a list in the given sort
+func (codec *CursorCodec) Encode(sort Sort, key Key) (string, error) {
+	payload, err := json.Marshal(Cursor{Sort: sort.String(), Key: key})
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	signature := base64.RawURLEncoding.EncodeToString(codec.sign(payload))
+	return encoded + "." + signature, nil
+}
+
+// Decode decodes a cursor of a list in the given sort.
+// It returns no key for an empty cursor, which starts from the first page.
+func (codec *CursorCodec) Decode(s string, sort Sort) (*Key, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	invalid := newError(i18n.NewMessage("listing.invalid_cursor"))
+
+	encoded, encodedSignature, ok := strings.Cut(s, ".")
+	if !ok {
+		return nil, invalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, invalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, codec.sign(payload)) {
+		return nil, invalid
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(payload, &cursor); err != nil || cursor.Sort != sort.String() {
+		return nil, invalid
+	}
+
+	return &cursor.Key, nil
+}
+
+func (codec *CursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, codec.signingKey)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,80 @@
+package listing
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCodec(t *testing.T) *CursorCodec {
+	codec, err := NewCursorCodec(util.RandomString(32))
+	require.NoError(t, err)
+	return codec
+}
+
+func TestNewCursorCodecInvalidKeySize(t *testing.T) {
+	codec, err := NewCursorCodec(util.RandomString(31))
+	require.Error(t, err)
+	require.Nil(t, codec)
+}
+
+func TestCursorCodec(t *testing.T) {
+	codec := newTestCodec(t)
+	sort := Sort{Field: "created_at", Desc: true}
+	key := Key{CreatedAt: time.Now().UTC().Truncate(time.Microsecond), ID: uuid.New()}
+
+	cursor, err := codec.Encode(sort, key)
+	require.NoError(t, err)
+	require.NotContains(t, cursor, key.ID.String())
+
+	decoded, err := codec.Decode(cursor, sort)
+	require.NoError(t, err)
+	require.Equal(t, key.ID, decoded.ID)
+	require.True(t, key.CreatedAt.Equal(decoded.CreatedAt))
+
+	decoded, err = codec.Decode("", sort)
+	require.NoError(t, err)
+	require.Nil(t, decoded)
+}
+
+func TestCursorCodecInvalid(t *testing.T) {
+	codec := newTestCodec(t)
+	sort := Sort{Field: "created_at"}
+
+	cursor, err := codec.Encode(sort, Key{CreatedAt: time.Now(), ID: uuid.New()})
+	require.NoError(t, err)
+	encoded, signature, _ := strings.Cut(cursor, ".")
+
+	otherCursor, err := newTestCodec(t).Encode(sort, Key{CreatedAt: time.Now(), ID: uuid.New()})
+	require.NoError(t, err)
+	otherEncoded, _, _ := strings.Cut(otherCursor, ".")
+
+	testCases := []struct {
+		name   string
+		cursor string
+		sort   Sort
+	}{
+		{name: "Malformed", cursor: "not-a-cursor", sort: sort},
+		{name: "InvalidEncoding", cursor: "!!!." + signature, sort: sort},
+		{name: "Tampered", cursor: otherEncoded + "." + signature, sort: sort},
+		{name: "SignedWithAnotherKey", cursor: otherCursor, sort: sort},
+		{name: "TruncatedSignature", cursor: encoded + "." + signature[:10], sort: sort},
+		{name: "OtherSort", cursor: cursor, sort: Sort{Field: "created_at", Desc: true}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := codec.Decode(tc.cursor, tc.sort)
+			require.Nil(t, key)
+
+			var listingErr *Error
+			require.True(t, errors.As(err, &listingErr))
+			require.Equal(t, "listing.invalid_cursor", listingErr.Message.Key)
+		})
+	}
+}
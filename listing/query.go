@@ -0,0 +1,201 @@
+package listing
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/i18n"
+)
+
+// Operators a filter can compare a field with
+const (
+	Eq       = "eq"
+	Gte      = "gte"
+	Lte      = "lte"
+	In       = "in"
+	Contains = "contains"
+)
+
+// Reserved query parameters, which are not filters
+const (
+	CursorParam = "cursor"
+	LimitParam  = "limit"
+	SortParam   = "sort"
+)
+
+// Kind is the type of the values of a field
+type Kind int
+
+const (
+	String Kind = iota
+	Bool
+	Time
+)
+
+// Field describes a field a list can be filtered by
+type Field struct {
+	Kind      Kind
+	Operators []string
+	// Values allow-lists the values of a string field, any value is accepted when empty
+	Values []string
+}
+
+// Sort is the order of a list, by a single field
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// String formats the sort the way it is given as a query parameter, such as -created_at
+func (s Sort) String() string {
+	if s.Desc {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// Filter is a condition on a field parsed from the query parameters.
+// Its values are strings, bools or times depending on the kind of the field.
+type Filter struct {
+	Field    string
+	Operator string
+	Values   []any
+}
+
+// Spec allow-lists the filters and sorts of a list endpoint
+type Spec struct {
+	Fields       map[string]Field
+	SortFields   []string
+	DefaultSort  Sort
+	DefaultLimit int32
+	MaxLimit     int32
+}
+
+// Query is the filters, sort and page of a list request
+type Query struct {
+	Filters []Filter
+	Sort    Sort
+	Limit   int32
+	Cursor  string
+}
+
+// Filter returns the filter on the field with the operator, if any
+func (q *Query) Filter(field, operator string) (Filter, bool) {
+	for _, filter := range q.Filters {
+		if filter.Field == field && filter.Operator == operator {
+			return filter, true
+		}
+	}
+	return Filter{}, false
+}
+
+// Error reports a query which does not match the spec, with a message meant for the client
+type Error struct {
+	Message i18n.Message
+}
+
+func (e *Error) Error() string {
+	return e.Message.String()
+}
+
+func newError(msg i18n.Message) error {
+	return &Error{Message: msg}
+}
+
+// Parse parses the query parameters of a list request.
+// A filter is given as field=value, which compares with eq, or as field[operator]=value;
+// the in operator takes comma-separated values.
+// The sort is given as sort=field, or sort=-field for the descending order.
+func (spec *Spec) Parse(params map[string]string) (*Query, error) {
+	query := &Query{
+		Sort:   spec.DefaultSort,
+		Limit:  spec.DefaultLimit,
+		Cursor: params[CursorParam],
+	}
+
+	if value, ok := params[SortParam]; ok {
+		s := Sort{Field: strings.TrimPrefix(value, "-"), Desc: strings.HasPrefix(value, "-")}
+		if !slices.Contains(spec.SortFields, s.Field) {
+			return nil, newError(i18n.NewMessage("listing.invalid_sort").With("sort", value))
+		}
+		query.Sort = s
+	}
+
+	if value, ok := params[LimitParam]; ok {
+		limit, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || limit < 1 || int32(limit) > spec.MaxLimit {
+			return nil, newError(i18n.NewMessage("listing.invalid_limit").With("max", spec.MaxLimit))
+		}
+		query.Limit = int32(limit)
+	}
+
+	// parse the filters in a stable order, so that the same query always reports the same error
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if key != CursorParam && key != SortParam && key != LimitParam {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		filter, err := spec.parseFilter(key, params[key])
+		if err != nil {
+			return nil, err
+		}
+		query.Filters = append(query.Filters, filter)
+	}
+
+	return query, nil
+}
+
+func (spec *Spec) parseFilter(key, value string) (Filter, error) {
+	name, operator := key, Eq
+	if i := strings.IndexByte(key, '['); i > 0 && strings.HasSuffix(key, "]") {
+		name, operator = key[:i], key[i+1:len(key)-1]
+	}
+
+	field, ok := spec.Fields[name]
+	if !ok {
+		return Filter{}, newError(i18n.NewMessage("listing.unknown_filter").With("field", name))
+	}
+	if !slices.Contains(field.Operators, operator) {
+		msg := i18n.NewMessage("listing.unsupported_operator").With("field", name).With("operator", operator)
+		return Filter{}, newError(msg)
+	}
+
+	raw := []string{value}
+	if operator == In {
+		raw = strings.Split(value, ",")
+	}
+
+	filter := Filter{Field: name, Operator: operator, Values: make([]any, 0, len(raw))}
+	for _, s := range raw {
+		v, ok := field.parseValue(s)
+		if !ok {
+			return Filter{}, newError(i18n.NewMessage("listing.invalid_value").With("field", name).With("value", s))
+		}
+		filter.Values = append(filter.Values, v)
+	}
+
+	return filter, nil
+}
+
+func (field Field) parseValue(s string) (any, bool) {
+	switch field.Kind {
+	case Bool:
+		b, err := strconv.ParseBool(s)
+		return b, err == nil
+	case Time:
+		t, err := time.Parse(time.RFC3339, s)
+		return t, err == nil
+	default:
+		if s == "" || len(field.Values) > 0 && !slices.Contains(field.Values, s) {
+			return nil, false
+		}
+		return s, true
+	}
+}
@@ -0,0 +1,94 @@
+package listing
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testSpec = &Spec{
+	Fields: map[string]Field{
+		"email":      {Kind: String, Operators: []string{Eq, Contains}},
+		"role":       {Kind: String, Operators: []string{Eq, In}, Values: []string{"borrower", "lender"}},
+		"verified":   {Kind: Bool, Operators: []string{Eq}},
+		"created_at": {Kind: Time, Operators: []string{Gte, Lte}},
+	},
+	SortFields:   []string{"created_at"},
+	DefaultSort:  Sort{Field: "created_at"},
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+func TestParse(t *testing.T) {
+	query, err := testSpec.Parse(map[string]string{
+		"email[contains]": "bob",
+		"role[in]":        "borrower,lender",
+		"verified":        "true",
+		"created_at[gte]": "2024-01-02T03:04:05Z",
+		"sort":            "-created_at",
+		"limit":           "50",
+		"cursor":          "abc",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, Sort{Field: "created_at", Desc: true}, query.Sort)
+	require.Equal(t, int32(50), query.Limit)
+	require.Equal(t, "abc", query.Cursor)
+	require.Equal(t, []Filter{
+		{Field: "created_at", Operator: Gte, Values: []any{time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}},
+		{Field: "email", Operator: Contains, Values: []any{"bob"}},
+		{Field: "role", Operator: In, Values: []any{"borrower", "lender"}},
+		{Field: "verified", Operator: Eq, Values: []any{true}},
+	}, query.Filters)
+
+	filter, ok := query.Filter("role", In)
+	require.True(t, ok)
+	require.Equal(t, []any{"borrower", "lender"}, filter.Values)
+
+	_, ok = query.Filter("role", Eq)
+	require.False(t, ok)
+}
+
+func TestParseDefaults(t *testing.T) {
+	query, err := testSpec.Parse(map[string]string{})
+	require.NoError(t, err)
+	require.Equal(t, &Query{Sort: Sort{Field: "created_at"}, Limit: 20}, query)
+}
+
+func TestParseInvalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		params map[string]string
+		key    string
+	}{
+		{name: "UnknownFilter", params: map[string]string{"nickname": "bob"}, key: "listing.unknown_filter"},
+		{name: "UnsupportedOperator", params: map[string]string{"email[in]": "a,b"}, key: "listing.unsupported_operator"},
+		{name: "ValueNotAllowed", params: map[string]string{"role": "admin"}, key: "listing.invalid_value"},
+		{name: "OneOfValuesNotAllowed", params: map[string]string{"role[in]": "borrower,admin"}, key: "listing.invalid_value"},
+		{name: "EmptyValue", params: map[string]string{"email": ""}, key: "listing.invalid_value"},
+		{name: "InvalidBool", params: map[string]string{"verified": "maybe"}, key: "listing.invalid_value"},
+		{name: "InvalidTime", params: map[string]string{"created_at[gte]": "yesterday"}, key: "listing.invalid_value"},
+		{name: "InvalidSort", params: map[string]string{"sort": "email"}, key: "listing.invalid_sort"},
+		{name: "InvalidLimit", params: map[string]string{"limit": "ten"}, key: "listing.invalid_limit"},
+		{name: "ZeroLimit", params: map[string]string{"limit": "0"}, key: "listing.invalid_limit"},
+		{name: "LimitTooLarge", params: map[string]string{"limit": "101"}, key: "listing.invalid_limit"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := testSpec.Parse(tc.params)
+			require.Nil(t, query)
+
+			var listingErr *Error
+			require.True(t, errors.As(err, &listingErr))
+			require.Equal(t, tc.key, listingErr.Message.Key)
+		})
+	}
+}
+
+func TestSortString(t *testing.T) {
+	require.Equal(t, "created_at", Sort{Field: "created_at"}.String())
+	require.Equal(t, "-created_at", Sort{Field: "created_at", Desc: true}.String())
+}
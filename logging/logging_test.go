@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	config := util.Config{
+		ObservabilityConfig: util.ObservabilityConfig{LogFormat: "json"},
+		RuntimeConfig:       util.RuntimeConfig{LogLevel: "warn"},
+	}
+	level := new(slog.LevelVar)
+	logger, err := New(config, level, &buf)
+	require.NoError(t, err)
+	require.Equal(t, slog.LevelWarn, level.Level())
+
+	logger.Info("ignored")
+	require.Zero(t, buf.Len())
+
+	logger.Warn("sign in failed", "email", "john@example.com", "password", "Secret123!")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "WARN", record["level"])
+	require.Equal(t, "sign in failed", record["msg"])
+	require.Equal(t, "j***@example.com", record["email"])
+	require.Equal(t, Redacted, record["password"])
+
+	// the level can be changed while the logger is in use
+	buf.Reset()
+	level.Set(slog.LevelInfo)
+	logger.Info("logged")
+	require.NotZero(t, buf.Len())
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	_, err := New(util.Config{RuntimeConfig: util.RuntimeConfig{LogLevel: "verbose"}}, new(slog.LevelVar), &bytes.Buffer{})
+	require.ErrorContains(t, err, `invalid log level "verbose"`)
+
+	_, err = New(util.Config{ObservabilityConfig: util.ObservabilityConfig{LogFormat: "xml"}}, new(slog.LevelVar), &bytes.Buffer{})
+	require.EqualError(t, err, `unknown log format "xml"`)
+}
+
+func TestContextAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(util.Config{}, new(slog.LevelVar), &buf)
+	require.NoError(t, err)
+
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+
+	logger.With("component", "test").InfoContext(ctx, "hello")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "req-1", record[RequestIDKey])
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record[TraceIDKey])
+	require.Equal(t, "test", record["component"])
+}
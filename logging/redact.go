@@ -0,0 +1,122 @@
+package logging
+
+import (
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the values which must never be logged
+const Redacted = "[REDACTED]"
+
+// secretKeyParts are parts of the keys whose values are secrets, such as passwords and tokens
+var secretKeyParts = []string{"password", "token", "secret", "authorization", "signature", "signing_key", "api_key", "cookie"}
+
+// emailPattern matches the email addresses written in free text, such as error messages
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// Redact is a slog.HandlerOptions.ReplaceAttr function which removes secrets and masks personal data.
+// The value of an attribute named after a secret is replaced altogether,
+// emails and LINE IDs are masked, and so are the emails found in any other string.
+func Redact(groups []string, attr slog.Attr) slog.Attr {
+	attr.Value = redactValue(attr.Key, attr.Value.Resolve())
+	return attr
+}
+
+func redactValue(key string, value slog.Value) slog.Value {
+	switch {
+	case isSecretKey(key):
+		return slog.StringValue(Redacted)
+	case isKey(key, "email"):
+		return slog.StringValue(MaskEmail(value.String()))
+	case isKey(key, "line_id"):
+		return slog.StringValue(MaskLineID(value.String()))
+	}
+
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.StringValue(maskEmails(value.String()))
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok {
+			return slog.StringValue(maskEmails(err.Error()))
+		}
+	}
+	return value
+}
+
+// RedactJSON redacts a JSON document, such as the body of a request, with the same rules as Redact.
+// It reports false when the document is not JSON, in which case it must not be logged at all.
+func RedactJSON(document []byte) (any, bool) {
+	var value any
+	err := json.Unmarshal(document, &value)
+	if err != nil {
+		return nil, false
+	}
+	return redactJSONValue("", value), true
+}
+
+func redactJSONValue(key string, value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = redactJSONValue(k, item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = redactJSONValue(key, item)
+		}
+		return v
+	case string:
+		return redactValue(key, slog.StringValue(v)).String()
+	case nil:
+		return nil
+	default:
+		if isSecretKey(key) {
+			return Redacted
+		}
+		return v
+	}
+}
+
+// MaskEmail keeps the first letter and the domain of an email, which is enough to tell users apart
+// when investigating an issue, e.g. j***@example.com
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return mask(email)
+	}
+	return mask(email[:at]) + email[at:]
+}
+
+// MaskLineID keeps the first letter of a LINE ID
+func MaskLineID(lineID string) string {
+	return mask(lineID)
+}
+
+func maskEmails(s string) string {
+	return emailPattern.ReplaceAllStringFunc(s, MaskEmail)
+}
+
+func mask(s string) string {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return ""
+	}
+	return string(runes[:1]) + "***"
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range secretKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return key == "key"
+}
+
+func isKey(key string, name string) bool {
+	return strings.EqualFold(key, name)
+}
@@ -0,0 +1,111 @@
+package logging
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedact(t *testing.T) {
+	testCases := []struct {
+		name     string
+		attr     slog.Attr
+		expected slog.Value
+	}{
+		{
+			name:     "Password",
+			attr:     slog.String("password", "Secret123!"),
+			expected: slog.StringValue(Redacted),
+		},
+		{
+			name:     "NewPassword",
+			attr:     slog.String("new_password", "Secret123!"),
+			expected: slog.StringValue(Redacted),
+		},
+		{
+			name:     "AccessToken",
+			attr:     slog.String("access_token", "v2.local.abc"),
+			expected: slog.StringValue(Redacted),
+		},
+		{
+			name:     "Authorization",
+			attr:     slog.String("Authorization", "Bearer abc"),
+			expected: slog.StringValue(Redacted),
+		},
+		{
+			name:     "NonStringSecret",
+			attr:     slog.Int("token", 42),
+			expected: slog.StringValue(Redacted),
+		},
+		{
+			name:     "Email",
+			attr:     slog.String("email", "john.doe@example.com"),
+			expected: slog.StringValue("j***@example.com"),
+		},
+		{
+			name:     "LineID",
+			attr:     slog.String("line_id", "johndoe"),
+			expected: slog.StringValue("j***"),
+		},
+		{
+			name:     "EmailInMessage",
+			attr:     slog.String(slog.MessageKey, "user john.doe@example.com signed in"),
+			expected: slog.StringValue("user j***@example.com signed in"),
+		},
+		{
+			name:     "EmailInError",
+			attr:     slog.Any("err", errors.New("email jane@example.com is in use")),
+			expected: slog.StringValue("email j***@example.com is in use"),
+		},
+		{
+			name:     "Untouched",
+			attr:     slog.Int("status", 200),
+			expected: slog.IntValue(200),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			attr := Redact(nil, tc.attr)
+			require.Equal(t, tc.attr.Key, attr.Key)
+			require.True(t, tc.expected.Equal(attr.Value), "got %v", attr.Value)
+		})
+	}
+}
+
+func TestRedactJSON(t *testing.T) {
+	body := []byte(`{
+		"email": "john.doe@example.com",
+		"password": "Secret123!",
+		"line_id": "johndoe",
+		"nickname": "john",
+		"profile": {"current_password": "Old123!", "contacts": [{"email": "jane@example.com"}]},
+		"page_size": 10
+	}`)
+
+	redacted, ok := RedactJSON(body)
+	require.True(t, ok)
+	require.Equal(t, map[string]any{
+		"email":    "j***@example.com",
+		"password": Redacted,
+		"line_id":  "j***",
+		"nickname": "john",
+		"profile": map[string]any{
+			"current_password": Redacted,
+			"contacts":         []any{map[string]any{"email": "j***@example.com"}},
+		},
+		"page_size": float64(10),
+	}, redacted)
+
+	_, ok = RedactJSON([]byte("--boundary\r\nContent-Disposition: form-data"))
+	require.False(t, ok)
+}
+
+func TestMaskEmail(t *testing.T) {
+	require.Equal(t, "j***@example.com", MaskEmail("john@example.com"))
+	require.Equal(t, "n***", MaskEmail("not-an-email"))
+	require.Equal(t, "陳***@example.com", MaskEmail("陳大文@example.com"))
+	require.Equal(t, "", MaskEmail(""))
+}
@@ -2,41 +2,349 @@ package main
 
 import (
 	"context"
-	"log"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/DamianZhang/957-lending-platform/api"
+	"github.com/DamianZhang/957-lending-platform/db/migration"
 	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
-	service "github.com/DamianZhang/957-lending-platform/service/impl"
+	"github.com/DamianZhang/957-lending-platform/gapi"
+	"github.com/DamianZhang/957-lending-platform/lifecycle"
+	"github.com/DamianZhang/957-lending-platform/logging"
+	"github.com/DamianZhang/957-lending-platform/metrics"
+	"github.com/DamianZhang/957-lending-platform/ratelimit"
+	"github.com/DamianZhang/957-lending-platform/service"
+	serviceimpl "github.com/DamianZhang/957-lending-platform/service/impl"
+	"github.com/DamianZhang/957-lending-platform/storage"
+	"github.com/DamianZhang/957-lending-platform/tracing"
 	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/DamianZhang/957-lending-platform/worker"
 	"github.com/jackc/pgx/v5/pgxpool"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 func main() {
 	config, err := util.LoadConfig(".")
 	if err != nil {
-		log.Fatal("can not load config:", err)
+		fatal("can not load config", err)
 	}
 
-	connPool, err := pgxpool.New(context.Background(), config.DBSource)
+	logLevel := new(slog.LevelVar)
+	logger, err := logging.New(config, logLevel, os.Stdout)
 	if err != nil {
-		log.Fatal("can not connect to DB:", err)
+		fatal("can not create logger", err)
 	}
+	slog.SetDefault(logger)
+	slog.Info("config loaded", "config", config)
+
+	// manage the migrations of the database schema
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(config.DBSource, os.Args[2:])
+		return
+	}
+
+	err = checkSchema(config.DBConfig)
+	if err != nil {
+		fatal("can not use database schema", err)
+	}
+
+	schemaVersion, err := migration.LatestVersion()
+	if err != nil {
+		fatal("can not read migrations", err)
+	}
+
+	connPool, err := newConnPool(config.DBSource, config.DBConfig)
+	if err != nil {
+		fatal("can not connect to DB", err)
+	}
+
+	var replicaPool *pgxpool.Pool
+	if config.DBReplicaSource != "" {
+		replicaPool, err = newConnPool(config.DBReplicaSource, config.DBConfig)
+		if err != nil {
+			fatal("can not connect to DB replica", err)
+		}
+	}
+
+	// tracing
+	tracerProvider, err := tracing.NewTracerProvider(context.Background(), config)
+	if err != nil {
+		fatal("can not create tracer provider", err)
+	}
+	tracing.SetGlobal(tracerProvider)
+
+	// metrics
+	appMetrics := metrics.New()
+	appMetrics.RegisterDBPool(func() metrics.PoolStat { return connPool.Stat() })
 
 	// store
-	store := db.NewStore(connPool)
+	store := db.NewReplicatedStore(connPool, replicaPool, appMetrics)
+
+	blobStore, err := storage.NewLocalBlobStore(config.BlobStoreDir)
+	if err != nil {
+		fatal("can not create blob store", err)
+	}
+
+	passwordPolicy, err := util.NewPasswordPolicy(config)
+	if err != nil {
+		fatal("can not create password policy", err)
+	}
+
+	// rate limiter
+	rateLimitStore, err := newRateLimitStore(config.RateLimitBackend, store)
+	if err != nil {
+		fatal("can not create rate limit store", err)
+	}
+
+	rateLimitPolicies, err := ratelimit.ParsePolicies(config.RateLimitPolicies)
+	if err != nil {
+		fatal("can not parse rate limit policies", err)
+	}
+	rateLimiter := ratelimit.NewLimiter(rateLimitStore, rateLimitPolicies)
 
 	// service
-	borrowerService := service.NewBorrowerServiceImpl(store)
+	borrowerService := serviceimpl.NewBorrowerServiceImpl(store, passwordPolicy, appMetrics)
+	userService := serviceimpl.NewUserServiceImpl(store, passwordPolicy, appMetrics)
+	adminService := serviceimpl.NewAdminServiceImpl(store, passwordPolicy, appMetrics)
+	privacyService := serviceimpl.NewPrivacyServiceImpl(store, blobStore, config.DataExportDir, config.DataExportTTL)
+	kycService := serviceimpl.NewKYCServiceImpl(store, blobStore)
+	apiKeyService := serviceimpl.NewAPIKeyServiceImpl(store, config.APIKeySigningSecret, config.APISignatureWindow)
+	healthService := serviceimpl.NewHealthServiceImpl(store, int64(schemaVersion))
+	idempotencyService := serviceimpl.NewIdempotencyServiceImpl(store, config.IdempotencyKeyTTL, config.IdempotencyStallTimeout)
+
+	// bootstrap the first admin
+	if len(os.Args) > 1 && os.Args[1] == "create_admin" {
+		runCreateAdmin(adminService, os.Args[2:])
+		closeConnPools(connPool, replicaPool)
+		shutDownTracerProvider(tracerProvider, config.ShutdownTimeout)
+		return
+	}
+
+	// background workers
+	dataExportProcessor := worker.NewDataExportProcessor(privacyService, config.DataExportPollInterval)
+	dataExportPurger := worker.NewDataExportPurger(privacyService, config.DataExportPurgeInterval)
+	apiKeyNoncePurger := worker.NewAPIKeyNoncePurger(apiKeyService, config.APIKeyNoncePurgeInterval)
+	rateLimitPurger := worker.NewRateLimitPurger(rateLimitStore, config.RateLimitPurgeInterval)
+	idempotencyKeyPurger := worker.NewIdempotencyKeyPurger(idempotencyService, config.IdempotencyKeyPurgeInterval)
+
+	// create servers, the gateway serves the gRPC API as JSON through the HTTP server
+	grpcServer, err := gapi.NewServer(config, borrowerService, userService, idempotencyService, rateLimiter)
+	if err != nil {
+		fatal("can not create gRPC server", err)
+	}
 
-	// create server
-	server, err := api.NewServer(borrowerService)
+	gateway, err := gapi.NewGateway(context.Background(), config.GRPCServerAddress)
 	if err != nil {
-		log.Fatal("can not create server:", err)
+		fatal("can not create gRPC gateway", err)
 	}
 
-	// start server
-	err = server.Start(config.HTTPServerAddress)
+	server, err := api.NewServer(config, borrowerService, userService, adminService, privacyService, kycService, apiKeyService, healthService, idempotencyService, appMetrics, rateLimiter, gateway)
 	if err != nil {
-		log.Fatal("can not start server:", err)
+		fatal("can not create server", err)
 	}
+
+	// runtime settings reloaded from the config files
+	configReloader := util.NewConfigReloader(".", config)
+	configReloader.AddCheck(func(runtime util.RuntimeConfig) error {
+		_, err := ratelimit.ParsePolicies(runtime.RateLimitPolicies)
+		return err
+	})
+	configReloader.Subscribe(func(runtime util.RuntimeConfig) {
+		level, err := logging.ParseLevel(runtime.LogLevel)
+		if err == nil {
+			logLevel.Set(level)
+		}
+
+		policies, err := ratelimit.ParsePolicies(runtime.RateLimitPolicies)
+		if err == nil {
+			rateLimiter.SetPolicies(policies)
+		}
+
+		server.SetMaintenanceMode(runtime.MaintenanceMode)
+		grpcServer.SetMaintenanceMode(runtime.MaintenanceMode)
+	})
+
+	// run until SIGINT or SIGTERM: the servers are drained first, then the workers are stopped,
+	// the DB pools are closed, and the spans left are exported last
+	runner := lifecycle.NewRunner()
+	runner.AddCloser("tracer provider", func() { shutDownTracerProvider(tracerProvider, config.ShutdownTimeout) })
+	runner.AddCloser("DB pools", func() { closeConnPools(connPool, replicaPool) })
+	runner.Add("data export processor", dataExportProcessor)
+	runner.Add("data export purger", dataExportPurger)
+	runner.Add("API key nonce purger", apiKeyNoncePurger)
+	runner.Add("rate limit purger", rateLimitPurger)
+	runner.Add("idempotency key purger", idempotencyKeyPurger)
+	runner.Add("config reloader", configReloader)
+	runner.Add("gRPC server", grpcServer)
+	runner.Add("HTTP server", server)
+
+	err = runner.Run(context.Background())
+	if err != nil {
+		fatal("application stopped with error", err)
+	}
+}
+
+// checkSchema applies the pending migrations when auto-migration is on, and refuses to run against a database
+// whose last migration failed halfway, which has pending migrations, or which has been migrated by a newer binary
+func checkSchema(config util.DBConfig) error {
+	migrator, err := migration.NewMigrator(config.DBSource)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	if config.DBAutoMigrate {
+		err = migrator.Up()
+		if err != nil {
+			return fmt.Errorf("cannot apply migrations: %w", err)
+		}
+	}
+
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	return status.Check()
+}
+
+// newConnPool creates a connection pool to the DB source, sized by the config
+func newConnPool(source string, config util.DBConfig) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(source)
+	if err != nil {
+		return nil, err
+	}
+
+	poolConfig.MaxConns = config.DBMaxConns
+	poolConfig.MinConns = config.DBMinConns
+	poolConfig.MaxConnLifetime = config.DBMaxConnLifetime
+	poolConfig.MaxConnIdleTime = config.DBMaxConnIdleTime
+	poolConfig.HealthCheckPeriod = config.DBHealthCheckPeriod
+	if config.DBStatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(config.DBStatementTimeout.Milliseconds(), 10)
+	}
+
+	return pgxpool.NewWithConfig(context.Background(), poolConfig)
+}
+
+// closeConnPools closes the connection pools, the one to the read replica being nil when there is none
+func closeConnPools(connPool *pgxpool.Pool, replicaPool *pgxpool.Pool) {
+	connPool.Close()
+	if replicaPool != nil {
+		replicaPool.Close()
+	}
+}
+
+// newRateLimitStore creates the store of the rate limit counters of the backend,
+// memory for a single instance or postgres to share the limits across instances
+func newRateLimitStore(backend string, store db.Store) (ratelimit.Store, error) {
+	switch backend {
+	case "memory":
+		return ratelimit.NewMemoryStore(), nil
+	case "postgres":
+		return ratelimit.NewPostgresStore(store), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", backend)
+	}
+}
+
+// shutDownTracerProvider exports the spans which have not been exported yet
+func shutDownTracerProvider(tracerProvider *sdktrace.TracerProvider, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := tracerProvider.Shutdown(ctx)
+	if err != nil {
+		slog.Error("can not shut down tracer provider", "err", err)
+	}
+}
+
+// runCreateAdmin creates the first admin of the platform.
+// The password is read from the ADMIN_PASSWORD environment variable
+// so that it does not end up in the shell history.
+func runCreateAdmin(adminService service.AdminService, args []string) {
+	fs := flag.NewFlagSet("create_admin", flag.ExitOnError)
+	email := fs.String("email", "", "email of the admin")
+	lineID := fs.String("line_id", "", "LINE ID of the admin")
+	nickname := fs.String("nickname", "admin", "nickname of the admin")
+	fs.Parse(args)
+
+	password := os.Getenv("ADMIN_PASSWORD")
+	if *email == "" || *lineID == "" || password == "" {
+		fmt.Fprintln(os.Stderr, "usage: ADMIN_PASSWORD=<password> create_admin -email <email> -line_id <line id> [-nickname <nickname>]")
+		os.Exit(2)
+	}
+
+	input := &service.CreateAdminInput{
+		Email:    *email,
+		Password: password,
+		LineID:   *lineID,
+		Nickname: *nickname,
+	}
+
+	output, err := adminService.CreateAdmin(context.Background(), input)
+	if err != nil {
+		fatal("can not create admin", err)
+	}
+
+	slog.Info("admin created", "email", output.Admin.Email)
+}
+
+// runMigrate applies or rolls back the migrations embedded in the binary, or reports the version of the database:
+// migrate up | down [steps] | status | version
+func runMigrate(dbSource string, args []string) {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "usage: migrate up | down [steps] | status | version")
+		os.Exit(2)
+	}
+	if len(args) == 0 {
+		usage()
+	}
+
+	migrator, err := migration.NewMigrator(dbSource)
+	if err != nil {
+		fatal("can not create migrator", err)
+	}
+	defer migrator.Close()
+
+	var status migration.Status
+	switch args[0] {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil {
+				usage()
+			}
+		}
+		err = migrator.Down(steps)
+	case "status":
+		status, err = migrator.Status()
+		if err == nil {
+			slog.Info("migration status", "version", status.Version, "dirty", status.Dirty, "latest", status.Latest, "pending", status.Pending())
+		}
+	case "version":
+		status, err = migrator.Status()
+		if err == nil {
+			fmt.Println(status.Version)
+		}
+	default:
+		usage()
+	}
+
+	if err != nil {
+		migrator.Close()
+		fatal("can not migrate database", err)
+	}
+}
+
+// fatal logs the error which prevents the application from running and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
 }
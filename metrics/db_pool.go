@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolStat is the statistics of a connection pool, as reported by pgxpool.Stat
+type PoolStat interface {
+	AcquiredConns() int32
+	IdleConns() int32
+	TotalConns() int32
+	MaxConns() int32
+	AcquireCount() int64
+	AcquireDuration() time.Duration
+	EmptyAcquireCount() int64
+}
+
+// dbPoolCollector reads the statistics of the connection pool on every scrape
+type dbPoolCollector struct {
+	stat func() PoolStat
+
+	acquiredConns     *prometheus.Desc
+	idleConns         *prometheus.Desc
+	totalConns        *prometheus.Desc
+	maxConns          *prometheus.Desc
+	acquireCount      *prometheus.Desc
+	acquireDuration   *prometheus.Desc
+	emptyAcquireCount *prometheus.Desc
+}
+
+// RegisterDBPool registers the statistics of the connection pool,
+// stat is typically a function returning pgxpool.Pool.Stat()
+func (m *Metrics) RegisterDBPool(stat func() PoolStat) {
+	m.Registry.MustRegister(newDBPoolCollector(stat))
+}
+
+func newDBPoolCollector(stat func() PoolStat) *dbPoolCollector {
+	desc := func(name string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+
+	return &dbPoolCollector{
+		stat:              stat,
+		acquiredConns:     desc("acquired_connections", "Number of connections currently acquired from the pool."),
+		idleConns:         desc("idle_connections", "Number of idle connections in the pool."),
+		totalConns:        desc("total_connections", "Number of connections in the pool, acquired, idle or being opened."),
+		maxConns:          desc("max_connections", "Maximum number of connections of the pool."),
+		acquireCount:      desc("acquires_total", "Number of connections acquired from the pool."),
+		acquireDuration:   desc("acquire_wait_seconds_total", "Time spent waiting to acquire connections from the pool."),
+		emptyAcquireCount: desc("empty_acquires_total", "Number of acquires which had to wait because the pool had no idle connection."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *dbPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquireCount
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquireCount
+}
+
+// Collect implements prometheus.Collector
+func (c *dbPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.stat()
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireCount, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "lending"
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+// Metrics holds the Prometheus collectors of the lending platform.
+// They are registered in a dedicated registry rather than the global one,
+// so that every test gets its own registry and can read the collected values without scraping.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	httpRequests         *prometheus.CounterVec
+	httpRequestDuration  *prometheus.HistogramVec
+	dbQueryDuration      *prometheus.HistogramVec
+	signUps              *prometheus.CounterVec
+	passwordHashDuration prometheus.Histogram
+}
+
+// New creates the collectors of the lending platform and registers them in a new registry,
+// along with the Go runtime and process collectors
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests handled, by method, route template and status.",
+		}, []string{"method", "route", "status"}),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Time taken to handle HTTP requests, by method, route template and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		dbQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "query_duration_seconds",
+			Help:      "Time taken by database queries, by sqlc query name and outcome.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"query", "outcome"}),
+		signUps: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sign_ups_total",
+			Help:      "Number of users signed up, by role.",
+		}, []string{"role"}),
+		passwordHashDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "password_hash_duration_seconds",
+			Help:      "Time taken to hash passwords.",
+			Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5},
+		}),
+	}
+
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpRequestDuration,
+		m.dbQueryDuration,
+		m.signUps,
+		m.passwordHashDuration,
+	)
+	return m
+}
+
+// ObserveHTTPRequest records an HTTP request handled by the route template
+func (m *Metrics) ObserveHTTPRequest(method string, route string, status int, duration time.Duration) {
+	statusLabel := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(method, route, statusLabel).Inc()
+	m.httpRequestDuration.WithLabelValues(method, route, statusLabel).Observe(duration.Seconds())
+}
+
+// ObserveQuery records a database query, it implements db.QueryObserver
+func (m *Metrics) ObserveQuery(name string, duration time.Duration, err error) {
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = OutcomeError
+	}
+	m.dbQueryDuration.WithLabelValues(name, outcome).Observe(duration.Seconds())
+}
+
+// ObserveSignUp records a user signed up with the role
+func (m *Metrics) ObserveSignUp(role string) {
+	m.signUps.WithLabelValues(role).Inc()
+}
+
+// ObservePasswordHash records the time taken to hash a password
+func (m *Metrics) ObservePasswordHash(duration time.Duration) {
+	m.passwordHashDuration.Observe(duration.Seconds())
+}
@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveSignUp(t *testing.T) {
+	m := New()
+	m.ObserveSignUp("borrower")
+	m.ObserveSignUp("borrower")
+	m.ObserveSignUp("admin")
+
+	require.Equal(t, float64(2), testutil.ToFloat64(m.signUps.WithLabelValues("borrower")))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.signUps.WithLabelValues("admin")))
+}
+
+func TestObserveQuery(t *testing.T) {
+	m := New()
+	m.ObserveQuery("GetUserByID", 3*time.Millisecond, nil)
+	m.ObserveQuery("GetUserByID", 4*time.Millisecond, errors.New("connection reset"))
+
+	require.Equal(t, 2, testutil.CollectAndCount(m.dbQueryDuration))
+
+	expected := `
+# HELP lending_db_query_duration_seconds Time taken by database queries, by sqlc query name and outcome.
+# TYPE lending_db_query_duration_seconds histogram
+lending_db_query_duration_seconds_bucket{outcome="error",query="GetUserByID",le="0.001"} 0
+lending_db_query_duration_seconds_bucket{outcome="error",query="GetUserByID",le="0.0025"} 0
+lending_db_query_duration_seconds_bucket{outcome="error",query="GetUserByID",le="0.005"} 1
+lending_db_query_duration_seconds_bucket{outcome="error",query="GetUserByID",le="0.01"} 1
+lending_db_query_duration_seconds_bucket{outcome="error",query="GetUserByID",le="0.025"} 1
+lending_db_query_duration_seconds_bucket{outcome="error",query="GetUserByID",le="0.05"} 1
+lending_db_query_duration_seconds_bucket{outcome="error",query="GetUserByID",le="0.1"} 1
+lending_db_query_duration_seconds_bucket{outcome="error",query="GetUserByID",le="0.25"} 1
+lending_db_query_duration_seconds_bucket{outcome="error",query="GetUserByID",le="0.5"} 1
+lending_db_query_duration_seconds_bucket{outcome="error",query="GetUserByID",le="1"} 1
+lending_db_query_duration_seconds_bucket{outcome="error",query="GetUserByID",le="2.5"} 1
+lending_db_query_duration_seconds_bucket{outcome="error",query="GetUserByID",le="+Inf"} 1
+lending_db_query_duration_seconds_sum{outcome="error",query="GetUserByID"} 0.004
+lending_db_query_duration_seconds_count{outcome="error",query="GetUserByID"} 1
+lending_db_query_duration_seconds_bucket{outcome="success",query="GetUserByID",le="0.001"} 0
+lending_db_query_duration_seconds_bucket{outcome="success",query="GetUserByID",le="0.0025"} 0
+lending_db_query_duration_seconds_bucket{outcome="success",query="GetUserByID",le="0.005"} 1
+lending_db_query_duration_seconds_bucket{outcome="success",query="GetUserByID",le="0.01"} 1
+lending_db_query_duration_seconds_bucket{outcome="success",query="GetUserByID",le="0.025"} 1
+lending_db_query_duration_seconds_bucket{outcome="success",query="GetUserByID",le="0.05"} 1
+lending_db_query_duration_seconds_bucket{outcome="success",query="GetUserByID",le="0.1"} 1
+lending_db_query_duration_seconds_bucket{outcome="success",query="GetUserByID",le="0.25"} 1
+lending_db_query_duration_seconds_bucket{outcome="success",query="GetUserByID",le="0.5"} 1
+lending_db_query_duration_seconds_bucket{outcome="success",query="GetUserByID",le="1"} 1
+lending_db_query_duration_seconds_bucket{outcome="success",query="GetUserByID",le="2.5"} 1
+lending_db_query_duration_seconds_bucket{outcome="success",query="GetUserByID",le="+Inf"} 1
+lending_db_query_duration_seconds_sum{outcome="success",query="GetUserByID"} 0.003
+lending_db_query_duration_seconds_count{outcome="success",query="GetUserByID"} 1
+`
+	err := testutil.GatherAndCompare(m.Registry, strings.NewReader(expected), "lending_db_query_duration_seconds")
+	require.NoError(t, err)
+}
+
+func TestObservePasswordHash(t *testing.T) {
+	m := New()
+	m.ObservePasswordHash(80 * time.Millisecond)
+
+	expected := `
+# HELP lending_password_hash_duration_seconds Time taken to hash passwords.
+# TYPE lending_password_hash_duration_seconds histogram
+lending_password_hash_duration_seconds_bucket{le="0.01"} 0
+lending_password_hash_duration_seconds_bucket{le="0.025"} 0
+lending_password_hash_duration_seconds_bucket{le="0.05"} 0
+lending_password_hash_duration_seconds_bucket{le="0.1"} 1
+lending_password_hash_duration_seconds_bucket{le="0.25"} 1
+lending_password_hash_duration_seconds_bucket{le="0.5"} 1
+lending_password_hash_duration_seconds_bucket{le="1"} 1
+lending_password_hash_duration_seconds_bucket{le="2.5"} 1
+lending_password_hash_duration_seconds_bucket{le="+Inf"} 1
+lending_password_hash_duration_seconds_sum 0.08
+lending_password_hash_duration_seconds_count 1
+`
+	err := testutil.GatherAndCompare(m.Registry, strings.NewReader(expected), "lending_password_hash_duration_seconds")
+	require.NoError(t, err)
+}
+
+type stubPoolStat struct{}
+
+func (stubPoolStat) AcquiredConns() int32           { return 3 }
+func (stubPoolStat) IdleConns() int32               { return 1 }
+func (stubPoolStat) TotalConns() int32              { return 4 }
+func (stubPoolStat) MaxConns() int32                { return 10 }
+func (stubPoolStat) AcquireCount() int64            { return 42 }
+func (stubPoolStat) AcquireDuration() time.Duration { return 1500 * time.Millisecond }
+func (stubPoolStat) EmptyAcquireCount() int64       { return 7 }
+
+func TestRegisterDBPool(t *testing.T) {
+	m := New()
+	m.RegisterDBPool(func() PoolStat { return stubPoolStat{} })
+
+	expected := `
+# HELP lending_db_pool_acquire_wait_seconds_total Time spent waiting to acquire connections from the pool.
+# TYPE lending_db_pool_acquire_wait_seconds_total counter
+lending_db_pool_acquire_wait_seconds_total 1.5
+# HELP lending_db_pool_acquired_connections Number of connections currently acquired from the pool.
+# TYPE lending_db_pool_acquired_connections gauge
+lending_db_pool_acquired_connections 3
+# HELP lending_db_pool_acquires_total Number of connections acquired from the pool.
+# TYPE lending_db_pool_acquires_total counter
+lending_db_pool_acquires_total 42
+# HELP lending_db_pool_empty_acquires_total Number of acquires which had to wait because the pool had no idle connection.
+# TYPE lending_db_pool_empty_acquires_total counter
+lending_db_pool_empty_acquires_total 7
+# HELP lending_db_pool_idle_connections Number of idle connections in the pool.
+# TYPE lending_db_pool_idle_connections gauge
+lending_db_pool_idle_connections 1
+# HELP lending_db_pool_max_connections Maximum number of connections of the pool.
+# TYPE lending_db_pool_max_connections gauge
+lending_db_pool_max_connections 10
+# HELP lending_db_pool_total_connections Number of connections in the pool, acquired, idle or being opened.
+# TYPE lending_db_pool_total_connections gauge
+lending_db_pool_total_connections 4
+`
+	err := testutil.GatherAndCompare(m.Registry, strings.NewReader(expected),
+		"lending_db_pool_acquire_wait_seconds_total",
+		"lending_db_pool_acquired_connections",
+		"lending_db_pool_acquires_total",
+		"lending_db_pool_empty_acquires_total",
+		"lending_db_pool_idle_connections",
+		"lending_db_pool_max_connections",
+		"lending_db_pool_total_connections",
+	)
+	require.NoError(t, err)
+}
@@ -0,0 +1,556 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JSONContentType is the media type of request and response bodies unless an operation says otherwise
+const JSONContentType = "application/json"
+
+// File documents an uploaded or downloaded file, it is never sent as is
+type File struct{}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+	fileType = reflect.TypeOf(File{})
+)
+
+// pathParamPattern matches the parameters of a fiber route, e.g. :id
+var pathParamPattern = regexp.MustCompile(`:(\w+)`)
+
+// Operation describes a route with the Go types it binds its request to and the ones it responds with
+type Operation struct {
+	Method string
+	// Path is the path of the route in fiber syntax, e.g. /api/v1/admin/users/:id
+	Path        string
+	ID          string
+	Summary     string
+	Description string
+	Tags        []string
+	// Security lists the names of the security schemes, any of which authenticates the request
+	Security []string
+	// Params and Query are structs whose fields are bound by their params and query tags
+	Params any
+	Query  any
+	// Headers are the headers the route reads on top of the ones of its security schemes
+	Headers []*Parameter
+	// Parameters are the ones which cannot be described by a struct, such as the filters of a list endpoint
+	Parameters []*Parameter
+	// Body is the request body, sent as JSON unless BodyContentType says otherwise
+	Body            any
+	BodyContentType string
+	BodyOptional    bool
+	Responses       []Response
+}
+
+// Response is a response of an operation with the Go type of its body, if any
+type Response struct {
+	Status int
+	// Description defaults to the status text
+	Description string
+	// ContentType defaults to JSON when there is a body
+	ContentType string
+	Body        any
+}
+
+// Rule applies a validate rule with its parameter, e.g. max and 20, to the schema of a field of the given kind
+type Rule func(schema *Schema, kind reflect.Kind, param string) error
+
+// Generator builds an OpenAPI document from operations, deriving the schemas from the Go types
+// and their validate tags, so that the document follows the validation of the requests
+type Generator struct {
+	doc             *Document
+	rules           map[string]Rule
+	defaultResponse *Response
+}
+
+// NewGenerator creates a new Generator with the rules of the built-in validate tags
+func NewGenerator(info Info) *Generator {
+	return &Generator{
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   make(map[string]*PathItem),
+			Components: Components{
+				Schemas:         make(map[string]*Schema),
+				SecuritySchemes: make(map[string]*SecurityScheme),
+			},
+		},
+		rules: builtinRules(),
+	}
+}
+
+// RegisterRule registers the schema of a custom validate tag
+func (g *Generator) RegisterRule(tag string, rule Rule) {
+	g.rules[tag] = rule
+}
+
+func (g *Generator) AddSecurityScheme(name string, scheme *SecurityScheme) {
+	g.doc.Components.SecuritySchemes[name] = scheme
+}
+
+// SetDefaultResponse sets the response documented for the statuses an operation does not list, such as errors
+func (g *Generator) SetDefaultResponse(response Response) {
+	g.defaultResponse = &response
+}
+
+// Document returns the document built so far
+func (g *Generator) Document() *Document {
+	return g.doc
+}
+
+// Path turns the path of a fiber route into an OpenAPI path, e.g. /users/:id into /users/{id}
+func Path(route string) string {
+	return pathParamPattern.ReplaceAllString(route, "{$1}")
+}
+
+// Add documents an operation, failing when its types cannot be described or its path parameters are not bound
+func (g *Generator) Add(op Operation) error {
+	path := Path(op.Path)
+	method := strings.ToLower(op.Method)
+
+	item, ok := g.doc.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		g.doc.Paths[path] = item
+	}
+	if _, ok := (*item)[method]; ok {
+		return fmt.Errorf("operation %s %s is documented twice", op.Method, op.Path)
+	}
+	if g.hasOperationID(op.ID) {
+		return fmt.Errorf("operation ID %q is used twice", op.ID)
+	}
+
+	operation := &OperationObject{
+		OperationID: op.ID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Responses:   make(map[string]*ResponseObject),
+	}
+
+	for _, name := range op.Security {
+		if _, ok := g.doc.Components.SecuritySchemes[name]; !ok {
+			return fmt.Errorf("operation %s uses unknown security scheme %q", op.ID, name)
+		}
+		operation.Security = append(operation.Security, map[string][]string{name: {}})
+	}
+
+	params, err := g.parameters(op.Params, "params", "path")
+	if err != nil {
+		return fmt.Errorf("operation %s: %w", op.ID, err)
+	}
+	for _, match := range pathParamPattern.FindAllStringSubmatch(op.Path, -1) {
+		if !slices.ContainsFunc(params, func(param *Parameter) bool { return param.Name == match[1] }) {
+			return fmt.Errorf("operation %s does not document path parameter %q", op.ID, match[1])
+		}
+	}
+
+	query, err := g.parameters(op.Query, "query", "query")
+	if err != nil {
+		return fmt.Errorf("operation %s: %w", op.ID, err)
+	}
+
+	operation.Parameters = append(operation.Parameters, params...)
+	operation.Parameters = append(operation.Parameters, query...)
+	operation.Parameters = append(operation.Parameters, op.Parameters...)
+	operation.Parameters = append(operation.Parameters, op.Headers...)
+
+	if op.Body != nil {
+		contentType := op.BodyContentType
+		if contentType == "" {
+			contentType = JSONContentType
+		}
+
+		schema, err := g.schemaOf(reflect.TypeOf(op.Body))
+		if err != nil {
+			return fmt.Errorf("operation %s: %w", op.ID, err)
+		}
+
+		operation.RequestBody = &RequestBody{
+			Required: !op.BodyOptional,
+			Content:  map[string]*MediaType{contentType: {Schema: schema}},
+		}
+	}
+
+	for _, response := range op.Responses {
+		rsp, err := g.response(response)
+		if err != nil {
+			return fmt.Errorf("operation %s: %w", op.ID, err)
+		}
+		operation.Responses[strconv.Itoa(response.Status)] = rsp
+	}
+	if g.defaultResponse != nil {
+		rsp, err := g.response(*g.defaultResponse)
+		if err != nil {
+			return fmt.Errorf("default response: %w", err)
+		}
+		operation.Responses["default"] = rsp
+	}
+
+	(*item)[method] = operation
+	return nil
+}
+
+func (g *Generator) hasOperationID(id string) bool {
+	for _, item := range g.doc.Paths {
+		for _, operation := range *item {
+			if operation.OperationID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (g *Generator) response(response Response) (*ResponseObject, error) {
+	rsp := &ResponseObject{
+		Description: response.Description,
+	}
+	if rsp.Description == "" {
+		rsp.Description = http.StatusText(response.Status)
+	}
+
+	if response.Body != nil {
+		contentType := response.ContentType
+		if contentType == "" {
+			contentType = JSONContentType
+		}
+
+		schema, err := g.schemaOf(reflect.TypeOf(response.Body))
+		if err != nil {
+			return nil, err
+		}
+		rsp.Content = map[string]*MediaType{contentType: {Schema: schema}}
+	}
+
+	return rsp, nil
+}
+
+// parameters documents the fields of a struct bound from the part of the request named by the tag
+func (g *Generator) parameters(v any, tag string, in string) ([]*Parameter, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s parameters must be a struct, not %s", in, t)
+	}
+
+	var params []*Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		schema, required, err := g.fieldSchema(t, field)
+		if err != nil {
+			return nil, err
+		}
+
+		params = append(params, &Parameter{
+			Name:     name,
+			In:       in,
+			Required: required || in == "path",
+			Schema:   schema,
+		})
+	}
+
+	return params, nil
+}
+
+// schemaOf returns the schema of a type, named structs are described once in the components and referenced
+func (g *Generator) schemaOf(t reflect.Type) (*Schema, error) {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}, nil
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}, nil
+	case fileType:
+		return &Schema{Type: "string", Format: "binary"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schemaOf(t.Elem())
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int32, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}, nil
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}, nil
+	case reflect.Int8, reflect.Int16, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := g.schemaOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("map keys of %s must be strings", t)
+		}
+		values, err := g.schemaOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.objectSchema(t)
+		}
+
+		ref := &Schema{Ref: "#/components/schemas/" + t.Name()}
+		if _, ok := g.doc.Components.Schemas[t.Name()]; ok {
+			return ref, nil
+		}
+
+		// the name is reserved first, so that a struct referencing itself does not recurse forever
+		g.doc.Components.Schemas[t.Name()] = &Schema{}
+		schema, err := g.objectSchema(t)
+		if err != nil {
+			delete(g.doc.Components.Schemas, t.Name())
+			return nil, err
+		}
+		*g.doc.Components.Schemas[t.Name()] = *schema
+		return ref, nil
+	default:
+		return nil, fmt.Errorf("type %s cannot be described", t)
+	}
+}
+
+// objectSchema describes the fields of a struct as they are encoded in JSON, embedded structs are flattened
+func (g *Generator) objectSchema(t reflect.Type) (*Schema, error) {
+	schema := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		// the fields of embedded structs are promoted even when the struct itself is unexported, as in encoding/json
+		if !field.IsExported() && !(field.Anonymous && field.Type.Kind() == reflect.Struct) {
+			continue
+		}
+
+		name := propertyName(field)
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded, err := g.objectSchema(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			for property, propertySchema := range embedded.Properties {
+				schema.Properties[property] = propertySchema
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		propertySchema, required, err := g.fieldSchema(t, field)
+		if err != nil {
+			return nil, err
+		}
+		schema.Properties[name] = propertySchema
+
+		// a field is required when validation requires it, or when it is always sent
+		_, hasRules := field.Tag.Lookup("validate")
+		_, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		alwaysSent := !hasRules && field.Type.Kind() != reflect.Pointer && !slices.Contains(strings.Split(options, ","), "omitempty")
+		if required || alwaysSent {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	slices.Sort(schema.Required)
+	return schema, nil
+}
+
+// propertyName returns the name of a field in JSON, or in a form when it is not sent as JSON
+func propertyName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// fieldSchema returns the schema of a field constrained by its validate tag, and whether the tag requires the field.
+// The rules following dive apply to the items of the field.
+func (g *Generator) fieldSchema(parent reflect.Type, field reflect.StructField) (*Schema, bool, error) {
+	schema, err := g.schemaOf(field.Type)
+	if err != nil {
+		return nil, false, err
+	}
+
+	tag := field.Tag.Get("validate")
+	if tag == "" {
+		return schema, false, nil
+	}
+
+	target := schema
+	t := field.Type
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+			continue
+		case "omitempty":
+			continue
+		case "dive":
+			if target.Items == nil {
+				return nil, false, fmt.Errorf("dive on %s.%s which is not a list", parent.Name(), field.Name)
+			}
+			target = target.Items
+			t = t.Elem()
+			for t.Kind() == reflect.Pointer {
+				t = t.Elem()
+			}
+			continue
+		}
+
+		apply, ok := g.rules[name]
+		if !ok {
+			return nil, false, fmt.Errorf("validate rule %q of %s.%s cannot be described", name, parent.Name(), field.Name)
+		}
+		if err := apply(target, t.Kind(), param); err != nil {
+			return nil, false, fmt.Errorf("validate rule %q of %s.%s: %w", rule, parent.Name(), field.Name, err)
+		}
+	}
+
+	return schema, required, nil
+}
+
+func builtinRules() map[string]Rule {
+	return map[string]Rule{
+		"min": func(schema *Schema, kind reflect.Kind, param string) error {
+			return bound(schema, kind, param, &schema.MinLength, &schema.Minimum, &schema.MinItems)
+		},
+		"max": func(schema *Schema, kind reflect.Kind, param string) error {
+			return bound(schema, kind, param, &schema.MaxLength, &schema.Maximum, &schema.MaxItems)
+		},
+		"len": func(schema *Schema, kind reflect.Kind, param string) error {
+			err := bound(schema, kind, param, &schema.MinLength, &schema.Minimum, &schema.MinItems)
+			if err != nil {
+				return err
+			}
+			return bound(schema, kind, param, &schema.MaxLength, &schema.Maximum, &schema.MaxItems)
+		},
+		"oneof": func(schema *Schema, kind reflect.Kind, param string) error {
+			for _, value := range strings.Fields(param) {
+				if kind == reflect.String {
+					schema.Enum = append(schema.Enum, value)
+					continue
+				}
+
+				n, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					return err
+				}
+				schema.Enum = append(schema.Enum, n)
+			}
+			return nil
+		},
+		"unique": func(schema *Schema, kind reflect.Kind, param string) error {
+			schema.UniqueItems = true
+			return nil
+		},
+		"email":    Format("email"),
+		"url":      Format("uri"),
+		"uuid":     Format("uuid"),
+		"alphanum": Pattern(`^[a-zA-Z0-9]+$`),
+		"numeric":  Pattern(`^[-+]?[0-9]+(\.[0-9]+)?$`),
+		"datetime": func(schema *Schema, kind reflect.Kind, param string) error {
+			if param == time.RFC3339 {
+				schema.Format = "date-time"
+				return nil
+			}
+			schema.Description = "time in the layout " + param
+			return nil
+		},
+	}
+}
+
+// Format creates a rule which sets the format of the schema
+func Format(format string) Rule {
+	return func(schema *Schema, kind reflect.Kind, param string) error {
+		schema.Format = format
+		return nil
+	}
+}
+
+// Pattern creates a rule which sets the pattern of the schema
+func Pattern(pattern string) Rule {
+	return func(schema *Schema, kind reflect.Kind, param string) error {
+		schema.Pattern = pattern
+		return nil
+	}
+}
+
+// bound sets the length, value or number of items of a schema, depending on the kind of the field
+func bound(schema *Schema, kind reflect.Kind, param string, length **int64, value **float64, items **int64) error {
+	switch kind {
+	case reflect.String:
+		n, err := strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			return err
+		}
+		*length = &n
+	case reflect.Slice, reflect.Array, reflect.Map:
+		n, err := strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			return err
+		}
+		*items = &n
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return err
+		}
+		*value = &n
+	default:
+		return fmt.Errorf("cannot bound a %s", kind)
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type testAddress struct {
+	City string `json:"city"`
+}
+
+type testTimestamps struct {
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type testRequest struct {
+	Email    string   `json:"email" validate:"required,email"`
+	Nickname string   `json:"nickname" validate:"required,alphanum,min=1,max=20"`
+	Age      int32    `json:"age" validate:"omitempty,min=18,max=120"`
+	Role     string   `json:"role" validate:"required,oneof=borrower lender"`
+	Tags     []string `json:"tags" validate:"required,min=1,unique,dive,max=10"`
+	LineID   *string  `json:"line_id" validate:"omitempty,line_id"`
+}
+
+type testResponse struct {
+	ID uuid.UUID `json:"id"`
+	testTimestamps
+	Address   testAddress `json:"address"`
+	Note      *string     `json:"note,omitempty"`
+	Secret    string      `json:"-"`
+	internal  string
+	Avatar    []byte            `json:"avatar,omitempty"`
+	Labels    map[string]string `json:"labels"`
+	UpdatedAt *time.Time        `json:"updated_at"`
+}
+
+type testParams struct {
+	ID string `params:"id" validate:"required,uuid"`
+}
+
+type testQuery struct {
+	PageID   int32 `query:"page_id" validate:"required,min=1"`
+	Archived *bool `query:"archived"`
+}
+
+func newTestGenerator() *Generator {
+	g := NewGenerator(Info{Title: "test", Version: "1"})
+	g.RegisterRule("line_id", Pattern(`^[a-z0-9._-]{4,20}$`))
+	g.AddSecurityScheme("bearerAuth", &SecurityScheme{Type: "http", Scheme: "bearer"})
+	return g
+}
+
+func int64Ptr(n int64) *int64 {
+	return &n
+}
+
+func float64Ptr(n float64) *float64 {
+	return &n
+}
+
+func TestGeneratorAdd(t *testing.T) {
+	g := newTestGenerator()
+	g.SetDefaultResponse(Response{Status: http.StatusInternalServerError, Description: "problem", ContentType: "application/problem+json", Body: testAddress{}})
+
+	err := g.Add(Operation{
+		Method:   http.MethodPost,
+		Path:     "/things/:id",
+		ID:       "createThing",
+		Tags:     []string{"things"},
+		Security: []string{"bearerAuth"},
+		Params:   testParams{},
+		Query:    testQuery{},
+		Body:     testRequest{},
+		Responses: []Response{
+			{Status: http.StatusCreated, Body: testResponse{}},
+			{Status: http.StatusNoContent},
+		},
+	})
+	require.NoError(t, err)
+
+	doc := g.Document()
+	require.Equal(t, Version, doc.OpenAPI)
+
+	operation := (*doc.Paths["/things/{id}"])["post"]
+	require.NotNil(t, operation)
+	require.Equal(t, "createThing", operation.OperationID)
+	require.Equal(t, []map[string][]string{{"bearerAuth": {}}}, operation.Security)
+
+	require.Equal(t, []*Parameter{
+		{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string", Format: "uuid"}},
+		{Name: "page_id", In: "query", Required: true, Schema: &Schema{Type: "integer", Format: "int32", Minimum: float64Ptr(1)}},
+		{Name: "archived", In: "query", Schema: &Schema{Type: "boolean"}},
+	}, operation.Parameters)
+
+	require.True(t, operation.RequestBody.Required)
+	require.Equal(t, &Schema{Ref: "#/components/schemas/testRequest"}, operation.RequestBody.Content[JSONContentType].Schema)
+
+	require.Equal(t, "Created", operation.Responses["201"].Description)
+	require.Equal(t, &Schema{Ref: "#/components/schemas/testResponse"}, operation.Responses["201"].Content[JSONContentType].Schema)
+	require.Equal(t, "No Content", operation.Responses["204"].Description)
+	require.Nil(t, operation.Responses["204"].Content)
+	require.Equal(t, "problem", operation.Responses["default"].Description)
+	require.Contains(t, operation.Responses["default"].Content, "application/problem+json")
+}
+
+func TestGeneratorRequestSchema(t *testing.T) {
+	g := newTestGenerator()
+
+	ref, err := g.schemaOf(reflect.TypeOf(testRequest{}))
+	require.NoError(t, err)
+	require.Equal(t, "#/components/schemas/testRequest", ref.Ref)
+
+	schema := g.Document().Components.Schemas["testRequest"]
+	require.Equal(t, "object", schema.Type)
+	require.Equal(t, []string{"email", "nickname", "role", "tags"}, schema.Required)
+
+	require.Equal(t, &Schema{Type: "string", Format: "email"}, schema.Properties["email"])
+	require.Equal(t, &Schema{
+		Type:      "string",
+		Pattern:   `^[a-zA-Z0-9]+$`,
+		MinLength: int64Ptr(1),
+		MaxLength: int64Ptr(20),
+	}, schema.Properties["nickname"])
+	require.Equal(t, &Schema{
+		Type:    "integer",
+		Format:  "int32",
+		Minimum: float64Ptr(18),
+		Maximum: float64Ptr(120),
+	}, schema.Properties["age"])
+	require.Equal(t, &Schema{Type: "string", Enum: []any{"borrower", "lender"}}, schema.Properties["role"])
+	require.Equal(t, &Schema{
+		Type:        "array",
+		MinItems:    int64Ptr(1),
+		UniqueItems: true,
+		Items:       &Schema{Type: "string", MaxLength: int64Ptr(10)},
+	}, schema.Properties["tags"])
+	require.Equal(t, &Schema{Type: "string", Pattern: `^[a-z0-9._-]{4,20}$`}, schema.Properties["line_id"])
+}
+
+func TestGeneratorResponseSchema(t *testing.T) {
+	g := newTestGenerator()
+
+	_, err := g.schemaOf(reflect.TypeOf(testResponse{}))
+	require.NoError(t, err)
+
+	schema := g.Document().Components.Schemas["testResponse"]
+	// fields which are always sent are required, the embedded struct is flattened
+	require.Equal(t, []string{"address", "created_at", "id", "labels"}, schema.Required)
+	require.Equal(t, []string{"address", "avatar", "created_at", "id", "labels", "note", "updated_at"}, keys(schema.Properties))
+
+	require.Equal(t, &Schema{Type: "string", Format: "date-time"}, schema.Properties["created_at"])
+	require.Equal(t, &Schema{Ref: "#/components/schemas/testAddress"}, schema.Properties["address"])
+	require.Equal(t, &Schema{Type: "string", Format: "byte"}, schema.Properties["avatar"])
+	require.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}}, schema.Properties["labels"])
+	require.Contains(t, g.Document().Components.Schemas, "testAddress")
+}
+
+func TestGeneratorAddInvalid(t *testing.T) {
+	type unknownRule struct {
+		Phone string `json:"phone" validate:"required,e164"`
+	}
+	type unsupportedType struct {
+		Callback func() `json:"callback"`
+	}
+
+	testCases := []struct {
+		name string
+		op   Operation
+	}{
+		{
+			name: "UnknownRule",
+			op:   Operation{Method: http.MethodPost, Path: "/things", ID: "createThing", Body: unknownRule{}},
+		},
+		{
+			name: "UnsupportedType",
+			op:   Operation{Method: http.MethodPost, Path: "/things", ID: "createThing", Body: unsupportedType{}},
+		},
+		{
+			name: "PathParamNotDocumented",
+			op:   Operation{Method: http.MethodGet, Path: "/things/:id/parts/:part", ID: "getPart", Params: testParams{}},
+		},
+		{
+			name: "UnknownSecurityScheme",
+			op:   Operation{Method: http.MethodGet, Path: "/things", ID: "listThings", Security: []string{"apiKey"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := newTestGenerator()
+			require.Error(t, g.Add(tc.op))
+		})
+	}
+}
+
+func TestGeneratorAddTwice(t *testing.T) {
+	g := newTestGenerator()
+	require.NoError(t, g.Add(Operation{Method: http.MethodGet, Path: "/things", ID: "listThings"}))
+
+	require.Error(t, g.Add(Operation{Method: http.MethodGet, Path: "/things", ID: "listOtherThings"}))
+	require.Error(t, g.Add(Operation{Method: http.MethodPost, Path: "/things", ID: "listThings"}))
+}
+
+func TestPath(t *testing.T) {
+	require.Equal(t, "/api/v1/kyc_submissions/{id}/documents/{document}", Path("/api/v1/kyc_submissions/:id/documents/:document"))
+	require.Equal(t, "/api/v1/me", Path("/api/v1/me"))
+}
+
+func keys(m map[string]*Schema) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,111 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.24.4
+// source: borrower_service.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	BorrowerService_SignUp_FullMethodName = "/pb.BorrowerService/SignUp"
+)
+
+// BorrowerServiceClient is the client API for BorrowerService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BorrowerServiceClient interface {
+	// SignUp signs up a new borrower
+	SignUp(ctx context.Context, in *SignUpRequest, opts ...grpc.CallOption) (*SignUpResponse, error)
+}
+
+type borrowerServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBorrowerServiceClient(cc grpc.ClientConnInterface) BorrowerServiceClient {
+	return &borrowerServiceClient{cc}
+}
+
+func (c *borrowerServiceClient) SignUp(ctx context.Context, in *SignUpRequest, opts ...grpc.CallOption) (*SignUpResponse, error) {
+	out := new(SignUpResponse)
+	err := c.cc.Invoke(ctx, BorrowerService_SignUp_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BorrowerServiceServer is the server API for BorrowerService service.
+// All implementations must embed UnimplementedBorrowerServiceServer
+// for forward compatibility
+type BorrowerServiceServer interface {
+	// SignUp signs up a new borrower
+	SignUp(context.Context, *SignUpRequest) (*SignUpResponse, error)
+	mustEmbedUnimplementedBorrowerServiceServer()
+}
+
+// UnimplementedBorrowerServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedBorrowerServiceServer struct {
+}
+
+func (UnimplementedBorrowerServiceServer) SignUp(context.Context, *SignUpRequest) (*SignUpResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SignUp not implemented")
+}
+func (UnimplementedBorrowerServiceServer) mustEmbedUnimplementedBorrowerServiceServer() {}
+
+// UnsafeBorrowerServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BorrowerServiceServer will
+// result in compilation errors.
+type UnsafeBorrowerServiceServer interface {
+	mustEmbedUnimplementedBorrowerServiceServer()
+}
+
+func RegisterBorrowerServiceServer(s grpc.ServiceRegistrar, srv BorrowerServiceServer) {
+	s.RegisterService(&BorrowerService_ServiceDesc, srv)
+}
+
+func _BorrowerService_SignUp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignUpRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BorrowerServiceServer).SignUp(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BorrowerService_SignUp_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BorrowerServiceServer).SignUp(ctx, req.(*SignUpRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// BorrowerService_ServiceDesc is the grpc.ServiceDesc for BorrowerService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BorrowerService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.BorrowerService",
+	HandlerType: (*BorrowerServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SignUp",
+			Handler:    _BorrowerService_SignUp_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "borrower_service.proto",
+}
@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Store counts the requests made with every key in fixed windows.
+// It is shared by all the instances of the application unless it lives in memory.
+type Store interface {
+	// Increment counts a request in the window starting at windowStart,
+	// and returns the number of requests counted in that window and in the previous one
+	Increment(ctx context.Context, key string, windowStart time.Time, window time.Duration) (current int64, previous int64, err error)
+	// Purge forgets the windows which are too old to be used again
+	Purge(ctx context.Context, now time.Time) (int64, error)
+}
+
+// Result tells whether a request is allowed, along with what the RateLimit headers report to the client
+type Result struct {
+	Allowed   bool
+	Limit     int64
+	Remaining int64
+	// Reset is the time left until the current window ends
+	Reset time.Duration
+	// RetryAfter is how long a rejected client should wait before retrying
+	RetryAfter time.Duration
+}
+
+// Limiter enforces rate limit policies with a sliding window counter.
+// The number of requests within the last window is estimated from the count of the current fixed window
+// and the count of the previous one, weighted by how much of it overlaps the sliding window.
+// Rejected requests are counted too, so that a client hammering the API keeps being rejected.
+type Limiter struct {
+	store Store
+	now   func() time.Time
+
+	mu       sync.RWMutex
+	policies map[string]Policy
+}
+
+// NewLimiter creates a new Limiter counting requests in the store.
+// Routes whose policy is not given are not limited.
+func NewLimiter(store Store, policies map[string]Policy) *Limiter {
+	return &Limiter{
+		store:    store,
+		policies: policies,
+		now:      time.Now,
+	}
+}
+
+// Policy returns the policy with the name, if it is configured
+func (limiter *Limiter) Policy(name string) (Policy, bool) {
+	limiter.mu.RLock()
+	defer limiter.mu.RUnlock()
+
+	policy, ok := limiter.policies[name]
+	return policy, ok
+}
+
+// SetPolicies replaces the policies enforced from the next request on
+func (limiter *Limiter) SetPolicies(policies map[string]Policy) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	limiter.policies = policies
+}
+
+// Allow counts a request made with the key and tells whether it is allowed by the policy
+func (limiter *Limiter) Allow(ctx context.Context, policy Policy, key string) (Result, error) {
+	now := limiter.now()
+	windowStart := now.Truncate(policy.Window)
+	elapsed := now.Sub(windowStart)
+
+	current, previous, err := limiter.store.Increment(ctx, policy.Name+":"+key, windowStart, policy.Window)
+	if err != nil {
+		return Result{}, err
+	}
+
+	previousWeight := 1 - float64(elapsed)/float64(policy.Window)
+	estimate := float64(previous)*previousWeight + float64(current)
+
+	result := Result{
+		Allowed:   estimate <= float64(policy.Limit),
+		Limit:     policy.Limit,
+		Remaining: int64(math.Max(0, math.Floor(float64(policy.Limit)-estimate))),
+		Reset:     policy.Window - elapsed,
+	}
+	if !result.Allowed {
+		result.RetryAfter = retryAfter(policy, current, previous, elapsed)
+	}
+	return result, nil
+}
+
+// retryAfter computes how long it takes for the estimate to let one more request in,
+// assuming the client stops sending requests in the meantime
+func retryAfter(policy Policy, current int64, previous int64, elapsed time.Duration) time.Duration {
+	window := float64(policy.Window)
+	left := policy.Window - elapsed
+
+	var wait time.Duration
+	if current < policy.Limit {
+		// the weight of the previous window must decrease until previous*weight+current+1 <= limit,
+		// unless the current window ends first, after which the request is allowed
+		weight := float64(policy.Limit-current-1) / float64(previous)
+		wait = min(time.Duration((1-weight)*window)-elapsed, left)
+	} else {
+		// the current window must end and become the previous one,
+		// then its weight must decrease until current*weight+1 <= limit
+		weight := float64(policy.Limit-1) / float64(current)
+		wait = left + time.Duration((1-weight)*window)
+	}
+
+	return max(wait, time.Second)
+}
@@ -0,0 +1,135 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(policies ...Policy) (*Limiter, *time.Time) {
+	byName := make(map[string]Policy)
+	for _, policy := range policies {
+		byName[policy.Name] = policy
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewLimiter(NewMemoryStore(), byName)
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+func TestLimiterSlidingWindow(t *testing.T) {
+	policy := Policy{Name: "test", Limit: 10, Window: time.Minute, Key: KeyIP}
+	limiter, now := newTestLimiter(policy)
+	ctx := context.Background()
+
+	*now = now.Add(30 * time.Second)
+	for i := int64(1); i <= policy.Limit; i++ {
+		result, err := limiter.Allow(ctx, policy, "ip:127.0.0.1")
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, policy.Limit, result.Limit)
+		require.Equal(t, policy.Limit-i, result.Remaining)
+		require.Equal(t, 30*time.Second, result.Reset)
+	}
+
+	// halfway into the next window, half of the requests of the previous one still count
+	*now = now.Add(time.Minute)
+	for i := int64(1); i <= 5; i++ {
+		result, err := limiter.Allow(ctx, policy, "ip:127.0.0.1")
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, 5-i, result.Remaining)
+	}
+
+	result, err := limiter.Allow(ctx, policy, "ip:127.0.0.1")
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Zero(t, result.Remaining)
+	require.Equal(t, 12*time.Second, result.RetryAfter)
+
+	// the request is allowed again once the client has waited as long as it was told to
+	*now = now.Add(result.RetryAfter)
+	result, err = limiter.Allow(ctx, policy, "ip:127.0.0.1")
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+}
+
+func TestLimiterRetryAfterNextWindow(t *testing.T) {
+	policy := Policy{Name: "test", Limit: 2, Window: time.Minute, Key: KeyIP}
+	limiter, now := newTestLimiter(policy)
+	ctx := context.Background()
+
+	var result Result
+	var err error
+	for i := 0; i < 3; i++ {
+		result, err = limiter.Allow(ctx, policy, "ip:127.0.0.1")
+		require.NoError(t, err)
+	}
+	require.False(t, result.Allowed)
+	// the current window must end, then the weight of its 3 requests must drop to a third
+	require.Equal(t, 100*time.Second, result.RetryAfter)
+
+	*now = now.Add(result.RetryAfter)
+	result, err = limiter.Allow(ctx, policy, "ip:127.0.0.1")
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+}
+
+func TestLimiterKeys(t *testing.T) {
+	signUp := Policy{Name: "sign_up", Limit: 1, Window: time.Hour, Key: KeyIP}
+	signIn := Policy{Name: "sign_in", Limit: 1, Window: time.Hour, Key: KeyIP}
+	limiter, _ := newTestLimiter(signUp, signIn)
+	ctx := context.Background()
+
+	result, err := limiter.Allow(ctx, signUp, "ip:127.0.0.1")
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	result, err = limiter.Allow(ctx, signUp, "ip:127.0.0.1")
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+
+	// every client and every policy has its own counter
+	result, err = limiter.Allow(ctx, signUp, "ip:10.0.0.1")
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	result, err = limiter.Allow(ctx, signIn, "ip:127.0.0.1")
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+}
+
+func TestLimiterPolicy(t *testing.T) {
+	policy := Policy{Name: "sign_up", Limit: 5, Window: time.Hour, Key: KeyIP}
+	limiter, _ := newTestLimiter(policy)
+
+	got, ok := limiter.Policy("sign_up")
+	require.True(t, ok)
+	require.Equal(t, policy, got)
+
+	_, ok = limiter.Policy("sign_in")
+	require.False(t, ok)
+}
+
+func TestLimiterSetPolicies(t *testing.T) {
+	policy := Policy{Name: "test", Limit: 10, Window: time.Minute, Key: KeyIP}
+	limiter, _ := newTestLimiter(policy)
+
+	got, ok := limiter.Policy(policy.Name)
+	require.True(t, ok)
+	require.Equal(t, policy, got)
+
+	updated := Policy{Name: "test", Limit: 20, Window: time.Minute, Key: KeyIP}
+	limiter.SetPolicies(map[string]Policy{updated.Name: updated})
+
+	got, ok = limiter.Policy(policy.Name)
+	require.True(t, ok)
+	require.Equal(t, updated, got)
+
+	limiter.SetPolicies(nil)
+	_, ok = limiter.Policy(policy.Name)
+	require.False(t, ok)
+}
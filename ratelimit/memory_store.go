@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore counts requests in the memory of a single instance of the application
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]*memoryCounter
+}
+
+type memoryCounter struct {
+	windowStart time.Time
+	current     int64
+	previous    int64
+	expiresAt   time.Time
+}
+
+// NewMemoryStore creates a new MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters: make(map[string]*memoryCounter),
+	}
+}
+
+// Increment implements Store
+func (store *MemoryStore) Increment(ctx context.Context, key string, windowStart time.Time, window time.Duration) (int64, int64, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	counter, ok := store.counters[key]
+	if !ok {
+		counter = &memoryCounter{}
+		store.counters[key] = counter
+	}
+
+	switch {
+	case counter.windowStart.Equal(windowStart):
+	case counter.windowStart.Add(window).Equal(windowStart):
+		counter.previous, counter.current = counter.current, 0
+	default:
+		// neither the current window nor the previous one has seen a request yet
+		counter.previous, counter.current = 0, 0
+	}
+
+	counter.windowStart = windowStart
+	counter.current++
+	counter.expiresAt = windowStart.Add(2 * window)
+	return counter.current, counter.previous, nil
+}
+
+// Purge implements Store
+func (store *MemoryStore) Purge(ctx context.Context, now time.Time) (int64, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var purged int64
+	for key, counter := range store.counters {
+		if counter.expiresAt.Before(now) {
+			delete(store.counters, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreIncrement(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	window := time.Minute
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name             string
+		windowStart      time.Time
+		expectedCurrent  int64
+		expectedPrevious int64
+	}{
+		{name: "FirstRequest", windowStart: start, expectedCurrent: 1, expectedPrevious: 0},
+		{name: "SameWindow", windowStart: start, expectedCurrent: 2, expectedPrevious: 0},
+		{name: "NextWindow", windowStart: start.Add(window), expectedCurrent: 1, expectedPrevious: 2},
+		{name: "SkippedWindow", windowStart: start.Add(3 * window), expectedCurrent: 1, expectedPrevious: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			current, previous, err := store.Increment(ctx, "test:ip:127.0.0.1", tc.windowStart, window)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedCurrent, current)
+			require.Equal(t, tc.expectedPrevious, previous)
+		})
+	}
+}
+
+func TestMemoryStorePurge(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	window := time.Minute
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	_, _, err := store.Increment(ctx, "old", start, window)
+	require.NoError(t, err)
+	_, _, err = store.Increment(ctx, "recent", start.Add(2*window), window)
+	require.NoError(t, err)
+
+	purged, err := store.Purge(ctx, start.Add(3*window))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), purged)
+
+	// the purged counter starts over, the other one is kept
+	current, _, err := store.Increment(ctx, "old", start, window)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), current)
+
+	current, _, err = store.Increment(ctx, "recent", start.Add(2*window), window)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), current)
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Keys which the requests are counted by
+const (
+	KeyIP     = "ip"
+	KeyUser   = "user"
+	KeyAPIKey = "api_key"
+)
+
+// Policy limits the number of requests a client can make within a sliding window
+type Policy struct {
+	Name   string
+	Limit  int64
+	Window time.Duration
+	// Key tells what the requests are counted by, one of KeyIP, KeyUser or KeyAPIKey
+	Key string
+}
+
+// String formats the policy as in the RateLimit-Policy header, e.g. 5;w=3600
+func (policy Policy) String() string {
+	return fmt.Sprintf("%d;w=%d", policy.Limit, int64(policy.Window/time.Second))
+}
+
+// ParsePolicies parses a comma separated list of policies such as "sign_up=5/1h/ip,partner=600/1m/api_key",
+// each made of a name, the number of requests allowed, the duration of the window and what requests are counted by
+func ParsePolicies(s string) (map[string]Policy, error) {
+	policies := make(map[string]Policy)
+
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		policy, err := parsePolicy(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit policy %q: %w", item, err)
+		}
+		if _, ok := policies[policy.Name]; ok {
+			return nil, fmt.Errorf("duplicate rate limit policy %q", policy.Name)
+		}
+		policies[policy.Name] = policy
+	}
+
+	return policies, nil
+}
+
+func parsePolicy(s string) (Policy, error) {
+	name, rule, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return Policy{}, fmt.Errorf("expected name=limit/window/key")
+	}
+
+	parts := strings.Split(rule, "/")
+	if len(parts) != 3 {
+		return Policy{}, fmt.Errorf("expected name=limit/window/key")
+	}
+
+	limit, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || limit < 1 {
+		return Policy{}, fmt.Errorf("limit must be a positive integer")
+	}
+
+	window, err := time.ParseDuration(parts[1])
+	if err != nil || window < time.Second {
+		return Policy{}, fmt.Errorf("window must be a duration of at least a second")
+	}
+
+	key := parts[2]
+	switch key {
+	case KeyIP, KeyUser, KeyAPIKey:
+	default:
+		return Policy{}, fmt.Errorf("key must be one of %s, %s or %s", KeyIP, KeyUser, KeyAPIKey)
+	}
+
+	return Policy{
+		Name:   name,
+		Limit:  limit,
+		Window: window,
+		Key:    key,
+	}, nil
+}
@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicies(t *testing.T) {
+	policies, err := ParsePolicies(" sign_up=5/1h/ip, me=120/1m/user,partner=600/1m/api_key,")
+	require.NoError(t, err)
+	require.Equal(t, map[string]Policy{
+		"sign_up": {Name: "sign_up", Limit: 5, Window: time.Hour, Key: KeyIP},
+		"me":      {Name: "me", Limit: 120, Window: time.Minute, Key: KeyUser},
+		"partner": {Name: "partner", Limit: 600, Window: time.Minute, Key: KeyAPIKey},
+	}, policies)
+
+	policies, err = ParsePolicies("")
+	require.NoError(t, err)
+	require.Empty(t, policies)
+}
+
+func TestParsePoliciesInvalid(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policies string
+	}{
+		{name: "NoName", policies: "=5/1h/ip"},
+		{name: "NoRule", policies: "sign_up"},
+		{name: "MissingPart", policies: "sign_up=5/1h"},
+		{name: "InvalidLimit", policies: "sign_up=five/1h/ip"},
+		{name: "ZeroLimit", policies: "sign_up=0/1h/ip"},
+		{name: "InvalidWindow", policies: "sign_up=5/hour/ip"},
+		{name: "WindowTooShort", policies: "sign_up=5/500ms/ip"},
+		{name: "InvalidKey", policies: "sign_up=5/1h/email"},
+		{name: "Duplicate", policies: "sign_up=5/1h/ip,sign_up=10/1h/ip"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policies, err := ParsePolicies(tc.policies)
+			require.Error(t, err)
+			require.Nil(t, policies)
+		})
+	}
+}
+
+func TestPolicyString(t *testing.T) {
+	policy := Policy{Name: "sign_up", Limit: 5, Window: time.Hour, Key: KeyIP}
+	require.Equal(t, "5;w=3600", policy.String())
+}
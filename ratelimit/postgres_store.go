@@ -0,0 +1,39 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+)
+
+// PostgresStore counts requests in Postgres, so that the limits hold across all the instances of the application
+type PostgresStore struct {
+	store db.Store
+}
+
+// NewPostgresStore creates a new PostgresStore
+func NewPostgresStore(store db.Store) *PostgresStore {
+	return &PostgresStore{
+		store: store,
+	}
+}
+
+// Increment implements Store
+func (store *PostgresStore) Increment(ctx context.Context, key string, windowStart time.Time, window time.Duration) (int64, int64, error) {
+	row, err := store.store.IncrementRateLimitCounter(ctx, db.IncrementRateLimitCounterParams{
+		Key:                 key,
+		PreviousWindowStart: windowStart.Add(-window),
+		WindowStart:         windowStart,
+		ExpiresAt:           windowStart.Add(2 * window),
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return row.CurrentCount, row.PreviousCount, nil
+}
+
+// Purge implements Store
+func (store *PostgresStore) Purge(ctx context.Context, now time.Time) (int64, error) {
+	return store.store.DeleteRateLimitCountersBefore(ctx, now)
+}
@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "github.com/DamianZhang/957-lending-platform/db/mock"
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresStoreIncrement(t *testing.T) {
+	window := time.Minute
+	windowStart := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, current int64, previous int64, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.IncrementRateLimitCounterParams{
+					Key:                 "test:ip:127.0.0.1",
+					PreviousWindowStart: windowStart.Add(-window),
+					WindowStart:         windowStart,
+					ExpiresAt:           windowStart.Add(2 * window),
+				}
+				store.EXPECT().
+					IncrementRateLimitCounter(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.IncrementRateLimitCounterRow{CurrentCount: 3, PreviousCount: 7}, nil)
+			},
+			checkResponse: func(t *testing.T, current int64, previous int64, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(3), current)
+				require.Equal(t, int64(7), previous)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					IncrementRateLimitCounter(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IncrementRateLimitCounterRow{}, errors.New("connection refused"))
+			},
+			checkResponse: func(t *testing.T, current int64, previous int64, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			current, previous, err := NewPostgresStore(store).Increment(context.Background(), "test:ip:127.0.0.1", windowStart, window)
+			tc.checkResponse(t, current, previous, err)
+		})
+	}
+}
+
+func TestPostgresStorePurge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		DeleteRateLimitCountersBefore(gomock.Any(), gomock.Eq(now)).
+		Times(1).
+		Return(int64(4), nil)
+
+	purged, err := NewPostgresStore(store).Purge(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), purged)
+}
@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type AdminService interface {
+	CreateAdmin(ctx context.Context, input *CreateAdminInput) (*CreateAdminOutput, error)
+	ListUsers(ctx context.Context, input *ListUsersInput) (*ListUsersOutput, error)
+	ViewUser(ctx context.Context, input *ViewUserInput) (*ViewUserOutput, error)
+	ChangeUserRole(ctx context.Context, input *ChangeUserRoleInput) (*ChangeUserRoleOutput, error)
+	SuspendUser(ctx context.Context, input *SuspendUserInput) (*SuspendUserOutput, error)
+	ReactivateUser(ctx context.Context, input *ReactivateUserInput) (*ReactivateUserOutput, error)
+	RequireAdmin(ctx context.Context, userID uuid.UUID) error
+}
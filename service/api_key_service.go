@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+)
+
+type APIKeyService interface {
+	IssueAPIKey(ctx context.Context, input *IssueAPIKeyInput) (*IssueAPIKeyOutput, error)
+	ListAPIKeys(ctx context.Context, input *ListAPIKeysInput) (*ListAPIKeysOutput, error)
+	RotateAPIKey(ctx context.Context, input *RotateAPIKeyInput) (*RotateAPIKeyOutput, error)
+	RevokeAPIKey(ctx context.Context, input *RevokeAPIKeyInput) (*RevokeAPIKeyOutput, error)
+	// AuthenticateAPIKey authenticates a partner presenting its API key as a bearer credential.
+	AuthenticateAPIKey(ctx context.Context, input *AuthenticateAPIKeyInput) (*AuthenticateAPIKeyOutput, error)
+	// AuthenticateSignedRequest authenticates a partner by the HMAC-SHA256 signature of its request.
+	// Each nonce is accepted once, and only within the signature window around its timestamp.
+	AuthenticateSignedRequest(ctx context.Context, input *AuthenticateSignedRequestInput) (*AuthenticateAPIKeyOutput, error)
+	// PurgeExpiredNonces deletes the nonces which are too old to be replayed and reports how many were deleted.
+	PurgeExpiredNonces(ctx context.Context) (int64, error)
+}
@@ -1,17 +1,50 @@
 package service
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/DamianZhang/957-lending-platform/i18n"
+)
 
 // Service errors
 var (
-	ErrInternalFailure = errors.New("internal failure")
+	ErrInternalFailure    = errors.New("internal failure")
+	ErrNotFound           = errors.New("not found")
+	ErrConflict           = errors.New("conflict")
+	ErrValidation         = errors.New("validation failed")
+	ErrUnauthenticated    = errors.New("unauthenticated")
+	ErrForbidden          = errors.New("forbidden")
+	ErrRateLimited        = errors.New("rate limited")
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrAccountInactive    = errors.New("account is not active")
+	ErrKYCRequired        = errors.New("identity verification is required")
+	ErrRequestInProgress  = errors.New("request in progress")
 )
 
+// errorCodes are the stable machine-readable codes of the service errors.
+// Clients rely on them, so a code must never change once released.
+var errorCodes = map[error]string{
+	ErrInternalFailure:    "internal_failure",
+	ErrNotFound:           "not_found",
+	ErrConflict:           "conflict",
+	ErrValidation:         "validation_failed",
+	ErrUnauthenticated:    "unauthenticated",
+	ErrForbidden:          "forbidden",
+	ErrRateLimited:        "rate_limited",
+	ErrPreconditionFailed: "precondition_failed",
+	ErrAccountInactive:    "account_inactive",
+	ErrKYCRequired:        "kyc_required",
+	ErrRequestInProgress:  "request_in_progress",
+}
+
 type Error struct {
-	svcErr error // service error
-	appErr error // the reason of service error
+	svcErr error        // service error
+	appErr error        // the reason of service error, never shown to clients
+	detail i18n.Message // explanation of the service error which is safe to show to clients
 }
 
+// NewError creates a service error caused by appErr.
+// The cause is only meant for logging and is never shown to clients.
 func NewError(svcErr, appErr error) error {
 	return Error{
 		svcErr: svcErr,
@@ -19,10 +52,37 @@ func NewError(svcErr, appErr error) error {
 	}
 }
 
+// NewErrorWithDetail creates a service error along with an explanation for the client,
+// such as the business rule which has been broken. The detail is localised by the API layer.
+func NewErrorWithDetail(svcErr error, detail i18n.Message) error {
+	return Error{
+		svcErr: svcErr,
+		appErr: errors.New(detail.String()),
+		detail: detail,
+	}
+}
+
 func (e Error) SvcErr() error {
 	return e.svcErr
 }
 
+// Code returns the stable machine-readable code of the service error
+func (e Error) Code() string {
+	if code, ok := errorCodes[e.svcErr]; ok {
+		return code
+	}
+	return errorCodes[ErrInternalFailure]
+}
+
+// Detail returns the explanation of the service error which is safe to show to clients, if any
+func (e Error) Detail() i18n.Message {
+	return e.detail
+}
+
 func (e Error) Error() string {
 	return errors.Join(e.svcErr, e.appErr).Error()
 }
+
+func (e Error) Unwrap() []error {
+	return []error{e.svcErr, e.appErr}
+}
@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+)
+
+// Names of the dependencies checked for readiness
+const (
+	DependencyDatabase = "database"
+	DependencySchema   = "schema"
+)
+
+type HealthService interface {
+	// CheckReadiness checks every dependency needed to serve requests.
+	// It does not fail by itself, the failure of each dependency is reported in the output.
+	CheckReadiness(ctx context.Context) *CheckReadinessOutput
+}
@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+)
+
+type IdempotencyService interface {
+	// BeginRequest claims the idempotency key of a request before it is handled.
+	// When the request has already been handled with the key, its stored response is returned to be replayed.
+	// The key cannot be reused for another request, nor while the request is still being handled.
+	BeginRequest(ctx context.Context, input *BeginIdempotentRequestInput) (*BeginIdempotentRequestOutput, error)
+	// CompleteRequest stores the response of a request so that it is replayed when the request is retried.
+	CompleteRequest(ctx context.Context, input *CompleteIdempotentRequestInput) error
+	// AbandonRequest releases the key of a request which could not be handled, so that it can be retried.
+	AbandonRequest(ctx context.Context, input *AbandonIdempotentRequestInput) error
+	// PurgeExpiredKeys deletes the keys whose responses are no longer replayed and reports how many were deleted.
+	PurgeExpiredKeys(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,313 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/listing"
+	"github.com/DamianZhang/957-lending-platform/metrics"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func NewAdminServiceImpl(adminStore db.Store, passwordPolicy *util.PasswordPolicy, metrics *metrics.Metrics) service.AdminService {
+	return &adminServiceImpl{
+		adminStore:     adminStore,
+		passwordPolicy: passwordPolicy,
+		metrics:        metrics,
+	}
+}
+
+type adminServiceImpl struct {
+	adminStore     db.Store
+	passwordPolicy *util.PasswordPolicy
+	metrics        *metrics.Metrics
+}
+
+func (svc *adminServiceImpl) CreateAdmin(ctx context.Context, input *service.CreateAdminInput) (*service.CreateAdminOutput, error) {
+	// the admin created by a previous run may not have been replicated yet
+	admins, err := svc.adminStore.ListUsers(db.WithPrimary(ctx), db.ListUsersParams{
+		Roles: []string{util.AdminRole},
+		Limit: 1,
+	})
+	if err != nil {
+		return nil, fromDBError(err)
+	}
+	if len(admins) > 0 {
+		return nil, service.NewErrorWithDetail(service.ErrConflict, i18n.NewMessage("admin.already_exists"))
+	}
+
+	hashedPassword, err := hashNewPassword(ctx, svc.passwordPolicy, svc.metrics, input.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	arg := db.CreateAdminTxParams{
+		CreateUserParams: db.CreateUserParams{
+			Email:    input.Email,
+			LineID:   input.LineID,
+			Nickname: input.Nickname,
+
+			HashedPassword: hashedPassword,
+			Role:           util.AdminRole,
+		},
+		AuditAction: util.AuditActionCreateAdmin,
+	}
+
+	result, err := svc.adminStore.CreateAdminTx(ctx, arg)
+	if err != nil {
+		return nil, fromDBError(err)
+	}
+	svc.metrics.ObserveSignUp(result.User.Role)
+
+	output := &service.CreateAdminOutput{
+		Admin: result.User,
+	}
+	return output, nil
+}
+
+func (svc *adminServiceImpl) ListUsers(ctx context.Context, input *service.ListUsersInput) (*service.ListUsersOutput, error) {
+	arg := db.ListUsersParams{
+		Email:           optionalLikeText(input.Email),
+		Roles:           input.Roles,
+		Statuses:        input.Statuses,
+		IsEmailVerified: optionalBool(input.IsEmailVerified),
+		CreatedFrom:     optionalTimestamptz(input.CreatedFrom),
+		CreatedTo:       optionalTimestamptz(input.CreatedTo),
+		IncludeDeleted:  input.IncludeDeleted,
+		// one more user than the page holds tells whether another page follows
+		Limit: input.Limit + 1,
+	}
+	if input.After != nil {
+		arg.AfterCreatedAt = pgtype.Timestamptz{Time: input.After.CreatedAt, Valid: true}
+		arg.AfterID = pgtype.UUID{Bytes: input.After.ID, Valid: true}
+	}
+
+	var users []db.User
+	var err error
+	if input.Descending {
+		users, err = svc.adminStore.ListUsersDesc(ctx, db.ListUsersDescParams(arg))
+	} else {
+		users, err = svc.adminStore.ListUsers(ctx, arg)
+	}
+	if err != nil {
+		return nil, fromDBError(err)
+	}
+
+	err = svc.audit(ctx, input.AdminID, util.AuditActionListUsers, nil, listUsersFilters(input))
+	if err != nil {
+		return nil, err
+	}
+
+	output := &service.ListUsersOutput{
+		Users: users,
+	}
+	if len(users) > int(input.Limit) {
+		output.Users = users[:input.Limit]
+		last := output.Users[len(output.Users)-1]
+		output.Next = &listing.Key{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	return output, nil
+}
+
+// listUsersFilters tells which filters a listing of users applied.
+// Their values are left out of the audit log, since the email searched for is personal data
+// which outlives the anonymization of the user it belongs to.
+func listUsersFilters(input *service.ListUsersInput) map[string]bool {
+	return map[string]bool{
+		"email_filter":          input.Email != nil,
+		"role_filter":           len(input.Roles) > 0,
+		"status_filter":         len(input.Statuses) > 0,
+		"email_verified_filter": input.IsEmailVerified != nil,
+		"created_from_filter":   input.CreatedFrom != nil,
+		"created_to_filter":     input.CreatedTo != nil,
+		"include_deleted":       input.IncludeDeleted,
+	}
+}
+
+func (svc *adminServiceImpl) ViewUser(ctx context.Context, input *service.ViewUserInput) (*service.ViewUserOutput, error) {
+	user, err := svc.getUserByID(ctx, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = svc.audit(ctx, input.AdminID, util.AuditActionViewUser, &user.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &service.ViewUserOutput{
+		User: user,
+	}
+	return output, nil
+}
+
+func (svc *adminServiceImpl) ChangeUserRole(ctx context.Context, input *service.ChangeUserRoleInput) (*service.ChangeUserRoleOutput, error) {
+	if input.AdminID == input.UserID {
+		return nil, service.NewErrorWithDetail(service.ErrForbidden, i18n.NewMessage("admin.change_own_role"))
+	}
+
+	user, err := svc.getUserByID(ctx, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	details, err := json.Marshal(map[string]string{
+		"old_role": user.Role,
+		"new_role": input.Role,
+	})
+	if err != nil {
+		return nil, service.NewError(service.ErrInternalFailure, err)
+	}
+
+	arg := db.UpdateUserRoleTxParams{
+		UpdateUserRoleParams: db.UpdateUserRoleParams{
+			ID:        user.ID,
+			Role:      input.Role,
+			UpdatedAt: time.Now(),
+		},
+		Audit: db.AuditParams{
+			ActorID: input.AdminID,
+			Action:  util.AuditActionChangeUserRole,
+			Details: details,
+		},
+	}
+
+	result, err := svc.adminStore.UpdateUserRoleTx(ctx, arg)
+	if err != nil {
+		return nil, fromDBError(err)
+	}
+
+	output := &service.ChangeUserRoleOutput{
+		User: result.User,
+	}
+	return output, nil
+}
+
+func (svc *adminServiceImpl) SuspendUser(ctx context.Context, input *service.SuspendUserInput) (*service.SuspendUserOutput, error) {
+	if input.AdminID == input.UserID {
+		return nil, service.NewErrorWithDetail(service.ErrForbidden, i18n.NewMessage("admin.suspend_self"))
+	}
+
+	user, err := svc.updateUserStatus(ctx, input.AdminID, input.UserID, util.SuspendedStatus, util.AuditActionSuspendUser, input.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &service.SuspendUserOutput{
+		User: user,
+	}
+	return output, nil
+}
+
+func (svc *adminServiceImpl) ReactivateUser(ctx context.Context, input *service.ReactivateUserInput) (*service.ReactivateUserOutput, error) {
+	user, err := svc.updateUserStatus(ctx, input.AdminID, input.UserID, util.ActiveStatus, util.AuditActionReactivateUser, input.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &service.ReactivateUserOutput{
+		User: user,
+	}
+	return output, nil
+}
+
+func (svc *adminServiceImpl) updateUserStatus(ctx context.Context, adminID, userID uuid.UUID, status, action, reason string) (db.User, error) {
+	user, err := svc.getUserByID(ctx, userID)
+	if err != nil {
+		return db.User{}, err
+	}
+
+	if user.Status == util.ClosedStatus {
+		return db.User{}, service.NewErrorWithDetail(service.ErrConflict, i18n.NewMessage("user.account_closed"))
+	}
+
+	details, err := json.Marshal(map[string]string{
+		"old_status": user.Status,
+		"new_status": status,
+		"reason":     reason,
+	})
+	if err != nil {
+		return db.User{}, service.NewError(service.ErrInternalFailure, err)
+	}
+
+	arg := db.UpdateUserStatusTxParams{
+		UpdateUserStatusParams: db.UpdateUserStatusParams{
+			ID:        user.ID,
+			Status:    status,
+			UpdatedAt: time.Now(),
+		},
+		Audit: db.AuditParams{
+			ActorID: adminID,
+			Action:  action,
+			Details: details,
+		},
+	}
+
+	result, err := svc.adminStore.UpdateUserStatusTx(ctx, arg)
+	if err != nil {
+		return db.User{}, fromDBError(err)
+	}
+
+	return result.User, nil
+}
+
+func (svc *adminServiceImpl) getUserByID(ctx context.Context, userID uuid.UUID) (db.User, error) {
+	user, err := svc.adminStore.GetUserByID(ctx, userID)
+	if err != nil {
+		return db.User{}, fromDBError(err)
+	}
+
+	return user, nil
+}
+
+func (svc *adminServiceImpl) RequireAdmin(ctx context.Context, userID uuid.UUID) error {
+	// an admin who has just been suspended or demoted must be turned away at once
+	user, err := svc.adminStore.GetUserByID(db.WithPrimary(ctx), userID)
+	if err != nil {
+		return fromDBError(err)
+	}
+
+	err = checkUserIsActive(user)
+	if err != nil {
+		return err
+	}
+
+	if user.Role != util.AdminRole {
+		return service.NewErrorWithDetail(service.ErrForbidden, i18n.NewMessage("auth.role_forbidden").With("role", user.Role))
+	}
+	return nil
+}
+
+// audit records an admin action which does not change any user
+func (svc *adminServiceImpl) audit(ctx context.Context, adminID uuid.UUID, action string, targetUserID *uuid.UUID, details any) error {
+	arg := db.CreateAuditLogParams{
+		ActorID: adminID,
+		Action:  action,
+		Details: []byte("{}"),
+	}
+
+	if targetUserID != nil {
+		arg.TargetUserID = pgtype.UUID{Bytes: *targetUserID, Valid: true}
+	}
+
+	if details != nil {
+		data, err := json.Marshal(details)
+		if err != nil {
+			return service.NewError(service.ErrInternalFailure, err)
+		}
+		arg.Details = data
+	}
+
+	_, err := svc.adminStore.CreateAuditLog(ctx, arg)
+	if err != nil {
+		return fromDBError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,550 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	mockdb "github.com/DamianZhang/957-lending-platform/db/mock"
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/listing"
+	"github.com/DamianZhang/957-lending-platform/metrics"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAdmin(t *testing.T) {
+	admin, password := expectedUser(t)
+	admin.Role = util.AdminRole
+
+	input := &service.CreateAdminInput{
+		Email:    admin.Email,
+		Password: password,
+		LineID:   admin.LineID,
+		Nickname: admin.Nickname,
+	}
+
+	testCases := []struct {
+		name        string
+		buildStubs  func(store *mockdb.MockStore)
+		checkOutput func(output *service.CreateAdminOutput, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListUsers(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ListUsersParams) ([]db.User, error) {
+						require.Equal(t, []string{util.AdminRole}, arg.Roles)
+						require.EqualValues(t, 1, arg.Limit)
+						return []db.User{}, nil
+					})
+
+				arg := db.CreateUserParams{
+					Email:    admin.Email,
+					LineID:   admin.LineID,
+					Nickname: admin.Nickname,
+					Role:     util.AdminRole,
+				}
+				store.EXPECT().
+					CreateAdminTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, txArg db.CreateAdminTxParams) (db.AuditedUserTxResult, error) {
+						require.True(t, EqCreateUserParams(arg, password).Matches(txArg.CreateUserParams))
+						require.Equal(t, util.AuditActionCreateAdmin, txArg.AuditAction)
+						return db.AuditedUserTxResult{User: admin}, nil
+					})
+			},
+			checkOutput: func(output *service.CreateAdminOutput, err error) {
+				require.NoError(t, err)
+				require.Equal(t, admin, output.Admin)
+			},
+		},
+		{
+			name: "AdminAlreadyExists",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListUsers(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.User{admin}, nil)
+				store.EXPECT().
+					CreateAdminTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.CreateAdminOutput, err error) {
+				requireSvcErr(t, err, service.ErrConflict)
+				require.Nil(t, output)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			adminService := NewAdminServiceImpl(store, testPasswordPolicy(t), metrics.New())
+
+			output, err := adminService.CreateAdmin(context.Background(), input)
+			tc.checkOutput(output, err)
+		})
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	adminID := uuid.New()
+
+	users := make([]db.User, 3)
+	for i := range users {
+		users[i], _ = expectedUser(t)
+	}
+	after := &listing.Key{CreatedAt: users[0].CreatedAt, ID: users[0].ID}
+	emailFilter := `100%_off\`
+
+	testCases := []struct {
+		name        string
+		input       *service.ListUsersInput
+		buildStubs  func(store *mockdb.MockStore)
+		checkOutput func(output *service.ListUsersOutput, err error)
+	}{
+		{
+			name: "FirstPage",
+			input: &service.ListUsersInput{
+				AdminID: adminID,
+				Roles:   []string{util.BorrowerRole, util.LenderRole},
+				Limit:   2,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListUsers(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ListUsersParams) ([]db.User, error) {
+						require.Equal(t, []string{util.BorrowerRole, util.LenderRole}, arg.Roles)
+						require.Nil(t, arg.Statuses)
+						require.False(t, arg.Email.Valid)
+						require.False(t, arg.IsEmailVerified.Valid)
+						require.False(t, arg.AfterCreatedAt.Valid)
+						require.False(t, arg.AfterID.Valid)
+						require.EqualValues(t, 3, arg.Limit)
+						return users, nil
+					})
+				store.EXPECT().
+					ListUsersDesc(gomock.Any(), gomock.Any()).
+					Times(0)
+				expectListUsersAudit(t, store, adminID, "role_filter")
+			},
+			checkOutput: func(output *service.ListUsersOutput, err error) {
+				require.NoError(t, err)
+				require.Equal(t, users[:2], output.Users)
+				require.Equal(t, &listing.Key{CreatedAt: users[1].CreatedAt, ID: users[1].ID}, output.Next)
+			},
+		},
+		{
+			name: "LastPageDescending",
+			input: &service.ListUsersInput{
+				AdminID:    adminID,
+				Descending: true,
+				After:      after,
+				Limit:      5,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListUsersDesc(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ListUsersDescParams) ([]db.User, error) {
+						require.True(t, arg.AfterCreatedAt.Valid)
+						require.Equal(t, after.CreatedAt, arg.AfterCreatedAt.Time)
+						require.Equal(t, after.ID, uuid.UUID(arg.AfterID.Bytes))
+						require.EqualValues(t, 6, arg.Limit)
+						return users[1:], nil
+					})
+				store.EXPECT().
+					ListUsers(gomock.Any(), gomock.Any()).
+					Times(0)
+				expectListUsersAudit(t, store, adminID)
+			},
+			checkOutput: func(output *service.ListUsersOutput, err error) {
+				require.NoError(t, err)
+				require.Equal(t, users[1:], output.Users)
+				require.Nil(t, output.Next)
+			},
+		},
+		{
+			name: "EmailFilterMatchesLiterally",
+			input: &service.ListUsersInput{
+				AdminID: adminID,
+				Email:   &emailFilter,
+				Limit:   5,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListUsers(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ListUsersParams) ([]db.User, error) {
+						require.True(t, arg.Email.Valid)
+						require.Equal(t, `100\%\_off\\`, arg.Email.String)
+						return users[:1], nil
+					})
+				expectListUsersAudit(t, store, adminID, "email_filter")
+			},
+			checkOutput: func(output *service.ListUsersOutput, err error) {
+				require.NoError(t, err)
+				require.Equal(t, users[:1], output.Users)
+			},
+		},
+		{
+			name: "InternalError",
+			input: &service.ListUsersInput{
+				AdminID: adminID,
+				Limit:   5,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListUsers(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+				store.EXPECT().
+					CreateAuditLog(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.ListUsersOutput, err error) {
+				requireSvcErr(t, err, service.ErrInternalFailure)
+				require.Nil(t, output)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			adminService := NewAdminServiceImpl(store, testPasswordPolicy(t), metrics.New())
+
+			output, err := adminService.ListUsers(context.Background(), tc.input)
+			tc.checkOutput(output, err)
+		})
+	}
+}
+
+func expectListUsersAudit(t *testing.T, store *mockdb.MockStore, adminID uuid.UUID, appliedFilters ...string) {
+	store.EXPECT().
+		CreateAuditLog(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateAuditLogParams) (db.AuditLog, error) {
+			require.Equal(t, adminID, arg.ActorID)
+			require.Equal(t, util.AuditActionListUsers, arg.Action)
+			require.False(t, arg.TargetUserID.Valid)
+
+			// only which filters were applied is recorded, never their values
+			var details map[string]bool
+			require.NoError(t, json.Unmarshal(arg.Details, &details))
+			for filter, applied := range details {
+				require.Equal(t, slices.Contains(appliedFilters, filter), applied, filter)
+			}
+			for _, filter := range appliedFilters {
+				require.Contains(t, details, filter)
+			}
+			return db.AuditLog{}, nil
+		})
+}
+
+func TestChangeUserRole(t *testing.T) {
+	adminID := uuid.New()
+	user, _ := expectedUser(t)
+
+	testCases := []struct {
+		name        string
+		input       *service.ChangeUserRoleInput
+		buildStubs  func(store *mockdb.MockStore)
+		checkOutput func(output *service.ChangeUserRoleOutput, err error)
+	}{
+		{
+			name: "OK",
+			input: &service.ChangeUserRoleInput{
+				AdminID: adminID,
+				UserID:  user.ID,
+				Role:    util.LenderRole,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+
+				updatedUser := user
+				updatedUser.Role = util.LenderRole
+				store.EXPECT().
+					UpdateUserRoleTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateUserRoleTxParams) (db.AuditedUserTxResult, error) {
+						require.Equal(t, user.ID, arg.ID)
+						require.Equal(t, util.LenderRole, arg.Role)
+						require.Equal(t, adminID, arg.Audit.ActorID)
+						require.Equal(t, util.AuditActionChangeUserRole, arg.Audit.Action)
+
+						var details map[string]string
+						require.NoError(t, json.Unmarshal(arg.Audit.Details, &details))
+						require.Equal(t, user.Role, details["old_role"])
+						require.Equal(t, util.LenderRole, details["new_role"])
+						return db.AuditedUserTxResult{User: updatedUser}, nil
+					})
+			},
+			checkOutput: func(output *service.ChangeUserRoleOutput, err error) {
+				require.NoError(t, err)
+				require.Equal(t, util.LenderRole, output.User.Role)
+			},
+		},
+		{
+			name: "OwnRole",
+			input: &service.ChangeUserRoleInput{
+				AdminID: adminID,
+				UserID:  adminID,
+				Role:    util.BorrowerRole,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					UpdateUserRoleTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.ChangeUserRoleOutput, err error) {
+				requireSvcErr(t, err, service.ErrForbidden)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "UserNotFound",
+			input: &service.ChangeUserRoleInput{
+				AdminID: adminID,
+				UserID:  user.ID,
+				Role:    util.LenderRole,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, pgx.ErrNoRows)
+				store.EXPECT().
+					UpdateUserRoleTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.ChangeUserRoleOutput, err error) {
+				requireSvcErr(t, err, service.ErrNotFound)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "DBErrConnDone",
+			input: &service.ChangeUserRoleInput{
+				AdminID: adminID,
+				UserID:  user.ID,
+				Role:    util.LenderRole,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserRoleTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AuditedUserTxResult{}, sql.ErrConnDone)
+			},
+			checkOutput: func(output *service.ChangeUserRoleOutput, err error) {
+				requireSvcErr(t, err, service.ErrInternalFailure)
+				require.Nil(t, output)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			adminService := NewAdminServiceImpl(store, testPasswordPolicy(t), metrics.New())
+
+			output, err := adminService.ChangeUserRole(context.Background(), tc.input)
+			tc.checkOutput(output, err)
+		})
+	}
+}
+
+func TestSuspendUser(t *testing.T) {
+	adminID := uuid.New()
+	user, _ := expectedUser(t)
+	user.Status = util.ActiveStatus
+	reason := util.RandomString(20)
+
+	testCases := []struct {
+		name        string
+		input       *service.SuspendUserInput
+		buildStubs  func(store *mockdb.MockStore)
+		checkOutput func(output *service.SuspendUserOutput, err error)
+	}{
+		{
+			name: "OK",
+			input: &service.SuspendUserInput{
+				AdminID: adminID,
+				UserID:  user.ID,
+				Reason:  reason,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+
+				suspendedUser := user
+				suspendedUser.Status = util.SuspendedStatus
+				store.EXPECT().
+					UpdateUserStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateUserStatusTxParams) (db.AuditedUserTxResult, error) {
+						require.Equal(t, util.SuspendedStatus, arg.Status)
+						require.Equal(t, util.AuditActionSuspendUser, arg.Audit.Action)
+
+						var details map[string]string
+						require.NoError(t, json.Unmarshal(arg.Audit.Details, &details))
+						require.Equal(t, reason, details["reason"])
+						return db.AuditedUserTxResult{User: suspendedUser}, nil
+					})
+			},
+			checkOutput: func(output *service.SuspendUserOutput, err error) {
+				require.NoError(t, err)
+				require.Equal(t, util.SuspendedStatus, output.User.Status)
+			},
+		},
+		{
+			name: "ClosedAccount",
+			input: &service.SuspendUserInput{
+				AdminID: adminID,
+				UserID:  user.ID,
+				Reason:  reason,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				closedUser := user
+				closedUser.Status = util.ClosedStatus
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(closedUser, nil)
+				store.EXPECT().
+					UpdateUserStatusTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.SuspendUserOutput, err error) {
+				requireSvcErr(t, err, service.ErrConflict)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "Themselves",
+			input: &service.SuspendUserInput{
+				AdminID: adminID,
+				UserID:  adminID,
+				Reason:  reason,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserStatusTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.SuspendUserOutput, err error) {
+				requireSvcErr(t, err, service.ErrForbidden)
+				require.Nil(t, output)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			adminService := NewAdminServiceImpl(store, testPasswordPolicy(t), metrics.New())
+
+			output, err := adminService.SuspendUser(context.Background(), tc.input)
+			tc.checkOutput(output, err)
+		})
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	admin, _ := expectedUser(t)
+	admin.Role = util.AdminRole
+
+	testCases := []struct {
+		name       string
+		buildUser  func(user db.User) db.User
+		checkError func(err error)
+	}{
+		{
+			name:      "OK",
+			buildUser: func(user db.User) db.User { return user },
+			checkError: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "Suspended",
+			buildUser: func(user db.User) db.User {
+				user.Status = util.SuspendedStatus
+				return user
+			},
+			checkError: func(err error) {
+				requireSvcErr(t, err, service.ErrAccountInactive)
+			},
+		},
+		{
+			name: "Demoted",
+			buildUser: func(user db.User) db.User {
+				user.Role = util.LenderRole
+				return user
+			},
+			checkError: func(err error) {
+				requireSvcErr(t, err, service.ErrForbidden)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUserByID(PrimaryContext(), gomock.Eq(admin.ID)).
+				Times(1).
+				Return(tc.buildUser(admin), nil)
+
+			adminService := NewAdminServiceImpl(store, testPasswordPolicy(t), metrics.New())
+
+			err := adminService.RequireAdmin(context.Background(), admin.ID)
+			tc.checkError(err)
+		})
+	}
+}
@@ -0,0 +1,385 @@
+package impl
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// apiKeyIDBytes is the number of random bytes in the public ID of an API key
+	apiKeyIDBytes = 12
+	// apiKeySecretBytes is the number of random bytes in the secret of an API key
+	apiKeySecretBytes = 32
+)
+
+func NewAPIKeyServiceImpl(apiKeyStore db.Store, signingSecret string, signatureWindow time.Duration) service.APIKeyService {
+	return &apiKeyServiceImpl{
+		apiKeyStore:     apiKeyStore,
+		signingSecret:   signingSecret,
+		signatureWindow: signatureWindow,
+	}
+}
+
+type apiKeyServiceImpl struct {
+	apiKeyStore db.Store
+	// signingSecret derives the signing keys of the API keys from their hashed secrets
+	signingSecret string
+	// signatureWindow is how far the timestamp of a signed request may be from the server clock
+	signatureWindow time.Duration
+}
+
+func (svc *apiKeyServiceImpl) IssueAPIKey(ctx context.Context, input *service.IssueAPIKeyInput) (*service.IssueAPIKeyOutput, error) {
+	partner, err := svc.apiKeyStore.GetUserByID(ctx, input.PartnerID)
+	if err != nil {
+		return nil, fromDBError(err)
+	}
+
+	if partner.Role != util.PartnerRole {
+		return nil, service.NewErrorWithDetail(service.ErrValidation, i18n.NewMessage("api_key.not_partner").With("role", partner.Role))
+	}
+
+	err = checkUserIsActive(partner)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return nil, service.NewErrorWithDetail(service.ErrValidation, i18n.NewMessage("api_key.expires_in_past"))
+	}
+
+	arg, key, signingKey, err := newCreateAPIKeyParams(svc.signingSecret, partner.ID, input.Name, input.Scopes, optionalTimestamptz(input.ExpiresAt))
+	if err != nil {
+		return nil, err
+	}
+
+	details, err := json.Marshal(map[string]any{
+		"key_id": arg.KeyID,
+		"name":   arg.Name,
+		"scopes": arg.Scopes,
+	})
+	if err != nil {
+		return nil, service.NewError(service.ErrInternalFailure, err)
+	}
+
+	result, err := svc.apiKeyStore.CreateAPIKeyTx(ctx, db.CreateAPIKeyTxParams{
+		CreateAPIKeyParams: arg,
+		Audit: db.AuditParams{
+			ActorID: input.AdminID,
+			Action:  util.AuditActionIssueAPIKey,
+			Details: details,
+		},
+	})
+	if err != nil {
+		return nil, fromDBError(err)
+	}
+
+	output := &service.IssueAPIKeyOutput{
+		APIKey:     result.APIKey,
+		Key:        key,
+		SigningKey: signingKey,
+	}
+	return output, nil
+}
+
+func (svc *apiKeyServiceImpl) ListAPIKeys(ctx context.Context, input *service.ListAPIKeysInput) (*service.ListAPIKeysOutput, error) {
+	apiKeys, err := svc.apiKeyStore.GetAPIKeysByPartner(ctx, input.PartnerID)
+	if err != nil {
+		return nil, fromDBError(err)
+	}
+
+	output := &service.ListAPIKeysOutput{
+		APIKeys: apiKeys,
+	}
+	return output, nil
+}
+
+func (svc *apiKeyServiceImpl) RotateAPIKey(ctx context.Context, input *service.RotateAPIKeyInput) (*service.RotateAPIKeyOutput, error) {
+	apiKey, err := svc.getUsableAPIKey(ctx, input.APIKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the new key inherits the scopes and the expiry of the rotated one
+	arg, key, signingKey, err := newCreateAPIKeyParams(svc.signingSecret, apiKey.PartnerID, apiKey.Name, apiKey.Scopes, apiKey.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	graceEndsAt := time.Now().Add(input.GracePeriod)
+	details, err := json.Marshal(map[string]any{
+		"old_key_id":    apiKey.KeyID,
+		"new_key_id":    arg.KeyID,
+		"grace_ends_at": graceEndsAt,
+	})
+	if err != nil {
+		return nil, service.NewError(service.ErrInternalFailure, err)
+	}
+
+	result, err := svc.apiKeyStore.RotateAPIKeyTx(ctx, db.RotateAPIKeyTxParams{
+		ExpireAPIKeyParams: db.ExpireAPIKeyParams{
+			ID:        apiKey.ID,
+			ExpiresAt: graceEndsAt,
+		},
+		NewAPIKey: arg,
+		Audit: db.AuditParams{
+			ActorID: input.AdminID,
+			Action:  util.AuditActionRotateAPIKey,
+			Details: details,
+		},
+	})
+	if err != nil {
+		return nil, fromDBError(err)
+	}
+
+	output := &service.RotateAPIKeyOutput{
+		OldAPIKey:  result.OldAPIKey,
+		APIKey:     result.APIKey,
+		Key:        key,
+		SigningKey: signingKey,
+	}
+	return output, nil
+}
+
+func (svc *apiKeyServiceImpl) RevokeAPIKey(ctx context.Context, input *service.RevokeAPIKeyInput) (*service.RevokeAPIKeyOutput, error) {
+	apiKey, err := svc.apiKeyStore.GetAPIKey(ctx, input.APIKeyID)
+	if err != nil {
+		return nil, fromDBError(err)
+	}
+
+	if apiKey.RevokedAt.Valid {
+		return nil, service.NewErrorWithDetail(service.ErrConflict, i18n.NewMessage("api_key.revoked"))
+	}
+
+	details, err := json.Marshal(map[string]string{
+		"key_id": apiKey.KeyID,
+		"reason": input.Reason,
+	})
+	if err != nil {
+		return nil, service.NewError(service.ErrInternalFailure, err)
+	}
+
+	result, err := svc.apiKeyStore.RevokeAPIKeyTx(ctx, db.RevokeAPIKeyTxParams{
+		RevokeAPIKeyParams: db.RevokeAPIKeyParams{
+			ID:        apiKey.ID,
+			RevokedAt: time.Now(),
+		},
+		Audit: db.AuditParams{
+			ActorID: input.AdminID,
+			Action:  util.AuditActionRevokeAPIKey,
+			Details: details,
+		},
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// revoked concurrently
+			return nil, service.NewErrorWithDetail(service.ErrConflict, i18n.NewMessage("api_key.revoked"))
+		}
+		return nil, fromDBError(err)
+	}
+
+	output := &service.RevokeAPIKeyOutput{
+		APIKey: result.APIKey,
+	}
+	return output, nil
+}
+
+func (svc *apiKeyServiceImpl) AuthenticateAPIKey(ctx context.Context, input *service.AuthenticateAPIKeyInput) (*service.AuthenticateAPIKeyOutput, error) {
+	keyID, secret, ok := util.ParseAPIKey(input.Key)
+	if !ok {
+		return nil, service.NewErrorWithDetail(service.ErrUnauthenticated, i18n.NewMessage("api_key.invalid"))
+	}
+
+	apiKey, err := svc.getAPIKeyByKeyID(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	hashedSecret := util.HashAPIKeySecret(secret)
+	if subtle.ConstantTimeCompare([]byte(hashedSecret), []byte(apiKey.HashedSecret)) != 1 {
+		return nil, service.NewErrorWithDetail(service.ErrUnauthenticated, i18n.NewMessage("api_key.invalid"))
+	}
+
+	return svc.authenticated(ctx, apiKey)
+}
+
+func (svc *apiKeyServiceImpl) AuthenticateSignedRequest(ctx context.Context, input *service.AuthenticateSignedRequestInput) (*service.AuthenticateAPIKeyOutput, error) {
+	signedAt := time.Unix(input.Timestamp, 0)
+	if d := time.Since(signedAt); d > svc.signatureWindow || d < -svc.signatureWindow {
+		return nil, service.NewErrorWithDetail(service.ErrUnauthenticated, i18n.NewMessage("api_key.timestamp_out_of_window").With("window", svc.signatureWindow.String()))
+	}
+
+	apiKey, err := svc.getAPIKeyByKeyID(ctx, input.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	stringToSign := util.APIKeyStringToSign(input.Method, input.Path, input.Timestamp, input.Nonce, input.Body)
+	signingKey := util.APIKeySigningKey(svc.signingSecret, apiKey.HashedSecret)
+	expected, err := util.SignAPIRequest(signingKey, stringToSign)
+	if err != nil {
+		return nil, service.NewError(service.ErrInternalFailure, err)
+	}
+
+	if !hmac.Equal([]byte(strings.ToLower(input.Signature)), []byte(expected)) {
+		return nil, service.NewErrorWithDetail(service.ErrUnauthenticated, i18n.NewMessage("api_key.signature_invalid"))
+	}
+
+	// the nonce is only recorded once the signature is verified,
+	// so that nobody without the signing key can burn the nonces of a partner
+	rows, err := svc.apiKeyStore.CreateAPIKeyNonce(ctx, db.CreateAPIKeyNonceParams{
+		APIKeyID: apiKey.ID,
+		Nonce:    input.Nonce,
+	})
+	if err != nil {
+		return nil, fromDBError(err)
+	}
+	if rows == 0 {
+		return nil, service.NewErrorWithDetail(service.ErrUnauthenticated, i18n.NewMessage("api_key.nonce_reused"))
+	}
+
+	return svc.authenticated(ctx, apiKey)
+}
+
+func (svc *apiKeyServiceImpl) PurgeExpiredNonces(ctx context.Context) (int64, error) {
+	// a request is accepted until its timestamp is one window behind the server clock,
+	// and its timestamp may be one window ahead of it when its nonce is recorded
+	before := time.Now().Add(-2 * svc.signatureWindow)
+
+	deleted, err := svc.apiKeyStore.DeleteAPIKeyNoncesBefore(ctx, before)
+	if err != nil {
+		return 0, fromDBError(err)
+	}
+
+	return deleted, nil
+}
+
+// authenticated checks that an API key whose secret was verified can still be used
+// and attributes the request to the partner owning it
+func (svc *apiKeyServiceImpl) authenticated(ctx context.Context, apiKey db.APIKey) (*service.AuthenticateAPIKeyOutput, error) {
+	if msg := apiKeyUnusableReason(apiKey); !msg.IsZero() {
+		return nil, service.NewErrorWithDetail(service.ErrUnauthenticated, msg)
+	}
+
+	partner, err := svc.apiKeyStore.GetUserByID(ctx, apiKey.PartnerID)
+	if err != nil {
+		return nil, fromDBError(err)
+	}
+
+	err = checkUserIsActive(partner)
+	if err != nil {
+		return nil, err
+	}
+
+	// last_used_at only helps admins spot stale keys, so failing to update it does not fail the request
+	err = svc.apiKeyStore.TouchAPIKey(ctx, db.TouchAPIKeyParams{
+		ID:         apiKey.ID,
+		LastUsedAt: time.Now(),
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "can not update last use of API key", "api_key_id", apiKey.KeyID, "err", err)
+	}
+
+	output := &service.AuthenticateAPIKeyOutput{
+		APIKey:  apiKey,
+		Partner: partner,
+	}
+	return output, nil
+}
+
+// getAPIKeyByKeyID looks up an API key by its public ID.
+// An unknown ID is reported like a wrong secret so that callers cannot probe for key IDs.
+func (svc *apiKeyServiceImpl) getAPIKeyByKeyID(ctx context.Context, keyID string) (db.APIKey, error) {
+	apiKey, err := svc.apiKeyStore.GetAPIKeyByKeyID(ctx, keyID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.APIKey{}, service.NewErrorWithDetail(service.ErrUnauthenticated, i18n.NewMessage("api_key.invalid"))
+		}
+		return db.APIKey{}, fromDBError(err)
+	}
+
+	return apiKey, nil
+}
+
+// getUsableAPIKey looks up an API key which is neither revoked nor expired
+func (svc *apiKeyServiceImpl) getUsableAPIKey(ctx context.Context, id uuid.UUID) (db.APIKey, error) {
+	apiKey, err := svc.apiKeyStore.GetAPIKey(ctx, id)
+	if err != nil {
+		return db.APIKey{}, fromDBError(err)
+	}
+
+	if msg := apiKeyUnusableReason(apiKey); !msg.IsZero() {
+		return db.APIKey{}, service.NewErrorWithDetail(service.ErrConflict, msg)
+	}
+
+	return apiKey, nil
+}
+
+// apiKeyUnusableReason explains why an API key can not be used anymore, if it can not
+func apiKeyUnusableReason(apiKey db.APIKey) i18n.Message {
+	if apiKey.RevokedAt.Valid {
+		return i18n.NewMessage("api_key.revoked")
+	}
+	if apiKey.ExpiresAt.Valid && !apiKey.ExpiresAt.Time.After(time.Now()) {
+		return i18n.NewMessage("api_key.expired")
+	}
+	return i18n.Message{}
+}
+
+// newCreateAPIKeyParams generates a new API key for a partner.
+// It returns the key and the signing key, which are handed to the partner but never stored.
+func newCreateAPIKeyParams(signingSecret string, partnerID uuid.UUID, name string, scopes []string, expiresAt pgtype.Timestamptz) (arg db.CreateAPIKeyParams, key, signingKey string, err error) {
+	id, err := randomBytes(apiKeyIDBytes)
+	if err != nil {
+		return
+	}
+	rawSecret, err := randomBytes(apiKeySecretBytes)
+	if err != nil {
+		return
+	}
+
+	keyID := util.APIKeyIDPrefix + hex.EncodeToString(id)
+	secret := base64.RawURLEncoding.EncodeToString(rawSecret)
+	hashedSecret := util.HashAPIKeySecret(secret)
+	signingKey = util.APIKeySigningKey(signingSecret, hashedSecret)
+
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+
+	arg = db.CreateAPIKeyParams{
+		PartnerID:    partnerID,
+		Name:         name,
+		KeyID:        keyID,
+		HashedSecret: hashedSecret,
+		Scopes:       slices.Compact(scopes),
+		ExpiresAt:    expiresAt,
+	}
+	return arg, util.FormatAPIKey(keyID, secret), signingKey, nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return nil, service.NewError(service.ErrInternalFailure, fmt.Errorf("can not generate random bytes: %w", err))
+	}
+	return b, nil
+}
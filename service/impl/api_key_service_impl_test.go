@@ -0,0 +1,822 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/DamianZhang/957-lending-platform/db/mock"
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+const testSignatureWindow = 5 * time.Minute
+
+var testAPIKeySigningSecret = util.RandomString(32)
+
+func TestIssueAPIKey(t *testing.T) {
+	admin, _ := expectedUser(t)
+	partner := expectedPartner(t)
+	expiresAt := time.Now().Add(24 * time.Hour)
+	expiredAt := time.Now().Add(-time.Minute)
+
+	testCases := []struct {
+		name        string
+		input       *service.IssueAPIKeyInput
+		buildStubs  func(store *mockdb.MockStore)
+		checkOutput func(output *service.IssueAPIKeyOutput, err error)
+	}{
+		{
+			name: "OK",
+			input: &service.IssueAPIKeyInput{
+				AdminID:   admin.ID,
+				PartnerID: partner.ID,
+				Name:      "checkout",
+				Scopes:    []string{util.ScopeBorrowersWrite, util.ScopeBorrowersRead, util.ScopeBorrowersWrite},
+				ExpiresAt: &expiresAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Eq(partner.ID)).
+					Times(1).
+					Return(partner, nil)
+				store.EXPECT().
+					CreateAPIKeyTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateAPIKeyTxParams) (db.APIKeyTxResult, error) {
+						require.Equal(t, partner.ID, arg.PartnerID)
+						require.Equal(t, "checkout", arg.Name)
+						require.Equal(t, []string{util.ScopeBorrowersRead, util.ScopeBorrowersWrite}, arg.Scopes)
+						require.Equal(t, expiresAt, arg.ExpiresAt.Time)
+						require.Equal(t, admin.ID, arg.Audit.ActorID)
+						require.Equal(t, util.AuditActionIssueAPIKey, arg.Audit.Action)
+						require.NotContains(t, string(arg.Audit.Details), arg.HashedSecret)
+						return db.APIKeyTxResult{APIKey: newAPIKey(arg.CreateAPIKeyParams)}, nil
+					})
+			},
+			checkOutput: func(output *service.IssueAPIKeyOutput, err error) {
+				require.NoError(t, err)
+
+				keyID, secret, ok := util.ParseAPIKey(output.Key)
+				require.True(t, ok)
+				require.Equal(t, output.APIKey.KeyID, keyID)
+				require.Equal(t, util.HashAPIKeySecret(secret), output.APIKey.HashedSecret)
+				require.Equal(t, util.APIKeySigningKey(testAPIKeySigningSecret, output.APIKey.HashedSecret), output.SigningKey)
+				// the stored hash alone does not give away the signing key
+				require.NotEqual(t, output.SigningKey, output.APIKey.HashedSecret)
+			},
+		},
+		{
+			name: "NotPartner",
+			input: &service.IssueAPIKeyInput{
+				AdminID:   admin.ID,
+				PartnerID: admin.ID,
+				Name:      "checkout",
+				Scopes:    []string{util.ScopeBorrowersRead},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Eq(admin.ID)).
+					Times(1).
+					Return(admin, nil)
+				store.EXPECT().
+					CreateAPIKeyTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.IssueAPIKeyOutput, err error) {
+				requireSvcErr(t, err, service.ErrValidation)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "PartnerInactive",
+			input: &service.IssueAPIKeyInput{
+				AdminID:   admin.ID,
+				PartnerID: partner.ID,
+				Name:      "checkout",
+				Scopes:    []string{util.ScopeBorrowersRead},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				suspendedPartner := partner
+				suspendedPartner.Status = util.SuspendedStatus
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(suspendedPartner, nil)
+				store.EXPECT().
+					CreateAPIKeyTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.IssueAPIKeyOutput, err error) {
+				requireSvcErr(t, err, service.ErrAccountInactive)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "ExpiresInPast",
+			input: &service.IssueAPIKeyInput{
+				AdminID:   admin.ID,
+				PartnerID: partner.ID,
+				Name:      "checkout",
+				Scopes:    []string{util.ScopeBorrowersRead},
+				ExpiresAt: &expiredAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(partner, nil)
+				store.EXPECT().
+					CreateAPIKeyTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.IssueAPIKeyOutput, err error) {
+				requireSvcErr(t, err, service.ErrValidation)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "PartnerNotFound",
+			input: &service.IssueAPIKeyInput{
+				AdminID:   admin.ID,
+				PartnerID: partner.ID,
+				Name:      "checkout",
+				Scopes:    []string{util.ScopeBorrowersRead},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, pgx.ErrNoRows)
+				store.EXPECT().
+					CreateAPIKeyTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.IssueAPIKeyOutput, err error) {
+				requireSvcErr(t, err, service.ErrNotFound)
+				require.Nil(t, output)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			apiKeyService := NewAPIKeyServiceImpl(store, testAPIKeySigningSecret, testSignatureWindow)
+
+			output, err := apiKeyService.IssueAPIKey(context.Background(), tc.input)
+			tc.checkOutput(output, err)
+		})
+	}
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	admin, _ := expectedUser(t)
+	partner := expectedPartner(t)
+	apiKey, _ := expectedAPIKey(t, partner)
+
+	input := &service.RotateAPIKeyInput{
+		AdminID:     admin.ID,
+		APIKeyID:    apiKey.ID,
+		GracePeriod: time.Hour,
+	}
+
+	testCases := []struct {
+		name        string
+		buildStubs  func(store *mockdb.MockStore)
+		checkOutput func(output *service.RotateAPIKeyOutput, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKey(gomock.Any(), gomock.Eq(apiKey.ID)).
+					Times(1).
+					Return(apiKey, nil)
+				store.EXPECT().
+					RotateAPIKeyTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RotateAPIKeyTxParams) (db.RotateAPIKeyTxResult, error) {
+						require.Equal(t, apiKey.ID, arg.ExpireAPIKeyParams.ID)
+						require.WithinDuration(t, time.Now().Add(time.Hour), arg.ExpireAPIKeyParams.ExpiresAt, time.Second)
+						require.Equal(t, apiKey.PartnerID, arg.NewAPIKey.PartnerID)
+						require.Equal(t, apiKey.Scopes, arg.NewAPIKey.Scopes)
+						require.NotEqual(t, apiKey.KeyID, arg.NewAPIKey.KeyID)
+						require.Equal(t, util.AuditActionRotateAPIKey, arg.Audit.Action)
+
+						oldAPIKey := apiKey
+						oldAPIKey.ExpiresAt = pgtype.Timestamptz{Time: arg.ExpireAPIKeyParams.ExpiresAt, Valid: true}
+						return db.RotateAPIKeyTxResult{
+							OldAPIKey: oldAPIKey,
+							APIKey:    newAPIKey(arg.NewAPIKey),
+						}, nil
+					})
+			},
+			checkOutput: func(output *service.RotateAPIKeyOutput, err error) {
+				require.NoError(t, err)
+				require.Equal(t, apiKey.ID, output.OldAPIKey.ID)
+
+				keyID, _, ok := util.ParseAPIKey(output.Key)
+				require.True(t, ok)
+				require.Equal(t, output.APIKey.KeyID, keyID)
+				require.Equal(t, util.APIKeySigningKey(testAPIKeySigningSecret, output.APIKey.HashedSecret), output.SigningKey)
+			},
+		},
+		{
+			name: "Revoked",
+			buildStubs: func(store *mockdb.MockStore) {
+				revokedAPIKey := apiKey
+				revokedAPIKey.RevokedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+				store.EXPECT().
+					GetAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(revokedAPIKey, nil)
+				store.EXPECT().
+					RotateAPIKeyTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.RotateAPIKeyOutput, err error) {
+				requireSvcErr(t, err, service.ErrConflict)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "Expired",
+			buildStubs: func(store *mockdb.MockStore) {
+				expiredAPIKey := apiKey
+				expiredAPIKey.ExpiresAt = pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true}
+				store.EXPECT().
+					GetAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(expiredAPIKey, nil)
+				store.EXPECT().
+					RotateAPIKeyTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.RotateAPIKeyOutput, err error) {
+				requireSvcErr(t, err, service.ErrConflict)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "NotFound",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.APIKey{}, pgx.ErrNoRows)
+				store.EXPECT().
+					RotateAPIKeyTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.RotateAPIKeyOutput, err error) {
+				requireSvcErr(t, err, service.ErrNotFound)
+				require.Nil(t, output)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			apiKeyService := NewAPIKeyServiceImpl(store, testAPIKeySigningSecret, testSignatureWindow)
+
+			output, err := apiKeyService.RotateAPIKey(context.Background(), input)
+			tc.checkOutput(output, err)
+		})
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	admin, _ := expectedUser(t)
+	apiKey, _ := expectedAPIKey(t, expectedPartner(t))
+
+	input := &service.RevokeAPIKeyInput{
+		AdminID:  admin.ID,
+		APIKeyID: apiKey.ID,
+		Reason:   "leaked in a public repository",
+	}
+
+	testCases := []struct {
+		name        string
+		buildStubs  func(store *mockdb.MockStore)
+		checkOutput func(output *service.RevokeAPIKeyOutput, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKey(gomock.Any(), gomock.Eq(apiKey.ID)).
+					Times(1).
+					Return(apiKey, nil)
+				store.EXPECT().
+					RevokeAPIKeyTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RevokeAPIKeyTxParams) (db.APIKeyTxResult, error) {
+						require.Equal(t, apiKey.ID, arg.ID)
+						require.Equal(t, admin.ID, arg.Audit.ActorID)
+						require.Equal(t, util.AuditActionRevokeAPIKey, arg.Audit.Action)
+						require.Contains(t, string(arg.Audit.Details), input.Reason)
+
+						revokedAPIKey := apiKey
+						revokedAPIKey.RevokedAt = pgtype.Timestamptz{Time: arg.RevokedAt, Valid: true}
+						return db.APIKeyTxResult{APIKey: revokedAPIKey}, nil
+					})
+			},
+			checkOutput: func(output *service.RevokeAPIKeyOutput, err error) {
+				require.NoError(t, err)
+				require.True(t, output.APIKey.RevokedAt.Valid)
+			},
+		},
+		{
+			name: "AlreadyRevoked",
+			buildStubs: func(store *mockdb.MockStore) {
+				revokedAPIKey := apiKey
+				revokedAPIKey.RevokedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+				store.EXPECT().
+					GetAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(revokedAPIKey, nil)
+				store.EXPECT().
+					RevokeAPIKeyTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.RevokeAPIKeyOutput, err error) {
+				requireSvcErr(t, err, service.ErrConflict)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "RevokedConcurrently",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(apiKey, nil)
+				store.EXPECT().
+					RevokeAPIKeyTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.APIKeyTxResult{}, pgx.ErrNoRows)
+			},
+			checkOutput: func(output *service.RevokeAPIKeyOutput, err error) {
+				requireSvcErr(t, err, service.ErrConflict)
+				require.Nil(t, output)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			apiKeyService := NewAPIKeyServiceImpl(store, testAPIKeySigningSecret, testSignatureWindow)
+
+			output, err := apiKeyService.RevokeAPIKey(context.Background(), input)
+			tc.checkOutput(output, err)
+		})
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	partner := expectedPartner(t)
+	apiKey, key := expectedAPIKey(t, partner)
+	keyID, _, _ := util.ParseAPIKey(key)
+
+	testCases := []struct {
+		name        string
+		key         string
+		buildStubs  func(store *mockdb.MockStore)
+		checkOutput func(output *service.AuthenticateAPIKeyOutput, err error)
+	}{
+		{
+			name: "OK",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKeyByKeyID(gomock.Any(), gomock.Eq(keyID)).
+					Times(1).
+					Return(apiKey, nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Eq(partner.ID)).
+					Times(1).
+					Return(partner, nil)
+				store.EXPECT().
+					TouchAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkOutput: func(output *service.AuthenticateAPIKeyOutput, err error) {
+				require.NoError(t, err)
+				require.Equal(t, apiKey, output.APIKey)
+				require.Equal(t, partner, output.Partner)
+			},
+		},
+		{
+			name: "TouchFailed",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKeyByKeyID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(apiKey, nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(partner, nil)
+				store.EXPECT().
+					TouchAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sql.ErrConnDone)
+			},
+			checkOutput: func(output *service.AuthenticateAPIKeyOutput, err error) {
+				require.NoError(t, err)
+				require.Equal(t, partner, output.Partner)
+			},
+		},
+		{
+			name: "Malformed",
+			key:  "not-an-api-key",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKeyByKeyID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.AuthenticateAPIKeyOutput, err error) {
+				requireSvcErr(t, err, service.ErrUnauthenticated)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "UnknownKeyID",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKeyByKeyID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.APIKey{}, pgx.ErrNoRows)
+			},
+			checkOutput: func(output *service.AuthenticateAPIKeyOutput, err error) {
+				var svcError service.Error
+				require.ErrorAs(t, err, &svcError)
+				require.ErrorIs(t, svcError.SvcErr(), service.ErrUnauthenticated)
+				require.Equal(t, i18n.NewMessage("api_key.invalid"), svcError.Detail())
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "WrongSecret",
+			key:  util.FormatAPIKey(keyID, util.RandomString(43)),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKeyByKeyID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(apiKey, nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.AuthenticateAPIKeyOutput, err error) {
+				var svcError service.Error
+				require.ErrorAs(t, err, &svcError)
+				require.ErrorIs(t, svcError.SvcErr(), service.ErrUnauthenticated)
+				require.Equal(t, i18n.NewMessage("api_key.invalid"), svcError.Detail())
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "Revoked",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				revokedAPIKey := apiKey
+				revokedAPIKey.RevokedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+				store.EXPECT().
+					GetAPIKeyByKeyID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(revokedAPIKey, nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.AuthenticateAPIKeyOutput, err error) {
+				requireSvcErr(t, err, service.ErrUnauthenticated)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "Expired",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				expiredAPIKey := apiKey
+				expiredAPIKey.ExpiresAt = pgtype.Timestamptz{Time: time.Now().Add(-time.Second), Valid: true}
+				store.EXPECT().
+					GetAPIKeyByKeyID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(expiredAPIKey, nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.AuthenticateAPIKeyOutput, err error) {
+				requireSvcErr(t, err, service.ErrUnauthenticated)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "PartnerSuspended",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				suspendedPartner := partner
+				suspendedPartner.Status = util.SuspendedStatus
+				store.EXPECT().
+					GetAPIKeyByKeyID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(apiKey, nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(suspendedPartner, nil)
+				store.EXPECT().
+					TouchAPIKey(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.AuthenticateAPIKeyOutput, err error) {
+				requireSvcErr(t, err, service.ErrAccountInactive)
+				require.Nil(t, output)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			apiKeyService := NewAPIKeyServiceImpl(store, testAPIKeySigningSecret, testSignatureWindow)
+
+			output, err := apiKeyService.AuthenticateAPIKey(context.Background(), &service.AuthenticateAPIKeyInput{Key: tc.key})
+			tc.checkOutput(output, err)
+		})
+	}
+}
+
+func TestAuthenticateSignedRequest(t *testing.T) {
+	partner := expectedPartner(t)
+	apiKey, _ := expectedAPIKey(t, partner)
+	body := []byte(`{"email":"borrower@example.com"}`)
+
+	signedInput := func(timestamp time.Time, body []byte) *service.AuthenticateSignedRequestInput {
+		input := &service.AuthenticateSignedRequestInput{
+			KeyID:     apiKey.KeyID,
+			Timestamp: timestamp.Unix(),
+			Nonce:     util.RandomString(16),
+			Method:    "POST",
+			Path:      "/api/v1/partner/borrowers",
+			Body:      body,
+		}
+
+		stringToSign := util.APIKeyStringToSign(input.Method, input.Path, input.Timestamp, input.Nonce, input.Body)
+		signature, err := util.SignAPIRequest(util.APIKeySigningKey(testAPIKeySigningSecret, apiKey.HashedSecret), stringToSign)
+		require.NoError(t, err)
+		input.Signature = signature
+		return input
+	}
+
+	testCases := []struct {
+		name        string
+		input       *service.AuthenticateSignedRequestInput
+		buildStubs  func(store *mockdb.MockStore)
+		checkOutput func(output *service.AuthenticateAPIKeyOutput, err error)
+	}{
+		{
+			name:  "OK",
+			input: signedInput(time.Now(), body),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKeyByKeyID(gomock.Any(), gomock.Eq(apiKey.KeyID)).
+					Times(1).
+					Return(apiKey, nil)
+				store.EXPECT().
+					CreateAPIKeyNonce(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(1), nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Eq(partner.ID)).
+					Times(1).
+					Return(partner, nil)
+				store.EXPECT().
+					TouchAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkOutput: func(output *service.AuthenticateAPIKeyOutput, err error) {
+				require.NoError(t, err)
+				require.Equal(t, apiKey, output.APIKey)
+				require.Equal(t, partner, output.Partner)
+			},
+		},
+		{
+			name:  "TimestampTooOld",
+			input: signedInput(time.Now().Add(-testSignatureWindow-time.Minute), body),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKeyByKeyID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.AuthenticateAPIKeyOutput, err error) {
+				requireSvcErr(t, err, service.ErrUnauthenticated)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name:  "TimestampInFuture",
+			input: signedInput(time.Now().Add(testSignatureWindow+time.Minute), body),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKeyByKeyID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.AuthenticateAPIKeyOutput, err error) {
+				requireSvcErr(t, err, service.ErrUnauthenticated)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "TamperedBody",
+			input: func() *service.AuthenticateSignedRequestInput {
+				input := signedInput(time.Now(), body)
+				input.Body = []byte(`{"email":"attacker@example.com"}`)
+				return input
+			}(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKeyByKeyID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(apiKey, nil)
+				store.EXPECT().
+					CreateAPIKeyNonce(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.AuthenticateAPIKeyOutput, err error) {
+				var svcError service.Error
+				require.ErrorAs(t, err, &svcError)
+				require.ErrorIs(t, svcError.SvcErr(), service.ErrUnauthenticated)
+				require.Equal(t, i18n.NewMessage("api_key.signature_invalid"), svcError.Detail())
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "SignedWithStoredHash",
+			input: func() *service.AuthenticateSignedRequestInput {
+				input := signedInput(time.Now(), body)
+				stringToSign := util.APIKeyStringToSign(input.Method, input.Path, input.Timestamp, input.Nonce, input.Body)
+				signature, err := util.SignAPIRequest(apiKey.HashedSecret, stringToSign)
+				require.NoError(t, err)
+				input.Signature = signature
+				return input
+			}(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKeyByKeyID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(apiKey, nil)
+				store.EXPECT().
+					CreateAPIKeyNonce(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.AuthenticateAPIKeyOutput, err error) {
+				var svcError service.Error
+				require.ErrorAs(t, err, &svcError)
+				require.ErrorIs(t, svcError.SvcErr(), service.ErrUnauthenticated)
+				require.Equal(t, i18n.NewMessage("api_key.signature_invalid"), svcError.Detail())
+				require.Nil(t, output)
+			},
+		},
+		{
+			name:  "NonceReused",
+			input: signedInput(time.Now(), body),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKeyByKeyID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(apiKey, nil)
+				store.EXPECT().
+					CreateAPIKeyNonce(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.AuthenticateAPIKeyOutput, err error) {
+				var svcError service.Error
+				require.ErrorAs(t, err, &svcError)
+				require.ErrorIs(t, svcError.SvcErr(), service.ErrUnauthenticated)
+				require.Equal(t, i18n.NewMessage("api_key.nonce_reused"), svcError.Detail())
+				require.Nil(t, output)
+			},
+		},
+		{
+			name:  "UnknownKeyID",
+			input: signedInput(time.Now(), body),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKeyByKeyID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.APIKey{}, pgx.ErrNoRows)
+				store.EXPECT().
+					CreateAPIKeyNonce(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.AuthenticateAPIKeyOutput, err error) {
+				requireSvcErr(t, err, service.ErrUnauthenticated)
+				require.Nil(t, output)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			apiKeyService := NewAPIKeyServiceImpl(store, testAPIKeySigningSecret, testSignatureWindow)
+
+			output, err := apiKeyService.AuthenticateSignedRequest(context.Background(), tc.input)
+			tc.checkOutput(output, err)
+		})
+	}
+}
+
+func TestPurgeExpiredNonces(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		DeleteAPIKeyNoncesBefore(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, before time.Time) (int64, error) {
+			require.WithinDuration(t, time.Now().Add(-2*testSignatureWindow), before, time.Second)
+			return 3, nil
+		})
+
+	apiKeyService := NewAPIKeyServiceImpl(store, testAPIKeySigningSecret, testSignatureWindow)
+
+	deleted, err := apiKeyService.PurgeExpiredNonces(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, 3, deleted)
+}
+
+func expectedPartner(t *testing.T) db.User {
+	partner, _ := expectedUser(t)
+	partner.Role = util.PartnerRole
+	return partner
+}
+
+// expectedAPIKey returns an API key of the partner, which expires in an hour, and the key handed to the partner
+func expectedAPIKey(t *testing.T, partner db.User) (db.APIKey, string) {
+	arg, key, _, err := newCreateAPIKeyParams(testAPIKeySigningSecret, partner.ID, util.RandomString(8), []string{util.ScopeBorrowersWrite}, pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true})
+	require.NoError(t, err)
+	return newAPIKey(arg), key
+}
+
+func newAPIKey(arg db.CreateAPIKeyParams) db.APIKey {
+	return db.APIKey{
+		ID:           uuid.New(),
+		CreatedAt:    time.Now(),
+		PartnerID:    arg.PartnerID,
+		Name:         arg.Name,
+		KeyID:        arg.KeyID,
+		HashedSecret: arg.HashedSecret,
+		Scopes:       arg.Scopes,
+		ExpiresAt:    arg.ExpiresAt,
+	}
+}
@@ -4,24 +4,32 @@ import (
 	"context"
 
 	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/metrics"
 	"github.com/DamianZhang/957-lending-platform/service"
 	"github.com/DamianZhang/957-lending-platform/util"
 )
 
-func NewBorrowerServiceImpl(borrowerStore db.Store) service.BorrowerService {
+func NewBorrowerServiceImpl(borrowerStore db.Store, passwordPolicy *util.PasswordPolicy, metrics *metrics.Metrics) service.BorrowerService {
 	return &borrowerServiceImpl{
-		borrowerStore: borrowerStore,
+		borrowerStore:  borrowerStore,
+		passwordPolicy: passwordPolicy,
+		metrics:        metrics,
 	}
 }
 
 type borrowerServiceImpl struct {
-	borrowerStore db.Store
+	borrowerStore  db.Store
+	passwordPolicy *util.PasswordPolicy
+	metrics        *metrics.Metrics
 }
 
-func (svc *borrowerServiceImpl) SignUp(ctx context.Context, input *service.SignUpInput) (*service.SignUpOutput, error) {
-	hashedPassword, err := util.HashPassword(input.Password)
+func (svc *borrowerServiceImpl) SignUp(ctx context.Context, input *service.SignUpInput) (output *service.SignUpOutput, err error) {
+	ctx, span := startSpan(ctx, "BorrowerService.SignUp")
+	defer func() { endSpan(span, err) }()
+
+	hashedPassword, err := hashNewPassword(ctx, svc.passwordPolicy, svc.metrics, input.Password)
 	if err != nil {
-		return nil, service.NewError(service.ErrInternalFailure, err)
+		return nil, err
 	}
 
 	arg := db.CreateUserParams{
@@ -35,10 +43,11 @@ func (svc *borrowerServiceImpl) SignUp(ctx context.Context, input *service.SignU
 
 	borrower, err := svc.borrowerStore.CreateUser(ctx, arg)
 	if err != nil {
-		return nil, service.NewError(service.ErrInternalFailure, err)
+		return nil, fromDBError(err)
 	}
+	svc.metrics.ObserveSignUp(borrower.Role)
 
-	output := &service.SignUpOutput{
+	output = &service.SignUpOutput{
 		Borrower: borrower,
 	}
 	return output, nil
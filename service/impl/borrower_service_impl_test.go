@@ -9,10 +9,19 @@ import (
 
 	mockdb "github.com/DamianZhang/957-lending-platform/db/mock"
 	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/metrics"
 	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/tracing/tracingtest"
 	"github.com/DamianZhang/957-lending-platform/util"
 	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type eqCreateUserParamsMatcher struct {
@@ -83,7 +92,7 @@ func TestSignUp(t *testing.T) {
 			name: "TooLongPassword",
 			input: &service.SignUpInput{
 				Email:    borrower.Email,
-				Password: "01234567890123456789012345678901234567890123456789012345678901234567890123456789",
+				Password: util.RandomString(util.MaxPasswordLength) + "1",
 				LineID:   borrower.LineID,
 				Nickname: borrower.Nickname,
 			},
@@ -95,7 +104,50 @@ func TestSignUp(t *testing.T) {
 			checkOutput: func(output *service.SignUpOutput, err error) {
 				var svcError service.Error
 				require.ErrorAs(t, err, &svcError)
-				require.ErrorIs(t, svcError.SvcErr(), service.ErrInternalFailure)
+				require.ErrorIs(t, svcError.SvcErr(), service.ErrValidation)
+				require.Equal(t, i18n.NewMessage("password.too_long").With("max_length", util.MaxPasswordLength), svcError.Detail())
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "TooShortPassword",
+			input: &service.SignUpInput{
+				Email:    borrower.Email,
+				Password: "abc123",
+				LineID:   borrower.LineID,
+				Nickname: borrower.Nickname,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.SignUpOutput, err error) {
+				var svcError service.Error
+				require.ErrorAs(t, err, &svcError)
+				require.ErrorIs(t, svcError.SvcErr(), service.ErrValidation)
+				require.Equal(t, i18n.NewMessage("password.too_short").With("min_length", 8), svcError.Detail())
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "DeniedPassword",
+			input: &service.SignUpInput{
+				Email:    borrower.Email,
+				Password: "password123",
+				LineID:   borrower.LineID,
+				Nickname: borrower.Nickname,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.SignUpOutput, err error) {
+				var svcError service.Error
+				require.ErrorAs(t, err, &svcError)
+				require.ErrorIs(t, svcError.SvcErr(), service.ErrValidation)
+				require.Equal(t, i18n.NewMessage("password.denied"), svcError.Detail())
 				require.Nil(t, output)
 			},
 		},
@@ -120,6 +172,28 @@ func TestSignUp(t *testing.T) {
 				require.Nil(t, output)
 			},
 		},
+		{
+			name: "DuplicateEmail",
+			input: &service.SignUpInput{
+				Email:    borrower.Email,
+				Password: password,
+				LineID:   borrower.LineID,
+				Nickname: borrower.Nickname,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, &pgconn.PgError{Code: uniqueViolation, ConstraintName: "users_email_key"})
+			},
+			checkOutput: func(output *service.SignUpOutput, err error) {
+				var svcError service.Error
+				require.ErrorAs(t, err, &svcError)
+				require.ErrorIs(t, svcError.SvcErr(), service.ErrConflict)
+				require.Equal(t, i18n.NewMessage("user.email_in_use"), svcError.Detail())
+				require.Nil(t, output)
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -130,16 +204,87 @@ func TestSignUp(t *testing.T) {
 			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
 
-			borrowerService := NewBorrowerServiceImpl(store)
+			m := metrics.New()
+			borrowerService := NewBorrowerServiceImpl(store, testPasswordPolicy(t), m)
 
 			output, err := borrowerService.SignUp(context.Background(), tc.input)
 			tc.checkOutput(output, err)
+
+			// only the borrowers actually created are counted as sign ups
+			expectedSignUps := 0
+			if err == nil {
+				expectedSignUps = 1
+			}
+			require.Equal(t, expectedSignUps, testutil.CollectAndCount(m.Registry, "lending_sign_ups_total"))
 		})
 	}
 }
 
+func TestSignUpSpans(t *testing.T) {
+	exporter := tracingtest.NewExporter(t)
+	borrower, password := expectedBorrower(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		CreateUser(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.CreateUserParams) (db.User, error) {
+			// the queries run within the span of the service
+			require.Equal(t, "BorrowerService.SignUp", trace.SpanFromContext(ctx).(sdktrace.ReadOnlySpan).Name())
+			return borrower, nil
+		})
+
+	borrowerService := NewBorrowerServiceImpl(store, testPasswordPolicy(t), metrics.New())
+	_, err := borrowerService.SignUp(context.Background(), &service.SignUpInput{
+		Email:    borrower.Email,
+		Password: password,
+		LineID:   borrower.LineID,
+		Nickname: borrower.Nickname,
+	})
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	signUpSpan := tracingtest.SpanNamed(t, spans, "BorrowerService.SignUp")
+	hashSpan := tracingtest.SpanNamed(t, spans, "util.HashPassword")
+	tracingtest.RequireChildOf(t, hashSpan, signUpSpan)
+	require.Equal(t, codes.Unset, signUpSpan.Status.Code)
+}
+
+func TestSignUpSpanOnConflict(t *testing.T) {
+	exporter := tracingtest.NewExporter(t)
+	borrower, password := expectedBorrower(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		CreateUser(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.User{}, &pgconn.PgError{Code: uniqueViolation, ConstraintName: "users_email_key"})
+
+	borrowerService := NewBorrowerServiceImpl(store, testPasswordPolicy(t), metrics.New())
+	_, err := borrowerService.SignUp(context.Background(), &service.SignUpInput{
+		Email:    borrower.Email,
+		Password: password,
+		LineID:   borrower.LineID,
+		Nickname: borrower.Nickname,
+	})
+	require.Error(t, err)
+
+	// a conflict is an answer to the client, not a failure of the service
+	signUpSpan := tracingtest.SpanNamed(t, exporter.GetSpans(), "BorrowerService.SignUp")
+	require.Equal(t, codes.Unset, signUpSpan.Status.Code)
+	require.Contains(t, signUpSpan.Attributes, attribute.String("error.code", "conflict"))
+}
+
 func expectedBorrower(t *testing.T) (borrower db.User, password string) {
-	password = util.RandomString(6)
+	password = util.RandomPassword()
 	hashedPassword, err := util.HashPassword(password)
 	require.NoError(t, err)
 
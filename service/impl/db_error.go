@@ -0,0 +1,50 @@
+package impl
+
+import (
+	"errors"
+
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Postgres error codes translated into service errors
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+	checkViolation      = "23514"
+)
+
+// constraintDetails explains to clients which rule a violated constraint stands for
+var constraintDetails = map[string]i18n.Message{
+	"users_email_key":                     i18n.NewMessage("user.email_in_use"),
+	"kyc_submissions_pending_user_id_key": i18n.NewMessage("kyc.submission_pending"),
+}
+
+// fromDBError translates an error of the store into a service error
+func fromDBError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return service.NewError(service.ErrNotFound, err)
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return service.NewError(service.ErrInternalFailure, err)
+	}
+
+	var svcErr error
+	switch pgErr.Code {
+	case uniqueViolation:
+		svcErr = service.ErrConflict
+	case foreignKeyViolation, checkViolation:
+		svcErr = service.ErrValidation
+	default:
+		return service.NewError(service.ErrInternalFailure, err)
+	}
+
+	if detail, ok := constraintDetails[pgErr.ConstraintName]; ok {
+		return service.NewErrorWithDetail(svcErr, detail)
+	}
+	return service.NewError(svcErr, err)
+}
@@ -0,0 +1,69 @@
+package impl
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromDBError(t *testing.T) {
+	testCases := []struct {
+		name           string
+		err            error
+		expectedSvcErr error
+		expectedDetail i18n.Message
+	}{
+		{
+			name:           "NoRows",
+			err:            pgx.ErrNoRows,
+			expectedSvcErr: service.ErrNotFound,
+		},
+		{
+			name:           "UniqueViolation",
+			err:            &pgconn.PgError{Code: uniqueViolation, ConstraintName: "users_email_key"},
+			expectedSvcErr: service.ErrConflict,
+			expectedDetail: i18n.NewMessage("user.email_in_use"),
+		},
+		{
+			name:           "UnknownUniqueViolation",
+			err:            &pgconn.PgError{Code: uniqueViolation, ConstraintName: "unknown_key"},
+			expectedSvcErr: service.ErrConflict,
+		},
+		{
+			name:           "ForeignKeyViolation",
+			err:            &pgconn.PgError{Code: foreignKeyViolation},
+			expectedSvcErr: service.ErrValidation,
+		},
+		{
+			name:           "CheckViolation",
+			err:            &pgconn.PgError{Code: checkViolation},
+			expectedSvcErr: service.ErrValidation,
+		},
+		{
+			name:           "OtherPgError",
+			err:            &pgconn.PgError{Code: "40001"},
+			expectedSvcErr: service.ErrInternalFailure,
+		},
+		{
+			name:           "ConnDone",
+			err:            sql.ErrConnDone,
+			expectedSvcErr: service.ErrInternalFailure,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := fromDBError(tc.err)
+
+			var svcError service.Error
+			require.ErrorAs(t, err, &svcError)
+			require.ErrorIs(t, svcError.SvcErr(), tc.expectedSvcErr)
+			require.Equal(t, tc.expectedDetail, svcError.Detail())
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/service"
+)
+
+// dependencyCheckTimeout bounds each dependency check, so that a hanging dependency is reported
+// as unhealthy before the orchestrator gives up on the probe
+const dependencyCheckTimeout = 2 * time.Second
+
+func NewHealthServiceImpl(healthStore db.Store, schemaVersion int64) service.HealthService {
+	return &healthServiceImpl{
+		healthStore:   healthStore,
+		schemaVersion: schemaVersion,
+	}
+}
+
+type healthServiceImpl struct {
+	healthStore db.Store
+	// schemaVersion is the migration version the database must be at
+	schemaVersion int64
+}
+
+func (svc *healthServiceImpl) CheckReadiness(ctx context.Context) *service.CheckReadinessOutput {
+	output := &service.CheckReadinessOutput{
+		Ready: true,
+		Checks: []service.DependencyCheck{
+			checkDependency(ctx, service.DependencyDatabase, svc.healthStore.Ping),
+			checkDependency(ctx, service.DependencySchema, svc.checkSchema),
+		},
+	}
+
+	for _, check := range output.Checks {
+		if !check.Healthy {
+			output.Ready = false
+		}
+	}
+	return output
+}
+
+// checkSchema checks that the database is migrated to the version this binary was built for
+func (svc *healthServiceImpl) checkSchema(ctx context.Context) error {
+	migration, err := svc.healthStore.GetSchemaMigration(ctx)
+	if err != nil {
+		return err
+	}
+
+	if migration.Dirty {
+		return fmt.Errorf("migration %d failed halfway", migration.Version)
+	}
+	if migration.Version != svc.schemaVersion {
+		return fmt.Errorf("schema version is %d, expected %d", migration.Version, svc.schemaVersion)
+	}
+	return nil
+}
+
+func checkDependency(ctx context.Context, name string, check func(ctx context.Context) error) service.DependencyCheck {
+	ctx, cancel := context.WithTimeout(ctx, dependencyCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+
+	result := service.DependencyCheck{
+		Name:    name,
+		Healthy: err == nil,
+		Latency: time.Since(start),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,124 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	mockdb "github.com/DamianZhang/957-lending-platform/db/mock"
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckReadiness(t *testing.T) {
+	const schemaVersion = 8
+
+	testCases := []struct {
+		name        string
+		buildStubs  func(store *mockdb.MockStore)
+		checkOutput func(output *service.CheckReadinessOutput)
+	}{
+		{
+			name: "Ready",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					Ping(gomock.Any()).
+					Times(1).
+					Return(nil)
+				store.EXPECT().
+					GetSchemaMigration(gomock.Any()).
+					Times(1).
+					Return(db.SchemaMigration{Version: schemaVersion}, nil)
+			},
+			checkOutput: func(output *service.CheckReadinessOutput) {
+				require.True(t, output.Ready)
+				require.Len(t, output.Checks, 2)
+				for _, check := range output.Checks {
+					require.True(t, check.Healthy, check.Name)
+					require.Empty(t, check.Error)
+				}
+			},
+		},
+		{
+			name: "DatabaseDown",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					Ping(gomock.Any()).
+					Times(1).
+					Return(sql.ErrConnDone)
+				store.EXPECT().
+					GetSchemaMigration(gomock.Any()).
+					Times(1).
+					Return(db.SchemaMigration{}, sql.ErrConnDone)
+			},
+			checkOutput: func(output *service.CheckReadinessOutput) {
+				require.False(t, output.Ready)
+				requireDependencyCheck(t, output, service.DependencyDatabase, false, sql.ErrConnDone.Error())
+				requireDependencyCheck(t, output, service.DependencySchema, false, sql.ErrConnDone.Error())
+			},
+		},
+		{
+			name: "SchemaOutdated",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					Ping(gomock.Any()).
+					Times(1).
+					Return(nil)
+				store.EXPECT().
+					GetSchemaMigration(gomock.Any()).
+					Times(1).
+					Return(db.SchemaMigration{Version: schemaVersion - 1}, nil)
+			},
+			checkOutput: func(output *service.CheckReadinessOutput) {
+				require.False(t, output.Ready)
+				requireDependencyCheck(t, output, service.DependencyDatabase, true, "")
+				requireDependencyCheck(t, output, service.DependencySchema, false, "schema version is 7, expected 8")
+			},
+		},
+		{
+			name: "SchemaDirty",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					Ping(gomock.Any()).
+					Times(1).
+					Return(nil)
+				store.EXPECT().
+					GetSchemaMigration(gomock.Any()).
+					Times(1).
+					Return(db.SchemaMigration{Version: schemaVersion, Dirty: true}, nil)
+			},
+			checkOutput: func(output *service.CheckReadinessOutput) {
+				require.False(t, output.Ready)
+				requireDependencyCheck(t, output, service.DependencySchema, false, "migration 8 failed halfway")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			healthService := NewHealthServiceImpl(store, schemaVersion)
+
+			output := healthService.CheckReadiness(context.Background())
+			tc.checkOutput(output)
+		})
+	}
+}
+
+func requireDependencyCheck(t *testing.T, output *service.CheckReadinessOutput, name string, healthy bool, errMsg string) {
+	for _, check := range output.Checks {
+		if check.Name == name {
+			require.Equal(t, healthy, check.Healthy)
+			require.Equal(t, errMsg, check.Error)
+			return
+		}
+	}
+	t.Fatalf("dependency %s is not checked", name)
+}
@@ -0,0 +1,118 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/jackc/pgx/v5"
+)
+
+func NewIdempotencyServiceImpl(idempotencyStore db.Store, keyTTL time.Duration, stallTimeout time.Duration) service.IdempotencyService {
+	return &idempotencyServiceImpl{
+		idempotencyStore: idempotencyStore,
+		keyTTL:           keyTTL,
+		stallTimeout:     stallTimeout,
+	}
+}
+
+type idempotencyServiceImpl struct {
+	idempotencyStore db.Store
+	// keyTTL is how long the response of a request is replayed
+	keyTTL time.Duration
+	// stallTimeout is how long a request may be handled before its key can be taken over by a retry,
+	// in case the instance handling it stopped before storing its response
+	stallTimeout time.Duration
+}
+
+func (svc *idempotencyServiceImpl) BeginRequest(ctx context.Context, input *service.BeginIdempotentRequestInput) (*service.BeginIdempotentRequestOutput, error) {
+	now := time.Now()
+	_, err := svc.idempotencyStore.AcquireIdempotencyKey(ctx, db.AcquireIdempotencyKeyParams{
+		Scope:         input.Scope,
+		Key:           input.Key,
+		Fingerprint:   input.Fingerprint,
+		ExpiresAt:     now.Add(svc.keyTTL),
+		Now:           now,
+		StalledBefore: now.Add(-svc.stallTimeout),
+	})
+	if err == nil {
+		return &service.BeginIdempotentRequestOutput{}, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fromDBError(err)
+	}
+
+	// the key is held by a request which is being handled or has been handled
+	held, err := svc.idempotencyStore.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+		Scope: input.Scope,
+		Key:   input.Key,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		// the request holding the key has just been abandoned, the client can retry right away
+		return nil, service.NewErrorWithDetail(service.ErrRequestInProgress, i18n.NewMessage("idempotency.request_in_progress"))
+	}
+	if err != nil {
+		return nil, fromDBError(err)
+	}
+
+	if held.Fingerprint != input.Fingerprint {
+		return nil, service.NewErrorWithDetail(service.ErrConflict, i18n.NewMessage("idempotency.key_reused"))
+	}
+
+	if held.Status != util.IdempotencyKeyCompleted {
+		return nil, service.NewErrorWithDetail(service.ErrRequestInProgress, i18n.NewMessage("idempotency.request_in_progress"))
+	}
+
+	return &service.BeginIdempotentRequestOutput{
+		Replay: &service.IdempotentResponse{
+			StatusCode:  int(held.ResponseStatusCode),
+			ContentType: held.ResponseContentType,
+			Body:        held.ResponseBody,
+		},
+	}, nil
+}
+
+func (svc *idempotencyServiceImpl) CompleteRequest(ctx context.Context, input *service.CompleteIdempotentRequestInput) error {
+	rows, err := svc.idempotencyStore.CompleteIdempotencyKey(ctx, db.CompleteIdempotencyKeyParams{
+		Scope:               input.Scope,
+		Key:                 input.Key,
+		ResponseStatusCode:  int32(input.Response.StatusCode),
+		ResponseContentType: input.Response.ContentType,
+		ResponseBody:        input.Response.Body,
+	})
+	if err != nil {
+		return fromDBError(err)
+	}
+
+	// the request stalled for so long that a retry took its key over
+	if rows == 0 {
+		return service.NewError(service.ErrConflict, errors.New("idempotency key is no longer held by the request"))
+	}
+
+	return nil
+}
+
+func (svc *idempotencyServiceImpl) AbandonRequest(ctx context.Context, input *service.AbandonIdempotentRequestInput) error {
+	err := svc.idempotencyStore.DeleteIdempotencyKey(ctx, db.DeleteIdempotencyKeyParams{
+		Scope: input.Scope,
+		Key:   input.Key,
+	})
+	if err != nil {
+		return fromDBError(err)
+	}
+
+	return nil
+}
+
+func (svc *idempotencyServiceImpl) PurgeExpiredKeys(ctx context.Context) (int64, error) {
+	deleted, err := svc.idempotencyStore.DeleteIdempotencyKeysBefore(ctx, time.Now())
+	if err != nil {
+		return 0, fromDBError(err)
+	}
+
+	return deleted, nil
+}
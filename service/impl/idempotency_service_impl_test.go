@@ -0,0 +1,300 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/DamianZhang/957-lending-platform/db/mock"
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIdempotencyKeyTTL = 24 * time.Hour
+	testStallTimeout      = time.Minute
+)
+
+func TestBeginIdempotentRequest(t *testing.T) {
+	input := &service.BeginIdempotentRequestInput{
+		Scope:       "ip:127.0.0.1",
+		Key:         util.RandomString(24),
+		Fingerprint: util.RandomString(64),
+	}
+	completed := db.IdempotencyKey{
+		Scope:               input.Scope,
+		Key:                 input.Key,
+		Fingerprint:         input.Fingerprint,
+		Status:              util.IdempotencyKeyCompleted,
+		ResponseStatusCode:  201,
+		ResponseContentType: "application/json",
+		ResponseBody:        []byte(`{"nickname":"john"}`),
+	}
+
+	testCases := []struct {
+		name        string
+		buildStubs  func(store *mockdb.MockStore)
+		checkOutput func(output *service.BeginIdempotentRequestOutput, err error)
+	}{
+		{
+			name: "Acquired",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AcquireIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.AcquireIdempotencyKeyParams) (db.IdempotencyKey, error) {
+						require.Equal(t, input.Scope, arg.Scope)
+						require.Equal(t, input.Key, arg.Key)
+						require.Equal(t, input.Fingerprint, arg.Fingerprint)
+						require.Equal(t, arg.Now.Add(testIdempotencyKeyTTL), arg.ExpiresAt)
+						require.Equal(t, arg.Now.Add(-testStallTimeout), arg.StalledBefore)
+						return db.IdempotencyKey{Scope: arg.Scope, Key: arg.Key, Status: util.IdempotencyKeyProcessing}, nil
+					})
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.BeginIdempotentRequestOutput, err error) {
+				require.NoError(t, err)
+				require.Nil(t, output.Replay)
+			},
+		},
+		{
+			name: "Replay",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AcquireIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, pgx.ErrNoRows)
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Eq(db.GetIdempotencyKeyParams{Scope: input.Scope, Key: input.Key})).
+					Times(1).
+					Return(completed, nil)
+			},
+			checkOutput: func(output *service.BeginIdempotentRequestOutput, err error) {
+				require.NoError(t, err)
+				require.Equal(t, &service.IdempotentResponse{
+					StatusCode:  201,
+					ContentType: "application/json",
+					Body:        []byte(`{"nickname":"john"}`),
+				}, output.Replay)
+			},
+		},
+		{
+			name: "KeyReused",
+			buildStubs: func(store *mockdb.MockStore) {
+				reused := completed
+				reused.Fingerprint = util.RandomString(64)
+				store.EXPECT().
+					AcquireIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, pgx.ErrNoRows)
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(reused, nil)
+			},
+			checkOutput: func(output *service.BeginIdempotentRequestOutput, err error) {
+				var svcError service.Error
+				require.ErrorAs(t, err, &svcError)
+				require.ErrorIs(t, svcError.SvcErr(), service.ErrConflict)
+				require.Equal(t, i18n.NewMessage("idempotency.key_reused"), svcError.Detail())
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "InProgress",
+			buildStubs: func(store *mockdb.MockStore) {
+				processing := completed
+				processing.Status = util.IdempotencyKeyProcessing
+				store.EXPECT().
+					AcquireIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, pgx.ErrNoRows)
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(processing, nil)
+			},
+			checkOutput: func(output *service.BeginIdempotentRequestOutput, err error) {
+				var svcError service.Error
+				require.ErrorAs(t, err, &svcError)
+				require.ErrorIs(t, svcError.SvcErr(), service.ErrRequestInProgress)
+				require.Equal(t, i18n.NewMessage("idempotency.request_in_progress"), svcError.Detail())
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "AbandonedMeanwhile",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AcquireIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, pgx.ErrNoRows)
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, pgx.ErrNoRows)
+			},
+			checkOutput: func(output *service.BeginIdempotentRequestOutput, err error) {
+				requireSvcErr(t, err, service.ErrRequestInProgress)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AcquireIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, sql.ErrConnDone)
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.BeginIdempotentRequestOutput, err error) {
+				requireSvcErr(t, err, service.ErrInternalFailure)
+				require.Nil(t, output)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			idempotencyService := NewIdempotencyServiceImpl(store, testIdempotencyKeyTTL, testStallTimeout)
+
+			output, err := idempotencyService.BeginRequest(context.Background(), input)
+			tc.checkOutput(output, err)
+		})
+	}
+}
+
+func TestCompleteIdempotentRequest(t *testing.T) {
+	input := &service.CompleteIdempotentRequestInput{
+		Scope: "ip:127.0.0.1",
+		Key:   util.RandomString(24),
+		Response: service.IdempotentResponse{
+			StatusCode:  201,
+			ContentType: "application/json",
+			Body:        []byte(`{"nickname":"john"}`),
+		},
+	}
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		checkErr   func(err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CompleteIdempotencyKeyParams{
+					Scope:               input.Scope,
+					Key:                 input.Key,
+					ResponseStatusCode:  201,
+					ResponseContentType: "application/json",
+					ResponseBody:        []byte(`{"nickname":"john"}`),
+				}
+				store.EXPECT().
+					CompleteIdempotencyKey(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(int64(1), nil)
+			},
+			checkErr: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "TakenOver",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CompleteIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+			},
+			checkErr: func(err error) {
+				requireSvcErr(t, err, service.ErrConflict)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CompleteIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), sql.ErrConnDone)
+			},
+			checkErr: func(err error) {
+				requireSvcErr(t, err, service.ErrInternalFailure)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			idempotencyService := NewIdempotencyServiceImpl(store, testIdempotencyKeyTTL, testStallTimeout)
+
+			err := idempotencyService.CompleteRequest(context.Background(), input)
+			tc.checkErr(err)
+		})
+	}
+}
+
+func TestAbandonIdempotentRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	input := &service.AbandonIdempotentRequestInput{
+		Scope: "ip:127.0.0.1",
+		Key:   util.RandomString(24),
+	}
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		DeleteIdempotencyKey(gomock.Any(), gomock.Eq(db.DeleteIdempotencyKeyParams{Scope: input.Scope, Key: input.Key})).
+		Times(1).
+		Return(nil)
+
+	idempotencyService := NewIdempotencyServiceImpl(store, testIdempotencyKeyTTL, testStallTimeout)
+
+	err := idempotencyService.AbandonRequest(context.Background(), input)
+	require.NoError(t, err)
+}
+
+func TestPurgeExpiredIdempotencyKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		DeleteIdempotencyKeysBefore(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, before time.Time) (int64, error) {
+			require.WithinDuration(t, time.Now(), before, time.Second)
+			return 3, nil
+		})
+
+	idempotencyService := NewIdempotencyServiceImpl(store, testIdempotencyKeyTTL, testStallTimeout)
+
+	deleted, err := idempotencyService.PurgeExpiredKeys(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, 3, deleted)
+}
@@ -0,0 +1,283 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"path"
+	"time"
+
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/storage"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// kycDocumentExtensions maps the accepted content types of KYC documents to the extension of their blobs
+var kycDocumentExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+func NewKYCServiceImpl(kycStore db.Store, blobStore storage.BlobStore) service.KYCService {
+	return &kycServiceImpl{
+		kycStore:  kycStore,
+		blobStore: blobStore,
+	}
+}
+
+type kycServiceImpl struct {
+	kycStore  db.Store
+	blobStore storage.BlobStore
+}
+
+func (svc *kycServiceImpl) SubmitKYC(ctx context.Context, input *service.SubmitKYCInput) (*service.SubmitKYCOutput, error) {
+	// the status guards against submitting twice, it must not lag behind the previous submission
+	user, err := svc.kycStore.GetUserByID(db.WithPrimary(ctx), input.UserID)
+	if err != nil {
+		return nil, fromDBError(err)
+	}
+
+	err = checkUserIsActive(user)
+	if err != nil {
+		return nil, err
+	}
+
+	switch user.KYCStatus {
+	case util.KYCPending:
+		return nil, service.NewErrorWithDetail(service.ErrConflict, i18n.NewMessage("kyc.submission_pending"))
+	case util.KYCVerified:
+		return nil, service.NewErrorWithDetail(service.ErrConflict, i18n.NewMessage("kyc.already_verified"))
+	}
+
+	submissionID := uuid.New()
+	arg := db.CreateKYCSubmissionTxParams{
+		CreateKYCSubmissionParams: db.CreateKYCSubmissionParams{
+			ID:     submissionID,
+			UserID: user.ID,
+		},
+	}
+
+	arg.IDDocumentKey, err = svc.putKYCDocument(ctx, user.ID, submissionID, util.KYCDocumentID, input.IDDocument)
+	if err != nil {
+		return nil, err
+	}
+
+	arg.SelfieKey, err = svc.putKYCDocument(ctx, user.ID, submissionID, util.KYCDocumentSelfie, input.Selfie)
+	if err != nil {
+		svc.deleteBlobs(ctx, arg.IDDocumentKey)
+		return nil, err
+	}
+
+	result, err := svc.kycStore.CreateKYCSubmissionTx(ctx, arg)
+	if err != nil {
+		svc.deleteBlobs(ctx, arg.IDDocumentKey, arg.SelfieKey)
+		return nil, fromDBError(err)
+	}
+
+	output := &service.SubmitKYCOutput{
+		KYCSubmission: result.KYCSubmission,
+	}
+	return output, nil
+}
+
+func (svc *kycServiceImpl) GetKYCStatus(ctx context.Context, input *service.GetKYCStatusInput) (*service.GetKYCStatusOutput, error) {
+	// the status is checked right after submitting or being reviewed, so it is read from the primary
+	ctx = db.WithPrimary(ctx)
+
+	user, err := svc.kycStore.GetUserByID(ctx, input.UserID)
+	if err != nil {
+		return nil, fromDBError(err)
+	}
+
+	output := &service.GetKYCStatusOutput{
+		KYCStatus: user.KYCStatus,
+	}
+
+	submission, err := svc.kycStore.GetLatestKYCSubmissionByUser(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return output, nil
+		}
+		return nil, service.NewError(service.ErrInternalFailure, err)
+	}
+
+	output.KYCSubmission = &submission
+	return output, nil
+}
+
+func (svc *kycServiceImpl) ListPendingKYCSubmissions(ctx context.Context, input *service.ListPendingKYCSubmissionsInput) (*service.ListPendingKYCSubmissionsOutput, error) {
+	submissions, err := svc.kycStore.GetPendingKYCSubmissions(ctx, db.GetPendingKYCSubmissionsParams{
+		Limit:  input.Limit,
+		Offset: input.Offset,
+	})
+	if err != nil {
+		return nil, fromDBError(err)
+	}
+
+	output := &service.ListPendingKYCSubmissionsOutput{
+		KYCSubmissions: submissions,
+	}
+	return output, nil
+}
+
+func (svc *kycServiceImpl) GetKYCDocument(ctx context.Context, input *service.GetKYCDocumentInput) (*service.GetKYCDocumentOutput, error) {
+	submission, err := svc.getKYCSubmission(ctx, input.KYCSubmissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var key string
+	switch input.Document {
+	case util.KYCDocumentID:
+		key = submission.IDDocumentKey
+	case util.KYCDocumentSelfie:
+		key = submission.SelfieKey
+	default:
+		return nil, service.NewErrorWithDetail(service.ErrNotFound, i18n.NewMessage("kyc.unknown_document").With("document", input.Document))
+	}
+
+	details, err := json.Marshal(map[string]string{
+		"kyc_submission_id": submission.ID.String(),
+		"document":          input.Document,
+	})
+	if err != nil {
+		return nil, service.NewError(service.ErrInternalFailure, err)
+	}
+
+	// every access to identity documents is traced
+	_, err = svc.kycStore.CreateAuditLog(ctx, db.CreateAuditLogParams{
+		ActorID:      input.AdminID,
+		Action:       util.AuditActionViewKYCDocument,
+		TargetUserID: optionalUUID(&submission.UserID),
+		Details:      details,
+	})
+	if err != nil {
+		return nil, fromDBError(err)
+	}
+
+	content, err := svc.blobStore.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrBlobNotFound) {
+			return nil, service.NewError(service.ErrNotFound, err)
+		}
+		return nil, service.NewError(service.ErrInternalFailure, err)
+	}
+
+	output := &service.GetKYCDocumentOutput{
+		ContentType: mime.TypeByExtension(path.Ext(key)),
+		Content:     content,
+	}
+	return output, nil
+}
+
+func (svc *kycServiceImpl) ReviewKYCSubmission(ctx context.Context, input *service.ReviewKYCSubmissionInput) (*service.ReviewKYCSubmissionOutput, error) {
+	submission, err := svc.getKYCSubmission(ctx, input.KYCSubmissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if submission.UserID == input.AdminID {
+		return nil, service.NewErrorWithDetail(service.ErrForbidden, i18n.NewMessage("kyc.review_own_submission"))
+	}
+
+	if submission.Status != util.KYCSubmissionPending {
+		return nil, service.NewErrorWithDetail(service.ErrConflict, i18n.NewMessage("kyc.submission_reviewed_as").With("status", submission.Status))
+	}
+
+	details, err := json.Marshal(map[string]string{
+		"kyc_submission_id": submission.ID.String(),
+		"reason":            input.Reason,
+	})
+	if err != nil {
+		return nil, service.NewError(service.ErrInternalFailure, err)
+	}
+
+	arg := db.ReviewKYCSubmissionTxParams{
+		ReviewKYCSubmissionParams: db.ReviewKYCSubmissionParams{
+			ID:           submission.ID,
+			Status:       util.KYCSubmissionApproved,
+			ReviewerID:   input.AdminID,
+			ReviewReason: input.Reason,
+			ReviewedAt:   time.Now(),
+		},
+		KYCStatus: util.KYCVerified,
+		Audit: db.AuditParams{
+			ActorID: input.AdminID,
+			Action:  util.AuditActionApproveKYC,
+			Details: details,
+		},
+	}
+	if !input.Approve {
+		arg.Status = util.KYCSubmissionRejected
+		arg.KYCStatus = util.KYCRejected
+		arg.Audit.Action = util.AuditActionRejectKYC
+	}
+
+	result, err := svc.kycStore.ReviewKYCSubmissionTx(ctx, arg)
+	if err != nil {
+		// another admin has reviewed the submission in the meantime
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, service.NewErrorWithDetail(service.ErrConflict, i18n.NewMessage("kyc.submission_already_reviewed"))
+		}
+		return nil, fromDBError(err)
+	}
+
+	output := &service.ReviewKYCSubmissionOutput{
+		KYCSubmission: result.KYCSubmission,
+		User:          result.User,
+	}
+	return output, nil
+}
+
+func (svc *kycServiceImpl) RequireKYCVerified(ctx context.Context, userID uuid.UUID) error {
+	// a user whose submission has just been approved must not be turned away
+	user, err := svc.kycStore.GetUserByID(db.WithPrimary(ctx), userID)
+	if err != nil {
+		return fromDBError(err)
+	}
+
+	if user.KYCStatus != util.KYCVerified {
+		return service.NewErrorWithDetail(service.ErrKYCRequired, i18n.NewMessage("kyc.required").With("status", user.KYCStatus))
+	}
+	return nil
+}
+
+func (svc *kycServiceImpl) getKYCSubmission(ctx context.Context, submissionID uuid.UUID) (db.KYCSubmission, error) {
+	submission, err := svc.kycStore.GetKYCSubmission(ctx, submissionID)
+	if err != nil {
+		return db.KYCSubmission{}, fromDBError(err)
+	}
+
+	return submission, nil
+}
+
+// putKYCDocument stores an uploaded document as kyc/<user id>/<submission id>/<document>.<ext> and returns its key
+func (svc *kycServiceImpl) putKYCDocument(ctx context.Context, userID, submissionID uuid.UUID, document string, upload service.KYCDocument) (string, error) {
+	ext, ok := kycDocumentExtensions[upload.ContentType]
+	if !ok {
+		return "", service.NewError(service.ErrInternalFailure, fmt.Errorf("unsupported content type %s", upload.ContentType))
+	}
+
+	key := path.Join("kyc", userID.String(), submissionID.String(), document+ext)
+	if err := svc.blobStore.Put(ctx, key, upload.Content); err != nil {
+		return "", service.NewError(service.ErrInternalFailure, err)
+	}
+
+	return key, nil
+}
+
+// deleteBlobs removes the documents of a submission which could not be recorded
+func (svc *kycServiceImpl) deleteBlobs(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := svc.blobStore.Delete(ctx, key); err != nil {
+			slog.WarnContext(ctx, "can not remove KYC document", "document", key, "err", err)
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"path"
+	"testing"
+	"time"
+
+	mockdb "github.com/DamianZhang/957-lending-platform/db/mock"
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/storage"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmitKYC(t *testing.T) {
+	user, _ := expectedUser(t)
+	idDocument := []byte("id document")
+	selfie := []byte("selfie")
+
+	newInput := func() *service.SubmitKYCInput {
+		return &service.SubmitKYCInput{
+			UserID: user.ID,
+			IDDocument: service.KYCDocument{
+				ContentType: "image/jpeg",
+				Content:     bytes.NewReader(idDocument),
+			},
+			Selfie: service.KYCDocument{
+				ContentType: "image/png",
+				Content:     bytes.NewReader(selfie),
+			},
+		}
+	}
+
+	// keys of the documents handed over to the store, to check they are cleaned up on failure
+	var storedKeys []string
+
+	testCases := []struct {
+		name        string
+		buildStubs  func(store *mockdb.MockStore)
+		checkOutput func(blobStore storage.BlobStore, output *service.SubmitKYCOutput, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateKYCSubmissionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateKYCSubmissionTxParams) (db.KYCSubmissionTxResult, error) {
+						require.Equal(t, user.ID, arg.UserID)
+						require.Equal(t, ".jpg", path.Ext(arg.IDDocumentKey))
+						require.Equal(t, ".png", path.Ext(arg.SelfieKey))
+						return db.KYCSubmissionTxResult{
+							KYCSubmission: db.KYCSubmission{
+								ID:            arg.ID,
+								UserID:        arg.UserID,
+								Status:        util.KYCSubmissionPending,
+								IDDocumentKey: arg.IDDocumentKey,
+								SelfieKey:     arg.SelfieKey,
+							},
+						}, nil
+					})
+			},
+			checkOutput: func(blobStore storage.BlobStore, output *service.SubmitKYCOutput, err error) {
+				require.NoError(t, err)
+				require.Equal(t, util.KYCSubmissionPending, output.KYCSubmission.Status)
+
+				requireBlob(t, blobStore, output.KYCSubmission.IDDocumentKey, idDocument)
+				requireBlob(t, blobStore, output.KYCSubmission.SelfieKey, selfie)
+			},
+		},
+		{
+			name: "AlreadyVerified",
+			buildStubs: func(store *mockdb.MockStore) {
+				verifiedUser := user
+				verifiedUser.KYCStatus = util.KYCVerified
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(verifiedUser, nil)
+				store.EXPECT().
+					CreateKYCSubmissionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(blobStore storage.BlobStore, output *service.SubmitKYCOutput, err error) {
+				requireSvcErr(t, err, service.ErrConflict)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "AccountInactive",
+			buildStubs: func(store *mockdb.MockStore) {
+				suspendedUser := user
+				suspendedUser.Status = util.SuspendedStatus
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(suspendedUser, nil)
+				store.EXPECT().
+					CreateKYCSubmissionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(blobStore storage.BlobStore, output *service.SubmitKYCOutput, err error) {
+				requireSvcErr(t, err, service.ErrAccountInactive)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "PendingSubmissionRace",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateKYCSubmissionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateKYCSubmissionTxParams) (db.KYCSubmissionTxResult, error) {
+						storedKeys = []string{arg.IDDocumentKey, arg.SelfieKey}
+						return db.KYCSubmissionTxResult{}, &pgconn.PgError{Code: uniqueViolation}
+					})
+			},
+			checkOutput: func(blobStore storage.BlobStore, output *service.SubmitKYCOutput, err error) {
+				requireSvcErr(t, err, service.ErrConflict)
+				require.Nil(t, output)
+
+				require.Len(t, storedKeys, 2)
+				for _, key := range storedKeys {
+					_, err := blobStore.Get(context.Background(), key)
+					require.ErrorIs(t, err, storage.ErrBlobNotFound)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			blobStore, err := storage.NewLocalBlobStore(t.TempDir())
+			require.NoError(t, err)
+
+			kycService := NewKYCServiceImpl(store, blobStore)
+
+			output, err := kycService.SubmitKYC(context.Background(), newInput())
+			tc.checkOutput(blobStore, output, err)
+		})
+	}
+}
+
+func TestReviewKYCSubmission(t *testing.T) {
+	adminID := uuid.New()
+	user, _ := expectedUser(t)
+	submission := randomKYCSubmission(user.ID)
+
+	testCases := []struct {
+		name        string
+		input       *service.ReviewKYCSubmissionInput
+		buildStubs  func(store *mockdb.MockStore)
+		checkOutput func(output *service.ReviewKYCSubmissionOutput, err error)
+	}{
+		{
+			name: "Approve",
+			input: &service.ReviewKYCSubmissionInput{
+				AdminID:         adminID,
+				KYCSubmissionID: submission.ID,
+				Approve:         true,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetKYCSubmission(gomock.Any(), gomock.Eq(submission.ID)).
+					Times(1).
+					Return(submission, nil)
+				store.EXPECT().
+					ReviewKYCSubmissionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ReviewKYCSubmissionTxParams) (db.KYCSubmissionTxResult, error) {
+						require.Equal(t, submission.ID, arg.ID)
+						require.Equal(t, util.KYCSubmissionApproved, arg.Status)
+						require.Equal(t, util.KYCVerified, arg.KYCStatus)
+						require.Equal(t, adminID, arg.ReviewerID)
+						require.Equal(t, util.AuditActionApproveKYC, arg.Audit.Action)
+
+						reviewed := submission
+						reviewed.Status = arg.Status
+						verifiedUser := user
+						verifiedUser.KYCStatus = arg.KYCStatus
+						return db.KYCSubmissionTxResult{KYCSubmission: reviewed, User: verifiedUser}, nil
+					})
+			},
+			checkOutput: func(output *service.ReviewKYCSubmissionOutput, err error) {
+				require.NoError(t, err)
+				require.Equal(t, util.KYCSubmissionApproved, output.KYCSubmission.Status)
+				require.Equal(t, util.KYCVerified, output.User.KYCStatus)
+			},
+		},
+		{
+			name: "Reject",
+			input: &service.ReviewKYCSubmissionInput{
+				AdminID:         adminID,
+				KYCSubmissionID: submission.ID,
+				Reason:          "blurry document",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetKYCSubmission(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(submission, nil)
+				store.EXPECT().
+					ReviewKYCSubmissionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ReviewKYCSubmissionTxParams) (db.KYCSubmissionTxResult, error) {
+						require.Equal(t, util.KYCSubmissionRejected, arg.Status)
+						require.Equal(t, util.KYCRejected, arg.KYCStatus)
+						require.Equal(t, "blurry document", arg.ReviewReason)
+						require.Equal(t, util.AuditActionRejectKYC, arg.Audit.Action)
+						require.JSONEq(t, `{"kyc_submission_id": "`+submission.ID.String()+`", "reason": "blurry document"}`, string(arg.Audit.Details))
+						return db.KYCSubmissionTxResult{KYCSubmission: submission, User: user}, nil
+					})
+			},
+			checkOutput: func(output *service.ReviewKYCSubmissionOutput, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "OwnSubmission",
+			input: &service.ReviewKYCSubmissionInput{
+				AdminID:         user.ID,
+				KYCSubmissionID: submission.ID,
+				Approve:         true,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetKYCSubmission(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(submission, nil)
+				store.EXPECT().
+					ReviewKYCSubmissionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.ReviewKYCSubmissionOutput, err error) {
+				requireSvcErr(t, err, service.ErrForbidden)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "AlreadyReviewed",
+			input: &service.ReviewKYCSubmissionInput{
+				AdminID:         adminID,
+				KYCSubmissionID: submission.ID,
+				Approve:         true,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				reviewed := submission
+				reviewed.Status = util.KYCSubmissionRejected
+				store.EXPECT().
+					GetKYCSubmission(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(reviewed, nil)
+				store.EXPECT().
+					ReviewKYCSubmissionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.ReviewKYCSubmissionOutput, err error) {
+				requireSvcErr(t, err, service.ErrConflict)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "NotFound",
+			input: &service.ReviewKYCSubmissionInput{
+				AdminID:         adminID,
+				KYCSubmissionID: submission.ID,
+				Approve:         true,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetKYCSubmission(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.KYCSubmission{}, pgx.ErrNoRows)
+			},
+			checkOutput: func(output *service.ReviewKYCSubmissionOutput, err error) {
+				requireSvcErr(t, err, service.ErrNotFound)
+				require.Nil(t, output)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			kycService := NewKYCServiceImpl(store, nil)
+
+			output, err := kycService.ReviewKYCSubmission(context.Background(), tc.input)
+			tc.checkOutput(output, err)
+		})
+	}
+}
+
+func TestGetKYCDocument(t *testing.T) {
+	adminID := uuid.New()
+	user, _ := expectedUser(t)
+	submission := randomKYCSubmission(user.ID)
+	selfie := []byte("selfie")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetKYCSubmission(gomock.Any(), gomock.Eq(submission.ID)).
+		Times(1).
+		Return(submission, nil)
+	store.EXPECT().
+		CreateAuditLog(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateAuditLogParams) (db.AuditLog, error) {
+			require.Equal(t, adminID, arg.ActorID)
+			require.Equal(t, util.AuditActionViewKYCDocument, arg.Action)
+			require.Equal(t, user.ID, uuid.UUID(arg.TargetUserID.Bytes))
+			return db.AuditLog{}, nil
+		})
+
+	blobStore, err := storage.NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, blobStore.Put(context.Background(), submission.SelfieKey, bytes.NewReader(selfie)))
+
+	kycService := NewKYCServiceImpl(store, blobStore)
+
+	output, err := kycService.GetKYCDocument(context.Background(), &service.GetKYCDocumentInput{
+		AdminID:         adminID,
+		KYCSubmissionID: submission.ID,
+		Document:        util.KYCDocumentSelfie,
+	})
+	require.NoError(t, err)
+	defer output.Content.Close()
+
+	require.Equal(t, "image/png", output.ContentType)
+	got, err := io.ReadAll(output.Content)
+	require.NoError(t, err)
+	require.Equal(t, selfie, got)
+}
+
+func TestRequireKYCVerified(t *testing.T) {
+	user, _ := expectedUser(t)
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		checkErr   func(err error)
+	}{
+		{
+			name: "Verified",
+			buildStubs: func(store *mockdb.MockStore) {
+				verifiedUser := user
+				verifiedUser.KYCStatus = util.KYCVerified
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(verifiedUser, nil)
+			},
+			checkErr: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "Pending",
+			buildStubs: func(store *mockdb.MockStore) {
+				pendingUser := user
+				pendingUser.KYCStatus = util.KYCPending
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(pendingUser, nil)
+			},
+			checkErr: func(err error) {
+				requireSvcErr(t, err, service.ErrKYCRequired)
+			},
+		},
+		{
+			name: "DBErrConnDone",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkErr: func(err error) {
+				requireSvcErr(t, err, service.ErrInternalFailure)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			kycService := NewKYCServiceImpl(store, nil)
+
+			err := kycService.RequireKYCVerified(context.Background(), user.ID)
+			tc.checkErr(err)
+		})
+	}
+}
+
+func randomKYCSubmission(userID uuid.UUID) db.KYCSubmission {
+	id := uuid.New()
+	return db.KYCSubmission{
+		ID:            id,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+		UserID:        userID,
+		Status:        util.KYCSubmissionPending,
+		IDDocumentKey: path.Join("kyc", userID.String(), id.String(), "id_document.jpg"),
+		SelfieKey:     path.Join("kyc", userID.String(), id.String(), "selfie.png"),
+	}
+}
+
+func requireBlob(t *testing.T, blobStore storage.BlobStore, key string, wanted []byte) {
+	blob, err := blobStore.Get(context.Background(), key)
+	require.NoError(t, err)
+	defer blob.Close()
+
+	got, err := io.ReadAll(blob)
+	require.NoError(t, err)
+	require.Equal(t, wanted, got)
+}
@@ -0,0 +1,66 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/metrics"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/util"
+)
+
+// passwordRuleMessages explains to clients the rules of the password policy
+var passwordRuleMessages = map[string]i18n.Message{
+	util.PasswordRuleTooShort:      i18n.NewMessage("password.too_short"),
+	util.PasswordRuleTooLong:       i18n.NewMessage("password.too_long"),
+	util.PasswordRuleMissingLower:  i18n.NewMessage("password.missing_lower"),
+	util.PasswordRuleMissingUpper:  i18n.NewMessage("password.missing_upper"),
+	util.PasswordRuleMissingLetter: i18n.NewMessage("password.missing_letter"),
+	util.PasswordRuleMissingDigit:  i18n.NewMessage("password.missing_digit"),
+	util.PasswordRuleMissingSymbol: i18n.NewMessage("password.missing_symbol"),
+	util.PasswordRuleDenied:        i18n.NewMessage("password.denied"),
+}
+
+// hashNewPassword checks a password chosen by a user against the password policy and hashes it
+func hashNewPassword(ctx context.Context, policy *util.PasswordPolicy, m *metrics.Metrics, password string) (string, error) {
+	err := policy.Check(ctx, password)
+	if err != nil {
+		var policyErr *util.PasswordPolicyError
+		if !errors.As(err, &policyErr) {
+			return "", service.NewError(service.ErrInternalFailure, err)
+		}
+
+		detail := passwordRuleMessages[policyErr.Rule]
+		switch policyErr.Rule {
+		case util.PasswordRuleTooShort:
+			detail = detail.With("min_length", policyErr.MinLength)
+		case util.PasswordRuleTooLong:
+			detail = detail.With("max_length", policyErr.MaxLength)
+		}
+		return "", service.NewErrorWithDetail(service.ErrValidation, detail)
+	}
+
+	hashedPassword, err := hashPassword(ctx, m, password)
+	if err != nil {
+		return "", service.NewError(service.ErrInternalFailure, err)
+	}
+	return hashedPassword, nil
+}
+
+// hashPassword hashes a password, recording how long it took.
+// The hash is expensive on purpose, its span tells it apart from the queries of a slow request.
+func hashPassword(ctx context.Context, m *metrics.Metrics, password string) (hashedPassword string, err error) {
+	_, span := startSpan(ctx, "util.HashPassword")
+	defer func() { endSpan(span, err) }()
+
+	start := time.Now()
+	hashedPassword, err = util.HashPassword(password)
+	if err != nil {
+		return "", err
+	}
+
+	m.ObservePasswordHash(time.Since(start))
+	return hashedPassword, nil
+}
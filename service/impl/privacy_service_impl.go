@@ -0,0 +1,334 @@
+package impl
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/storage"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// dataExportStalledAfter is how long a data export may stay in processing
+// before another worker is allowed to pick it up again
+const dataExportStalledAfter = 10 * time.Minute
+
+func NewPrivacyServiceImpl(privacyStore db.Store, blobStore storage.BlobStore, exportDir string, exportTTL time.Duration) service.PrivacyService {
+	return &privacyServiceImpl{
+		privacyStore: privacyStore,
+		blobStore:    blobStore,
+		exportDir:    exportDir,
+		exportTTL:    exportTTL,
+	}
+}
+
+type privacyServiceImpl struct {
+	privacyStore db.Store
+	blobStore    storage.BlobStore
+	exportDir    string
+	exportTTL    time.Duration
+}
+
+func (svc *privacyServiceImpl) RequestDataExport(ctx context.Context, input *service.RequestDataExportInput) (*service.RequestDataExportOutput, error) {
+	dataExport, err := svc.privacyStore.CreateDataExport(ctx, input.UserID)
+	if err != nil {
+		return nil, fromDBError(err)
+	}
+
+	output := &service.RequestDataExportOutput{
+		DataExport: dataExport,
+	}
+	return output, nil
+}
+
+func (svc *privacyServiceImpl) GetDataExport(ctx context.Context, input *service.GetDataExportInput) (*service.GetDataExportOutput, error) {
+	dataExport, err := svc.privacyStore.GetDataExport(ctx, input.DataExportID)
+	if err != nil {
+		return nil, fromDBError(err)
+	}
+
+	// never reveal the exports of other users
+	if dataExport.UserID != input.UserID {
+		return nil, service.NewError(service.ErrNotFound, errors.New("data export belongs to another user"))
+	}
+
+	if dataExport.ExpiresAt.Valid && time.Now().After(dataExport.ExpiresAt.Time) {
+		return nil, service.NewError(service.ErrNotFound, errors.New("data export has expired"))
+	}
+
+	output := &service.GetDataExportOutput{
+		DataExport: dataExport,
+	}
+	return output, nil
+}
+
+func (svc *privacyServiceImpl) ProcessDataExport(ctx context.Context) (bool, error) {
+	dataExport, err := svc.privacyStore.ClaimDataExport(ctx, time.Now().Add(-dataExportStalledAfter))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, service.NewError(service.ErrInternalFailure, err)
+	}
+
+	filePath := filepath.Join(svc.exportDir, fmt.Sprintf("%s.zip", dataExport.ID))
+	err = svc.writeDataExport(ctx, dataExport, filePath)
+	if err != nil {
+		// a bundle left half written would hold personal data which nothing ever removes
+		removeErr := removeDataExportFile(filePath)
+		_, failErr := svc.privacyStore.FailDataExport(ctx, db.FailDataExportParams{
+			ID:            dataExport.ID,
+			FailureReason: "failed to build the data export",
+		})
+		return true, service.NewError(service.ErrInternalFailure, errors.Join(err, removeErr, failErr))
+	}
+
+	completedAt := time.Now()
+	_, err = svc.privacyStore.CompleteDataExport(ctx, db.CompleteDataExportParams{
+		ID:          dataExport.ID,
+		FilePath:    filePath,
+		CompletedAt: completedAt,
+		ExpiresAt:   completedAt.Add(svc.exportTTL),
+	})
+	if err != nil {
+		// the purger only removes the bundles of the exports it finds, so a bundle without its export
+		// must be removed here, as when the user has been anonymized while the bundle was being written
+		removeErr := removeDataExportFile(filePath)
+		if errors.Is(err, pgx.ErrNoRows) && removeErr == nil {
+			return true, nil
+		}
+		return true, service.NewError(service.ErrInternalFailure, errors.Join(err, removeErr))
+	}
+
+	return true, nil
+}
+
+func (svc *privacyServiceImpl) PurgeExpiredDataExports(ctx context.Context) (int64, error) {
+	dataExports, err := svc.privacyStore.GetExpiredDataExports(ctx, time.Now())
+	if err != nil {
+		return 0, service.NewError(service.ErrInternalFailure, err)
+	}
+
+	var purged int64
+	for _, dataExport := range dataExports {
+		// the bundle goes first, so that an export whose bundle could not be removed is retried on the next run
+		err = removeDataExportFile(dataExport.FilePath)
+		if err != nil {
+			return purged, service.NewError(service.ErrInternalFailure, err)
+		}
+
+		_, err = svc.privacyStore.ExpireDataExport(ctx, dataExport.ID)
+		if err != nil {
+			return purged, service.NewError(service.ErrInternalFailure, err)
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+func (svc *privacyServiceImpl) AnonymizeUser(ctx context.Context, input *service.AnonymizeUserInput) (*service.AnonymizeUserOutput, error) {
+	if input.AdminID == input.UserID {
+		return nil, service.NewErrorWithDetail(service.ErrForbidden, i18n.NewMessage("admin.anonymize_self"))
+	}
+
+	arg := db.AnonymizeUserTxParams{
+		AnonymizeUserParams: db.AnonymizeUserParams{
+			ID:           input.UserID,
+			Email:        fmt.Sprintf("%s@anonymized.invalid", input.UserID),
+			Nickname:     "anonymized",
+			AnonymizedAt: time.Now(),
+		},
+		Audit: db.AuditParams{
+			ActorID: input.AdminID,
+			Action:  util.AuditActionAnonymizeUser,
+		},
+	}
+
+	result, err := svc.privacyStore.AnonymizeUserTx(ctx, arg)
+	if err != nil {
+		return nil, fromDBError(err)
+	}
+
+	// the bundles of the exports still being processed are removed by the worker, which cannot complete them anymore
+	for _, dataExport := range result.DataExports {
+		if dataExport.FilePath == "" {
+			continue
+		}
+		if err := removeDataExportFile(dataExport.FilePath); err != nil {
+			slog.WarnContext(ctx, "can not remove data export", "data_export_id", dataExport.ID, "err", err)
+		}
+	}
+
+	// the documents are only removed once their records are gone, so that none is left referencing a missing blob
+	for _, submission := range result.KYCSubmissions {
+		for _, key := range []string{submission.IDDocumentKey, submission.SelfieKey} {
+			if err := svc.blobStore.Delete(ctx, key); err != nil {
+				slog.WarnContext(ctx, "can not remove KYC document", "kyc_submission_id", submission.ID, "document", key, "err", err)
+			}
+		}
+	}
+
+	output := &service.AnonymizeUserOutput{
+		User: result.User,
+	}
+	return output, nil
+}
+
+// writeDataExport builds the bundle of a user and writes it as a ZIP archive at the given path of the export directory
+func (svc *privacyServiceImpl) writeDataExport(ctx context.Context, dataExport db.DataExport, filePath string) error {
+	bundle, err := svc.buildDataExportBundle(ctx, dataExport.UserID)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(svc.exportDir, 0o700)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+
+	entry, err := archive.Create("data.json")
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(bundle)
+	if err != nil {
+		return err
+	}
+
+	err = archive.Close()
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// removeDataExportFile removes the bundle of a data export, which may never have been written
+func removeDataExportFile(filePath string) error {
+	err := os.Remove(filePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (svc *privacyServiceImpl) buildDataExportBundle(ctx context.Context, userID uuid.UUID) (service.DataExportBundle, error) {
+	var bundle service.DataExportBundle
+
+	user, err := svc.privacyStore.GetUserByID(ctx, userID)
+	if err != nil {
+		return bundle, err
+	}
+
+	auditLogs, err := svc.privacyStore.GetAuditLogsByUser(ctx, userID)
+	if err != nil {
+		return bundle, err
+	}
+
+	dataExports, err := svc.privacyStore.GetDataExportsByUser(ctx, userID)
+	if err != nil {
+		return bundle, err
+	}
+
+	kycSubmissions, err := svc.privacyStore.GetKYCSubmissionsByUser(ctx, userID)
+	if err != nil {
+		return bundle, err
+	}
+
+	// only partners hold API keys, the section is empty for everyone else
+	apiKeys, err := svc.privacyStore.GetAPIKeysByPartner(ctx, userID)
+	if err != nil {
+		return bundle, err
+	}
+
+	bundle.GeneratedAt = time.Now()
+	bundle.Profile = service.DataExportProfile{
+		ID:              user.ID,
+		Email:           user.Email,
+		LineID:          user.LineID,
+		Nickname:        user.Nickname,
+		IsEmailVerified: user.IsEmailVerified,
+		Role:            user.Role,
+		Status:          user.Status,
+		KYCStatus:       user.KYCStatus,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}
+	if user.DeletedAt.Valid {
+		bundle.Profile.DeletedAt = &user.DeletedAt.Time
+	}
+
+	bundle.AuditLogs = make([]service.DataExportAudit, 0, len(auditLogs))
+	for _, auditLog := range auditLogs {
+		audit := service.DataExportAudit{
+			CreatedAt: auditLog.CreatedAt,
+			Action:    auditLog.Action,
+			ActorID:   auditLog.ActorID,
+			Details:   auditLog.Details,
+		}
+		if auditLog.TargetUserID.Valid {
+			targetUserID := uuid.UUID(auditLog.TargetUserID.Bytes)
+			audit.TargetUserID = &targetUserID
+		}
+		bundle.AuditLogs = append(bundle.AuditLogs, audit)
+	}
+
+	bundle.DataExports = make([]service.DataExportEntry, 0, len(dataExports))
+	for _, dataExport := range dataExports {
+		bundle.DataExports = append(bundle.DataExports, service.DataExportEntry{
+			ID:        dataExport.ID,
+			CreatedAt: dataExport.CreatedAt,
+			Status:    dataExport.Status,
+		})
+	}
+
+	bundle.KYCSubmissions = make([]service.DataExportKYCSubmission, 0, len(kycSubmissions))
+	for _, submission := range kycSubmissions {
+		bundle.KYCSubmissions = append(bundle.KYCSubmissions, service.DataExportKYCSubmission{
+			ID:           submission.ID,
+			CreatedAt:    submission.CreatedAt,
+			Status:       submission.Status,
+			ReviewReason: submission.ReviewReason,
+			ReviewedAt:   timeOrNil(submission.ReviewedAt),
+		})
+	}
+
+	bundle.APIKeys = make([]service.DataExportAPIKey, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		bundle.APIKeys = append(bundle.APIKeys, service.DataExportAPIKey{
+			ID:         apiKey.ID,
+			CreatedAt:  apiKey.CreatedAt,
+			Name:       apiKey.Name,
+			KeyID:      apiKey.KeyID,
+			Scopes:     apiKey.Scopes,
+			ExpiresAt:  timeOrNil(apiKey.ExpiresAt),
+			RevokedAt:  timeOrNil(apiKey.RevokedAt),
+			LastUsedAt: timeOrNil(apiKey.LastUsedAt),
+		})
+	}
+
+	return bundle, nil
+}
@@ -0,0 +1,561 @@
+package impl
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/DamianZhang/957-lending-platform/db/mock"
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/storage"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDataExport(t *testing.T) {
+	userID := uuid.New()
+	dataExport := randomDataExport(userID)
+
+	testCases := []struct {
+		name        string
+		input       *service.GetDataExportInput
+		buildStubs  func(store *mockdb.MockStore)
+		checkOutput func(output *service.GetDataExportOutput, err error)
+	}{
+		{
+			name: "OK",
+			input: &service.GetDataExportInput{
+				UserID:       userID,
+				DataExportID: dataExport.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDataExport(gomock.Any(), gomock.Eq(dataExport.ID)).
+					Times(1).
+					Return(dataExport, nil)
+			},
+			checkOutput: func(output *service.GetDataExportOutput, err error) {
+				require.NoError(t, err)
+				require.Equal(t, dataExport, output.DataExport)
+			},
+		},
+		{
+			name: "OtherUser",
+			input: &service.GetDataExportInput{
+				UserID:       uuid.New(),
+				DataExportID: dataExport.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDataExport(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(dataExport, nil)
+			},
+			checkOutput: func(output *service.GetDataExportOutput, err error) {
+				requireSvcErr(t, err, service.ErrNotFound)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "Expired",
+			input: &service.GetDataExportInput{
+				UserID:       userID,
+				DataExportID: dataExport.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expiredDataExport := dataExport
+				expiredDataExport.ExpiresAt = pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true}
+				store.EXPECT().
+					GetDataExport(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(expiredDataExport, nil)
+			},
+			checkOutput: func(output *service.GetDataExportOutput, err error) {
+				requireSvcErr(t, err, service.ErrNotFound)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "NotFound",
+			input: &service.GetDataExportInput{
+				UserID:       userID,
+				DataExportID: dataExport.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDataExport(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.DataExport{}, pgx.ErrNoRows)
+			},
+			checkOutput: func(output *service.GetDataExportOutput, err error) {
+				requireSvcErr(t, err, service.ErrNotFound)
+				require.Nil(t, output)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			privacyService := NewPrivacyServiceImpl(store, nil, t.TempDir(), time.Hour)
+
+			output, err := privacyService.GetDataExport(context.Background(), tc.input)
+			tc.checkOutput(output, err)
+		})
+	}
+}
+
+func TestProcessDataExport(t *testing.T) {
+	user, _ := expectedUser(t)
+	dataExport := randomDataExport(user.ID)
+	dataExport.Status = util.DataExportProcessing
+	auditLog := db.AuditLog{
+		ID:        1,
+		CreatedAt: time.Now(),
+		ActorID:   user.ID,
+		Action:    util.AuditActionCloseAccount,
+		Details:   []byte("{}"),
+	}
+	kycSubmission := db.KYCSubmission{
+		ID:            uuid.New(),
+		CreatedAt:     time.Now(),
+		UserID:        user.ID,
+		Status:        util.KYCSubmissionRejected,
+		IDDocumentKey: "kyc/id_document.jpg",
+		SelfieKey:     "kyc/selfie.jpg",
+		ReviewReason:  "blurry selfie",
+		ReviewedAt:    pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+	apiKey := db.APIKey{
+		ID:           uuid.New(),
+		CreatedAt:    time.Now(),
+		PartnerID:    user.ID,
+		Name:         "backend",
+		KeyID:        util.RandomString(16),
+		HashedSecret: "hashed-secret",
+		Scopes:       []string{util.ScopeBorrowersWrite},
+	}
+
+	// stubBundle lets the bundle of the user be built, with the user's profile only
+	stubBundle := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetUserByID(gomock.Any(), gomock.Eq(user.ID)).
+			Times(1).
+			Return(user, nil)
+		store.EXPECT().
+			GetAuditLogsByUser(gomock.Any(), gomock.Eq(user.ID)).
+			Times(1).
+			Return([]db.AuditLog{}, nil)
+		store.EXPECT().
+			GetDataExportsByUser(gomock.Any(), gomock.Eq(user.ID)).
+			Times(1).
+			Return([]db.DataExport{}, nil)
+		store.EXPECT().
+			GetKYCSubmissionsByUser(gomock.Any(), gomock.Eq(user.ID)).
+			Times(1).
+			Return([]db.KYCSubmission{}, nil)
+		store.EXPECT().
+			GetAPIKeysByPartner(gomock.Any(), gomock.Eq(user.ID)).
+			Times(1).
+			Return([]db.APIKey{}, nil)
+	}
+
+	testCases := []struct {
+		name        string
+		buildStubs  func(store *mockdb.MockStore)
+		checkOutput func(exportDir string, processed bool, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ClaimDataExport(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(dataExport, nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetAuditLogsByUser(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return([]db.AuditLog{auditLog}, nil)
+				store.EXPECT().
+					GetDataExportsByUser(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return([]db.DataExport{dataExport}, nil)
+				store.EXPECT().
+					GetKYCSubmissionsByUser(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return([]db.KYCSubmission{kycSubmission}, nil)
+				store.EXPECT().
+					GetAPIKeysByPartner(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return([]db.APIKey{apiKey}, nil)
+				store.EXPECT().
+					CompleteDataExport(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CompleteDataExportParams) (db.DataExport, error) {
+						require.Equal(t, dataExport.ID, arg.ID)
+						require.WithinDuration(t, arg.CompletedAt.Add(time.Hour), arg.ExpiresAt, time.Second)
+						return dataExport, nil
+					})
+			},
+			checkOutput: func(exportDir string, processed bool, err error) {
+				require.NoError(t, err)
+				require.True(t, processed)
+
+				archive, err := zip.OpenReader(filepath.Join(exportDir, dataExport.ID.String()+".zip"))
+				require.NoError(t, err)
+				defer archive.Close()
+				require.Len(t, archive.File, 1)
+
+				file, err := archive.File[0].Open()
+				require.NoError(t, err)
+				defer file.Close()
+
+				data, err := io.ReadAll(file)
+				require.NoError(t, err)
+				require.NotContains(t, string(data), apiKey.HashedSecret)
+				require.NotContains(t, string(data), kycSubmission.IDDocumentKey)
+
+				var bundle service.DataExportBundle
+				require.NoError(t, json.Unmarshal(data, &bundle))
+				require.Equal(t, user.ID, bundle.Profile.ID)
+				require.Equal(t, user.Email, bundle.Profile.Email)
+				require.Equal(t, user.LineID, bundle.Profile.LineID)
+				require.Len(t, bundle.AuditLogs, 1)
+				require.Equal(t, auditLog.Action, bundle.AuditLogs[0].Action)
+				require.Len(t, bundle.DataExports, 1)
+
+				require.Len(t, bundle.KYCSubmissions, 1)
+				require.Equal(t, kycSubmission.ID, bundle.KYCSubmissions[0].ID)
+				require.Equal(t, kycSubmission.ReviewReason, bundle.KYCSubmissions[0].ReviewReason)
+				require.NotNil(t, bundle.KYCSubmissions[0].ReviewedAt)
+
+				require.Len(t, bundle.APIKeys, 1)
+				require.Equal(t, apiKey.KeyID, bundle.APIKeys[0].KeyID)
+				require.Equal(t, apiKey.Scopes, bundle.APIKeys[0].Scopes)
+				require.Nil(t, bundle.APIKeys[0].RevokedAt)
+			},
+		},
+		{
+			name: "CompleteFails",
+			buildStubs: func(store *mockdb.MockStore) {
+				stubBundle(store)
+				store.EXPECT().
+					ClaimDataExport(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(dataExport, nil)
+				store.EXPECT().
+					CompleteDataExport(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.DataExport{}, sql.ErrConnDone)
+			},
+			checkOutput: func(exportDir string, processed bool, err error) {
+				requireSvcErr(t, err, service.ErrInternalFailure)
+				require.True(t, processed)
+				require.NoFileExists(t, filepath.Join(exportDir, dataExport.ID.String()+".zip"))
+			},
+		},
+		{
+			// the user has been anonymized while the bundle was being written
+			name: "ExportDropped",
+			buildStubs: func(store *mockdb.MockStore) {
+				stubBundle(store)
+				store.EXPECT().
+					ClaimDataExport(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(dataExport, nil)
+				store.EXPECT().
+					CompleteDataExport(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.DataExport{}, pgx.ErrNoRows)
+			},
+			checkOutput: func(exportDir string, processed bool, err error) {
+				require.NoError(t, err)
+				require.True(t, processed)
+				require.NoFileExists(t, filepath.Join(exportDir, dataExport.ID.String()+".zip"))
+			},
+		},
+		{
+			name: "NonePending",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ClaimDataExport(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.DataExport{}, pgx.ErrNoRows)
+				store.EXPECT().
+					CompleteDataExport(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(exportDir string, processed bool, err error) {
+				require.NoError(t, err)
+				require.False(t, processed)
+			},
+		},
+		{
+			name: "DBErrConnDone",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ClaimDataExport(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(dataExport, nil)
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+				store.EXPECT().
+					FailDataExport(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(dataExport, nil)
+				store.EXPECT().
+					CompleteDataExport(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(exportDir string, processed bool, err error) {
+				requireSvcErr(t, err, service.ErrInternalFailure)
+				require.True(t, processed)
+				require.NoFileExists(t, filepath.Join(exportDir, dataExport.ID.String()+".zip"))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			exportDir := t.TempDir()
+			privacyService := NewPrivacyServiceImpl(store, nil, exportDir, time.Hour)
+
+			processed, err := privacyService.ProcessDataExport(context.Background())
+			tc.checkOutput(exportDir, processed, err)
+		})
+	}
+}
+
+func TestPurgeExpiredDataExports(t *testing.T) {
+	testCases := []struct {
+		name        string
+		buildStubs  func(store *mockdb.MockStore, exportDir string)
+		checkOutput func(exportDir string, purged int64, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore, exportDir string) {
+				dataExport := randomDataExport(uuid.New())
+				dataExport.Status = util.DataExportReady
+				dataExport.FilePath = filepath.Join(exportDir, "bundle.zip")
+				require.NoError(t, os.WriteFile(dataExport.FilePath, []byte("bundle"), 0o600))
+
+				// the bundle of this export is already gone
+				removedDataExport := randomDataExport(uuid.New())
+				removedDataExport.Status = util.DataExportReady
+				removedDataExport.FilePath = filepath.Join(exportDir, "removed.zip")
+
+				store.EXPECT().
+					GetExpiredDataExports(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.DataExport{dataExport, removedDataExport}, nil)
+				store.EXPECT().
+					ExpireDataExport(gomock.Any(), gomock.Eq(dataExport.ID)).
+					Times(1).
+					Return(dataExport, nil)
+				store.EXPECT().
+					ExpireDataExport(gomock.Any(), gomock.Eq(removedDataExport.ID)).
+					Times(1).
+					Return(removedDataExport, nil)
+			},
+			checkOutput: func(exportDir string, purged int64, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(2), purged)
+				require.NoFileExists(t, filepath.Join(exportDir, "bundle.zip"))
+			},
+		},
+		{
+			name: "DBErrConnDone",
+			buildStubs: func(store *mockdb.MockStore, exportDir string) {
+				dataExport := randomDataExport(uuid.New())
+				dataExport.Status = util.DataExportReady
+				dataExport.FilePath = filepath.Join(exportDir, "bundle.zip")
+				require.NoError(t, os.WriteFile(dataExport.FilePath, []byte("bundle"), 0o600))
+
+				store.EXPECT().
+					GetExpiredDataExports(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.DataExport{dataExport}, nil)
+				store.EXPECT().
+					ExpireDataExport(gomock.Any(), gomock.Eq(dataExport.ID)).
+					Times(1).
+					Return(db.DataExport{}, sql.ErrConnDone)
+			},
+			checkOutput: func(exportDir string, purged int64, err error) {
+				requireSvcErr(t, err, service.ErrInternalFailure)
+				require.Zero(t, purged)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			exportDir := t.TempDir()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store, exportDir)
+
+			privacyService := NewPrivacyServiceImpl(store, nil, exportDir, time.Hour)
+
+			purged, err := privacyService.PurgeExpiredDataExports(context.Background())
+			tc.checkOutput(exportDir, purged, err)
+		})
+	}
+}
+
+func TestAnonymizeUser(t *testing.T) {
+	adminID := uuid.New()
+	user, _ := expectedUser(t)
+
+	testCases := []struct {
+		name        string
+		input       *service.AnonymizeUserInput
+		buildStubs  func(store *mockdb.MockStore, blobStore storage.BlobStore, exportDir string)
+		checkOutput func(blobStore storage.BlobStore, exportDir string, output *service.AnonymizeUserOutput, err error)
+	}{
+		{
+			name: "OK",
+			input: &service.AnonymizeUserInput{
+				AdminID: adminID,
+				UserID:  user.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore, blobStore storage.BlobStore, exportDir string) {
+				dataExport := randomDataExport(user.ID)
+				dataExport.FilePath = filepath.Join(exportDir, "bundle.zip")
+				require.NoError(t, os.WriteFile(dataExport.FilePath, []byte("bundle"), 0o600))
+
+				submission := db.KYCSubmission{
+					ID:            uuid.New(),
+					UserID:        user.ID,
+					IDDocumentKey: "kyc/id_document.jpg",
+					SelfieKey:     "kyc/selfie.jpg",
+				}
+				for _, key := range []string{submission.IDDocumentKey, submission.SelfieKey} {
+					require.NoError(t, blobStore.Put(context.Background(), key, strings.NewReader("document")))
+				}
+
+				anonymizedUser := user
+				anonymizedUser.Status = util.ClosedStatus
+				store.EXPECT().
+					AnonymizeUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.AnonymizeUserTxParams) (db.AnonymizeUserTxResult, error) {
+						require.Equal(t, user.ID, arg.ID)
+						require.NotEqual(t, user.Email, arg.Email)
+						require.NotContains(t, arg.Email, user.Email)
+						require.Equal(t, adminID, arg.Audit.ActorID)
+						require.Equal(t, util.AuditActionAnonymizeUser, arg.Audit.Action)
+						anonymizedUser.Email = arg.Email
+						return db.AnonymizeUserTxResult{
+							AuditedUserTxResult: db.AuditedUserTxResult{User: anonymizedUser},
+							DataExports:         []db.DataExport{dataExport},
+							KYCSubmissions:      []db.KYCSubmission{submission},
+						}, nil
+					})
+			},
+			checkOutput: func(blobStore storage.BlobStore, exportDir string, output *service.AnonymizeUserOutput, err error) {
+				require.NoError(t, err)
+				require.Equal(t, util.ClosedStatus, output.User.Status)
+				require.NoFileExists(t, filepath.Join(exportDir, "bundle.zip"))
+
+				for _, key := range []string{"kyc/id_document.jpg", "kyc/selfie.jpg"} {
+					_, err := blobStore.Get(context.Background(), key)
+					require.ErrorIs(t, err, storage.ErrBlobNotFound)
+				}
+			},
+		},
+		{
+			name: "Themselves",
+			input: &service.AnonymizeUserInput{
+				AdminID: adminID,
+				UserID:  adminID,
+			},
+			buildStubs: func(store *mockdb.MockStore, blobStore storage.BlobStore, exportDir string) {
+				store.EXPECT().
+					AnonymizeUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(blobStore storage.BlobStore, exportDir string, output *service.AnonymizeUserOutput, err error) {
+				requireSvcErr(t, err, service.ErrForbidden)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "AlreadyAnonymized",
+			input: &service.AnonymizeUserInput{
+				AdminID: adminID,
+				UserID:  user.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore, blobStore storage.BlobStore, exportDir string) {
+				store.EXPECT().
+					AnonymizeUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AnonymizeUserTxResult{}, pgx.ErrNoRows)
+			},
+			checkOutput: func(blobStore storage.BlobStore, exportDir string, output *service.AnonymizeUserOutput, err error) {
+				requireSvcErr(t, err, service.ErrNotFound)
+				require.Nil(t, output)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			blobStore, err := storage.NewLocalBlobStore(t.TempDir())
+			require.NoError(t, err)
+
+			exportDir := t.TempDir()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store, blobStore, exportDir)
+
+			privacyService := NewPrivacyServiceImpl(store, blobStore, exportDir, time.Hour)
+
+			output, err := privacyService.AnonymizeUser(context.Background(), tc.input)
+			tc.checkOutput(blobStore, exportDir, output, err)
+		})
+	}
+}
+
+func randomDataExport(userID uuid.UUID) db.DataExport {
+	return db.DataExport{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    userID,
+		Status:    util.DataExportPending,
+	}
+}
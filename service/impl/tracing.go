@@ -0,0 +1,36 @@
+package impl
+
+import (
+	"context"
+	"errors"
+
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/DamianZhang/957-lending-platform/service/impl"
+
+// startSpan starts a span within the span of the request carried by the context
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracing.Tracer(tracerName).Start(ctx, name)
+}
+
+// endSpan ends a span, recording the error it ended with.
+// Only internal failures mark the span as failed, the other service errors are answers to the client.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		var svcErr service.Error
+		if errors.As(err, &svcErr) {
+			span.SetAttributes(attribute.String("error.code", svcErr.Code()))
+		}
+
+		span.RecordError(err)
+		if !errors.As(err, &svcErr) || errors.Is(err, service.ErrInternalFailure) {
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
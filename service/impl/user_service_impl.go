@@ -0,0 +1,213 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/i18n"
+	"github.com/DamianZhang/957-lending-platform/metrics"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func NewUserServiceImpl(userStore db.Store, passwordPolicy *util.PasswordPolicy, metrics *metrics.Metrics) service.UserService {
+	return &userServiceImpl{
+		userStore:      userStore,
+		passwordPolicy: passwordPolicy,
+		metrics:        metrics,
+	}
+}
+
+type userServiceImpl struct {
+	userStore      db.Store
+	passwordPolicy *util.PasswordPolicy
+	metrics        *metrics.Metrics
+}
+
+func (svc *userServiceImpl) SignIn(ctx context.Context, input *service.SignInInput) (*service.SignInOutput, error) {
+	// a password just changed, or an account just suspended, must be seen at once
+	user, err := svc.userStore.GetUserByEmail(db.WithPrimary(ctx), input.Email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, service.NewError(service.ErrUnauthenticated, err)
+		}
+		return nil, service.NewError(service.ErrInternalFailure, err)
+	}
+
+	err = util.CheckPassword(user.HashedPassword, input.Password)
+	if err != nil {
+		return nil, service.NewError(service.ErrUnauthenticated, err)
+	}
+
+	err = checkUserIsActive(user)
+	if err != nil {
+		return nil, err
+	}
+
+	if util.NeedsRehash(user.HashedPassword) {
+		user = svc.rehashPassword(ctx, user, input.Password)
+	}
+
+	output := &service.SignInOutput{
+		User: user,
+	}
+	return output, nil
+}
+
+func (svc *userServiceImpl) GetUser(ctx context.Context, input *service.GetUserInput) (*service.GetUserOutput, error) {
+	user, err := svc.getActiveUserByID(ctx, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &service.GetUserOutput{
+		User: user,
+	}
+	return output, nil
+}
+
+func (svc *userServiceImpl) UpdateUser(ctx context.Context, input *service.UpdateUserInput) (*service.UpdateUserOutput, error) {
+	user, err := svc.getActiveUserByID(ctx, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	arg := db.UpdateUserTxParams{
+		UpdateUserByEmailParams: db.UpdateUserByEmailParams{
+			Email:     user.Email,
+			UpdatedAt: time.Now(),
+			LineID:    optionalText(input.LineID),
+			Nickname:  optionalText(input.Nickname),
+		},
+		NewEmail: optionalText(input.Email),
+	}
+
+	result, err := svc.userStore.UpdateUserTx(ctx, arg)
+	if err != nil {
+		return nil, fromDBError(err)
+	}
+
+	output := &service.UpdateUserOutput{
+		User: result.User,
+	}
+	return output, nil
+}
+
+func (svc *userServiceImpl) ChangePassword(ctx context.Context, input *service.ChangePasswordInput) error {
+	user, err := svc.getActiveUserByID(ctx, input.UserID)
+	if err != nil {
+		return err
+	}
+
+	err = util.CheckPassword(user.HashedPassword, input.CurrentPassword)
+	if err != nil {
+		return service.NewError(service.ErrUnauthenticated, err)
+	}
+
+	hashedPassword, err := hashNewPassword(ctx, svc.passwordPolicy, svc.metrics, input.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	arg := db.UpdateUserByEmailParams{
+		Email:     user.Email,
+		UpdatedAt: time.Now(),
+		HashedPassword: pgtype.Text{
+			String: hashedPassword,
+			Valid:  true,
+		},
+	}
+
+	_, err = svc.userStore.UpdateUserByEmail(ctx, arg)
+	if err != nil {
+		return fromDBError(err)
+	}
+
+	return nil
+}
+
+func (svc *userServiceImpl) CloseAccount(ctx context.Context, input *service.CloseAccountInput) error {
+	user, err := svc.getActiveUserByID(ctx, input.UserID)
+	if err != nil {
+		return err
+	}
+
+	err = util.CheckPassword(user.HashedPassword, input.Password)
+	if err != nil {
+		return service.NewError(service.ErrUnauthenticated, err)
+	}
+
+	arg := db.CloseUserTxParams{
+		CloseUserParams: db.CloseUserParams{
+			ID:       user.ID,
+			ClosedAt: time.Now(),
+		},
+		Audit: db.AuditParams{
+			ActorID: user.ID,
+			Action:  util.AuditActionCloseAccount,
+		},
+	}
+
+	_, err = svc.userStore.CloseUserTx(ctx, arg)
+	if err != nil {
+		return fromDBError(err)
+	}
+
+	return nil
+}
+
+// rehashPassword replaces a hash using a legacy algorithm or outdated parameters once the password is known.
+// The user is signed in anyway when it fails, the hash is upgraded on a later sign in.
+func (svc *userServiceImpl) rehashPassword(ctx context.Context, user db.User, password string) db.User {
+	hashedPassword, err := hashPassword(ctx, svc.metrics, password)
+	if err != nil {
+		slog.WarnContext(ctx, "can not rehash password", "user_id", user.ID, "err", err)
+		return user
+	}
+
+	arg := db.UpdateUserByEmailParams{
+		Email:     user.Email,
+		UpdatedAt: user.UpdatedAt,
+		HashedPassword: pgtype.Text{
+			String: hashedPassword,
+			Valid:  true,
+		},
+	}
+
+	rehashedUser, err := svc.userStore.UpdateUserByEmail(ctx, arg)
+	if err != nil {
+		slog.WarnContext(ctx, "can not rehash password", "user_id", user.ID, "err", err)
+		return user
+	}
+
+	return rehashedUser
+}
+
+// getActiveUserByID reads the user from the primary, since its email is what the user is then updated by
+// and its password and status must be up to date
+func (svc *userServiceImpl) getActiveUserByID(ctx context.Context, userID uuid.UUID) (db.User, error) {
+	user, err := svc.userStore.GetUserByID(db.WithPrimary(ctx), userID)
+	if err != nil {
+		return db.User{}, fromDBError(err)
+	}
+
+	err = checkUserIsActive(user)
+	if err != nil {
+		return db.User{}, err
+	}
+
+	return user, nil
+}
+
+func checkUserIsActive(user db.User) error {
+	if user.Status != util.ActiveStatus {
+		return service.NewErrorWithDetail(service.ErrAccountInactive, i18n.NewMessage("user.account_inactive").With("status", user.Status))
+	}
+	return nil
+}
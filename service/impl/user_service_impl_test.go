@@ -0,0 +1,540 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	mockdb "github.com/DamianZhang/957-lending-platform/db/mock"
+	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/metrics"
+	"github.com/DamianZhang/957-lending-platform/service"
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type primaryContextMatcher struct{}
+
+func (primaryContextMatcher) Matches(x interface{}) bool {
+	ctx, ok := x.(context.Context)
+	return ok && db.UsesPrimary(ctx)
+}
+
+func (primaryContextMatcher) String() string {
+	return "is a context whose queries are sent to the primary"
+}
+
+// PrimaryContext matches the contexts whose queries are sent to the primary rather than the read replica
+func PrimaryContext() gomock.Matcher {
+	return primaryContextMatcher{}
+}
+
+type eqChangedPasswordParamsMatcher struct {
+	email    string
+	password string
+}
+
+func (e eqChangedPasswordParamsMatcher) Matches(x interface{}) bool {
+	gotArg, ok := x.(db.UpdateUserByEmailParams)
+	if !ok {
+		return false
+	}
+
+	if gotArg.Email != e.email || !gotArg.HashedPassword.Valid || gotArg.UpdatedAt.IsZero() {
+		return false
+	}
+
+	err := util.CheckPassword(gotArg.HashedPassword.String, e.password)
+	return err == nil
+}
+
+func (e eqChangedPasswordParamsMatcher) String() string {
+	return fmt.Sprintf("matches email %v and password %v", e.email, e.password)
+}
+
+func EqChangedPasswordParams(email, password string) gomock.Matcher {
+	return eqChangedPasswordParamsMatcher{email, password}
+}
+
+func TestSignIn(t *testing.T) {
+	user, password := expectedUser(t)
+
+	legacyHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	require.NoError(t, err)
+
+	legacyUser := user
+	legacyUser.HashedPassword = string(legacyHash)
+	legacyUser.UpdatedAt = time.Now()
+
+	testCases := []struct {
+		name        string
+		input       *service.SignInInput
+		buildStubs  func(store *mockdb.MockStore)
+		checkOutput func(output *service.SignInOutput, err error)
+	}{
+		{
+			name: "OK",
+			input: &service.SignInInput{
+				Email:    user.Email,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(PrimaryContext(), gomock.Eq(user.Email)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkOutput: func(output *service.SignInOutput, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user, output.User)
+			},
+		},
+		{
+			name: "RehashLegacyHash",
+			input: &service.SignInInput{
+				Email:    user.Email,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(PrimaryContext(), gomock.Eq(user.Email)).
+					Times(1).
+					Return(legacyUser, nil)
+				store.EXPECT().
+					UpdateUserByEmail(gomock.Any(), EqChangedPasswordParams(user.Email, password)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkOutput: func(output *service.SignInOutput, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user, output.User)
+			},
+		},
+		{
+			name: "RehashFailure",
+			input: &service.SignInInput{
+				Email:    user.Email,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(PrimaryContext(), gomock.Eq(user.Email)).
+					Times(1).
+					Return(legacyUser, nil)
+				store.EXPECT().
+					UpdateUserByEmail(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkOutput: func(output *service.SignInOutput, err error) {
+				require.NoError(t, err)
+				require.Equal(t, legacyUser, output.User)
+			},
+		},
+		{
+			name: "UserNotFound",
+			input: &service.SignInInput{
+				Email:    user.Email,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, pgx.ErrNoRows)
+			},
+			checkOutput: func(output *service.SignInOutput, err error) {
+				requireSvcErr(t, err, service.ErrUnauthenticated)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "IncorrectPassword",
+			input: &service.SignInInput{
+				Email:    user.Email,
+				Password: util.RandomString(7),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(user, nil)
+			},
+			checkOutput: func(output *service.SignInOutput, err error) {
+				requireSvcErr(t, err, service.ErrUnauthenticated)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "SuspendedAccount",
+			input: &service.SignInInput{
+				Email:    user.Email,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				suspendedUser := user
+				suspendedUser.Status = util.SuspendedStatus
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(suspendedUser, nil)
+			},
+			checkOutput: func(output *service.SignInOutput, err error) {
+				requireSvcErr(t, err, service.ErrAccountInactive)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "DBErrConnDone",
+			input: &service.SignInInput{
+				Email:    user.Email,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkOutput: func(output *service.SignInOutput, err error) {
+				requireSvcErr(t, err, service.ErrInternalFailure)
+				require.Nil(t, output)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			userService := NewUserServiceImpl(store, testPasswordPolicy(t), metrics.New())
+
+			output, err := userService.SignIn(context.Background(), tc.input)
+			tc.checkOutput(output, err)
+		})
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	user, _ := expectedUser(t)
+	newEmail := util.RandomEmail()
+	newNickname := util.RandomString(6)
+
+	testCases := []struct {
+		name        string
+		input       *service.UpdateUserInput
+		buildStubs  func(store *mockdb.MockStore)
+		checkOutput func(output *service.UpdateUserOutput, err error)
+	}{
+		{
+			name: "OK",
+			input: &service.UpdateUserInput{
+				UserID:   user.ID,
+				Email:    &newEmail,
+				Nickname: &newNickname,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByID(PrimaryContext(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+
+				updatedUser := user
+				updatedUser.Email = newEmail
+				updatedUser.Nickname = newNickname
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.Equal(t, user.Email, arg.Email)
+						require.Equal(t, newEmail, arg.NewEmail.String)
+						require.Equal(t, newNickname, arg.Nickname.String)
+						require.False(t, arg.LineID.Valid)
+						require.False(t, arg.HashedPassword.Valid)
+						require.NotZero(t, arg.UpdatedAt)
+						return db.UpdateUserTxResult{User: updatedUser}, nil
+					})
+			},
+			checkOutput: func(output *service.UpdateUserOutput, err error) {
+				require.NoError(t, err)
+				require.Equal(t, newEmail, output.User.Email)
+				require.Equal(t, newNickname, output.User.Nickname)
+			},
+		},
+		{
+			name: "UserNotFound",
+			input: &service.UpdateUserInput{
+				UserID:   user.ID,
+				Nickname: &newNickname,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, pgx.ErrNoRows)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(output *service.UpdateUserOutput, err error) {
+				requireSvcErr(t, err, service.ErrNotFound)
+				require.Nil(t, output)
+			},
+		},
+		{
+			name: "DBErrConnDone",
+			input: &service.UpdateUserInput{
+				UserID:   user.ID,
+				Nickname: &newNickname,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateUserTxResult{}, sql.ErrConnDone)
+			},
+			checkOutput: func(output *service.UpdateUserOutput, err error) {
+				requireSvcErr(t, err, service.ErrInternalFailure)
+				require.Nil(t, output)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			userService := NewUserServiceImpl(store, testPasswordPolicy(t), metrics.New())
+
+			output, err := userService.UpdateUser(context.Background(), tc.input)
+			tc.checkOutput(output, err)
+		})
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	user, password := expectedUser(t)
+	newPassword := util.RandomPassword()
+
+	testCases := []struct {
+		name        string
+		input       *service.ChangePasswordInput
+		buildStubs  func(store *mockdb.MockStore)
+		checkOutput func(err error)
+	}{
+		{
+			name: "OK",
+			input: &service.ChangePasswordInput{
+				UserID:          user.ID,
+				CurrentPassword: password,
+				NewPassword:     newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByID(PrimaryContext(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserByEmail(gomock.Any(), EqChangedPasswordParams(user.Email, newPassword)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkOutput: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "IncorrectCurrentPassword",
+			input: &service.ChangePasswordInput{
+				UserID:          user.ID,
+				CurrentPassword: util.RandomString(7),
+				NewPassword:     newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserByEmail(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(err error) {
+				requireSvcErr(t, err, service.ErrUnauthenticated)
+			},
+		},
+		{
+			name: "DBErrConnDone",
+			input: &service.ChangePasswordInput{
+				UserID:          user.ID,
+				CurrentPassword: password,
+				NewPassword:     newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserByEmail(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkOutput: func(err error) {
+				requireSvcErr(t, err, service.ErrInternalFailure)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			userService := NewUserServiceImpl(store, testPasswordPolicy(t), metrics.New())
+
+			err := userService.ChangePassword(context.Background(), tc.input)
+			tc.checkOutput(err)
+		})
+	}
+}
+
+func expectedUser(t *testing.T) (user db.User, password string) {
+	user, password = expectedBorrower(t)
+	user.ID = uuid.New()
+	user.Status = util.ActiveStatus
+	user.KYCStatus = util.KYCUnverified
+	return
+}
+
+func requireSvcErr(t *testing.T, err error, wanted error) {
+	var svcError service.Error
+	require.ErrorAs(t, err, &svcError)
+	require.ErrorIs(t, svcError.SvcErr(), wanted)
+}
+
+func testPasswordPolicy(t *testing.T) *util.PasswordPolicy {
+	policy, err := util.NewPasswordPolicy(util.Config{
+		AuthConfig: util.AuthConfig{
+			PasswordMinLength:        8,
+			PasswordCharacterClasses: []string{util.PasswordClassLetter, util.PasswordClassDigit},
+			PasswordDenyListFile:     "../../data/password_deny_list.txt",
+		},
+	})
+	require.NoError(t, err)
+	return policy
+}
+
+func TestCloseAccount(t *testing.T) {
+	user, password := expectedUser(t)
+
+	testCases := []struct {
+		name        string
+		input       *service.CloseAccountInput
+		buildStubs  func(store *mockdb.MockStore)
+		checkOutput func(err error)
+	}{
+		{
+			name: "OK",
+			input: &service.CloseAccountInput{
+				UserID:   user.ID,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByID(PrimaryContext(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CloseUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CloseUserTxParams) (db.AuditedUserTxResult, error) {
+						require.Equal(t, user.ID, arg.ID)
+						require.NotZero(t, arg.ClosedAt)
+						require.Equal(t, user.ID, arg.Audit.ActorID)
+						require.Equal(t, util.AuditActionCloseAccount, arg.Audit.Action)
+						return db.AuditedUserTxResult{}, nil
+					})
+			},
+			checkOutput: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "IncorrectPassword",
+			input: &service.CloseAccountInput{
+				UserID:   user.ID,
+				Password: util.RandomString(7),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CloseUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(err error) {
+				requireSvcErr(t, err, service.ErrUnauthenticated)
+			},
+		},
+		{
+			name: "AlreadyClosed",
+			input: &service.CloseAccountInput{
+				UserID:   user.ID,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				closedUser := user
+				closedUser.Status = util.ClosedStatus
+				store.EXPECT().
+					GetUserByID(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(closedUser, nil)
+				store.EXPECT().
+					CloseUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkOutput: func(err error) {
+				requireSvcErr(t, err, service.ErrAccountInactive)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			userService := NewUserServiceImpl(store, testPasswordPolicy(t), metrics.New())
+
+			err := userService.CloseAccount(context.Background(), tc.input)
+			tc.checkOutput(err)
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type KYCService interface {
+	SubmitKYC(ctx context.Context, input *SubmitKYCInput) (*SubmitKYCOutput, error)
+	GetKYCStatus(ctx context.Context, input *GetKYCStatusInput) (*GetKYCStatusOutput, error)
+	ListPendingKYCSubmissions(ctx context.Context, input *ListPendingKYCSubmissionsInput) (*ListPendingKYCSubmissionsOutput, error)
+	GetKYCDocument(ctx context.Context, input *GetKYCDocumentInput) (*GetKYCDocumentOutput, error)
+	ReviewKYCSubmission(ctx context.Context, input *ReviewKYCSubmissionInput) (*ReviewKYCSubmissionOutput, error)
+	// RequireKYCVerified fails with ErrKYCRequired unless the identity of the user has been verified.
+	// It guards the sensitive actions, such as receiving money.
+	RequireKYCVerified(ctx context.Context, userID uuid.UUID) error
+}
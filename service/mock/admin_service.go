@@ -0,0 +1,141 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/DamianZhang/957-lending-platform/service (interfaces: AdminService)
+
+// Package mocksvc is a generated GoMock package.
+package mocksvc
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/DamianZhang/957-lending-platform/service"
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+)
+
+// MockAdminService is a mock of AdminService interface.
+type MockAdminService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAdminServiceMockRecorder
+}
+
+// MockAdminServiceMockRecorder is the mock recorder for MockAdminService.
+type MockAdminServiceMockRecorder struct {
+	mock *MockAdminService
+}
+
+// NewMockAdminService creates a new mock instance.
+func NewMockAdminService(ctrl *gomock.Controller) *MockAdminService {
+	mock := &MockAdminService{ctrl: ctrl}
+	mock.recorder = &MockAdminServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAdminService) EXPECT() *MockAdminServiceMockRecorder {
+	return m.recorder
+}
+
+// ChangeUserRole mocks base method.
+func (m *MockAdminService) ChangeUserRole(arg0 context.Context, arg1 *service.ChangeUserRoleInput) (*service.ChangeUserRoleOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeUserRole", arg0, arg1)
+	ret0, _ := ret[0].(*service.ChangeUserRoleOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeUserRole indicates an expected call of ChangeUserRole.
+func (mr *MockAdminServiceMockRecorder) ChangeUserRole(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeUserRole", reflect.TypeOf((*MockAdminService)(nil).ChangeUserRole), arg0, arg1)
+}
+
+// CreateAdmin mocks base method.
+func (m *MockAdminService) CreateAdmin(arg0 context.Context, arg1 *service.CreateAdminInput) (*service.CreateAdminOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAdmin", arg0, arg1)
+	ret0, _ := ret[0].(*service.CreateAdminOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAdmin indicates an expected call of CreateAdmin.
+func (mr *MockAdminServiceMockRecorder) CreateAdmin(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAdmin", reflect.TypeOf((*MockAdminService)(nil).CreateAdmin), arg0, arg1)
+}
+
+// ListUsers mocks base method.
+func (m *MockAdminService) ListUsers(arg0 context.Context, arg1 *service.ListUsersInput) (*service.ListUsersOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", arg0, arg1)
+	ret0, _ := ret[0].(*service.ListUsersOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockAdminServiceMockRecorder) ListUsers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockAdminService)(nil).ListUsers), arg0, arg1)
+}
+
+// ReactivateUser mocks base method.
+func (m *MockAdminService) ReactivateUser(arg0 context.Context, arg1 *service.ReactivateUserInput) (*service.ReactivateUserOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReactivateUser", arg0, arg1)
+	ret0, _ := ret[0].(*service.ReactivateUserOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReactivateUser indicates an expected call of ReactivateUser.
+func (mr *MockAdminServiceMockRecorder) ReactivateUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReactivateUser", reflect.TypeOf((*MockAdminService)(nil).ReactivateUser), arg0, arg1)
+}
+
+// RequireAdmin mocks base method.
+func (m *MockAdminService) RequireAdmin(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequireAdmin", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequireAdmin indicates an expected call of RequireAdmin.
+func (mr *MockAdminServiceMockRecorder) RequireAdmin(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequireAdmin", reflect.TypeOf((*MockAdminService)(nil).RequireAdmin), arg0, arg1)
+}
+
+// SuspendUser mocks base method.
+func (m *MockAdminService) SuspendUser(arg0 context.Context, arg1 *service.SuspendUserInput) (*service.SuspendUserOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuspendUser", arg0, arg1)
+	ret0, _ := ret[0].(*service.SuspendUserOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SuspendUser indicates an expected call of SuspendUser.
+func (mr *MockAdminServiceMockRecorder) SuspendUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuspendUser", reflect.TypeOf((*MockAdminService)(nil).SuspendUser), arg0, arg1)
+}
+
+// ViewUser mocks base method.
+func (m *MockAdminService) ViewUser(arg0 context.Context, arg1 *service.ViewUserInput) (*service.ViewUserOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ViewUser", arg0, arg1)
+	ret0, _ := ret[0].(*service.ViewUserOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ViewUser indicates an expected call of ViewUser.
+func (mr *MockAdminServiceMockRecorder) ViewUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ViewUser", reflect.TypeOf((*MockAdminService)(nil).ViewUser), arg0, arg1)
+}
@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/DamianZhang/957-lending-platform/service (interfaces: IdempotencyService)
+
+// Package mocksvc is a generated GoMock package.
+package mocksvc
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/DamianZhang/957-lending-platform/service"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIdempotencyService is a mock of IdempotencyService interface.
+type MockIdempotencyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyServiceMockRecorder
+}
+
+// MockIdempotencyServiceMockRecorder is the mock recorder for MockIdempotencyService.
+type MockIdempotencyServiceMockRecorder struct {
+	mock *MockIdempotencyService
+}
+
+// NewMockIdempotencyService creates a new mock instance.
+func NewMockIdempotencyService(ctrl *gomock.Controller) *MockIdempotencyService {
+	mock := &MockIdempotencyService{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyService) EXPECT() *MockIdempotencyServiceMockRecorder {
+	return m.recorder
+}
+
+// AbandonRequest mocks base method.
+func (m *MockIdempotencyService) AbandonRequest(arg0 context.Context, arg1 *service.AbandonIdempotentRequestInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AbandonRequest", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AbandonRequest indicates an expected call of AbandonRequest.
+func (mr *MockIdempotencyServiceMockRecorder) AbandonRequest(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AbandonRequest", reflect.TypeOf((*MockIdempotencyService)(nil).AbandonRequest), arg0, arg1)
+}
+
+// BeginRequest mocks base method.
+func (m *MockIdempotencyService) BeginRequest(arg0 context.Context, arg1 *service.BeginIdempotentRequestInput) (*service.BeginIdempotentRequestOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginRequest", arg0, arg1)
+	ret0, _ := ret[0].(*service.BeginIdempotentRequestOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginRequest indicates an expected call of BeginRequest.
+func (mr *MockIdempotencyServiceMockRecorder) BeginRequest(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginRequest", reflect.TypeOf((*MockIdempotencyService)(nil).BeginRequest), arg0, arg1)
+}
+
+// CompleteRequest mocks base method.
+func (m *MockIdempotencyService) CompleteRequest(arg0 context.Context, arg1 *service.CompleteIdempotentRequestInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteRequest", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteRequest indicates an expected call of CompleteRequest.
+func (mr *MockIdempotencyServiceMockRecorder) CompleteRequest(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteRequest", reflect.TypeOf((*MockIdempotencyService)(nil).CompleteRequest), arg0, arg1)
+}
+
+// PurgeExpiredKeys mocks base method.
+func (m *MockIdempotencyService) PurgeExpiredKeys(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeExpiredKeys", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeExpiredKeys indicates an expected call of PurgeExpiredKeys.
+func (mr *MockIdempotencyServiceMockRecorder) PurgeExpiredKeys(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeExpiredKeys", reflect.TypeOf((*MockIdempotencyService)(nil).PurgeExpiredKeys), arg0)
+}
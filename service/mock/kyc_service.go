@@ -0,0 +1,126 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/DamianZhang/957-lending-platform/service (interfaces: KYCService)
+
+// Package mocksvc is a generated GoMock package.
+package mocksvc
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/DamianZhang/957-lending-platform/service"
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+)
+
+// MockKYCService is a mock of KYCService interface.
+type MockKYCService struct {
+	ctrl     *gomock.Controller
+	recorder *MockKYCServiceMockRecorder
+}
+
+// MockKYCServiceMockRecorder is the mock recorder for MockKYCService.
+type MockKYCServiceMockRecorder struct {
+	mock *MockKYCService
+}
+
+// NewMockKYCService creates a new mock instance.
+func NewMockKYCService(ctrl *gomock.Controller) *MockKYCService {
+	mock := &MockKYCService{ctrl: ctrl}
+	mock.recorder = &MockKYCServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKYCService) EXPECT() *MockKYCServiceMockRecorder {
+	return m.recorder
+}
+
+// GetKYCDocument mocks base method.
+func (m *MockKYCService) GetKYCDocument(arg0 context.Context, arg1 *service.GetKYCDocumentInput) (*service.GetKYCDocumentOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKYCDocument", arg0, arg1)
+	ret0, _ := ret[0].(*service.GetKYCDocumentOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKYCDocument indicates an expected call of GetKYCDocument.
+func (mr *MockKYCServiceMockRecorder) GetKYCDocument(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKYCDocument", reflect.TypeOf((*MockKYCService)(nil).GetKYCDocument), arg0, arg1)
+}
+
+// GetKYCStatus mocks base method.
+func (m *MockKYCService) GetKYCStatus(arg0 context.Context, arg1 *service.GetKYCStatusInput) (*service.GetKYCStatusOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKYCStatus", arg0, arg1)
+	ret0, _ := ret[0].(*service.GetKYCStatusOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKYCStatus indicates an expected call of GetKYCStatus.
+func (mr *MockKYCServiceMockRecorder) GetKYCStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKYCStatus", reflect.TypeOf((*MockKYCService)(nil).GetKYCStatus), arg0, arg1)
+}
+
+// ListPendingKYCSubmissions mocks base method.
+func (m *MockKYCService) ListPendingKYCSubmissions(arg0 context.Context, arg1 *service.ListPendingKYCSubmissionsInput) (*service.ListPendingKYCSubmissionsOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingKYCSubmissions", arg0, arg1)
+	ret0, _ := ret[0].(*service.ListPendingKYCSubmissionsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingKYCSubmissions indicates an expected call of ListPendingKYCSubmissions.
+func (mr *MockKYCServiceMockRecorder) ListPendingKYCSubmissions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingKYCSubmissions", reflect.TypeOf((*MockKYCService)(nil).ListPendingKYCSubmissions), arg0, arg1)
+}
+
+// RequireKYCVerified mocks base method.
+func (m *MockKYCService) RequireKYCVerified(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequireKYCVerified", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequireKYCVerified indicates an expected call of RequireKYCVerified.
+func (mr *MockKYCServiceMockRecorder) RequireKYCVerified(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequireKYCVerified", reflect.TypeOf((*MockKYCService)(nil).RequireKYCVerified), arg0, arg1)
+}
+
+// ReviewKYCSubmission mocks base method.
+func (m *MockKYCService) ReviewKYCSubmission(arg0 context.Context, arg1 *service.ReviewKYCSubmissionInput) (*service.ReviewKYCSubmissionOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReviewKYCSubmission", arg0, arg1)
+	ret0, _ := ret[0].(*service.ReviewKYCSubmissionOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReviewKYCSubmission indicates an expected call of ReviewKYCSubmission.
+func (mr *MockKYCServiceMockRecorder) ReviewKYCSubmission(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReviewKYCSubmission", reflect.TypeOf((*MockKYCService)(nil).ReviewKYCSubmission), arg0, arg1)
+}
+
+// SubmitKYC mocks base method.
+func (m *MockKYCService) SubmitKYC(arg0 context.Context, arg1 *service.SubmitKYCInput) (*service.SubmitKYCOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubmitKYC", arg0, arg1)
+	ret0, _ := ret[0].(*service.SubmitKYCOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubmitKYC indicates an expected call of SubmitKYC.
+func (mr *MockKYCServiceMockRecorder) SubmitKYC(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitKYC", reflect.TypeOf((*MockKYCService)(nil).SubmitKYC), arg0, arg1)
+}
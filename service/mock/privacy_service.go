@@ -0,0 +1,111 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/DamianZhang/957-lending-platform/service (interfaces: PrivacyService)
+
+// Package mocksvc is a generated GoMock package.
+package mocksvc
+
+import (
+	context "context"
+	reflect "reflect"
+
+	service "github.com/DamianZhang/957-lending-platform/service"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPrivacyService is a mock of PrivacyService interface.
+type MockPrivacyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPrivacyServiceMockRecorder
+}
+
+// MockPrivacyServiceMockRecorder is the mock recorder for MockPrivacyService.
+type MockPrivacyServiceMockRecorder struct {
+	mock *MockPrivacyService
+}
+
+// NewMockPrivacyService creates a new mock instance.
+func NewMockPrivacyService(ctrl *gomock.Controller) *MockPrivacyService {
+	mock := &MockPrivacyService{ctrl: ctrl}
+	mock.recorder = &MockPrivacyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPrivacyService) EXPECT() *MockPrivacyServiceMockRecorder {
+	return m.recorder
+}
+
+// AnonymizeUser mocks base method.
+func (m *MockPrivacyService) AnonymizeUser(arg0 context.Context, arg1 *service.AnonymizeUserInput) (*service.AnonymizeUserOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnonymizeUser", arg0, arg1)
+	ret0, _ := ret[0].(*service.AnonymizeUserOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AnonymizeUser indicates an expected call of AnonymizeUser.
+func (mr *MockPrivacyServiceMockRecorder) AnonymizeUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnonymizeUser", reflect.TypeOf((*MockPrivacyService)(nil).AnonymizeUser), arg0, arg1)
+}
+
+// GetDataExport mocks base method.
+func (m *MockPrivacyService) GetDataExport(arg0 context.Context, arg1 *service.GetDataExportInput) (*service.GetDataExportOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDataExport", arg0, arg1)
+	ret0, _ := ret[0].(*service.GetDataExportOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDataExport indicates an expected call of GetDataExport.
+func (mr *MockPrivacyServiceMockRecorder) GetDataExport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataExport", reflect.TypeOf((*MockPrivacyService)(nil).GetDataExport), arg0, arg1)
+}
+
+// ProcessDataExport mocks base method.
+func (m *MockPrivacyService) ProcessDataExport(arg0 context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessDataExport", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProcessDataExport indicates an expected call of ProcessDataExport.
+func (mr *MockPrivacyServiceMockRecorder) ProcessDataExport(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessDataExport", reflect.TypeOf((*MockPrivacyService)(nil).ProcessDataExport), arg0)
+}
+
+// PurgeExpiredDataExports mocks base method.
+func (m *MockPrivacyService) PurgeExpiredDataExports(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeExpiredDataExports", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeExpiredDataExports indicates an expected call of PurgeExpiredDataExports.
+func (mr *MockPrivacyServiceMockRecorder) PurgeExpiredDataExports(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeExpiredDataExports", reflect.TypeOf((*MockPrivacyService)(nil).PurgeExpiredDataExports), arg0)
+}
+
+// RequestDataExport mocks base method.
+func (m *MockPrivacyService) RequestDataExport(arg0 context.Context, arg1 *service.RequestDataExportInput) (*service.RequestDataExportOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestDataExport", arg0, arg1)
+	ret0, _ := ret[0].(*service.RequestDataExportOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestDataExport indicates an expected call of RequestDataExport.
+func (mr *MockPrivacyServiceMockRecorder) RequestDataExport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestDataExport", reflect.TypeOf((*MockPrivacyService)(nil).RequestDataExport), arg0, arg1)
+}
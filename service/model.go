@@ -1,7 +1,13 @@
 package service
 
 import (
+	"encoding/json"
+	"io"
+	"time"
+
 	db "github.com/DamianZhang/957-lending-platform/db/sqlc"
+	"github.com/DamianZhang/957-lending-platform/listing"
+	"github.com/google/uuid"
 )
 
 type SignUpInput struct {
@@ -14,3 +20,381 @@ type SignUpInput struct {
 type SignUpOutput struct {
 	Borrower db.User `json:"borrower"`
 }
+
+type SignInInput struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type SignInOutput struct {
+	User db.User `json:"user"`
+}
+
+type GetUserInput struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+type GetUserOutput struct {
+	User db.User `json:"user"`
+}
+
+// UpdateUserInput holds the fields of a user which can be changed by the user.
+// A nil field is left untouched.
+type UpdateUserInput struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Email    *string   `json:"email"`
+	LineID   *string   `json:"line_id"`
+	Nickname *string   `json:"nickname"`
+}
+
+type UpdateUserOutput struct {
+	User db.User `json:"user"`
+}
+
+type ChangePasswordInput struct {
+	UserID          uuid.UUID `json:"user_id"`
+	CurrentPassword string    `json:"current_password"`
+	NewPassword     string    `json:"new_password"`
+}
+
+type CloseAccountInput struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Password string    `json:"password"`
+}
+
+type CreateAdminInput struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	LineID   string `json:"line_id"`
+	Nickname string `json:"nickname"`
+}
+
+type CreateAdminOutput struct {
+	Admin db.User `json:"admin"`
+}
+
+// ListUsersInput holds the search criteria and the page of the back-office user listing.
+// A nil or empty criterion is not applied.
+// Users are ordered by creation time, the page starting right after the After key, if any.
+type ListUsersInput struct {
+	AdminID         uuid.UUID    `json:"admin_id"`
+	Email           *string      `json:"email"`
+	Roles           []string     `json:"roles"`
+	Statuses        []string     `json:"statuses"`
+	IsEmailVerified *bool        `json:"is_email_verified"`
+	CreatedFrom     *time.Time   `json:"created_from"`
+	CreatedTo       *time.Time   `json:"created_to"`
+	IncludeDeleted  bool         `json:"include_deleted"`
+	Descending      bool         `json:"descending"`
+	After           *listing.Key `json:"after"`
+	Limit           int32        `json:"limit"`
+}
+
+// ListUsersOutput holds a page of users.
+// Next is the key of the last user of the page when more users follow it.
+type ListUsersOutput struct {
+	Users []db.User    `json:"users"`
+	Next  *listing.Key `json:"next"`
+}
+
+type ViewUserInput struct {
+	AdminID uuid.UUID `json:"admin_id"`
+	UserID  uuid.UUID `json:"user_id"`
+}
+
+type ViewUserOutput struct {
+	User db.User `json:"user"`
+}
+
+type ChangeUserRoleInput struct {
+	AdminID uuid.UUID `json:"admin_id"`
+	UserID  uuid.UUID `json:"user_id"`
+	Role    string    `json:"role"`
+}
+
+type ChangeUserRoleOutput struct {
+	User db.User `json:"user"`
+}
+
+type SuspendUserInput struct {
+	AdminID uuid.UUID `json:"admin_id"`
+	UserID  uuid.UUID `json:"user_id"`
+	Reason  string    `json:"reason"`
+}
+
+type SuspendUserOutput struct {
+	User db.User `json:"user"`
+}
+
+type ReactivateUserInput struct {
+	AdminID uuid.UUID `json:"admin_id"`
+	UserID  uuid.UUID `json:"user_id"`
+	Reason  string    `json:"reason"`
+}
+
+type ReactivateUserOutput struct {
+	User db.User `json:"user"`
+}
+
+type RequestDataExportInput struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+type RequestDataExportOutput struct {
+	DataExport db.DataExport `json:"data_export"`
+}
+
+type GetDataExportInput struct {
+	UserID       uuid.UUID `json:"user_id"`
+	DataExportID uuid.UUID `json:"data_export_id"`
+}
+
+type GetDataExportOutput struct {
+	DataExport db.DataExport `json:"data_export"`
+}
+
+type AnonymizeUserInput struct {
+	AdminID uuid.UUID `json:"admin_id"`
+	UserID  uuid.UUID `json:"user_id"`
+}
+
+type AnonymizeUserOutput struct {
+	User db.User `json:"user"`
+}
+
+// DataExportBundle is everything the platform holds about a user,
+// as written into the archive of a data export
+type DataExportBundle struct {
+	GeneratedAt    time.Time                 `json:"generated_at"`
+	Profile        DataExportProfile         `json:"profile"`
+	AuditLogs      []DataExportAudit         `json:"audit_logs"`
+	DataExports    []DataExportEntry         `json:"data_exports"`
+	KYCSubmissions []DataExportKYCSubmission `json:"kyc_submissions"`
+	APIKeys        []DataExportAPIKey        `json:"api_keys"`
+}
+
+type DataExportProfile struct {
+	ID              uuid.UUID  `json:"id"`
+	Email           string     `json:"email"`
+	LineID          string     `json:"line_id"`
+	Nickname        string     `json:"nickname"`
+	IsEmailVerified bool       `json:"is_email_verified"`
+	Role            string     `json:"role"`
+	Status          string     `json:"status"`
+	KYCStatus       string     `json:"kyc_status"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
+}
+
+type DataExportAudit struct {
+	CreatedAt    time.Time       `json:"created_at"`
+	Action       string          `json:"action"`
+	ActorID      uuid.UUID       `json:"actor_id"`
+	TargetUserID *uuid.UUID      `json:"target_user_id,omitempty"`
+	Details      json.RawMessage `json:"details"`
+}
+
+type DataExportEntry struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Status    string    `json:"status"`
+}
+
+// DataExportKYCSubmission describes a KYC submission, the documents themselves are not exported
+type DataExportKYCSubmission struct {
+	ID           uuid.UUID  `json:"id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	Status       string     `json:"status"`
+	ReviewReason string     `json:"review_reason,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// DataExportAPIKey describes an API key of a partner, its secret is never exported
+type DataExportAPIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Name       string     `json:"name"`
+	KeyID      string     `json:"key_id"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// KYCDocument is an uploaded image of a KYC submission
+type KYCDocument struct {
+	ContentType string    `json:"content_type"`
+	Content     io.Reader `json:"-"`
+}
+
+type SubmitKYCInput struct {
+	UserID     uuid.UUID   `json:"user_id"`
+	IDDocument KYCDocument `json:"id_document"`
+	Selfie     KYCDocument `json:"selfie"`
+}
+
+type SubmitKYCOutput struct {
+	KYCSubmission db.KYCSubmission `json:"kyc_submission"`
+}
+
+type GetKYCStatusInput struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// GetKYCStatusOutput holds the KYC status of a user and its latest submission, if any
+type GetKYCStatusOutput struct {
+	KYCStatus     string            `json:"kyc_status"`
+	KYCSubmission *db.KYCSubmission `json:"kyc_submission"`
+}
+
+type ListPendingKYCSubmissionsInput struct {
+	AdminID uuid.UUID `json:"admin_id"`
+	Limit   int32     `json:"limit"`
+	Offset  int32     `json:"offset"`
+}
+
+type ListPendingKYCSubmissionsOutput struct {
+	KYCSubmissions []db.KYCSubmission `json:"kyc_submissions"`
+}
+
+type GetKYCDocumentInput struct {
+	AdminID         uuid.UUID `json:"admin_id"`
+	KYCSubmissionID uuid.UUID `json:"kyc_submission_id"`
+	// Document is either util.KYCDocumentID or util.KYCDocumentSelfie
+	Document string `json:"document"`
+}
+
+// GetKYCDocumentOutput holds an uploaded image of a KYC submission. The caller must close its content.
+type GetKYCDocumentOutput struct {
+	ContentType string        `json:"content_type"`
+	Content     io.ReadCloser `json:"-"`
+}
+
+type ReviewKYCSubmissionInput struct {
+	AdminID         uuid.UUID `json:"admin_id"`
+	KYCSubmissionID uuid.UUID `json:"kyc_submission_id"`
+	Approve         bool      `json:"approve"`
+	Reason          string    `json:"reason"`
+}
+
+type ReviewKYCSubmissionOutput struct {
+	KYCSubmission db.KYCSubmission `json:"kyc_submission"`
+	User          db.User          `json:"user"`
+}
+
+type IssueAPIKeyInput struct {
+	AdminID   uuid.UUID  `json:"admin_id"`
+	PartnerID uuid.UUID  `json:"partner_id"`
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// IssueAPIKeyOutput holds an issued API key. Key and SigningKey are never stored,
+// so this is the only chance to hand them to the partner.
+type IssueAPIKeyOutput struct {
+	APIKey     db.APIKey `json:"api_key"`
+	Key        string    `json:"key"`
+	SigningKey string    `json:"signing_key"`
+}
+
+type ListAPIKeysInput struct {
+	AdminID   uuid.UUID `json:"admin_id"`
+	PartnerID uuid.UUID `json:"partner_id"`
+}
+
+type ListAPIKeysOutput struct {
+	APIKeys []db.APIKey `json:"api_keys"`
+}
+
+type RotateAPIKeyInput struct {
+	AdminID  uuid.UUID `json:"admin_id"`
+	APIKeyID uuid.UUID `json:"api_key_id"`
+	// GracePeriod is how long the rotated key keeps working, so that the partner can switch keys without downtime
+	GracePeriod time.Duration `json:"grace_period"`
+}
+
+// RotateAPIKeyOutput holds the rotated key and the key replacing it. Key and SigningKey belong to the new key.
+type RotateAPIKeyOutput struct {
+	OldAPIKey  db.APIKey `json:"old_api_key"`
+	APIKey     db.APIKey `json:"api_key"`
+	Key        string    `json:"key"`
+	SigningKey string    `json:"signing_key"`
+}
+
+type RevokeAPIKeyInput struct {
+	AdminID  uuid.UUID `json:"admin_id"`
+	APIKeyID uuid.UUID `json:"api_key_id"`
+	Reason   string    `json:"reason"`
+}
+
+type RevokeAPIKeyOutput struct {
+	APIKey db.APIKey `json:"api_key"`
+}
+
+type AuthenticateAPIKeyInput struct {
+	Key string `json:"key"`
+}
+
+type AuthenticateSignedRequestInput struct {
+	KeyID     string `json:"key_id"`
+	Timestamp int64  `json:"timestamp"`
+	Nonce     string `json:"nonce"`
+	Signature string `json:"signature"`
+	Method    string `json:"method"`
+	// Path includes the query string of the request
+	Path string `json:"path"`
+	Body []byte `json:"body"`
+}
+
+// AuthenticateAPIKeyOutput holds the API key a request was authenticated with and the partner owning it
+type AuthenticateAPIKeyOutput struct {
+	APIKey  db.APIKey `json:"api_key"`
+	Partner db.User   `json:"partner"`
+}
+
+// DependencyCheck is the result of checking a dependency of the application
+type DependencyCheck struct {
+	Name    string        `json:"name"`
+	Healthy bool          `json:"healthy"`
+	Latency time.Duration `json:"latency"`
+	// Error explains why the dependency is unhealthy
+	Error string `json:"error"`
+}
+
+type CheckReadinessOutput struct {
+	Ready  bool              `json:"ready"`
+	Checks []DependencyCheck `json:"checks"`
+}
+
+type BeginIdempotentRequestInput struct {
+	// Scope is the client sending the request, the keys of different clients never collide
+	Scope string `json:"scope"`
+	Key   string `json:"key"`
+	// Fingerprint identifies the request, a key can only be reused to retry the same request
+	Fingerprint string `json:"fingerprint"`
+}
+
+// IdempotentResponse is the response stored for a request handled with an idempotency key
+type IdempotentResponse struct {
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+type BeginIdempotentRequestOutput struct {
+	// Replay is the response to send again when the request has already been handled, nil otherwise
+	Replay *IdempotentResponse `json:"replay"`
+}
+
+type CompleteIdempotentRequestInput struct {
+	Scope    string             `json:"scope"`
+	Key      string             `json:"key"`
+	Response IdempotentResponse `json:"response"`
+}
+
+type AbandonIdempotentRequestInput struct {
+	Scope string `json:"scope"`
+	Key   string `json:"key"`
+}
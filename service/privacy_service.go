@@ -0,0 +1,16 @@
+package service
+
+import (
+	"context"
+)
+
+type PrivacyService interface {
+	RequestDataExport(ctx context.Context, input *RequestDataExportInput) (*RequestDataExportOutput, error)
+	GetDataExport(ctx context.Context, input *GetDataExportInput) (*GetDataExportOutput, error)
+	// ProcessDataExport builds the bundle of the next pending data export, if any.
+	// It reports whether an export has been processed.
+	ProcessDataExport(ctx context.Context) (bool, error)
+	// PurgeExpiredDataExports deletes the bundles of the expired data exports and reports how many were purged.
+	PurgeExpiredDataExports(ctx context.Context) (int64, error)
+	AnonymizeUser(ctx context.Context, input *AnonymizeUserInput) (*AnonymizeUserOutput, error)
+}
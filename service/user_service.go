@@ -0,0 +1,13 @@
+package service
+
+import (
+	"context"
+)
+
+type UserService interface {
+	SignIn(ctx context.Context, input *SignInInput) (*SignInOutput, error)
+	GetUser(ctx context.Context, input *GetUserInput) (*GetUserOutput, error)
+	UpdateUser(ctx context.Context, input *UpdateUserInput) (*UpdateUserOutput, error)
+	ChangePassword(ctx context.Context, input *ChangePasswordInput) error
+	CloseAccount(ctx context.Context, input *CloseAccountInput) error
+}
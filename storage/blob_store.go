@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// Different types of error returned by a BlobStore
+var (
+	ErrBlobNotFound   = errors.New("blob not found")
+	ErrInvalidBlobKey = errors.New("invalid blob key")
+)
+
+// BlobStore is an interface for storing binary objects, such as uploaded documents, by key.
+// Keys are slash-separated paths like "kyc/<user id>/selfie.jpg".
+type BlobStore interface {
+	// Put stores the content under the key, replacing any existing blob
+	Put(ctx context.Context, key string, content io.Reader) error
+
+	// Get opens the blob stored under the key. The caller must close it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the blob stored under the key. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
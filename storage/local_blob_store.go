@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// LocalBlobStore is a BlobStore keeping the blobs as files below a root directory
+type LocalBlobStore struct {
+	rootDir string
+}
+
+// NewLocalBlobStore creates a new LocalBlobStore, creating the root directory if needed
+func NewLocalBlobStore(rootDir string) (BlobStore, error) {
+	if err := os.MkdirAll(rootDir, 0o700); err != nil {
+		return nil, fmt.Errorf("cannot create blob directory: %w", err)
+	}
+	return &LocalBlobStore{rootDir}, nil
+}
+
+// Put stores the content under the key, replacing any existing blob
+func (store *LocalBlobStore) Put(ctx context.Context, key string, content io.Reader) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	// write into a temporary file first so that a failed upload never leaves a partial blob behind
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if _, err := io.Copy(file, content); err != nil {
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+// Get opens the blob stored under the key. The caller must close it.
+func (store *LocalBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrBlobNotFound
+		}
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// Delete removes the blob stored under the key. Deleting a missing blob is not an error.
+func (store *LocalBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// path maps a key to its file, refusing keys which would escape the root directory
+func (store *LocalBlobStore) path(key string) (string, error) {
+	localPath := filepath.FromSlash(key)
+	if key == "" || !filepath.IsLocal(localPath) {
+		return "", ErrInvalidBlobKey
+	}
+	return filepath.Join(store.rootDir, localPath), nil
+}
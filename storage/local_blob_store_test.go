@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalBlobStore(t *testing.T) {
+	store, err := NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	key := "kyc/" + util.RandomString(6) + "/selfie.jpg"
+	content := []byte(util.RandomString(32))
+
+	err = store.Put(context.Background(), key, bytes.NewReader(content))
+	require.NoError(t, err)
+
+	blob, err := store.Get(context.Background(), key)
+	require.NoError(t, err)
+
+	got, err := io.ReadAll(blob)
+	require.NoError(t, err)
+	require.NoError(t, blob.Close())
+	require.Equal(t, content, got)
+
+	err = store.Delete(context.Background(), key)
+	require.NoError(t, err)
+
+	_, err = store.Get(context.Background(), key)
+	require.ErrorIs(t, err, ErrBlobNotFound)
+
+	// deleting twice is fine
+	err = store.Delete(context.Background(), key)
+	require.NoError(t, err)
+}
+
+func TestLocalBlobStoreInvalidKey(t *testing.T) {
+	store, err := NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "../outside.jpg", "kyc/../../outside.jpg", "/etc/passwd"} {
+		err = store.Put(context.Background(), key, bytes.NewReader(nil))
+		require.ErrorIs(t, err, ErrInvalidBlobKey, key)
+
+		_, err = store.Get(context.Background(), key)
+		require.ErrorIs(t, err, ErrInvalidBlobKey, key)
+
+		err = store.Delete(context.Background(), key)
+		require.ErrorIs(t, err, ErrInvalidBlobKey, key)
+	}
+}
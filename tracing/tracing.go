@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DamianZhang/957-lending-platform/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName identifies the spans of the lending platform in the tracing backend
+const ServiceName = "957-lending-platform"
+
+// Exporters of the spans
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Tracer returns the tracer of an instrumented package from the global tracer provider.
+// It is looked up on every span rather than once, so that tests can install their own provider.
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
+// NewTracerProvider creates a tracer provider exporting the spans with the configured exporter.
+// The OTLP exporter sends them over HTTP to the configured endpoint,
+// or to the one of the standard OTEL_EXPORTER_OTLP_* environment variables when it is empty.
+func NewTracerProvider(ctx context.Context, config util.Config) (*sdktrace.TracerProvider, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create resource: %w", err)
+	}
+
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+
+	switch config.TracingExporter {
+	case "", ExporterNone:
+		// spans are still created so that trace contexts are propagated, they are just not exported
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("cannot create stdout exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	case ExporterOTLP:
+		var exporterOpts []otlptracehttp.Option
+		if config.TracingOTLPEndpoint != "" {
+			exporterOpts = append(exporterOpts, otlptracehttp.WithEndpoint(config.TracingOTLPEndpoint))
+		}
+
+		exporter, err := otlptracehttp.New(ctx, exporterOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create OTLP exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", config.TracingExporter)
+	}
+
+	return sdktrace.NewTracerProvider(opts...), nil
+}
+
+// SetGlobal installs the tracer provider used by the instrumented packages,
+// along with the propagation of W3C trace context and baggage headers
+func SetGlobal(tp trace.TracerProvider) {
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+}
@@ -0,0 +1,33 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DamianZhang/957-lending-platform/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTracerProvider(t *testing.T) {
+	for _, exporter := range []string{"", ExporterNone, ExporterStdout, ExporterOTLP} {
+		t.Run(exporter, func(t *testing.T) {
+			tp, err := NewTracerProvider(context.Background(), util.Config{
+				ObservabilityConfig: util.ObservabilityConfig{
+					TracingExporter:     exporter,
+					TracingOTLPEndpoint: "localhost:4318",
+				},
+			})
+			require.NoError(t, err)
+			require.NotNil(t, tp)
+
+			// nothing has been recorded, so there is nothing to send on shutdown
+			require.NoError(t, tp.Shutdown(context.Background()))
+		})
+	}
+}
+
+func TestNewTracerProviderUnknownExporter(t *testing.T) {
+	tp, err := NewTracerProvider(context.Background(), util.Config{ObservabilityConfig: util.ObservabilityConfig{TracingExporter: "zipkin"}})
+	require.EqualError(t, err, `unknown tracing exporter "zipkin"`)
+	require.Nil(t, tp)
+}
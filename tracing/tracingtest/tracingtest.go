@@ -0,0 +1,50 @@
+// Package tracingtest provides utilities to assert the spans recorded by the instrumented packages
+package tracingtest
+
+import (
+	"testing"
+
+	"github.com/DamianZhang/957-lending-platform/tracing"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// NewExporter installs a global tracer provider recording the spans in memory for the duration of the test.
+// Spans are recorded as soon as they end, so they can be asserted right after the code under test returns.
+func NewExporter(t testing.TB) *tracetest.InMemoryExporter {
+	previousProvider := otel.GetTracerProvider()
+	previousPropagator := otel.GetTextMapPropagator()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracing.SetGlobal(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return exporter
+}
+
+// SpanNamed returns the first recorded span with the name, failing the test when there is none
+func SpanNamed(t testing.TB, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+
+	t.Fatalf("no span named %q among %d spans", name, len(spans))
+	return tracetest.SpanStub{}
+}
+
+// RequireChildOf fails the test unless the span is a direct child of the parent span
+func RequireChildOf(t testing.TB, span tracetest.SpanStub, parent tracetest.SpanStub) {
+	t.Helper()
+
+	if span.SpanContext.TraceID() != parent.SpanContext.TraceID() || span.Parent.SpanID() != parent.SpanContext.SpanID() {
+		t.Fatalf("span %q is not a child of span %q", span.Name, parent.Name)
+	}
+}
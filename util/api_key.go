@@ -0,0 +1,76 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Constants for all scopes an API key can be granted
+const (
+	ScopeBorrowersRead  = "borrowers:read"
+	ScopeBorrowersWrite = "borrowers:write"
+	ScopeLoansRead      = "loans:read"
+	ScopeLoansWrite     = "loans:write"
+)
+
+// APIKeyIDPrefix starts the public part of every API key, which makes leaked keys easy to scan for
+const APIKeyIDPrefix = "lpk_"
+
+// APIKeySeparator separates the public key ID from the secret in an API key
+const APIKeySeparator = "."
+
+// FormatAPIKey builds the API key handed to a partner out of its public ID and its secret
+func FormatAPIKey(keyID, secret string) string {
+	return keyID + APIKeySeparator + secret
+}
+
+// ParseAPIKey splits an API key into its public ID and its secret
+func ParseAPIKey(key string) (keyID, secret string, ok bool) {
+	keyID, secret, ok = strings.Cut(key, APIKeySeparator)
+	if !ok || !strings.HasPrefix(keyID, APIKeyIDPrefix) || secret == "" {
+		return "", "", false
+	}
+	return keyID, secret, true
+}
+
+// HashAPIKeySecret returns the one-way hash of the secret of an API key, which verifies the keys sent
+// as bearer credentials. Only this hash is stored, so that the secret itself never is.
+func HashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeySigningKey derives the key partners sign their requests with from the hashed secret of their API key,
+// keyed by a secret of the server, so that the signing keys cannot be recomputed from the stored hashes alone.
+// Changing the server secret changes the signing keys of all API keys.
+func APIKeySigningKey(serverSecret, hashedSecret string) string {
+	mac := hmac.New(sha256.New, []byte(serverSecret))
+	mac.Write([]byte(hashedSecret))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// APIKeyStringToSign is the canonical form of a request signed by a partner:
+//
+//	METHOD\nPATH?QUERY\nTIMESTAMP\nNONCE\nhex(SHA-256(BODY))
+//
+// where TIMESTAMP is in Unix seconds.
+func APIKeyStringToSign(method, path string, timestamp int64, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	return fmt.Sprintf("%s\n%s\n%d\n%s\n%s", strings.ToUpper(method), path, timestamp, nonce, hex.EncodeToString(bodyHash[:]))
+}
+
+// SignAPIRequest returns the hex HMAC-SHA256 signature of a request in its canonical form,
+// keyed by the hex signing key of an API key
+func SignAPIRequest(signingKey, stringToSign string) (string, error) {
+	key, err := hex.DecodeString(signingKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid signing key: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,38 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAPIKey(t *testing.T) {
+	key := FormatAPIKey(APIKeyIDPrefix+"abc123", "s3cr3t.with.dots")
+
+	keyID, secret, ok := ParseAPIKey(key)
+	require.True(t, ok)
+	require.Equal(t, APIKeyIDPrefix+"abc123", keyID)
+	require.Equal(t, "s3cr3t.with.dots", secret)
+
+	for _, key := range []string{"", "lpk_abc123", "lpk_abc123.", "abc123.s3cr3t"} {
+		_, _, ok := ParseAPIKey(key)
+		require.False(t, ok, key)
+	}
+}
+
+func TestSignAPIRequest(t *testing.T) {
+	hashedSecret := HashAPIKeySecret("s3cr3t")
+	require.Equal(t, "4e738ca5563c06cfd0018299933d58db1dd8bf97f6973dc99bf6cdc64b5550bd", hashedSecret)
+
+	signingKey := APIKeySigningKey("0123456789abcdefghijklmnopqrstuv", hashedSecret)
+	require.Equal(t, "fb1d9274552860a8728778557ada7e2ea7a44d2aaec17dd77d21568e099f90b1", signingKey)
+	require.NotEqual(t, signingKey, APIKeySigningKey("vutsrqponmlkjihgfedcba9876543210", hashedSecret))
+
+	stringToSign := APIKeyStringToSign("post", "/api/v1/partner/borrowers?x=1", 1700000000, "abc", []byte(`{"a":1}`))
+	signature, err := SignAPIRequest(signingKey, stringToSign)
+	require.NoError(t, err)
+	require.Equal(t, "4acf44751fd7c4be1d80c439583668f10579898cd5cc45a0d4afec87cc2d7d4f", signature)
+
+	_, err = SignAPIRequest("not hex", stringToSign)
+	require.Error(t, err)
+}
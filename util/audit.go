@@ -0,0 +1,19 @@
+package util
+
+// Constants for all audited actions
+const (
+	AuditActionCreateAdmin     = "create_admin"
+	AuditActionListUsers       = "list_users"
+	AuditActionViewUser        = "view_user"
+	AuditActionChangeUserRole  = "change_user_role"
+	AuditActionSuspendUser     = "suspend_user"
+	AuditActionReactivateUser  = "reactivate_user"
+	AuditActionCloseAccount    = "close_account"
+	AuditActionAnonymizeUser   = "anonymize_user"
+	AuditActionViewKYCDocument = "view_kyc_document"
+	AuditActionApproveKYC      = "approve_kyc"
+	AuditActionRejectKYC       = "reject_kyc"
+	AuditActionIssueAPIKey     = "issue_api_key"
+	AuditActionRotateAPIKey    = "rotate_api_key"
+	AuditActionRevokeAPIKey    = "revoke_api_key"
+)
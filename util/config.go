@@ -1,29 +1,404 @@
 package util
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
 	"github.com/spf13/viper"
 )
 
-// Config stores all configuration of the application
-// The values are read by viper from a config file or environment variables
+// Profiles of the environments the application runs in
+const (
+	ProfileDev     = "dev"
+	ProfileTest    = "test"
+	ProfileStaging = "staging"
+	ProfileProd    = "prod"
+)
+
+// profileKey is the key selecting the profile, from the environment or the base config file
+const profileKey = "APP_ENV"
+
+// secretFileSuffix is the suffix of the environment variables naming a file which holds the value of a key,
+// such as TOKEN_SYMMETRIC_KEY_FILE for a secret mounted as a file
+const secretFileSuffix = "_FILE"
+
+// redactedValue replaces the values of the secrets in the dump of the config
+const redactedValue = "[REDACTED]"
+
+// Config stores all configuration of the application, in sections.
+// The values are read by viper from the defaults, the base config file app.env,
+// the config file of the profile such as app.prod.env, the environment variables
+// and the files named by the *_FILE environment variables, each layer overriding the previous ones.
 type Config struct {
-	DBSource          string `mapstructure:"DB_SOURCE"`
-	HTTPServerAddress string `mapstructure:"HTTP_SERVER_ADDRESS"`
+	Profile string `mapstructure:"APP_ENV" validate:"oneof=dev test staging prod"`
+
+	HTTPConfig          `mapstructure:",squash"`
+	DBConfig            `mapstructure:",squash"`
+	AuthConfig          `mapstructure:",squash"`
+	MailConfig          `mapstructure:",squash"`
+	JobsConfig          `mapstructure:",squash"`
+	StorageConfig       `mapstructure:",squash"`
+	ObservabilityConfig `mapstructure:",squash"`
+	RuntimeConfig       `mapstructure:",squash"`
+}
+
+// HTTPConfig configures the HTTP and gRPC servers and the protections of the HTTP API
+type HTTPConfig struct {
+	HTTPServerAddress  string        `mapstructure:"HTTP_SERVER_ADDRESS" validate:"required,hostname_port"`
+	GRPCServerAddress  string        `mapstructure:"GRPC_SERVER_ADDRESS" validate:"required,hostname_port"`
+	ShutdownTimeout    time.Duration `mapstructure:"SHUTDOWN_TIMEOUT" validate:"gt=0"`
+	ShutdownDrainDelay time.Duration `mapstructure:"SHUTDOWN_DRAIN_DELAY" validate:"gte=0"`
+
+	HTTPReadTimeout     time.Duration `mapstructure:"HTTP_READ_TIMEOUT" validate:"gte=0"`
+	HTTPWriteTimeout    time.Duration `mapstructure:"HTTP_WRITE_TIMEOUT" validate:"gte=0"`
+	HTTPIdleTimeout     time.Duration `mapstructure:"HTTP_IDLE_TIMEOUT" validate:"gte=0"`
+	HTTPBodyLimit       int           `mapstructure:"HTTP_BODY_LIMIT" validate:"gt=0"`
+	HTTPUploadBodyLimit int           `mapstructure:"HTTP_UPLOAD_BODY_LIMIT" validate:"gt=0"`
+	HTTPTrustedProxies  []string      `mapstructure:"HTTP_TRUSTED_PROXIES" validate:"dive,ip|cidr"`
+	HTTPProxyHeader     string        `mapstructure:"HTTP_PROXY_HEADER"`
+	TLSCertFile         string        `mapstructure:"TLS_CERT_FILE" validate:"required_with=TLSKeyFile"`
+	TLSKeyFile          string        `mapstructure:"TLS_KEY_FILE" validate:"required_with=TLSCertFile"`
+
+	CORSAllowedOrigins    []string      `mapstructure:"CORS_ALLOWED_ORIGINS" validate:"dive,url"`
+	CORSAllowCredentials  bool          `mapstructure:"CORS_ALLOW_CREDENTIALS"`
+	CORSMaxAge            time.Duration `mapstructure:"CORS_MAX_AGE" validate:"gte=0"`
+	HSTSMaxAge            time.Duration `mapstructure:"HSTS_MAX_AGE" validate:"gte=0"`
+	ContentSecurityPolicy string        `mapstructure:"CONTENT_SECURITY_POLICY"`
+
+	RateLimitBackend        string        `mapstructure:"RATE_LIMIT_BACKEND" validate:"oneof=memory postgres"`
+	IdempotencyKeyTTL       time.Duration `mapstructure:"IDEMPOTENCY_KEY_TTL" validate:"gt=0"`
+	IdempotencyStallTimeout time.Duration `mapstructure:"IDEMPOTENCY_STALL_TIMEOUT" validate:"gt=0"`
 }
 
-// LoadConfig reads configuration from a config file or environment variables
+// DBConfig configures the database, its optional read replica and the sizing of their connection pools
+type DBConfig struct {
+	DBSource            string        `mapstructure:"DB_SOURCE" validate:"required" redact:"url"`
+	DBReplicaSource     string        `mapstructure:"DB_REPLICA_SOURCE" redact:"url"`
+	DBMaxConns          int32         `mapstructure:"DB_MAX_CONNS" validate:"gt=0"`
+	DBMinConns          int32         `mapstructure:"DB_MIN_CONNS" validate:"gte=0,ltefield=DBMaxConns"`
+	DBMaxConnLifetime   time.Duration `mapstructure:"DB_MAX_CONN_LIFETIME" validate:"gt=0"`
+	DBMaxConnIdleTime   time.Duration `mapstructure:"DB_MAX_CONN_IDLE_TIME" validate:"gt=0"`
+	DBHealthCheckPeriod time.Duration `mapstructure:"DB_HEALTH_CHECK_PERIOD" validate:"gt=0"`
+	// DBStatementTimeout aborts the statements running for longer, none are aborted when it is zero
+	DBStatementTimeout time.Duration `mapstructure:"DB_STATEMENT_TIMEOUT" validate:"gte=0"`
+	// DBAutoMigrate applies the pending migrations on startup
+	DBAutoMigrate bool `mapstructure:"DB_AUTO_MIGRATE"`
+}
+
+// AuthConfig configures the authentication of users and partners and the password policy
+type AuthConfig struct {
+	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY" validate:"required,min=32" redact:"true"`
+	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION" validate:"gt=0"`
+	CursorSigningKey    string        `mapstructure:"CURSOR_SIGNING_KEY" validate:"required,min=32" redact:"true"`
+	APISignatureWindow  time.Duration `mapstructure:"API_SIGNATURE_WINDOW" validate:"gt=0"`
+	// APIKeySigningSecret derives the signing keys of the API keys, changing it invalidates all of them
+	APIKeySigningSecret string `mapstructure:"API_KEY_SIGNING_SECRET" validate:"required,min=32" redact:"true"`
+
+	PasswordMinLength        int      `mapstructure:"PASSWORD_MIN_LENGTH" validate:"min=1,max=128"`
+	PasswordCharacterClasses []string `mapstructure:"PASSWORD_CHARACTER_CLASSES" validate:"dive,oneof=lower upper letter digit symbol"`
+	PasswordDenyListFile     string   `mapstructure:"PASSWORD_DENY_LIST_FILE"`
+}
+
+// MailConfig configures the SMTP server outgoing mail is sent through, mail is not sent when no host is set
+type MailConfig struct {
+	MailSMTPHost     string `mapstructure:"MAIL_SMTP_HOST" validate:"omitempty,hostname"`
+	MailSMTPPort     int    `mapstructure:"MAIL_SMTP_PORT" validate:"min=1,max=65535"`
+	MailSMTPUsername string `mapstructure:"MAIL_SMTP_USERNAME"`
+	MailSMTPPassword string `mapstructure:"MAIL_SMTP_PASSWORD" redact:"true"`
+	MailSender       string `mapstructure:"MAIL_SENDER" validate:"required_with=MailSMTPHost,omitempty,email"`
+}
+
+// JobsConfig configures how often the background workers run
+type JobsConfig struct {
+	DataExportPollInterval      time.Duration `mapstructure:"DATA_EXPORT_POLL_INTERVAL" validate:"gt=0"`
+	DataExportPurgeInterval     time.Duration `mapstructure:"DATA_EXPORT_PURGE_INTERVAL" validate:"gt=0"`
+	APIKeyNoncePurgeInterval    time.Duration `mapstructure:"API_KEY_NONCE_PURGE_INTERVAL" validate:"gt=0"`
+	RateLimitPurgeInterval      time.Duration `mapstructure:"RATE_LIMIT_PURGE_INTERVAL" validate:"gt=0"`
+	IdempotencyKeyPurgeInterval time.Duration `mapstructure:"IDEMPOTENCY_KEY_PURGE_INTERVAL" validate:"gt=0"`
+}
+
+// StorageConfig configures where files are stored
+type StorageConfig struct {
+	DataExportDir string        `mapstructure:"DATA_EXPORT_DIR" validate:"required"`
+	DataExportTTL time.Duration `mapstructure:"DATA_EXPORT_TTL" validate:"gt=0"`
+	BlobStoreDir  string        `mapstructure:"BLOB_STORE_DIR" validate:"required"`
+}
+
+// ObservabilityConfig configures the logs and traces
+type ObservabilityConfig struct {
+	TracingExporter     string `mapstructure:"TRACING_EXPORTER" validate:"oneof=none stdout otlp"`
+	TracingOTLPEndpoint string `mapstructure:"TRACING_OTLP_ENDPOINT" validate:"required_if=TracingExporter otlp"`
+	LogFormat           string `mapstructure:"LOG_FORMAT" validate:"oneof=json text"`
+}
+
+// RuntimeConfig holds the settings which take effect without a restart, see ConfigReloader
+type RuntimeConfig struct {
+	LogLevel          string `mapstructure:"LOG_LEVEL" validate:"oneof=debug info warn error"`
+	RateLimitPolicies string `mapstructure:"RATE_LIMIT_POLICIES"`
+	// FeatureFlags are the names of the features turned on, such as new_dashboard
+	FeatureFlags []string `mapstructure:"FEATURE_FLAGS" validate:"dive,required,max=64,printascii,excludesall= "`
+	// MaintenanceMode makes the API reject requests with 503 Service Unavailable, except for the probes
+	MaintenanceMode bool `mapstructure:"MAINTENANCE_MODE"`
+}
+
+// FeatureEnabled tells whether the feature flag is turned on
+func (config RuntimeConfig) FeatureEnabled(flag string) bool {
+	return slices.Contains(config.FeatureFlags, flag)
+}
+
+// configDefaults are the values of the keys set by no layer.
+// Secrets and the DB source have no default, they must always be configured.
+var configDefaults = map[string]any{
+	profileKey: ProfileDev,
+
+	"HTTP_SERVER_ADDRESS":       "0.0.0.0:8000",
+	"GRPC_SERVER_ADDRESS":       "0.0.0.0:9000",
+	"SHUTDOWN_TIMEOUT":          "15s",
+	"SHUTDOWN_DRAIN_DELAY":      "5s",
+	"HTTP_READ_TIMEOUT":         "15s",
+	"HTTP_WRITE_TIMEOUT":        "30s",
+	"HTTP_IDLE_TIMEOUT":         "2m",
+	"HTTP_BODY_LIMIT":           1 << 20,
+	"HTTP_UPLOAD_BODY_LIMIT":    11 << 20,
+	"HTTP_PROXY_HEADER":         "X-Forwarded-For",
+	"CORS_MAX_AGE":              "10m",
+	"HSTS_MAX_AGE":              "8760h",
+	"CONTENT_SECURITY_POLICY":   "default-src 'none'; frame-ancestors 'none'",
+	"RATE_LIMIT_BACKEND":        "memory",
+	"IDEMPOTENCY_KEY_TTL":       "24h",
+	"IDEMPOTENCY_STALL_TIMEOUT": "1m",
+
+	"DB_MAX_CONNS":           10,
+	"DB_MIN_CONNS":           0,
+	"DB_MAX_CONN_LIFETIME":   "1h",
+	"DB_MAX_CONN_IDLE_TIME":  "30m",
+	"DB_HEALTH_CHECK_PERIOD": "1m",
+	"DB_STATEMENT_TIMEOUT":   "30s",
+
+	"ACCESS_TOKEN_DURATION":      "15m",
+	"API_SIGNATURE_WINDOW":       "5m",
+	"PASSWORD_MIN_LENGTH":        8,
+	"PASSWORD_CHARACTER_CLASSES": "letter,digit",
+
+	"MAIL_SMTP_PORT": 587,
+
+	"DATA_EXPORT_POLL_INTERVAL":      "30s",
+	"DATA_EXPORT_PURGE_INTERVAL":     "1h",
+	"API_KEY_NONCE_PURGE_INTERVAL":   "10m",
+	"RATE_LIMIT_PURGE_INTERVAL":      "10m",
+	"IDEMPOTENCY_KEY_PURGE_INTERVAL": "1h",
+
+	"DATA_EXPORT_DIR": "./var/data_exports",
+	"DATA_EXPORT_TTL": "168h",
+	"BLOB_STORE_DIR":  "./var/blobs",
+
+	"TRACING_EXPORTER": "none",
+	"LOG_FORMAT":       "json",
+
+	"LOG_LEVEL": "info",
+}
+
+// LoadConfig reads configuration from the config files in path, the environment variables and the secret files,
+// and validates it. The base config file app.env and the one of the profile, such as app.prod.env, are optional.
 func LoadConfig(path string) (config Config, err error) {
-	viper.AddConfigPath(path)
-	viper.SetConfigName("app")
-	viper.SetConfigType("env")
+	v := viper.New()
+	v.SetConfigType("env")
+
+	keys := configKeys(reflect.TypeOf(config))
+	for _, key := range keys {
+		v.BindEnv(key)
+	}
+	for key, value := range configDefaults {
+		v.SetDefault(key, value)
+	}
+
+	err = mergeConfigFile(v, filepath.Join(path, "app.env"))
+	if err != nil {
+		return
+	}
+
+	// the profile is known once the environment and the base config file have been read
+	profile := v.GetString(profileKey)
+	err = mergeConfigFile(v, filepath.Join(path, fmt.Sprintf("app.%s.env", profile)))
+	if err != nil {
+		return
+	}
 
-	viper.AutomaticEnv()
+	for _, key := range keys {
+		err = readSecretFile(v, key)
+		if err != nil {
+			return
+		}
+	}
 
-	err = viper.ReadInConfig()
+	err = v.Unmarshal(&config)
 	if err != nil {
 		return
 	}
 
-	err = viper.Unmarshal(&config)
+	err = config.Validate()
 	return
 }
+
+// mergeConfigFile merges the config file over the values read so far, if it exists
+func mergeConfigFile(v *viper.Viper, file string) error {
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot open config file: %w", err)
+	}
+	defer f.Close()
+
+	err = v.MergeConfig(f)
+	if err != nil {
+		return fmt.Errorf("cannot read config file %s: %w", file, err)
+	}
+	return nil
+}
+
+// readSecretFile sets the key to the content of the file named by its *_FILE environment variable, if any
+func readSecretFile(v *viper.Viper, key string) error {
+	file, ok := os.LookupEnv(key + secretFileSuffix)
+	if !ok {
+		return nil
+	}
+	if _, ok := os.LookupEnv(key); ok {
+		return fmt.Errorf("both %s and %s%s are set", key, key, secretFileSuffix)
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("cannot read %s%s: %w", key, secretFileSuffix, err)
+	}
+
+	// files written by editors and secret managers usually end with a newline
+	v.Set(key, strings.TrimRight(string(content), "\r\n"))
+	return nil
+}
+
+// configKeys lists the keys of the fields of the config, including the ones of its sections
+func configKeys(t reflect.Type) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			keys = append(keys, configKeys(field.Type)...)
+			continue
+		}
+		keys = append(keys, field.Tag.Get("mapstructure"))
+	}
+	return keys
+}
+
+// Validate checks the config, reporting every invalid key at once
+func (config Config) Validate() error {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return field.Tag.Get("mapstructure")
+	})
+
+	err := validate.Struct(config)
+
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return err
+	}
+
+	problems := make([]string, 0, len(fieldErrors))
+	for _, fieldError := range fieldErrors {
+		problems = append(problems, configProblem(fieldError))
+	}
+	return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+}
+
+// configProblem explains why the value of a key is invalid
+func configProblem(fieldError validator.FieldError) string {
+	key := fieldError.Field()
+	param := fieldError.Param()
+
+	switch fieldError.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", key)
+	case "required_with":
+		return fmt.Sprintf("%s is required when %s is set", key, configFieldKey(param))
+	case "required_if":
+		field, value, _ := strings.Cut(param, " ")
+		return fmt.Sprintf("%s is required when %s is %s", key, configFieldKey(field), value)
+	case "min", "gte":
+		return fmt.Sprintf("%s must be at least %s", key, param)
+	case "max", "lte":
+		return fmt.Sprintf("%s must be at most %s", key, param)
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", key, param)
+	case "ltefield":
+		return fmt.Sprintf("%s must be at most the value of %s", key, configFieldKey(param))
+	case "oneof":
+		return fmt.Sprintf("%s must be one of [%s], not %q", key, param, fieldError.Value())
+	case "excludesall":
+		return fmt.Sprintf("%s must not contain any of %q, not %q", key, param, fieldError.Value())
+	default:
+		return fmt.Sprintf("%s must be a valid %s, not %q", key, fieldError.Tag(), fieldError.Value())
+	}
+}
+
+// configFieldKey returns the key of the field of the config named by a validation rule
+func configFieldKey(name string) string {
+	field, ok := reflect.TypeOf(Config{}).FieldByName(name)
+	if !ok {
+		return name
+	}
+	return field.Tag.Get("mapstructure")
+}
+
+// LogValue dumps the effective config with its secrets redacted, so that it can be logged at startup
+func (config Config) LogValue() slog.Value {
+	return slog.GroupValue(configAttrs(reflect.ValueOf(config))...)
+}
+
+func configAttrs(v reflect.Value) []slog.Attr {
+	var attrs []slog.Attr
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Anonymous {
+			attrs = append(attrs, configAttrs(v.Field(i))...)
+			continue
+		}
+
+		key := field.Tag.Get("mapstructure")
+		value := v.Field(i).Interface()
+		switch field.Tag.Get("redact") {
+		case "true":
+			if !v.Field(i).IsZero() {
+				value = redactedValue
+			}
+		case "url":
+			value = redactURL(v.Field(i).String())
+		}
+
+		attrs = append(attrs, slog.Any(key, value))
+	}
+	return attrs
+}
+
+// redactURL hides the password of a URL such as a DB source, and the whole value when it is not a URL
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" {
+		if s == "" {
+			return s
+		}
+		return redactedValue
+	}
+	return u.Redacted()
+}
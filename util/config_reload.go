@@ -0,0 +1,170 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ConfigReloader holds a snapshot of the config, whose runtime settings are reloaded when the config files change.
+// A reloaded config which is invalid, or rejected by a check, is logged and the last good config is kept.
+// The other settings only take effect on restart, their changes are logged and ignored.
+type ConfigReloader struct {
+	path   string
+	config atomic.Pointer[Config]
+
+	// mu serializes the reloads, so that subscribers are notified in order
+	mu          sync.Mutex
+	checks      []func(RuntimeConfig) error
+	subscribers []func(RuntimeConfig)
+}
+
+// NewConfigReloader creates a new ConfigReloader holding the config loaded from the config files in path
+func NewConfigReloader(path string, config Config) *ConfigReloader {
+	reloader := &ConfigReloader{path: path}
+	reloader.config.Store(&config)
+	return reloader
+}
+
+// Config returns the current snapshot of the config
+func (reloader *ConfigReloader) Config() Config {
+	return *reloader.config.Load()
+}
+
+// AddCheck adds a check of the runtime settings beyond what Config.Validate checks,
+// such as parsing the rate limit policies, a reload failing a check is rejected
+func (reloader *ConfigReloader) AddCheck(check func(RuntimeConfig) error) {
+	reloader.mu.Lock()
+	defer reloader.mu.Unlock()
+
+	reloader.checks = append(reloader.checks, check)
+}
+
+// Subscribe adds a function notified of the runtime settings every time they change
+func (reloader *ConfigReloader) Subscribe(subscriber func(RuntimeConfig)) {
+	reloader.mu.Lock()
+	defer reloader.mu.Unlock()
+
+	reloader.subscribers = append(reloader.subscribers, subscriber)
+}
+
+// Reload loads the config again and applies its runtime settings,
+// it fails and leaves the config unchanged when the loaded one is invalid
+func (reloader *ConfigReloader) Reload() error {
+	reloader.mu.Lock()
+	defer reloader.mu.Unlock()
+
+	loaded, err := LoadConfig(reloader.path)
+	if err != nil {
+		return err
+	}
+
+	for _, check := range reloader.checks {
+		err = check(loaded.RuntimeConfig)
+		if err != nil {
+			return fmt.Errorf("invalid runtime config: %w", err)
+		}
+	}
+
+	current := reloader.Config()
+
+	restartKeys := changedConfigKeys(current.withoutRuntime(), loaded.withoutRuntime())
+	if len(restartKeys) > 0 {
+		slog.Warn("config changes ignored until restart", "keys", restartKeys)
+	}
+
+	runtimeKeys := changedConfigKeys(reflect.ValueOf(current.RuntimeConfig), reflect.ValueOf(loaded.RuntimeConfig))
+	if len(runtimeKeys) == 0 {
+		return nil
+	}
+
+	next := current
+	next.RuntimeConfig = loaded.RuntimeConfig
+	reloader.config.Store(&next)
+	slog.Info("config reloaded", "keys", runtimeKeys)
+
+	for _, subscriber := range reloader.subscribers {
+		subscriber(next.RuntimeConfig)
+	}
+	return nil
+}
+
+// Start watches the config files, reloading the config whenever one of them is written, until the context is cancelled
+func (reloader *ConfigReloader) Start(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("cannot watch config files: %w", err)
+	}
+	defer watcher.Close()
+
+	// the directory is watched rather than the files, so that a profile file created later
+	// and the files replaced by editors on save are picked up
+	err = watcher.Add(reloader.path)
+	if err != nil {
+		return fmt.Errorf("cannot watch config files: %w", err)
+	}
+
+	profile := reloader.Config().Profile
+	names := []string{"app.env", fmt.Sprintf("app.%s.env", profile)}
+
+	// bursts of writes, as editors make when saving, are coalesced into one reload
+	changed := make(chan struct{}, 1)
+	go func() {
+		for event := range watcher.Events {
+			if !slices.Contains(names, filepath.Base(event.Name)) || !(event.Has(fsnotify.Write) || event.Has(fsnotify.Create)) {
+				continue
+			}
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	go func() {
+		for err := range watcher.Errors {
+			slog.Error("cannot watch config files", "err", err)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+			err := reloader.Reload()
+			if err != nil {
+				slog.Error("config reload rejected, keeping the last good config", "err", err)
+			}
+		}
+	}
+}
+
+// withoutRuntime returns the config without its runtime settings, which are compared separately
+func (config Config) withoutRuntime() reflect.Value {
+	config.RuntimeConfig = RuntimeConfig{}
+	return reflect.ValueOf(config)
+}
+
+// changedConfigKeys lists the keys whose values differ between two configs or sections of the same type
+func changedConfigKeys(a, b reflect.Value) []string {
+	var keys []string
+	for i := 0; i < a.NumField(); i++ {
+		field := a.Type().Field(i)
+		if field.Anonymous {
+			keys = append(keys, changedConfigKeys(a.Field(i), b.Field(i))...)
+			continue
+		}
+
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			keys = append(keys, field.Tag.Get("mapstructure"))
+		}
+	}
+	return keys
+}
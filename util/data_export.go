@@ -0,0 +1,10 @@
+package util
+
+// Constants for all statuses of a personal data export
+const (
+	DataExportPending    = "pending"
+	DataExportProcessing = "processing"
+	DataExportReady      = "ready"
+	DataExportFailed     = "failed"
+	DataExportExpired    = "expired"
+)
@@ -0,0 +1,7 @@
+package util
+
+// Constants for all statuses of a request sent with an idempotency key
+const (
+	IdempotencyKeyProcessing = "processing"
+	IdempotencyKeyCompleted  = "completed"
+)
@@ -0,0 +1,22 @@
+package util
+
+// Constants for all KYC statuses of a user
+const (
+	KYCUnverified = "unverified"
+	KYCPending    = "pending"
+	KYCVerified   = "verified"
+	KYCRejected   = "rejected"
+)
+
+// Constants for all statuses of a KYC submission
+const (
+	KYCSubmissionPending  = "pending"
+	KYCSubmissionApproved = "approved"
+	KYCSubmissionRejected = "rejected"
+)
+
+// Constants for the documents of a KYC submission
+const (
+	KYCDocumentID     = "id_document"
+	KYCDocumentSelfie = "selfie"
+)
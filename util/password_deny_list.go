@@ -0,0 +1,87 @@
+package util
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// passwordHashPrefixLength is the number of leading hex characters of a SHA-1 hash used as lookup range
+const passwordHashPrefixLength = 5
+
+// PasswordDenyList holds the SHA-1 hashes of common and breached passwords.
+// Like the range API of Have I Been Pwned, it is only ever handed the first 5 hex characters
+// of the hash of a password and answers the suffixes of all the hashes in that range,
+// so that a remote implementation never learns the password nor its full hash.
+type PasswordDenyList interface {
+	Range(ctx context.Context, prefix string) ([]string, error)
+}
+
+// IsPasswordDenied looks the password up in the deny list by the prefix of its SHA-1 hash
+func IsPasswordDenied(ctx context.Context, denyList PasswordDenyList, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:passwordHashPrefixLength], hash[passwordHashPrefixLength:]
+
+	suffixes, err := denyList.Range(ctx, prefix)
+	if err != nil {
+		return false, err
+	}
+
+	for _, s := range suffixes {
+		if s == suffix {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// filePasswordDenyList is a PasswordDenyList loaded from a local file
+type filePasswordDenyList struct {
+	ranges map[string][]string
+}
+
+// NewFilePasswordDenyList loads a deny list file holding one uppercase SHA-1 hex hash per line,
+// optionally followed by ":<count>" as in the Have I Been Pwned downloads.
+// Empty lines and lines starting with # are skipped.
+func NewFilePasswordDenyList(path string) (PasswordDenyList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open password deny list: %w", err)
+	}
+	defer file.Close()
+
+	denyList := &filePasswordDenyList{
+		ranges: make(map[string][]string),
+	}
+
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		hash, _, _ := strings.Cut(line, ":")
+		hash = strings.ToUpper(hash)
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != 2*sha1.Size {
+			return nil, fmt.Errorf("invalid SHA-1 hash on line %d of password deny list", lineNo)
+		}
+
+		prefix := hash[:passwordHashPrefixLength]
+		denyList.ranges[prefix] = append(denyList.ranges[prefix], hash[passwordHashPrefixLength:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read password deny list: %w", err)
+	}
+
+	return denyList, nil
+}
+
+func (denyList *filePasswordDenyList) Range(_ context.Context, prefix string) ([]string, error) {
+	return denyList.ranges[strings.ToUpper(prefix)], nil
+}
@@ -0,0 +1,123 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Character classes a password policy can require
+const (
+	PasswordClassLower  = "lower"
+	PasswordClassUpper  = "upper"
+	PasswordClassLetter = "letter"
+	PasswordClassDigit  = "digit"
+	PasswordClassSymbol = "symbol"
+)
+
+var passwordClasses = map[string]func(r rune) bool{
+	PasswordClassLower:  unicode.IsLower,
+	PasswordClassUpper:  unicode.IsUpper,
+	PasswordClassLetter: unicode.IsLetter,
+	PasswordClassDigit:  unicode.IsDigit,
+	PasswordClassSymbol: func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSymbol(r)
+	},
+}
+
+// MaxPasswordLength bounds the work of hashing a password, whatever the policy
+const MaxPasswordLength = 128
+
+// Rules of a password policy a password can break
+const (
+	PasswordRuleTooShort      = "too_short"
+	PasswordRuleTooLong       = "too_long"
+	PasswordRuleMissingLower  = "missing_lower"
+	PasswordRuleMissingUpper  = "missing_upper"
+	PasswordRuleMissingLetter = "missing_letter"
+	PasswordRuleMissingDigit  = "missing_digit"
+	PasswordRuleMissingSymbol = "missing_symbol"
+	PasswordRuleDenied        = "denied"
+)
+
+// PasswordPolicyError tells which rule of the password policy a password breaks
+type PasswordPolicyError struct {
+	Rule      string
+	MinLength int
+	MaxLength int
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return fmt.Sprintf("password breaks the %s rule of the password policy", e.Rule)
+}
+
+// PasswordPolicy is the set of rules passwords chosen by users must follow
+type PasswordPolicy struct {
+	MinLength        int
+	CharacterClasses []string
+	DenyList         PasswordDenyList // optional, common and breached passwords
+}
+
+// NewPasswordPolicy creates the password policy set up by the config, loading its deny list if any
+func NewPasswordPolicy(config Config) (*PasswordPolicy, error) {
+	for _, class := range config.PasswordCharacterClasses {
+		if _, ok := passwordClasses[class]; !ok {
+			return nil, fmt.Errorf("unknown password character class %q", class)
+		}
+	}
+
+	policy := &PasswordPolicy{
+		MinLength:        config.PasswordMinLength,
+		CharacterClasses: config.PasswordCharacterClasses,
+	}
+
+	if config.PasswordDenyListFile != "" {
+		denyList, err := NewFilePasswordDenyList(config.PasswordDenyListFile)
+		if err != nil {
+			return nil, err
+		}
+		policy.DenyList = denyList
+	}
+
+	return policy, nil
+}
+
+// Check returns a *PasswordPolicyError when the password breaks a rule of the policy.
+// The deny list is only looked up once the cheaper rules pass.
+func (policy *PasswordPolicy) Check(ctx context.Context, password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < policy.MinLength {
+		return &PasswordPolicyError{Rule: PasswordRuleTooShort, MinLength: policy.MinLength}
+	}
+	if length > MaxPasswordLength {
+		return &PasswordPolicyError{Rule: PasswordRuleTooLong, MaxLength: MaxPasswordLength}
+	}
+
+	for _, class := range policy.CharacterClasses {
+		if !containsClass(password, passwordClasses[class]) {
+			return &PasswordPolicyError{Rule: "missing_" + class}
+		}
+	}
+
+	if policy.DenyList != nil {
+		denied, err := IsPasswordDenied(ctx, policy.DenyList, password)
+		if err != nil {
+			return fmt.Errorf("failed to look up password deny list: %w", err)
+		}
+		if denied {
+			return &PasswordPolicyError{Rule: PasswordRuleDenied}
+		}
+	}
+
+	return nil
+}
+
+func containsClass(password string, inClass func(r rune) bool) bool {
+	for _, r := range password {
+		if inClass(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package util
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeDenyList(t *testing.T, passwords ...string) string {
+	lines := []string{"# test deny list", ""}
+	for i, password := range passwords {
+		sum := sha1.Sum([]byte(password))
+		lines = append(lines, fmt.Sprintf("%s:%d", strings.ToUpper(hex.EncodeToString(sum[:])), i+1))
+	}
+
+	path := filepath.Join(t.TempDir(), "deny_list.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o600))
+	return path
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	policy, err := NewPasswordPolicy(Config{
+		AuthConfig: AuthConfig{
+			PasswordMinLength:        10,
+			PasswordCharacterClasses: []string{PasswordClassLower, PasswordClassUpper, PasswordClassDigit, PasswordClassSymbol},
+			PasswordDenyListFile:     writeDenyList(t, "Password123!", "Welcome2024!"),
+		},
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		password string
+		wantRule string
+	}{
+		{name: "OK", password: "Lend1ng-Platform"},
+		{name: "TooShort", password: "Ab1!", wantRule: PasswordRuleTooShort},
+		{name: "TooLong", password: "Ab1!" + strings.Repeat("a", MaxPasswordLength), wantRule: PasswordRuleTooLong},
+		{name: "MissingLower", password: "LENDING-957", wantRule: PasswordRuleMissingLower},
+		{name: "MissingUpper", password: "lending-957", wantRule: PasswordRuleMissingUpper},
+		{name: "MissingDigit", password: "Lending-Platform", wantRule: PasswordRuleMissingDigit},
+		{name: "MissingSymbol", password: "Lending957Platform", wantRule: PasswordRuleMissingSymbol},
+		{name: "Denied", password: "Welcome2024!", wantRule: PasswordRuleDenied},
+		{name: "MultibyteLength", password: "密碼Aa1!密碼密碼密碼", wantRule: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := policy.Check(context.Background(), tc.password)
+			if tc.wantRule == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			var policyErr *PasswordPolicyError
+			require.ErrorAs(t, err, &policyErr)
+			require.Equal(t, tc.wantRule, policyErr.Rule)
+		})
+	}
+}
+
+func TestNewPasswordPolicy(t *testing.T) {
+	_, err := NewPasswordPolicy(Config{AuthConfig: AuthConfig{PasswordCharacterClasses: []string{"emoji"}}})
+	require.Error(t, err)
+
+	_, err = NewPasswordPolicy(Config{AuthConfig: AuthConfig{PasswordDenyListFile: filepath.Join(t.TempDir(), "missing.txt")}})
+	require.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "deny_list.txt")
+	require.NoError(t, os.WriteFile(path, []byte("not-a-hash\n"), 0o600))
+	_, err = NewPasswordPolicy(Config{AuthConfig: AuthConfig{PasswordDenyListFile: path}})
+	require.Error(t, err)
+
+	policy, err := NewPasswordPolicy(Config{AuthConfig: AuthConfig{PasswordMinLength: 8}})
+	require.NoError(t, err)
+	require.Nil(t, policy.DenyList)
+	require.NoError(t, policy.Check(context.Background(), "password123"))
+}
+
+// rangeRecorder records the ranges looked up in the deny list
+type rangeRecorder struct {
+	PasswordDenyList
+	prefixes []string
+}
+
+func (r *rangeRecorder) Range(ctx context.Context, prefix string) ([]string, error) {
+	r.prefixes = append(r.prefixes, prefix)
+	return r.PasswordDenyList.Range(ctx, prefix)
+}
+
+func TestPasswordDenyListRange(t *testing.T) {
+	denyList, err := NewFilePasswordDenyList(writeDenyList(t, "password123"))
+	require.NoError(t, err)
+
+	recorder := &rangeRecorder{PasswordDenyList: denyList}
+
+	denied, err := IsPasswordDenied(context.Background(), recorder, "password123")
+	require.NoError(t, err)
+	require.True(t, denied)
+
+	denied, err = IsPasswordDenied(context.Background(), recorder, RandomPassword())
+	require.NoError(t, err)
+	require.False(t, denied)
+
+	// only the prefix of the hash is ever handed to the deny list
+	for _, prefix := range recorder.prefixes {
+		require.Len(t, prefix, passwordHashPrefixLength)
+	}
+}
+
+func TestDefaultPasswordDenyList(t *testing.T) {
+	denyList, err := NewFilePasswordDenyList("../data/password_deny_list.txt")
+	require.NoError(t, err)
+
+	denied, err := IsPasswordDenied(context.Background(), denyList, "qwerty123")
+	require.NoError(t, err)
+	require.True(t, denied)
+}
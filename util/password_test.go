@@ -1,6 +1,8 @@
 package util
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -13,16 +15,61 @@ func TestPassword(t *testing.T) {
 	hashedPassword1, err := HashPassword(password)
 	require.NoError(t, err)
 	require.NotEmpty(t, hashedPassword1)
+	require.True(t, strings.HasPrefix(hashedPassword1, "$argon2id$v=19$m=19456,t=2,p=1$"))
+	require.False(t, NeedsRehash(hashedPassword1))
 
 	err = CheckPassword(hashedPassword1, password)
 	require.NoError(t, err)
 
 	wrongPassword := RandomString(6)
 	err = CheckPassword(hashedPassword1, wrongPassword)
-	require.EqualError(t, err, bcrypt.ErrMismatchedHashAndPassword.Error())
+	require.ErrorIs(t, err, ErrMismatchedPassword)
 
 	hashedPassword2, err := HashPassword(password)
 	require.NoError(t, err)
 	require.NotEmpty(t, hashedPassword2)
 	require.NotEqual(t, hashedPassword1, hashedPassword2)
 }
+
+func TestLegacyBcryptPassword(t *testing.T) {
+	password := RandomString(6)
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	require.NoError(t, err)
+	require.True(t, NeedsRehash(string(hashedPassword)))
+
+	err = CheckPassword(string(hashedPassword), password)
+	require.NoError(t, err)
+
+	err = CheckPassword(string(hashedPassword), RandomString(6))
+	require.ErrorIs(t, err, ErrMismatchedPassword)
+}
+
+func TestNeedsRehash(t *testing.T) {
+	password := RandomString(6)
+
+	hashedPassword, err := HashPassword(password)
+	require.NoError(t, err)
+
+	// a hash with a lower memory cost than the current parameters
+	outdatedHash := strings.Replace(hashedPassword, fmt.Sprintf("m=%d,", DefaultArgon2Params.Memory), "m=4096,", 1)
+	require.True(t, NeedsRehash(outdatedHash))
+
+	err = CheckPassword(outdatedHash, password)
+	require.ErrorIs(t, err, ErrMismatchedPassword)
+}
+
+func TestUnsupportedHash(t *testing.T) {
+	for _, hashedPassword := range []string{
+		"",
+		"plain",
+		"$argon2i$v=19$m=19456,t=2,p=1$c2FsdA$a2V5",
+		"$argon2id$v=18$m=19456,t=2,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=19456,t=2$c2FsdA$a2V5",
+		"$argon2id$v=19$m=19456,t=2,p=1$!!!$a2V5",
+	} {
+		err := CheckPassword(hashedPassword, RandomString(6))
+		require.ErrorIs(t, err, ErrUnsupportedHash, hashedPassword)
+		require.True(t, NeedsRehash(hashedPassword))
+	}
+}
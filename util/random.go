@@ -37,3 +37,8 @@ func RandomRole() string {
 	n := len(roles)
 	return roles[rand.Intn(n)]
 }
+
+// RandomPassword generates a random password mixing letters and digits
+func RandomPassword() string {
+	return fmt.Sprintf("%s%d", RandomString(6), rand.Intn(900)+100)
+}
@@ -4,4 +4,6 @@ package util
 const (
 	BorrowerRole = "borrower"
 	LenderRole   = "lender"
+	AdminRole    = "admin"
+	PartnerRole  = "partner"
 )
@@ -0,0 +1,8 @@
+package util
+
+// Constants for all supported user statuses
+const (
+	ActiveStatus    = "active"
+	SuspendedStatus = "suspended"
+	ClosedStatus    = "closed"
+)
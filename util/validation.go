@@ -0,0 +1,10 @@
+package util
+
+import (
+	"regexp"
+)
+
+var (
+	LineIDRegexp  = regexp.MustCompile(`^[a-z0-9._-]{4,20}$`)
+	TWPhoneRegexp = regexp.MustCompile(`^(09|\+8869)\d{8}$`)
+)
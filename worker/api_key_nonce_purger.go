@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/service"
+)
+
+// APIKeyNoncePurger deletes the nonces of signed partner requests once they are too old to be replayed
+type APIKeyNoncePurger struct {
+	apiKeyService service.APIKeyService
+	interval      time.Duration
+}
+
+// NewAPIKeyNoncePurger creates a new APIKeyNoncePurger purging expired nonces at the given interval
+func NewAPIKeyNoncePurger(apiKeyService service.APIKeyService, interval time.Duration) *APIKeyNoncePurger {
+	return &APIKeyNoncePurger{
+		apiKeyService: apiKeyService,
+		interval:      interval,
+	}
+}
+
+// Start purges expired nonces until the context is cancelled
+func (purger *APIKeyNoncePurger) Start(ctx context.Context) error {
+	ticker := time.NewTicker(purger.interval)
+	defer ticker.Stop()
+
+	for {
+		_, err := purger.apiKeyService.PurgeExpiredNonces(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "can not purge API key nonces", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
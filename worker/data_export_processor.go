@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/service"
+)
+
+// DataExportProcessor builds the bundles of the requested personal data exports in the background
+type DataExportProcessor struct {
+	privacyService service.PrivacyService
+	interval       time.Duration
+}
+
+// NewDataExportProcessor creates a new DataExportProcessor polling for pending exports at the given interval
+func NewDataExportProcessor(privacyService service.PrivacyService, interval time.Duration) *DataExportProcessor {
+	return &DataExportProcessor{
+		privacyService: privacyService,
+		interval:       interval,
+	}
+}
+
+// Start processes pending data exports until the context is cancelled
+func (processor *DataExportProcessor) Start(ctx context.Context) error {
+	ticker := time.NewTicker(processor.interval)
+	defer ticker.Stop()
+
+	for {
+		processor.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain processes data exports until none is pending
+func (processor *DataExportProcessor) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		processed, err := processor.privacyService.ProcessDataExport(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "can not process data export", "err", err)
+		}
+		if !processed {
+			return
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mocksvc "github.com/DamianZhang/957-lending-platform/service/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataExportProcessor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	svc := mocksvc.NewMockPrivacyService(ctrl)
+	gomock.InOrder(
+		svc.EXPECT().ProcessDataExport(gomock.Any()).Return(true, nil),
+		svc.EXPECT().ProcessDataExport(gomock.Any()).Return(true, errors.New("failed to build")),
+		svc.EXPECT().ProcessDataExport(gomock.Any()).DoAndReturn(func(context.Context) (bool, error) {
+			cancel()
+			return false, nil
+		}),
+	)
+
+	processor := NewDataExportProcessor(svc, time.Hour)
+
+	done := make(chan error)
+	go func() {
+		done <- processor.Start(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("processor did not stop after the context was cancelled")
+	}
+}
@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/service"
+)
+
+// DataExportPurger deletes the bundles of the data exports which can no longer be downloaded
+type DataExportPurger struct {
+	privacyService service.PrivacyService
+	interval       time.Duration
+}
+
+// NewDataExportPurger creates a new DataExportPurger purging expired data exports at the given interval
+func NewDataExportPurger(privacyService service.PrivacyService, interval time.Duration) *DataExportPurger {
+	return &DataExportPurger{
+		privacyService: privacyService,
+		interval:       interval,
+	}
+}
+
+// Start purges expired data exports until the context is cancelled
+func (purger *DataExportPurger) Start(ctx context.Context) error {
+	ticker := time.NewTicker(purger.interval)
+	defer ticker.Stop()
+
+	for {
+		_, err := purger.privacyService.PurgeExpiredDataExports(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "can not purge data exports", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/service"
+)
+
+// IdempotencyKeyPurger deletes the idempotency keys whose responses are no longer replayed
+type IdempotencyKeyPurger struct {
+	idempotencyService service.IdempotencyService
+	interval           time.Duration
+}
+
+// NewIdempotencyKeyPurger creates a new IdempotencyKeyPurger purging expired keys at the given interval
+func NewIdempotencyKeyPurger(idempotencyService service.IdempotencyService, interval time.Duration) *IdempotencyKeyPurger {
+	return &IdempotencyKeyPurger{
+		idempotencyService: idempotencyService,
+		interval:           interval,
+	}
+}
+
+// Start purges expired keys until the context is cancelled
+func (purger *IdempotencyKeyPurger) Start(ctx context.Context) error {
+	ticker := time.NewTicker(purger.interval)
+	defer ticker.Stop()
+
+	for {
+		_, err := purger.idempotencyService.PurgeExpiredKeys(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "can not purge idempotency keys", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
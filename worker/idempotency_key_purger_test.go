@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mocksvc "github.com/DamianZhang/957-lending-platform/service/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyKeyPurger(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	svc := mocksvc.NewMockIdempotencyService(ctrl)
+	gomock.InOrder(
+		svc.EXPECT().PurgeExpiredKeys(gomock.Any()).Return(int64(0), errors.New("failed to purge")),
+		svc.EXPECT().PurgeExpiredKeys(gomock.Any()).DoAndReturn(func(context.Context) (int64, error) {
+			cancel()
+			return 3, nil
+		}),
+	)
+
+	purger := NewIdempotencyKeyPurger(svc, time.Millisecond)
+
+	done := make(chan error)
+	go func() {
+		done <- purger.Start(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("purger did not stop after the context was cancelled")
+	}
+}
@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/DamianZhang/957-lending-platform/ratelimit"
+)
+
+// RateLimitPurger deletes the rate limit counters of windows which are too old to be used again
+type RateLimitPurger struct {
+	store    ratelimit.Store
+	interval time.Duration
+}
+
+// NewRateLimitPurger creates a new RateLimitPurger purging expired counters at the given interval
+func NewRateLimitPurger(store ratelimit.Store, interval time.Duration) *RateLimitPurger {
+	return &RateLimitPurger{
+		store:    store,
+		interval: interval,
+	}
+}
+
+// Start purges expired counters until the context is cancelled
+func (purger *RateLimitPurger) Start(ctx context.Context) error {
+	ticker := time.NewTicker(purger.interval)
+	defer ticker.Stop()
+
+	for {
+		_, err := purger.store.Purge(ctx, time.Now())
+		if err != nil {
+			slog.ErrorContext(ctx, "can not purge rate limit counters", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type stubRateLimitStore struct {
+	purges int
+	cancel context.CancelFunc
+}
+
+func (store *stubRateLimitStore) Increment(ctx context.Context, key string, windowStart time.Time, window time.Duration) (int64, int64, error) {
+	return 0, 0, nil
+}
+
+func (store *stubRateLimitStore) Purge(ctx context.Context, now time.Time) (int64, error) {
+	store.purges++
+	if store.purges == 1 {
+		return 0, errors.New("failed to purge")
+	}
+	store.cancel()
+	return 3, nil
+}
+
+func TestRateLimitPurger(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	store := &stubRateLimitStore{cancel: cancel}
+
+	purger := NewRateLimitPurger(store, time.Millisecond)
+
+	done := make(chan error)
+	go func() {
+		done <- purger.Start(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+		require.Equal(t, 2, store.purges)
+	case <-time.After(5 * time.Second):
+		t.Fatal("purger did not stop after the context was cancelled")
+	}
+}